@@ -4,6 +4,7 @@ import (
 	_ "InstaSpace/docs"
 	InstaHandlers "InstaSpace/internal/handlers"
 	_ "InstaSpace/internal/models"
+	"InstaSpace/internal/pubsub"
 	"InstaSpace/internal/repositories"
 	"InstaSpace/internal/services"
 	"InstaSpace/pkg/config"
@@ -50,19 +51,61 @@ func main() {
 	commentRepo := repositories.NewCommentRepository(db)
 	likeRepo := repositories.NewLikeRepository(db)
 	messageRepo := repositories.NewMessageRepository(db)
+	followRepo := repositories.NewFollowRepository(db)
+	blockRepo := repositories.NewBlockRepository(db)
+	notificationRepo := repositories.NewNotificationRepository(db)
+	mentionRepo := repositories.NewMentionRepository(db)
+	reactionRepo := repositories.NewReactionRepository(db)
+	collectionRepo := repositories.NewCollectionRepository(db)
+	storyRepo := repositories.NewStoryRepository(db)
+	highlightRepo := repositories.NewHighlightRepository(db)
+	wsTicketRepo := repositories.NewWSTicketRepository(db)
+	presenceRepo := repositories.NewPresenceRepository(db)
 
 	authService := services.NewAuthService(userRepo, cfg.JWTSecret)
-	photoService := services.NewPhotoService(photoRepo)
-	commentService := services.NewCommentService(commentRepo)
-	likeService := services.NewLikeService(likeRepo)
-	messageService := services.NewMessageService(messageRepo)
+	messageService := services.NewMessageService(messageRepo, blockRepo, sugaredLogger)
+	wsTicketService := services.NewWSTicketService(wsTicketRepo)
+	if cfg.WSTicketTTL > 0 {
+		wsTicketService.TTL = cfg.WSTicketTTL
+	}
+	wsHandler := InstaHandlers.NewWebSocketHandler(sugaredLogger, messageService, wsTicketService, cfg.JWTSecret, cfg.WSAllowedOrigins)
+	wsHandler.Hub.PingInterval = cfg.WSPingInterval
+	wsHandler.Hub.PongTimeout = cfg.WSPongTimeout
+	wsHandler.Hub.WriteTimeout = cfg.WSWriteTimeout
+	wsHandler.Hub.MaxMessageSize = cfg.WSMaxMessageSize
+	// События публикуются через Postgres, чтобы дойти до подключений на всех экземплярах сервера
+	eventBus := services.NewEventBus(pubsub.NewPostgres(db, sugaredLogger), sugaredLogger)
+	messageService.Publisher = eventBus
+	presenceService := services.NewPresenceService(presenceRepo, eventBus, sugaredLogger)
+	wsHandler.Presence = presenceService
+	notificationService := services.NewNotificationService(notificationRepo, eventBus, sugaredLogger)
+	mentionService := services.NewMentionService(mentionRepo, notificationService, sugaredLogger)
+	photoService := services.NewPhotoService(photoRepo, mentionService)
+	commentService := services.NewCommentService(commentRepo, photoRepo, blockRepo, followRepo, notificationService, mentionService)
+	commentService.EditWindow = cfg.CommentEditWindow
+	reactionService := services.NewReactionService(reactionRepo, photoRepo, messageRepo, blockRepo, notificationService, cfg.Reactions)
+	likeService := services.NewLikeService(likeRepo, reactionService, commentRepo, blockRepo)
+	followService := services.NewFollowService(followRepo, blockRepo, notificationService)
+	blockService := services.NewBlockService(blockRepo)
+	collectionService := services.NewCollectionService(collectionRepo, photoRepo, blockRepo, mentionService)
+	albumService := services.NewAlbumService(photoRepo, mentionService)
+	storyService := services.NewStoryService(storyRepo, sugaredLogger)
+	highlightService := services.NewHighlightService(highlightRepo)
 
 	authHandler := InstaHandlers.NewAuthHandler(authService, sugaredLogger)
 	photoHandler := InstaHandlers.NewPhotoHandler(photoService, sugaredLogger)
 	commentHandler := InstaHandlers.NewCommentHandler(commentService, sugaredLogger)
 	likeHandler := InstaHandlers.NewLikeHandler(likeService, sugaredLogger)
+	reactionHandler := InstaHandlers.NewReactionHandler(reactionService, sugaredLogger)
 	messageHandler := InstaHandlers.NewMessageHandler(messageService, sugaredLogger)
-	wsHandler := InstaHandlers.NewWebSocketHandler(sugaredLogger, messageService)
+	presenceHandler := InstaHandlers.NewPresenceHandler(presenceService, sugaredLogger)
+	followHandler := InstaHandlers.NewFollowHandler(followService, sugaredLogger)
+	blockHandler := InstaHandlers.NewBlockHandler(blockService, sugaredLogger)
+	notificationHandler := InstaHandlers.NewNotificationHandler(notificationService, sugaredLogger)
+	collectionHandler := InstaHandlers.NewCollectionHandler(collectionService, sugaredLogger)
+	albumHandler := InstaHandlers.NewAlbumHandler(albumService, sugaredLogger)
+	storyHandler := InstaHandlers.NewStoryHandler(storyService, sugaredLogger)
+	highlightHandler := InstaHandlers.NewHighlightHandler(highlightService, sugaredLogger)
 
 	r := mux.NewRouter()
 
@@ -75,22 +118,103 @@ func main() {
 	secure.Use(middleware.JWTMiddleware(cfg.JWTSecret, sugaredLogger))
 
 	secure.HandleFunc("/photos", photoHandler.UploadPhoto).Methods("POST")
+	secure.HandleFunc("/feed", photoHandler.GetFeed).Methods("GET")
+
+	secure.HandleFunc("/users/{id}/follow", followHandler.Follow).Methods("POST")
+	secure.HandleFunc("/users/{id}/follow", followHandler.Unfollow).Methods("DELETE")
+	secure.HandleFunc("/users/{id}/block", blockHandler.Block).Methods("POST")
+	secure.HandleFunc("/users/{id}/block", blockHandler.Unblock).Methods("DELETE")
+	secure.HandleFunc("/users/{id}/mute", blockHandler.Mute).Methods("POST")
+	secure.HandleFunc("/users/{id}/mute", blockHandler.Unmute).Methods("DELETE")
+	secure.HandleFunc("/blocks", blockHandler.GetBlocked).Methods("GET")
+	secure.HandleFunc("/mutes", blockHandler.GetMuted).Methods("GET")
+
+	secure.HandleFunc("/notifications", notificationHandler.GetNotifications).Methods("GET")
+	secure.HandleFunc("/notifications/unread-count", notificationHandler.GetUnreadCount).Methods("GET")
+	secure.HandleFunc("/notifications/read", notificationHandler.MarkRead).Methods("POST")
 
 	secure.HandleFunc("/comments", commentHandler.CreateComment).Methods("POST")
 	secure.HandleFunc("/comments/{photoID}", commentHandler.GetCommentsByPhotoID).Methods("GET")
+	secure.HandleFunc("/comments/{id}/replies", commentHandler.GetReplies).Methods("GET")
 	secure.HandleFunc("/comments/{id}/edit", commentHandler.UpdateComment).Methods("PUT")
 	secure.HandleFunc("/comments/{id}/delete", commentHandler.DeleteComment).Methods("DELETE")
+	secure.HandleFunc("/reactions", reactionHandler.GetAllowedReactions).Methods("GET")
+	secure.HandleFunc("/photos/{id}/reaction", reactionHandler.SetPhotoReaction).Methods("PUT")
+	secure.HandleFunc("/photos/{id}/reaction", reactionHandler.RemovePhotoReaction).Methods("DELETE")
+	secure.HandleFunc("/photos/{id}/reactions", reactionHandler.GetPhotoReactions).Methods("GET")
+	secure.HandleFunc("/messages/{id}/reaction", reactionHandler.SetMessageReaction).Methods("PUT")
+	secure.HandleFunc("/messages/{id}/reaction", reactionHandler.RemoveMessageReaction).Methods("DELETE")
+	secure.HandleFunc("/messages/{id}/reactions", reactionHandler.GetMessageReactions).Methods("GET")
+
+	secure.HandleFunc("/stories", storyHandler.CreateStory).Methods("POST")
+	secure.HandleFunc("/stories/tray", storyHandler.GetTray).Methods("GET")
+	secure.HandleFunc("/stories/{id}", storyHandler.DeleteStory).Methods("DELETE")
+	secure.HandleFunc("/stories/{id}/seen", storyHandler.MarkSeen).Methods("POST")
+	secure.HandleFunc("/stories/{id}/viewers", storyHandler.GetViewers).Methods("GET")
+	secure.HandleFunc("/users/{id}/stories", storyHandler.GetUserStories).Methods("GET")
+
+	secure.HandleFunc("/albums", albumHandler.CreateAlbum).Methods("POST")
+	secure.HandleFunc("/users/{id}/albums", albumHandler.GetUserAlbums).Methods("GET")
+	secure.HandleFunc("/albums/{id}", albumHandler.GetAlbum).Methods("GET")
+	secure.HandleFunc("/albums/{id}", albumHandler.UpdateAlbum).Methods("PUT")
+	secure.HandleFunc("/albums/{id}", albumHandler.DeleteAlbum).Methods("DELETE")
+	secure.HandleFunc("/albums/{id}/photos", albumHandler.GetAlbumPhotos).Methods("GET")
+	secure.HandleFunc("/albums/{id}/photos", albumHandler.AddAlbumPhotos).Methods("POST")
+	secure.HandleFunc("/albums/{id}/photos/{photoID}", albumHandler.RemoveAlbumPhoto).Methods("DELETE")
+	secure.HandleFunc("/albums/{id}/order", albumHandler.ReorderAlbum).Methods("PUT")
+
+	secure.HandleFunc("/highlights", highlightHandler.CreateHighlight).Methods("POST")
+	secure.HandleFunc("/highlights/order", highlightHandler.ReorderHighlights).Methods("PUT")
+	secure.HandleFunc("/users/{id}/highlights", highlightHandler.GetUserHighlights).Methods("GET")
+	secure.HandleFunc("/highlights/{id}", highlightHandler.GetHighlight).Methods("GET")
+	secure.HandleFunc("/highlights/{id}", highlightHandler.UpdateHighlight).Methods("PUT")
+	secure.HandleFunc("/highlights/{id}", highlightHandler.DeleteHighlight).Methods("DELETE")
+	secure.HandleFunc("/highlights/{id}/items", highlightHandler.AddHighlightItems).Methods("POST")
+	secure.HandleFunc("/highlights/{id}/items/{itemID}", highlightHandler.RemoveHighlightItem).Methods("DELETE")
+	secure.HandleFunc("/highlights/{id}/order", highlightHandler.ReorderHighlightItems).Methods("PUT")
 
-	secure.HandleFunc("/likes", likeHandler.AddLikeHandler).Methods("POST")
+	secure.HandleFunc("/collections", collectionHandler.GetCollections).Methods("GET")
+	secure.HandleFunc("/collections", collectionHandler.CreateCollection).Methods("POST")
+	secure.HandleFunc("/collections/{id}", collectionHandler.DeleteCollection).Methods("DELETE")
+	secure.HandleFunc("/collections/{id}/photos", collectionHandler.GetCollectionPhotos).Methods("GET")
+	secure.HandleFunc("/photos/{id}/save", collectionHandler.SavePhoto).Methods("PUT")
+	secure.HandleFunc("/photos/{id}/save", collectionHandler.UnsavePhoto).Methods("DELETE")
+
+	secure.HandleFunc("/comments/{id}/like", likeHandler.AddCommentLikeHandler).Methods("POST")
+	secure.HandleFunc("/comments/{id}/like", likeHandler.RemoveCommentLikeHandler).Methods("DELETE")
+	secure.HandleFunc("/comments/{id}/history", commentHandler.GetCommentHistory).Methods("GET")
+	secure.HandleFunc("/comments/{id}/pin", commentHandler.PinComment).Methods("POST")
+	secure.HandleFunc("/comments/{id}/pin", commentHandler.UnpinComment).Methods("DELETE")
+	secure.HandleFunc("/photos/{id}/comment-settings", commentHandler.GetCommentSettings).Methods("GET")
+	secure.HandleFunc("/photos/{id}/comment-settings", commentHandler.UpdateCommentSettings).Methods("PUT")
+
+	secure.HandleFunc("/likes", likeHandler.AddLikeHandler).Methods("POST", "PUT")
 	secure.HandleFunc("/likes", likeHandler.RemoveLikeHandler).Methods("DELETE")
 	secure.HandleFunc("/likes/users", likeHandler.GetLikesHandler).Methods("GET")
 	secure.HandleFunc("/likes/count", likeHandler.GetLikeCountHandler).Methods("GET")
+	secure.HandleFunc("/likes/state", likeHandler.GetViewerStatesHandler).Methods("GET")
 
+	secure.HandleFunc("/ws-ticket", wsHandler.IssueTicket).Methods("POST")
+	secure.HandleFunc("/presence", presenceHandler.GetPresence).Methods("GET")
+	secure.HandleFunc("/presence/settings", presenceHandler.GetSettings).Methods("GET")
+	secure.HandleFunc("/presence/settings", presenceHandler.UpdateSettings).Methods("PUT")
+	secure.HandleFunc("/conversations", messageHandler.GetInbox).Methods("GET")
+	secure.HandleFunc("/conversations", messageHandler.CreateConversation).Methods("POST")
+	secure.HandleFunc("/conversations/groups", messageHandler.CreateGroup).Methods("POST")
+	secure.HandleFunc("/conversations/unread-count", messageHandler.GetUnreadCount).Methods("GET")
+	secure.HandleFunc("/conversations/{id}", messageHandler.GetConversation).Methods("GET")
+	secure.HandleFunc("/conversations/{id}", messageHandler.UpdateGroup).Methods("PUT")
+	secure.HandleFunc("/conversations/{id}/participants", messageHandler.AddParticipants).Methods("POST")
+	secure.HandleFunc("/conversations/{id}/participants/{userID}", messageHandler.RemoveParticipant).Methods("DELETE")
+	secure.HandleFunc("/conversations/{id}/participants/{userID}/role", messageHandler.SetParticipantRole).Methods("PUT")
+	secure.HandleFunc("/conversations/{id}/leave", messageHandler.LeaveGroup).Methods("POST")
+	secure.HandleFunc("/conversations/{id}/read", messageHandler.MarkRead).Methods("POST")
 	secure.HandleFunc("/messages", messageHandler.SendMessage).Methods("POST")
 	secure.HandleFunc("/messages/{conversationID}", messageHandler.GetMessages).Methods("GET")
 	secure.HandleFunc("/messages/{messageID}", messageHandler.DeleteMessageHandler).Methods("DELETE")
 
 	r.HandleFunc("/ws", wsHandler.HandleWS).Methods("GET")
+	r.HandleFunc("/ws/schema", wsHandler.Schema).Methods("GET")
 
 	corsMiddleware := handlers.CORS(
 		handlers.AllowedOrigins([]string{"*"}), // Разрешаем все источники
@@ -107,6 +231,15 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	if err := eventBus.Run(jobsCtx, wsHandler); err != nil {
+		zapLogger.Fatal("Не удалось подписаться на события", zap.Error(err))
+	}
+	go reactionService.RunReconciliation(jobsCtx, cfg.LikesReconcileInterval, sugaredLogger)
+	go storyService.RunSweeper(jobsCtx, cfg.StorySweepInterval)
+	go presenceService.RunHeartbeat(jobsCtx)
+
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt)
 
@@ -125,6 +258,13 @@ func main() {
 	if err := server.Shutdown(ctx); err != nil {
 		zapLogger.Fatal("Ошибка завершения работы сервера", zap.Error(err))
 	}
+	// Shutdown не ждет перехваченных WebSocket-соединений: закрываем их сами, чтобы клиенты переподключились
+	if err := wsHandler.Hub.Shutdown(ctx); err != nil {
+		zapLogger.Warn("Не все WebSocket-соединения закрылись вовремя", zap.Error(err))
+	}
+	// Подписку на события и heartbeat присутствия останавливаем последними: пока соединения закрываются,
+	// им нужны события других экземпляров, а отключения учитываются в общей таблице присутствия
+	stopJobs()
 
 	zapLogger.Info("Сервер успешно остановлен")
 }
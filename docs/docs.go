@@ -15,9 +15,14 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/comments": {
+        "/api/albums": {
             "post": {
-                "description": "Создает новый комментарий к фото",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создает альбом из собственных фото текущего пользователя. Фото из photo_ids добавляются в переданном порядке",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,30 +30,35 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Comments"
+                    "Albums"
                 ],
-                "summary": "Создать комментарий",
+                "summary": "Создать альбом",
                 "parameters": [
                     {
-                        "description": "Данные комментария",
-                        "name": "comment",
+                        "description": "Альбом",
+                        "name": "album",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Comment"
+                            "$ref": "#/definitions/models.AlbumRequest"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "message: comment created successfully, id: 1",
+                        "description": "Created",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/models.Album"
                         }
                     },
                     "400": {
-                        "description": "Некорректный ввод",
+                        "description": "Некорректные данные альбома, чужие фото или обложка не из альбома",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
                         "schema": {
                             "type": "string"
                         }
@@ -62,40 +72,35 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/comments/{id}/delete": {
-            "delete": {
-                "description": "Удаляет комментарий по ID",
+        "/api/albums/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает альбом, если текущий пользователь может его видеть",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Comments"
+                    "Albums"
                 ],
-                "summary": "Удалить комментарий",
+                "summary": "Получить альбом",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID комментария",
+                        "description": "ID альбома",
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "ID пользователя",
-                        "name": "user_id",
-                        "in": "query",
-                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "message: comment deleted successfully",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.Album"
                         }
                     },
                     "400": {
@@ -104,6 +109,18 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Альбом не найден или недоступен",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Ошибка сервера",
                         "schema": {
@@ -111,11 +128,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/comments/{id}/edit": {
+            },
             "put": {
-                "description": "Обновляет текст комментария",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Меняет название, описание, видимость и обложку альбома. Без cover_photo_id обложкой становится первое фото",
                 "consumes": [
                     "application/json"
                 ],
@@ -123,39 +143,48 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Comments"
+                    "Albums"
                 ],
-                "summary": "Обновить комментарий",
+                "summary": "Изменить альбом",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID комментария",
+                        "description": "ID альбома",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Обновленные данные комментария",
-                        "name": "comment",
+                        "description": "Альбом",
+                        "name": "album",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Comment"
+                            "$ref": "#/definitions/models.AlbumRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "message: comment updated successfully",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.Album"
                         }
                     },
                     "400": {
-                        "description": "Некорректные данные",
+                        "description": "Некорректные данные альбома или обложка не из альбома",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Альбом не найден",
                         "schema": {
                             "type": "string"
                         }
@@ -167,39 +196,54 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/comments/{photoID}": {
-            "get": {
-                "description": "Возвращает список комментариев по photo_id",
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Удаляет альбом текущего пользователя. Фото альбома не удаляются",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Comments"
+                    "Albums"
                 ],
-                "summary": "Получить комментарии",
+                "summary": "Удалить альбом",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID фото",
-                        "name": "photoID",
+                        "description": "ID альбома",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "message: Album deleted",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Comment"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
                             }
                         }
                     },
                     "400": {
-                        "description": "Неверный photo_id",
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Альбом не найден",
                         "schema": {
                             "type": "string"
                         }
@@ -213,35 +257,45 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/likes": {
-            "post": {
-                "description": "Добавляет лайк к фото от имени пользователя",
+        "/api/albums/{id}/order": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Задает новый порядок фото альбома. Список должен содержать каждое фото альбома ровно один раз",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Likes"
+                    "Albums"
                 ],
-                "summary": "Добавить лайк",
+                "summary": "Изменить порядок фото",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID фото",
-                        "name": "photoID",
-                        "in": "query",
+                        "description": "ID альбома",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "ID пользователя",
-                        "name": "userID",
-                        "in": "query",
-                        "required": true
+                        "description": "Фото в новом порядке",
+                        "name": "photos",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AlbumPhotosRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "message: Like added successfully",
+                        "description": "message: Album reordered",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -250,7 +304,19 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Некорректные параметры",
+                        "description": "Список не совпадает с фото альбома",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Альбом не найден",
                         "schema": {
                             "type": "string"
                         }
@@ -262,39 +328,51 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Удаляет лайк с фото, поставленный пользователем",
+            }
+        },
+        "/api/albums/{id}/photos": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает фото альбома в порядке, заданном автором",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Likes"
+                    "Albums"
                 ],
-                "summary": "Удалить лайк",
+                "summary": "Фото альбома",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID фото",
-                        "name": "photoID",
-                        "in": "query",
+                        "description": "ID альбома",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     },
                     {
                         "type": "integer",
-                        "description": "ID пользователя",
-                        "name": "userID",
-                        "in": "query",
-                        "required": true
+                        "description": "Количество фото (по умолчанию 50, максимум 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "message: Like removed successfully",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Photo"
                             }
                         }
                     },
@@ -304,6 +382,18 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Альбом не найден или недоступен",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Ошибка сервера",
                         "schema": {
@@ -311,37 +401,66 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/likes/count": {
-            "get": {
-                "description": "Возвращает количество лайков у фото",
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Добавляет собственные фото в конец альбома в переданном порядке. Фото, которые уже есть в альбоме, не перемещаются",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Likes"
+                    "Albums"
                 ],
-                "summary": "Получить количество лайков",
+                "summary": "Добавить фото в альбом",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID фото",
-                        "name": "photoID",
-                        "in": "query",
+                        "description": "ID альбома",
+                        "name": "id",
+                        "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Фото",
+                        "name": "photos",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AlbumPhotosRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "likes_count: Количество лайков",
+                        "description": "message: Photos added",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": true
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Некорректные параметры",
+                        "description": "Чужие или несуществующие фото, превышен лимит",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Альбом не найден",
                         "schema": {
                             "type": "string"
                         }
@@ -355,35 +474,61 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/likes/users": {
-            "get": {
-                "description": "Возвращает список пользователей, поставивших лайк на фото",
+        "/api/albums/{id}/photos/{photoID}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Убирает фото из альбома. Если фото было обложкой, обложкой становится первое фото. Отсутствующее фото не является ошибкой",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Likes"
+                    "Albums"
                 ],
-                "summary": "Получить список лайков",
+                "summary": "Убрать фото из альбома",
                 "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID альбома",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "type": "integer",
                         "description": "ID фото",
                         "name": "photoID",
-                        "in": "query",
+                        "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "users: [список пользователей]",
+                        "description": "message: Photo removed",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": true
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Некорректные параметры",
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Альбом не найден",
                         "schema": {
                             "type": "string"
                         }
@@ -397,42 +542,69 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/messages": {
-            "post": {
-                "description": "Отправляет новое сообщение в указанную беседу",
-                "consumes": [
-                    "application/json"
-                ],
+        "/api/blocks": {
+            "get": {
+                "description": "Возвращает пользователей, заблокированных текущим пользователем",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Messages"
+                    "Blocks"
                 ],
-                "summary": "Отправить сообщение",
-                "parameters": [
-                    {
-                        "description": "Данные сообщения",
-                        "name": "message",
-                        "in": "body",
-                        "required": true,
+                "summary": "Список заблокированных",
+                "responses": {
+                    "200": {
+                        "description": "users: [список пользователей]",
                         "schema": {
-                            "$ref": "#/definitions/models.Message"
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
                         }
                     }
+                }
+            }
+        },
+        "/api/collections": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает коллекции сохраненных фото текущего пользователя. Первой идет коллекция по умолчанию «Saved»",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Collections"
                 ],
+                "summary": "Список коллекций",
                 "responses": {
                     "200": {
-                        "description": "message_id: ID созданного сообщения",
+                        "description": "collections: [список коллекций]",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
-                                "type": "integer"
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/models.Collection"
+                                }
                             }
                         }
                     },
-                    "400": {
-                        "description": "Некорректный запрос",
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
                         "schema": {
                             "type": "string"
                         }
@@ -444,39 +616,56 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/messages/{conversationID}": {
-            "get": {
-                "description": "Возвращает список сообщений по conversation_id",
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создает именованную коллекцию сохраненных фото",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Messages"
+                    "Collections"
                 ],
-                "summary": "Получить сообщения",
+                "summary": "Создать коллекцию",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID беседы",
-                        "name": "conversationID",
-                        "in": "path",
-                        "required": true
+                        "description": "Название коллекции",
+                        "name": "collection",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CollectionRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Message"
-                            }
+                            "$ref": "#/definitions/models.Collection"
                         }
                     },
                     "400": {
-                        "description": "Некорректный conversation_id",
+                        "description": "Некорректное название",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Коллекция с таким названием уже есть",
                         "schema": {
                             "type": "string"
                         }
@@ -490,28 +679,33 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/messages/{messageID}": {
+        "/api/collections/{id}": {
             "delete": {
-                "description": "Удаляет сообщение по его ID",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Удаляет именованную коллекцию вместе с сохранениями. Коллекцию «Saved» удалить нельзя",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Messages"
+                    "Collections"
                 ],
-                "summary": "Удалить сообщение",
+                "summary": "Удалить коллекцию",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID сообщения",
-                        "name": "messageID",
+                        "description": "ID коллекции",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "message: Message deleted successfully",
+                        "description": "message: Collection deleted",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -525,6 +719,18 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Коллекция не найдена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Ошибка сервера",
                         "schema": {
@@ -534,50 +740,63 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/photos": {
-            "post": {
-                "description": "Загружает фото в систему и сохраняет в базе данных",
-                "consumes": [
-                    "multipart/form-data"
+        "/api/collections/{id}/photos": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Возвращает страницу фото коллекции, последние сохраненные первыми. Фото авторов, с которыми есть блокировка, не показываются",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Photos"
+                    "Collections"
                 ],
-                "summary": "Загрузить фото",
+                "summary": "Фото коллекции",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID пользователя",
-                        "name": "user_id",
-                        "in": "header",
+                        "description": "ID коллекции",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     },
                     {
-                        "type": "file",
-                        "description": "Файл изображения",
-                        "name": "file",
-                        "in": "formData",
-                        "required": true
+                        "type": "integer",
+                        "description": "Количество фото (по умолчанию 30, максимум 100)",
+                        "name": "limit",
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Описание изображения",
-                        "name": "description",
-                        "in": "formData"
+                        "description": "Курсор следующей страницы из next_cursor",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Photo"
+                            "$ref": "#/definitions/models.SavedPhotosPage"
                         }
                     },
                     "400": {
-                        "description": "Некорректный ввод",
+                        "description": "Некорректный ID или параметры страницы",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Коллекция не найдена",
                         "schema": {
                             "type": "string"
                         }
@@ -591,9 +810,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/login": {
+        "/api/comments": {
             "post": {
-                "description": "Проверяет учетные данные пользователя и выдает JWT-токен",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создает новый комментарий к фото от имени текущего пользователя, user_id в теле игнорируется. Если указан parent_id, комментарий становится ответом; ответ на ответ прикрепляется к комментарию верхнего уровня. Упоминания @username сохраняются и возвращаются в entities, упоминания несуществующих и заблокированных пользователей игнорируются",
                 "consumes": [
                     "application/json"
                 ],
@@ -601,28 +825,26 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Comments"
                 ],
-                "summary": "Вход пользователя",
+                "summary": "Создать комментарий",
                 "parameters": [
                     {
-                        "description": "Учетные данные пользователя",
-                        "name": "credentials",
+                        "description": "Данные комментария",
+                        "name": "comment",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/models.Comment"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "token: JWT токен, username: Имя пользователя",
+                    "201": {
+                        "description": "message: comment created successfully, id: 1, entities: [упоминания]",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "additionalProperties": true
                         }
                     },
                     "400": {
@@ -632,13 +854,19 @@ const docTemplate = `{
                         }
                     },
                     "401": {
-                        "description": "Ошибка аутентификации",
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Автор фото заблокировал пользователя или ограничил комментарии",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "500": {
-                        "description": "Ошибка генерации токена",
+                        "description": "Ошибка сервера",
                         "schema": {
                             "type": "string"
                         }
@@ -646,33 +874,33 @@ const docTemplate = `{
                 }
             }
         },
-        "/register": {
-            "post": {
-                "description": "Создает нового пользователя и отправляет подтверждение по email",
-                "consumes": [
-                    "application/json"
+        "/api/comments/{id}/delete": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Удаляет комментарий по ID. Удалить комментарий может его автор или автор фото. Если у комментария есть ответы, на его месте остается \"[deleted]\"",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Comments"
                 ],
-                "summary": "Регистрация пользователя",
+                "summary": "Удалить комментарий",
                 "parameters": [
                     {
-                        "description": "Данные пользователя",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.User"
-                        }
+                        "type": "integer",
+                        "description": "ID комментария",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "message: Успешная регистрация. Пожалуйста подтвердите email",
+                    "200": {
+                        "description": "message: comment deleted successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -681,7 +909,13 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Некорректный ввод",
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
                         "schema": {
                             "type": "string"
                         }
@@ -695,9 +929,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/ws": {
-            "get": {
-                "description": "Устанавливает WebSocket соединение и отправляет/получает сообщения в режиме реального времени",
+        "/api/comments/{id}/edit": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Обновляет текст комментария текущего пользователя, сохраняя прежнюю версию в истории правок. user_id в теле игнорируется. Править комментарий можно только в течение окна редактирования после создания",
                 "consumes": [
                     "application/json"
                 ],
@@ -705,98 +944,5484 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "WebSocket"
+                    "Comments"
+                ],
+                "summary": "Обновить комментарий",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID комментария",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Обновленные данные комментария",
+                        "name": "comment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Comment"
+                        }
+                    }
                 ],
-                "summary": "Установить WebSocket соединение",
                 "responses": {
-                    "101": {
-                        "description": "WebSocket connection established",
+                    "200": {
+                        "description": "message: comment updated successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные данные",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "500": {
-                        "description": "Ошибка сервера",
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
                         "schema": {
                             "type": "string"
                         }
-                    }
-                }
+                    },
+                    "403": {
+                        "description": "Окно редактирования истекло",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/comments/{id}/history": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает все версии комментария от исходной к текущей. Доступно автору фото и модераторам",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Comments"
+                ],
+                "summary": "История правок комментария",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID комментария",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CommentHistory"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID комментария",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Пользователь не автор фото и не модератор",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Комментарий не найден",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/comments/{id}/like": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Добавляет лайк текущего пользователя к комментарию. Повторный лайк ничего не меняет",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Likes"
+                ],
+                "summary": "Лайкнуть комментарий",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID комментария",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Like added successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Автор комментария заблокировал пользователя",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Комментарий не найден",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Удаляет лайк текущего пользователя с комментария. Если лайка не было, запрос ничего не меняет",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Likes"
+                ],
+                "summary": "Убрать лайк с комментария",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID комментария",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Like removed successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/comments/{id}/pin": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Закрепляет комментарий верхнего уровня к фото текущего пользователя. Одновременно можно закрепить до 3 комментариев",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Comments"
+                ],
+                "summary": "Закрепить комментарий",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID комментария",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: comment pinned",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID или достигнут лимит закреплений",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Комментарий не найден среди комментариев к фото пользователя",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Снимает закрепление комментария к фото текущего пользователя",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Comments"
+                ],
+                "summary": "Открепить комментарий",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID комментария",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: comment unpinned",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Комментарий не найден среди комментариев к фото пользователя",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/comments/{id}/replies": {
+            "get": {
+                "description": "Возвращает страницу ответов на комментарий верхнего уровня",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Comments"
+                ],
+                "summary": "Получить ответы на комментарий",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID комментария",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Сортировка: oldest (по умолчанию), newest, top",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Количество ответов (по умолчанию 20, максимум 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор следующей страницы из next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CommentPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID комментария или параметры страницы",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Комментарий не найден",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/comments/{photoID}": {
+            "get": {
+                "description": "Возвращает страницу комментариев верхнего уровня по photo_id с количеством ответов. Закрепленные комментарии идут в начале первой страницы. Комментарии пользователей, с которыми у текущего пользователя есть блокировка, и комментарии со скрываемыми словами (кроме собственных) не показываются",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Comments"
+                ],
+                "summary": "Получить комментарии",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID фото",
+                        "name": "photoID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Сортировка: newest (по умолчанию), oldest, top",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Количество комментариев (по умолчанию 20, максимум 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор следующей страницы из next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CommentPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный photo_id или параметры страницы",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/conversations": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает беседы текущего пользователя, последние активные первыми, с профилем собеседника, последним сообщением и количеством непрочитанных",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Входящие",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Количество бесед (по умолчанию 20, максимум 50)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор следующей страницы из next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.InboxPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры страницы",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает беседу текущего пользователя с собеседником, создавая ее при необходимости. Пара (A,B) и (B,A) дает одну беседу",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Начать беседу",
+                "parameters": [
+                    {
+                        "description": "Собеседник",
+                        "name": "conversation",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ConversationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Conversation"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный собеседник",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Пользователь заблокирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/conversations/groups": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создает групповую беседу с названием, аватаром и участниками. Создатель становится администратором",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Создать группу",
+                "parameters": [
+                    {
+                        "description": "Группа",
+                        "name": "group",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.GroupRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Conversation"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректное название или участники, превышен лимит участников",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Пользователь заблокирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/conversations/unread-count": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает для значка входящих количество непрочитанных сообщений других участников во всех беседах и количество бесед с ними",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Количество непрочитанных сообщений",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UnreadCount"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/conversations/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает беседу с участниками и их ролями. Доступно только участникам",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Получить беседу",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID беседы",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Conversation"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Беседа не найдена или пользователь в ней не участвует",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Меняет название и аватар группы. Доступно администраторам, участники получают служебное сообщение",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Изменить группу",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID беседы",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Название и аватар",
+                        "name": "group",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.GroupRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Conversation"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректное название или беседа не групповая",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Пользователь не администратор группы",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Беседа не найдена или пользователь в ней не участвует",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/conversations/{id}/leave": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Выводит текущего пользователя из группы. Если ушел последний администратор, им становится самый давний участник",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Выйти из группы",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID беседы",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Left the group",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID или беседа не групповая",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Беседа не найдена или пользователь в ней не участвует",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/conversations/{id}/participants": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Добавляет пользователей в группу. Доступно администраторам. Уже состоящие в группе пропускаются",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Добавить участников",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID беседы",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Пользователи",
+                        "name": "participants",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ParticipantsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Participants added",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные пользователи, беседа не групповая или превышен лимит участников",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Пользователь не администратор группы или заблокирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Беседа не найдена или пользователь в ней не участвует",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/conversations/{id}/participants/{userID}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Исключает участника из группы. Исключать других могут администраторы, исключение себя означает выход из группы",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Исключить участника",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID беседы",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID участника",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Participant removed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID или беседа не групповая",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Пользователь не администратор группы",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Беседа или участник не найдены",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/conversations/{id}/participants/{userID}/role": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Назначает или снимает администратора группы. Доступно администраторам. Последнего администратора снять нельзя",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Изменить роль участника",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID беседы",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID участника",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Роль",
+                        "name": "role",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RoleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Role updated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректная роль, беседа не групповая или это последний администратор",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Пользователь не администратор группы",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Беседа или участник не найдены",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/conversations/{id}/read": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Сдвигает отметку прочтения текущего пользователя до message_id включительно, без message_id — до последнего сообщения. Отметка не сдвигается назад. Остальные участники получают событие read по WebSocket",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Отметить беседу прочитанной",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID беседы",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Последнее прочитанное сообщение",
+                        "name": "read",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.ReadRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ReadReceipt"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Беседа или сообщение не найдены",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/feed": {
+            "get": {
+                "description": "Возвращает фото пользователя и его подписок от новых к старым. Скрытые и заблокированные авторы исключаются",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Photos"
+                ],
+                "summary": "Лента",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Количество фото (по умолчанию 20, максимум 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Photo"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/highlights": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создает подборку в конце профиля из собственных фото и историй текущего пользователя. Каждый элемент содержит photo_id или story_id. Истории из подборки не удаляются по истечении срока",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Highlights"
+                ],
+                "summary": "Создать подборку",
+                "parameters": [
+                    {
+                        "description": "Подборка",
+                        "name": "highlight",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.HighlightRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Highlight"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректное название, чужие или несуществующие элементы, превышен лимит",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/highlights/order": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Задает новый порядок подборок текущего пользователя. Список должен содержать каждую подборку ровно один раз",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Highlights"
+                ],
+                "summary": "Изменить порядок подборок",
+                "parameters": [
+                    {
+                        "description": "ID подборок в новом порядке",
+                        "name": "order",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.OrderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Highlights reordered",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Список не совпадает с подборками пользователя",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/highlights/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает подборку со всеми элементами по порядку",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Highlights"
+                ],
+                "summary": "Получить подборку",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID подборки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Highlight"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Подборка не найдена или недоступна",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Меняет название и обложку подборки. Без cover_item_id обложкой становится первый элемент",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Highlights"
+                ],
+                "summary": "Изменить подборку",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID подборки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Подборка",
+                        "name": "highlight",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.HighlightRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Highlight"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректное название или обложка не из подборки",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Подборка не найдена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Удаляет подборку текущего пользователя. Фото не удаляются, истекшие истории удаляются при следующей очистке, если их нет в других подборках",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Highlights"
+                ],
+                "summary": "Удалить подборку",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID подборки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Highlight deleted",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Подборка не найдена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/highlights/{id}/items": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Добавляет собственные фото и истории в конец подборки в переданном порядке. Элементы, которые уже есть в подборке, не перемещаются",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Highlights"
+                ],
+                "summary": "Добавить элементы в подборку",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID подборки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Элементы",
+                        "name": "items",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.HighlightItemsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Items added",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Чужие или несуществующие элементы, превышен лимит",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Подборка не найдена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/highlights/{id}/items/{itemID}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Убирает элемент из подборки. Если элемент был обложкой, обложкой становится первый элемент. Отсутствующий элемент не является ошибкой",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Highlights"
+                ],
+                "summary": "Убрать элемент из подборки",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID подборки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID элемента",
+                        "name": "itemID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Item removed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Подборка не найдена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/highlights/{id}/order": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Задает новый порядок элементов подборки. Список должен содержать каждый элемент подборки ровно один раз",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Highlights"
+                ],
+                "summary": "Изменить порядок элементов",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID подборки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "ID элементов в новом порядке",
+                        "name": "order",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.OrderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Highlight reordered",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Список не совпадает с элементами подборки",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Подборка не найдена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/likes": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Ставит на фото реакцию ❤️ от имени текущего пользователя. Повторный запрос ничего не меняет. Совместимый вариант PUT /api/photos/{id}/reaction",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Likes"
+                ],
+                "summary": "Добавить лайк",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID фото",
+                        "name": "photoID",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Like added successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Автор фото заблокировал пользователя",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Ставит на фото реакцию ❤️ от имени текущего пользователя. Повторный запрос ничего не меняет. Совместимый вариант PUT /api/photos/{id}/reaction",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Likes"
+                ],
+                "summary": "Добавить лайк",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID фото",
+                        "name": "photoID",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Like added successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Автор фото заблокировал пользователя",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Снимает с фото реакцию ❤️, поставленную текущим пользователем. Если ее не было, запрос ничего не меняет",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Likes"
+                ],
+                "summary": "Удалить лайк",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID фото",
+                        "name": "photoID",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Like removed successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/likes/count": {
+            "get": {
+                "description": "Возвращает количество реакций ❤️ у фото",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Likes"
+                ],
+                "summary": "Получить количество лайков",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID фото",
+                        "name": "photoID",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "likes_count: Количество лайков",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/likes/state": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Для каждого фото из списка возвращает количество лайков, лайк текущего пользователя и количество комментариев. Несуществующие фото и фото авторов, связанных с пользователем блокировкой, пропускаются",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Likes"
+                ],
+                "summary": "Состояние фото для зрителя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID фото через запятую, не больше 100",
+                        "name": "photoIDs",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "photos: [состояния фото]",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/models.PhotoViewerState"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный список ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/likes/users": {
+            "get": {
+                "description": "Возвращает страницу пользователей, поставивших на фото реакцию ❤️. Сначала идут те, на кого подписан текущий пользователь, затем остальные, новые лайки первыми",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Likes"
+                ],
+                "summary": "Получить список лайков",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID фото",
+                        "name": "photoID",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Количество пользователей (по умолчанию 20, максимум 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор следующей страницы из next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.LikersPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/messages": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Отправляет новое сообщение в беседу от имени текущего пользователя. sender_id из тела игнорируется. Повторная отправка с тем же client_message_id в ту же беседу возвращает ID уже сохраненного сообщения",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Отправить сообщение",
+                "parameters": [
+                    {
+                        "description": "Данные сообщения",
+                        "name": "message",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message_id: ID созданного сообщения",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Участник беседы заблокирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Беседа не найдена или пользователь в ней не участвует",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "client_message_id уже использован в другой беседе",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/messages/{conversationID}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает список сообщений беседы. Доступно только участникам. Сообщения не отмечаются прочитанными, для этого есть POST /api/conversations/{id}/read",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Получить сообщения",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID беседы",
+                        "name": "conversationID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Message"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный conversation_id",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Беседа не найдена или пользователь в ней не участвует",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/messages/{id}/reaction": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Ставит реакцию участника беседы на сообщение. Повторный запрос с другой реакцией меняет ее",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reactions"
+                ],
+                "summary": "Поставить реакцию на сообщение",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID сообщения",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Реакция",
+                        "name": "reaction",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ReactionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Reaction set",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID или недопустимая реакция",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Отправитель заблокировал пользователя",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Сообщение не найдено",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Снимает реакцию участника беседы с сообщения",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reactions"
+                ],
+                "summary": "Убрать реакцию с сообщения",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID сообщения",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Reaction removed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Сообщение не найдено",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/messages/{id}/reactions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает количество реакций каждого вида и реакцию текущего пользователя. Доступно участникам беседы",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reactions"
+                ],
+                "summary": "Реакции на сообщение",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID сообщения",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ReactionSummary"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Сообщение не найдено",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/messages/{messageID}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Удаляет сообщение по его ID. Удалить сообщение может только отправитель",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Удалить сообщение",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID сообщения",
+                        "name": "messageID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Message deleted successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Сообщение отправил другой участник",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Сообщение не найдено или пользователь не участвует в беседе",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/mutes": {
+            "get": {
+                "description": "Возвращает пользователей, скрытых из ленты текущего пользователя",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Blocks"
+                ],
+                "summary": "Список скрытых",
+                "responses": {
+                    "200": {
+                        "description": "users: [список пользователей]",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/notifications": {
+            "get": {
+                "description": "Возвращает уведомления о лайках, комментариях, подписках и упоминаниях от новых к старым. Однотипные непрочитанные события объединены. Участники, связанные с пользователем блокировкой, не учитываются, а уведомления только от них не возвращаются",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Получить уведомления",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Количество уведомлений (по умолчанию 20, максимум 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Notification"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/notifications/read": {
+            "post": {
+                "description": "Отмечает прочитанными уведомления из списка ids. Без тела запроса или с пустым списком отмечает все",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Отметить уведомления прочитанными",
+                "parameters": [
+                    {
+                        "description": "ids: [ID уведомлений]",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "updated: Количество отмеченных уведомлений",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/notifications/unread-count": {
+            "get": {
+                "description": "Возвращает количество непрочитанных уведомлений текущего пользователя",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Количество непрочитанных уведомлений",
+                "responses": {
+                    "200": {
+                        "description": "unread_count: Количество непрочитанных",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/photos": {
+            "post": {
+                "description": "Загружает фото в систему и сохраняет в базе данных. Упоминания @username в описании возвращаются в entities",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Photos"
+                ],
+                "summary": "Загрузить фото",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Файл изображения",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Описание изображения",
+                        "name": "description",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Photo"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ввод",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/photos/{id}/comment-settings": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает политику комментирования и скрываемые слова. Доступно только автору фото",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Comments"
+                ],
+                "summary": "Получить настройки комментариев",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID фото",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CommentSettings"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID фото",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Пользователь не автор фото",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Фото не найдено",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Задает, кто может комментировать фото (everyone, followers, off), и список слов, при наличии которых комментарий видят только его автор. Доступно только автору фото",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Comments"
+                ],
+                "summary": "Изменить настройки комментариев",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID фото",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Настройки комментариев",
+                        "name": "settings",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CommentSettings"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CommentSettings"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные настройки",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Пользователь не автор фото",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Фото не найдено",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/photos/{id}/reaction": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Ставит реакцию текущего пользователя на фото. Повторный запрос с другой реакцией меняет ее",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reactions"
+                ],
+                "summary": "Поставить реакцию на фото",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID фото",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Реакция",
+                        "name": "reaction",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ReactionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Reaction set",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID или недопустимая реакция",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Автор фото заблокировал пользователя",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Фото не найдено",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Снимает реакцию текущего пользователя с фото. Если реакции не было, запрос ничего не меняет",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reactions"
+                ],
+                "summary": "Убрать реакцию с фото",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID фото",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Reaction removed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/photos/{id}/reactions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает количество реакций каждого вида и реакцию текущего пользователя",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reactions"
+                ],
+                "summary": "Реакции на фото",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID фото",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ReactionSummary"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Фото не найдено",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/photos/{id}/save": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Сохраняет фото в коллекцию текущего пользователя, по умолчанию в «Saved». Повторное сохранение ничего не меняет",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Collections"
+                ],
+                "summary": "Сохранить фото",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID фото",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Коллекция",
+                        "name": "save",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.SaveRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Photo saved",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Автор фото заблокировал пользователя",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Фото или коллекция не найдены",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Убирает фото из указанной коллекции или, если collectionID не передан, из всех коллекций текущего пользователя",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Collections"
+                ],
+                "summary": "Убрать фото из сохраненных",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID фото",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID коллекции",
+                        "name": "collectionID",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Photo unsaved",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Коллекция не найдена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/presence": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Для каждого пользователя из списка возвращает, в сети ли он, и время последнего выхода из сети. Присутствие видно только собеседникам — пользователям с общей беседой без блокировки, остальные пропускаются. Если пользователь скрыл время выхода, оно не возвращается",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Presence"
+                ],
+                "summary": "Присутствие пользователей",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователей через запятую, не больше 100",
+                        "name": "user_ids",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "users: [присутствие пользователей]",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/models.Presence"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный список ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/presence/settings": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает настройки приватности присутствия текущего пользователя",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Presence"
+                ],
+                "summary": "Настройки присутствия",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PresenceSettings"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Задает, видят ли собеседники время последнего выхода текущего пользователя из сети. Сам статус \"в сети\" виден всегда",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Presence"
+                ],
+                "summary": "Изменить настройки присутствия",
+                "parameters": [
+                    {
+                        "description": "Настройки присутствия",
+                        "name": "settings",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PresenceSettings"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PresenceSettings"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/reactions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает реакции, которые можно ставить на фото и сообщения",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reactions"
+                ],
+                "summary": "Список реакций",
+                "responses": {
+                    "200": {
+                        "description": "reactions: [список реакций]",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/stories": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Загружает изображение истории с теми же ограничениями, что и у фото. История исчезает через 24 часа",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Stories"
+                ],
+                "summary": "Опубликовать историю",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Файл изображения",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подпись",
+                        "name": "caption",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Story"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ввод",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/stories/tray": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает подписки текущего пользователя с активными историями: сначала с непросмотренными, затем по свежести. Скрытые и заблокированные авторы исключаются",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Stories"
+                ],
+                "summary": "Лента историй",
+                "responses": {
+                    "200": {
+                        "description": "tray: [авторы с историями]",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/models.StoryTrayItem"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/stories/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Удаляет историю текущего пользователя вместе с файлом",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Stories"
+                ],
+                "summary": "Удалить историю",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID истории",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Story deleted",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "История не найдена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/stories/{id}/seen": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Отмечает историю просмотренной текущим пользователем. Повторный просмотр ничего не меняет",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Stories"
+                ],
+                "summary": "Отметить просмотр истории",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID истории",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Story seen",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "История не найдена или истекла",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/stories/{id}/viewers": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает пользователей, просмотревших историю, последние первыми. Доступно только автору",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Stories"
+                ],
+                "summary": "Просмотры истории",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID истории",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "viewers: [список просмотревших]",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/models.StoryViewer"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "История не найдена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{id}/albums": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает альбомы пользователя, которые может видеть текущий пользователь: публичные, для подписчиков, если он подписан, и все свои",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Albums"
+                ],
+                "summary": "Альбомы пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "albums: [список альбомов]",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/models.Album"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{id}/block": {
+            "post": {
+                "description": "Блокирует пользователя: он не сможет подписаться, писать сообщения, комментировать и лайкать фото. Подписки в обе стороны удаляются",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Blocks"
+                ],
+                "summary": "Заблокировать пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: User blocked successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Снимает блокировку с пользователя. Повторный вызов не является ошибкой",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Blocks"
+                ],
+                "summary": "Разблокировать пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: User unblocked successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{id}/follow": {
+            "post": {
+                "description": "Подписывает текущего пользователя на другого. Недоступно при блокировке",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Follows"
+                ],
+                "summary": "Подписаться",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Followed successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Пользователь заблокирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Отменяет подписку текущего пользователя. Повторный вызов не является ошибкой",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Follows"
+                ],
+                "summary": "Отписаться",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Unfollowed successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{id}/highlights": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает подборки пользователя в порядке профиля с обложкой и количеством элементов. Заблокированным пользователям список не показывается",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Highlights"
+                ],
+                "summary": "Подборки пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "highlights: [список подборок]",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/models.Highlight"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{id}/mute": {
+            "post": {
+                "description": "Скрывает публикации пользователя из ленты текущего пользователя",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Blocks"
+                ],
+                "summary": "Скрыть пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: User muted successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Отменяет скрытие публикаций пользователя. Повторный вызов не является ошибкой",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Blocks"
+                ],
+                "summary": "Вернуть пользователя в ленту",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: User unmuted successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{id}/stories": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает активные истории пользователя от старых к новым с отметкой просмотра. Автор также видит количество просмотров",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Stories"
+                ],
+                "summary": "Истории пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "stories: [список историй]",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/models.Story"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/ws-ticket": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Выдает одноразовый короткоживущий билет для подключения к /ws?ticket=... из браузера, где нельзя передать заголовок Authorization. Подключение по билету закрывается, когда истекает токен, которым билет получен",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "WebSocket"
+                ],
+                "summary": "Получить билет для WebSocket",
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.WSTicket"
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/login": {
+            "post": {
+                "description": "Проверяет учетные данные пользователя и выдает JWT-токен",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Вход пользователя",
+                "parameters": [
+                    {
+                        "description": "Учетные данные пользователя",
+                        "name": "credentials",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "token: JWT токен, username: Имя пользователя",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ввод",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка аутентификации",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка генерации токена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/register": {
+            "post": {
+                "description": "Создает нового пользователя и отправляет подтверждение по email",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Регистрация пользователя",
+                "parameters": [
+                    {
+                        "description": "Данные пользователя",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "message: Успешная регистрация. Пожалуйста подтвердите email",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ввод",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/ws": {
+            "get": {
+                "description": "Устанавливает WebSocket соединение для сообщений в реальном времени. Подключение требует токена: заголовок Authorization, подпротоколы \"instaspace\" и \"bearer.\u003cJWT\u003e\" в Sec-WebSocket-Protocol или билет из POST /api/ws-ticket. При истечении токена соединение закрывается. Сервер периодически отправляет ping: клиент, не ответивший pong и не приславший сообщений за отведенное время, отключается. Сообщение больше допустимого размера закрывает соединение с кодом 1009, при перезапуске сервера соединение закрывается с кодом 1012 — клиенту следует переподключиться. Все сообщения — конверты {\"v\":1,\"type\":...,\"id\":...,\"payload\":...}, схема протокола доступна по GET /ws/schema. Клиент отправляет send, read и typing; на send приходит ack с ID сохраненного сообщения, на ошибку — error с кодом и тем же id. ID конверта send хранится как client_message_id: повторная отправка не создает дубликат. Сервер присылает message, read, typing, presence, unread_count и notification. typing с typing=true клиент повторяет, пока пользователь набирает текст: участникам он пересылается не чаще раза в 3 секунды, а если не повторился за 6 секунд, участники получают typing=false. presence приходит собеседникам, когда пользователь подключается первым устройством и отключается последним",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "WebSocket"
+                ],
+                "summary": "Установить WebSocket соединение",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Билет из POST /api/ws-ticket",
+                        "name": "ticket",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "instaspace, bearer.\u003cJWT\u003e",
+                        "name": "Sec-WebSocket-Protocol",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "WebSocket connection established",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Токен отсутствует, недействителен или билет уже использован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Источник не разрешен",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/ws/schema": {
+            "get": {
+                "description": "Возвращает JSON-схему конвертов и типов сообщений протокола WebSocket текущей версии",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "WebSocket"
+                ],
+                "summary": "Схема протокола WebSocket",
+                "responses": {
+                    "200": {
+                        "description": "JSON-схема",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.Album": {
+            "type": "object",
+            "properties": {
+                "cover_photo_id": {
+                    "description": "ID обложки. Если не выбрана, обложкой служит первое фото альбома",
+                    "type": "integer",
+                    "example": 7
+                },
+                "cover_url": {
+                    "description": "URL обложки",
+                    "type": "string",
+                    "example": "https://example.com/uploads/photo7.jpg"
+                },
+                "created_at": {
+                    "description": "Дата создания",
+                    "type": "string",
+                    "example": "2024-02-01T16:30:00Z"
+                },
+                "description": {
+                    "description": "Описание альбома",
+                    "type": "string",
+                    "example": "Поездка на море"
+                },
+                "id": {
+                    "description": "ID альбома",
+                    "type": "integer",
+                    "example": 1
+                },
+                "photos_count": {
+                    "description": "Количество фото в альбоме",
+                    "type": "integer",
+                    "example": 12
+                },
+                "title": {
+                    "description": "Название альбома",
+                    "type": "string",
+                    "example": "Лето"
+                },
+                "updated_at": {
+                    "description": "Дата последнего изменения",
+                    "type": "string",
+                    "example": "2024-02-01T16:45:00Z"
+                },
+                "user_id": {
+                    "description": "ID автора",
+                    "type": "integer",
+                    "example": 42
+                },
+                "visibility": {
+                    "description": "Кто видит альбом: public, followers, private",
+                    "type": "string",
+                    "example": "followers"
+                }
+            }
+        },
+        "models.AlbumPhotosRequest": {
+            "type": "object",
+            "properties": {
+                "photo_ids": {
+                    "description": "ID фото",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        9,
+                        7,
+                        8
+                    ]
+                }
+            }
+        },
+        "models.AlbumRequest": {
+            "type": "object",
+            "properties": {
+                "cover_photo_id": {
+                    "description": "ID обложки из фото альбома",
+                    "type": "integer",
+                    "example": 7
+                },
+                "description": {
+                    "description": "Описание альбома",
+                    "type": "string",
+                    "example": "Поездка на море"
+                },
+                "photo_ids": {
+                    "description": "Фото альбома при создании, в нужном порядке",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        7,
+                        8,
+                        9
+                    ]
+                },
+                "title": {
+                    "description": "Название альбома",
+                    "type": "string",
+                    "example": "Лето"
+                },
+                "visibility": {
+                    "description": "Видимость: public (по умолчанию), followers, private",
+                    "type": "string",
+                    "example": "followers"
+                }
+            }
+        },
+        "models.Collection": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "Дата создания",
+                    "type": "string",
+                    "example": "2024-02-01T16:30:00Z"
+                },
+                "id": {
+                    "description": "ID коллекции",
+                    "type": "integer",
+                    "example": 1
+                },
+                "is_default": {
+                    "description": "Коллекция по умолчанию «Saved»",
+                    "type": "boolean",
+                    "example": false
+                },
+                "name": {
+                    "description": "Название коллекции",
+                    "type": "string",
+                    "example": "Путешествия"
+                },
+                "photos_count": {
+                    "description": "Количество доступных фото в коллекции",
+                    "type": "integer",
+                    "example": 12
+                },
+                "user_id": {
+                    "description": "ID владельца",
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "models.CollectionRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "description": "Название коллекции",
+                    "type": "string",
+                    "example": "Путешествия"
+                }
+            }
+        },
+        "models.Comment": {
+            "type": "object",
+            "properties": {
+                "avatar_url": {
+                    "description": "Аватар пользователя, оставившего комментарий",
+                    "type": "string",
+                    "example": "https://example.com/uploads/avatar.jpg"
+                },
+                "content": {
+                    "description": "Текст комментария (\"[deleted]\" для удаленного комментария с ответами)",
+                    "type": "string",
+                    "example": "Отличное фото!"
+                },
+                "created_at": {
+                    "description": "Дата создания комментария",
+                    "type": "string",
+                    "example": "2024-01-31T12:45:00Z"
+                },
+                "deleted": {
+                    "description": "Флаг удаленного комментария, оставленного ради ответов",
+                    "type": "boolean",
+                    "example": false
+                },
+                "edited": {
+                    "description": "Флаг редактирования комментария",
+                    "type": "boolean",
+                    "example": true
+                },
+                "entities": {
+                    "description": "Упоминания пользователей в тексте",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Mention"
+                    }
+                },
+                "id": {
+                    "description": "ID комментария",
+                    "type": "integer",
+                    "example": 1
+                },
+                "liked_by_viewer": {
+                    "description": "Флаг лайка текущего пользователя",
+                    "type": "boolean",
+                    "example": false
+                },
+                "likes_count": {
+                    "description": "Количество лайков комментария",
+                    "type": "integer",
+                    "example": 5
+                },
+                "parent_id": {
+                    "description": "ID родительского комментария, если это ответ",
+                    "type": "integer",
+                    "example": 3
+                },
+                "photo_id": {
+                    "description": "ID фото, к которому относится комментарий",
+                    "type": "integer",
+                    "example": 101
+                },
+                "pinned": {
+                    "description": "Флаг комментария, закрепленного автором фото",
+                    "type": "boolean",
+                    "example": false
+                },
+                "reply_count": {
+                    "description": "Количество видимых текущему пользователю ответов (только для комментариев верхнего уровня)",
+                    "type": "integer",
+                    "example": 2
+                },
+                "revision_count": {
+                    "description": "Количество сохраненных правок комментария",
+                    "type": "integer",
+                    "example": 1
+                },
+                "updated_at": {
+                    "description": "Дата последнего обновления комментария",
+                    "type": "string",
+                    "example": "2024-01-31T12:50:00Z"
+                },
+                "user_id": {
+                    "description": "ID пользователя, оставившего комментарий",
+                    "type": "integer",
+                    "example": 42
+                },
+                "username": {
+                    "description": "Имя пользователя, оставившего комментарий",
+                    "type": "string",
+                    "example": "johndoe"
+                }
+            }
+        },
+        "models.CommentHistory": {
+            "type": "object",
+            "properties": {
+                "comment_id": {
+                    "description": "ID комментария",
+                    "type": "integer",
+                    "example": 1
+                },
+                "revisions": {
+                    "description": "Версии комментария, последняя — текущая",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CommentRevision"
+                    }
+                }
+            }
+        },
+        "models.CommentPage": {
+            "type": "object",
+            "properties": {
+                "comments": {
+                    "description": "Комментарии страницы",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Comment"
+                    }
+                },
+                "next_cursor": {
+                    "description": "Курсор следующей страницы, отсутствует на последней странице",
+                    "type": "string",
+                    "example": "eyJpZCI6MTJ9"
+                }
+            }
+        },
+        "models.CommentRevision": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "description": "Текст версии",
+                    "type": "string",
+                    "example": "Первый вариант"
+                },
+                "created_at": {
+                    "description": "Время, когда версия была написана",
+                    "type": "string",
+                    "example": "2025-01-01T12:00:00Z"
+                }
+            }
+        },
+        "models.CommentSettings": {
+            "type": "object",
+            "properties": {
+                "comments_policy": {
+                    "description": "Кто может комментировать: everyone, followers, off",
+                    "type": "string",
+                    "example": "followers"
+                },
+                "hidden_keywords": {
+                    "description": "Комментарии с этими словами видны только их авторам",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "spam",
+                        "реклама"
+                    ]
+                },
+                "owner_id": {
+                    "description": "ID автора фотографии",
+                    "type": "integer",
+                    "example": 42
+                },
+                "photo_id": {
+                    "description": "ID фотографии",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.Conversation": {
+            "type": "object",
+            "properties": {
+                "avatar_url": {
+                    "description": "URL аватара группы",
+                    "type": "string",
+                    "example": "https://example.com/groups/1.jpg"
+                },
+                "created_at": {
+                    "description": "Дата создания беседы",
+                    "type": "string",
+                    "example": "2024-02-01T14:30:00Z"
+                },
+                "id": {
+                    "description": "ID беседы",
+                    "type": "integer",
+                    "example": 1
+                },
+                "is_group": {
+                    "description": "Групповая беседа",
+                    "type": "boolean",
+                    "example": false
+                },
+                "participants": {
+                    "description": "Участники, только при запросе одной беседы",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ConversationParticipant"
+                    }
+                },
+                "title": {
+                    "description": "Название группы",
+                    "type": "string",
+                    "example": "Поход"
+                },
+                "user1_id": {
+                    "description": "ID первого пользователя личной беседы",
+                    "type": "integer",
+                    "example": 42
+                },
+                "user2_id": {
+                    "description": "ID второго пользователя личной беседы",
+                    "type": "integer",
+                    "example": 58
+                }
+            }
+        },
+        "models.ConversationParticipant": {
+            "type": "object",
+            "properties": {
+                "avatar_url": {
+                    "description": "URL аватара",
+                    "type": "string",
+                    "example": "https://example.com/avatars/58.jpg"
+                },
+                "joined_at": {
+                    "description": "Дата вступления",
+                    "type": "string",
+                    "example": "2024-02-01T14:30:00Z"
+                },
+                "last_read_at": {
+                    "description": "Время последней отметки прочтения",
+                    "type": "string",
+                    "example": "2024-02-01T15:50:00Z"
+                },
+                "last_read_message_id": {
+                    "description": "ID последнего прочитанного сообщения, 0 если участник еще ничего не читал",
+                    "type": "integer",
+                    "example": 15
+                },
+                "role": {
+                    "description": "Роль: admin или member",
+                    "type": "string",
+                    "example": "member"
+                },
+                "user_id": {
+                    "description": "ID пользователя",
+                    "type": "integer",
+                    "example": 58
+                },
+                "username": {
+                    "description": "Имя пользователя",
+                    "type": "string",
+                    "example": "janedoe"
+                }
+            }
+        },
+        "models.ConversationRequest": {
+            "type": "object",
+            "properties": {
+                "user_id": {
+                    "description": "ID собеседника",
+                    "type": "integer",
+                    "example": 58
+                }
+            }
+        },
+        "models.ConversationUser": {
+            "type": "object",
+            "properties": {
+                "avatar_url": {
+                    "description": "URL аватара",
+                    "type": "string",
+                    "example": "https://example.com/avatars/58.jpg"
+                },
+                "id": {
+                    "description": "ID пользователя",
+                    "type": "integer",
+                    "example": 58
+                },
+                "username": {
+                    "description": "Имя пользователя",
+                    "type": "string",
+                    "example": "janedoe"
+                }
+            }
+        },
+        "models.GroupRequest": {
+            "type": "object",
+            "properties": {
+                "avatar_url": {
+                    "description": "URL аватара группы",
+                    "type": "string",
+                    "example": "https://example.com/groups/1.jpg"
+                },
+                "title": {
+                    "description": "Название группы",
+                    "type": "string",
+                    "example": "Поход"
+                },
+                "user_ids": {
+                    "description": "Участники при создании, кроме создателя",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        58,
+                        73
+                    ]
+                }
+            }
+        },
+        "models.Highlight": {
+            "type": "object",
+            "properties": {
+                "cover_item_id": {
+                    "description": "ID элемента-обложки. Если не выбран, обложкой служит первый элемент",
+                    "type": "integer",
+                    "example": 7
+                },
+                "cover_url": {
+                    "description": "URL обложки",
+                    "type": "string",
+                    "example": "uploads/20240201160000_story.jpg"
+                },
+                "created_at": {
+                    "description": "Дата создания",
+                    "type": "string",
+                    "example": "2024-02-01T16:30:00Z"
+                },
+                "id": {
+                    "description": "ID подборки",
+                    "type": "integer",
+                    "example": 1
+                },
+                "items": {
+                    "description": "Элементы подборки по порядку, только при запросе одной подборки",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.HighlightItem"
+                    }
+                },
+                "items_count": {
+                    "description": "Количество элементов",
+                    "type": "integer",
+                    "example": 5
+                },
+                "title": {
+                    "description": "Название подборки",
+                    "type": "string",
+                    "example": "Путешествия"
+                },
+                "updated_at": {
+                    "description": "Дата последнего изменения",
+                    "type": "string",
+                    "example": "2024-02-01T16:45:00Z"
+                },
+                "user_id": {
+                    "description": "ID автора",
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "models.HighlightItem": {
+            "type": "object",
+            "properties": {
+                "added_at": {
+                    "description": "Дата добавления в подборку",
+                    "type": "string",
+                    "example": "2024-02-01T16:30:00Z"
+                },
+                "caption": {
+                    "description": "Описание фото или подпись истории",
+                    "type": "string",
+                    "example": "Закат"
+                },
+                "id": {
+                    "description": "ID элемента",
+                    "type": "integer",
+                    "example": 7
+                },
+                "photo_id": {
+                    "description": "ID фото",
+                    "type": "integer",
+                    "example": 101
+                },
+                "story_id": {
+                    "description": "ID истории",
+                    "type": "integer",
+                    "example": 12
+                },
+                "type": {
+                    "description": "Вид элемента: photo или story",
+                    "type": "string",
+                    "example": "story"
+                },
+                "url": {
+                    "description": "URL изображения",
+                    "type": "string",
+                    "example": "uploads/20240201160000_story.jpg"
+                }
+            }
+        },
+        "models.HighlightItemRef": {
+            "type": "object",
+            "properties": {
+                "photo_id": {
+                    "description": "ID фото",
+                    "type": "integer",
+                    "example": 101
+                },
+                "story_id": {
+                    "description": "ID истории",
+                    "type": "integer",
+                    "example": 12
+                }
+            }
+        },
+        "models.HighlightItemsRequest": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "description": "Элементы в нужном порядке",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.HighlightItemRef"
+                    }
+                }
+            }
+        },
+        "models.HighlightRequest": {
+            "type": "object",
+            "properties": {
+                "cover_item_id": {
+                    "description": "ID элемента-обложки, только при изменении",
+                    "type": "integer",
+                    "example": 7
+                },
+                "items": {
+                    "description": "Элементы при создании, в нужном порядке",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.HighlightItemRef"
+                    }
+                },
+                "title": {
+                    "description": "Название подборки",
+                    "type": "string",
+                    "example": "Путешествия"
+                }
+            }
+        },
+        "models.InboxEntry": {
+            "type": "object",
+            "properties": {
+                "avatar_url": {
+                    "description": "URL аватара группы",
+                    "type": "string",
+                    "example": "https://example.com/groups/1.jpg"
+                },
+                "id": {
+                    "description": "ID беседы",
+                    "type": "integer",
+                    "example": 1
+                },
+                "is_group": {
+                    "description": "Групповая беседа",
+                    "type": "boolean",
+                    "example": false
+                },
+                "last_activity_at": {
+                    "description": "Время последней активности",
+                    "type": "string",
+                    "example": "2024-02-01T15:45:00Z"
+                },
+                "last_message": {
+                    "description": "Последнее сообщение, отсутствует в пустой беседе",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MessagePreview"
+                        }
+                    ]
+                },
+                "title": {
+                    "description": "Название группы",
+                    "type": "string",
+                    "example": "Поход"
+                },
+                "unread_count": {
+                    "description": "Количество непрочитанных сообщений собеседника",
+                    "type": "integer",
+                    "example": 2
+                },
+                "user": {
+                    "description": "Собеседник личной беседы",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ConversationUser"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.InboxPage": {
+            "type": "object",
+            "properties": {
+                "conversations": {
+                    "description": "Беседы, последние активные первыми",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.InboxEntry"
+                    }
+                },
+                "next_cursor": {
+                    "description": "Курсор следующей страницы, отсутствует на последней странице",
+                    "type": "string",
+                    "example": "eyJpZCI6MTJ9"
+                }
+            }
+        },
+        "models.Liker": {
+            "type": "object",
+            "properties": {
+                "avatar_url": {
+                    "description": "URL аватара",
+                    "type": "string",
+                    "example": "https://example.com/avatars/42.jpg"
+                },
+                "followed_by_viewer": {
+                    "description": "Текущий пользователь подписан на этого пользователя",
+                    "type": "boolean",
+                    "example": true
+                },
+                "id": {
+                    "description": "ID пользователя",
+                    "type": "integer",
+                    "example": 42
+                },
+                "liked_at": {
+                    "description": "Дата и время лайка",
+                    "type": "string",
+                    "example": "2024-02-01T16:30:00Z"
+                },
+                "username": {
+                    "description": "Имя пользователя",
+                    "type": "string",
+                    "example": "johndoe"
+                }
+            }
+        },
+        "models.LikersPage": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "description": "Курсор следующей страницы, отсутствует на последней странице",
+                    "type": "string",
+                    "example": "eyJpZCI6MTJ9"
+                },
+                "users": {
+                    "description": "Пользователи страницы: сначала те, на кого подписан текущий пользователь",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Liker"
+                    }
+                }
+            }
+        },
+        "models.Mention": {
+            "type": "object",
+            "properties": {
+                "length": {
+                    "description": "Длина упоминания вместе с @ (в символах)",
+                    "type": "integer",
+                    "example": 6
+                },
+                "offset": {
+                    "description": "Позиция символа @ в тексте (в символах)",
+                    "type": "integer",
+                    "example": 8
+                },
+                "user_id": {
+                    "description": "ID упомянутого пользователя",
+                    "type": "integer",
+                    "example": 58
+                },
+                "username": {
+                    "description": "Имя упомянутого пользователя",
+                    "type": "string",
+                    "example": "alice"
+                }
+            }
+        },
+        "models.Message": {
+            "type": "object",
+            "properties": {
+                "client_message_id": {
+                    "description": "ID, присвоенный сообщению клиентом-отправителем",
+                    "type": "string",
+                    "example": "6f1c2a9e-3b7d-4c41-9a55-0e8f2d7b1c3a"
+                },
+                "content": {
+                    "description": "Содержимое сообщения",
+                    "type": "string",
+                    "example": "Привет! Как дела?"
+                },
+                "conversation_id": {
+                    "description": "ID беседы, к которой относится сообщение",
+                    "type": "integer",
+                    "example": 101
+                },
+                "created_at": {
+                    "description": "Дата и время отправки сообщения",
+                    "type": "string",
+                    "example": "2024-02-01T15:45:00Z"
+                },
+                "id": {
+                    "description": "ID сообщения",
+                    "type": "integer",
+                    "example": 1
+                },
+                "kind": {
+                    "description": "Вид сообщения: text или вид служебного сообщения",
+                    "type": "string",
+                    "example": "text"
+                },
+                "sender_id": {
+                    "description": "ID отправителя сообщения",
+                    "type": "integer",
+                    "example": 42
+                },
+                "target_user_id": {
+                    "description": "ID пользователя, над которым совершено действие служебного сообщения",
+                    "type": "integer",
+                    "example": 73
+                }
+            }
+        },
+        "models.MessagePreview": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "description": "Начало текста сообщения",
+                    "type": "string",
+                    "example": "До встречи!"
+                },
+                "created_at": {
+                    "description": "Дата отправки",
+                    "type": "string",
+                    "example": "2024-02-01T15:45:00Z"
+                },
+                "id": {
+                    "description": "ID сообщения",
+                    "type": "integer",
+                    "example": 15
+                },
+                "sender_id": {
+                    "description": "ID отправителя",
+                    "type": "integer",
+                    "example": 58
+                }
             }
-        }
-    },
-    "definitions": {
-        "models.Comment": {
+        },
+        "models.Notification": {
             "type": "object",
             "properties": {
-                "content": {
-                    "description": "Текст комментария",
+                "actor_id": {
+                    "description": "ID последнего пользователя, вызвавшего событие",
+                    "type": "integer",
+                    "example": 58
+                },
+                "actor_username": {
+                    "description": "Имя последнего пользователя, вызвавшего событие",
                     "type": "string",
-                    "example": "Отличное фото!"
+                    "example": "alice"
+                },
+                "actors_count": {
+                    "description": "Количество разных пользователей в уведомлении",
+                    "type": "integer",
+                    "example": 13
+                },
+                "comment_id": {
+                    "description": "ID комментария, к которому относится событие",
+                    "type": "integer",
+                    "example": 7
                 },
                 "created_at": {
-                    "description": "Дата создания комментария",
+                    "description": "Дата создания уведомления",
                     "type": "string",
-                    "example": "2024-01-31T12:45:00Z"
+                    "example": "2024-02-01T16:30:00Z"
                 },
                 "id": {
-                    "description": "ID комментария",
+                    "description": "ID уведомления",
                     "type": "integer",
                     "example": 1
                 },
                 "photo_id": {
-                    "description": "ID фото, к которому относится комментарий",
+                    "description": "ID фото, к которому относится событие",
                     "type": "integer",
                     "example": 101
                 },
+                "read": {
+                    "description": "Флаг прочтения",
+                    "type": "boolean",
+                    "example": false
+                },
+                "text": {
+                    "description": "Текст уведомления",
+                    "type": "string",
+                    "example": "alice and 12 others liked your photo"
+                },
+                "type": {
+                    "description": "Тип уведомления: like, comment, follow, mention",
+                    "type": "string",
+                    "example": "like"
+                },
                 "updated_at": {
-                    "description": "Дата последнего обновления комментария",
+                    "description": "Дата последнего события в уведомлении",
                     "type": "string",
-                    "example": "2024-01-31T12:50:00Z"
+                    "example": "2024-02-01T16:45:00Z"
                 },
                 "user_id": {
-                    "description": "ID пользователя, оставившего комментарий",
+                    "description": "ID получателя",
                     "type": "integer",
                     "example": 42
+                }
+            }
+        },
+        "models.OrderRequest": {
+            "type": "object",
+            "properties": {
+                "ids": {
+                    "description": "ID в новом порядке",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        3,
+                        1,
+                        2
+                    ]
+                }
+            }
+        },
+        "models.ParticipantsRequest": {
+            "type": "object",
+            "properties": {
+                "user_ids": {
+                    "description": "ID пользователей",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        58,
+                        73
+                    ]
+                }
+            }
+        },
+        "models.Photo": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "Дата загрузки фото (в формате ISO 8601)",
+                    "type": "string",
+                    "example": "2024-02-01T16:00:00Z"
                 },
-                "username": {
-                    "description": "Имя пользователя, оставившего комментарий",
+                "description": {
+                    "description": "Описание фотографии",
                     "type": "string",
-                    "example": "johndoe"
+                    "example": "Закат на пляже"
+                },
+                "entities": {
+                    "description": "Упоминания пользователей в описании",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Mention"
+                    }
+                },
+                "id": {
+                    "description": "ID фотографии",
+                    "type": "integer",
+                    "example": 1
+                },
+                "url": {
+                    "description": "URL изображения",
+                    "type": "string",
+                    "example": "https://example.com/uploads/photo1.jpg"
+                },
+                "user_id": {
+                    "description": "ID пользователя, загрузившего фото",
+                    "type": "integer",
+                    "example": 42
                 }
             }
         },
-        "models.Message": {
+        "models.PhotoViewerState": {
             "type": "object",
             "properties": {
-                "content": {
-                    "description": "Содержимое сообщения",
-                    "type": "string",
-                    "example": "Привет! Как дела?"
+                "comments_count": {
+                    "description": "Количество комментариев",
+                    "type": "integer",
+                    "example": 3
                 },
-                "conversation_id": {
-                    "description": "ID беседы, к которой относится сообщение",
+                "liked_by_viewer": {
+                    "description": "Текущий пользователь поставил лайк",
+                    "type": "boolean",
+                    "example": true
+                },
+                "likes_count": {
+                    "description": "Количество лайков",
                     "type": "integer",
-                    "example": 101
+                    "example": 12
                 },
-                "created_at": {
-                    "description": "Дата и время отправки сообщения",
+                "photo_id": {
+                    "description": "ID фотографии",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.Presence": {
+            "type": "object",
+            "properties": {
+                "last_seen_at": {
+                    "description": "Время последнего выхода из сети, если пользователь его не скрыл",
                     "type": "string",
                     "example": "2024-02-01T15:45:00Z"
                 },
-                "id": {
-                    "description": "ID сообщения",
+                "online": {
+                    "description": "Пользователь в сети",
+                    "type": "boolean",
+                    "example": false
+                },
+                "user_id": {
+                    "description": "ID пользователя",
+                    "type": "integer",
+                    "example": 58
+                }
+            }
+        },
+        "models.PresenceSettings": {
+            "type": "object",
+            "properties": {
+                "hide_last_seen": {
+                    "description": "Скрывать время последнего выхода из сети от других пользователей",
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "models.ReactionRequest": {
+            "type": "object",
+            "properties": {
+                "reaction": {
+                    "description": "Реакция из разрешенного набора",
+                    "type": "string",
+                    "example": "😂"
+                }
+            }
+        },
+        "models.ReactionSummary": {
+            "type": "object",
+            "properties": {
+                "counts": {
+                    "description": "Количество реакций каждого вида",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "viewer_reaction": {
+                    "description": "Реакция текущего пользователя, отсутствует, если он не реагировал",
+                    "type": "string",
+                    "example": "🔥"
+                }
+            }
+        },
+        "models.ReadReceipt": {
+            "type": "object",
+            "properties": {
+                "conversation_id": {
+                    "description": "ID беседы",
                     "type": "integer",
                     "example": 1
                 },
-                "sender_id": {
-                    "description": "ID отправителя сообщения",
+                "last_read_message_id": {
+                    "description": "ID последнего прочитанного сообщения",
                     "type": "integer",
-                    "example": 42
+                    "example": 15
+                },
+                "read_at": {
+                    "description": "Время отметки, отсутствует, если участник еще ничего не читал",
+                    "type": "string",
+                    "example": "2024-02-01T15:50:00Z"
+                },
+                "user_id": {
+                    "description": "ID прочитавшего пользователя",
+                    "type": "integer",
+                    "example": 58
                 }
             }
         },
-        "models.Photo": {
+        "models.ReadRequest": {
+            "type": "object",
+            "properties": {
+                "message_id": {
+                    "description": "ID последнего прочитанного сообщения. Если не указан, беседа читается до последнего сообщения",
+                    "type": "integer",
+                    "example": 15
+                }
+            }
+        },
+        "models.RoleRequest": {
+            "type": "object",
+            "properties": {
+                "role": {
+                    "description": "Роль: admin или member",
+                    "type": "string",
+                    "example": "admin"
+                }
+            }
+        },
+        "models.SaveRequest": {
+            "type": "object",
+            "properties": {
+                "collection_id": {
+                    "description": "ID коллекции. Если не указан, фото сохраняется в «Saved»",
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "models.SavedPhoto": {
             "type": "object",
             "properties": {
                 "created_at": {
@@ -809,11 +6434,23 @@ const docTemplate = `{
                     "type": "string",
                     "example": "Закат на пляже"
                 },
+                "entities": {
+                    "description": "Упоминания пользователей в описании",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Mention"
+                    }
+                },
                 "id": {
                     "description": "ID фотографии",
                     "type": "integer",
                     "example": 1
                 },
+                "saved_at": {
+                    "description": "Дата сохранения",
+                    "type": "string",
+                    "example": "2024-02-01T16:30:00Z"
+                },
                 "url": {
                     "description": "URL изображения",
                     "type": "string",
@@ -826,6 +6463,143 @@ const docTemplate = `{
                 }
             }
         },
+        "models.SavedPhotosPage": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "description": "Курсор следующей страницы, отсутствует на последней странице",
+                    "type": "string",
+                    "example": "eyJpZCI6MTJ9"
+                },
+                "photos": {
+                    "description": "Фото страницы, последние сохраненные первыми",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SavedPhoto"
+                    }
+                }
+            }
+        },
+        "models.Story": {
+            "type": "object",
+            "properties": {
+                "caption": {
+                    "description": "Подпись",
+                    "type": "string",
+                    "example": "Доброе утро"
+                },
+                "created_at": {
+                    "description": "Дата публикации",
+                    "type": "string",
+                    "example": "2024-02-01T16:00:00Z"
+                },
+                "expires_at": {
+                    "description": "Дата, после которой история исчезает",
+                    "type": "string",
+                    "example": "2024-02-02T16:00:00Z"
+                },
+                "id": {
+                    "description": "ID истории",
+                    "type": "integer",
+                    "example": 1
+                },
+                "seen": {
+                    "description": "Текущий пользователь уже просмотрел историю",
+                    "type": "boolean",
+                    "example": false
+                },
+                "url": {
+                    "description": "URL изображения",
+                    "type": "string",
+                    "example": "uploads/20240201160000_story.jpg"
+                },
+                "user_id": {
+                    "description": "ID автора",
+                    "type": "integer",
+                    "example": 42
+                },
+                "views_count": {
+                    "description": "Количество просмотров, только для автора",
+                    "type": "integer",
+                    "example": 15
+                }
+            }
+        },
+        "models.StoryTrayItem": {
+            "type": "object",
+            "properties": {
+                "avatar_url": {
+                    "description": "URL аватара автора",
+                    "type": "string",
+                    "example": "https://example.com/avatars/42.jpg"
+                },
+                "has_unseen": {
+                    "description": "Есть непросмотренные истории",
+                    "type": "boolean",
+                    "example": true
+                },
+                "latest_at": {
+                    "description": "Дата последней истории",
+                    "type": "string",
+                    "example": "2024-02-01T16:00:00Z"
+                },
+                "stories_count": {
+                    "description": "Количество активных историй",
+                    "type": "integer",
+                    "example": 3
+                },
+                "user_id": {
+                    "description": "ID автора",
+                    "type": "integer",
+                    "example": 42
+                },
+                "username": {
+                    "description": "Имя автора",
+                    "type": "string",
+                    "example": "johndoe"
+                }
+            }
+        },
+        "models.StoryViewer": {
+            "type": "object",
+            "properties": {
+                "avatar_url": {
+                    "description": "URL аватара",
+                    "type": "string",
+                    "example": "https://example.com/avatars/58.jpg"
+                },
+                "user_id": {
+                    "description": "ID пользователя",
+                    "type": "integer",
+                    "example": 58
+                },
+                "username": {
+                    "description": "Имя пользователя",
+                    "type": "string",
+                    "example": "alice"
+                },
+                "viewed_at": {
+                    "description": "Дата просмотра",
+                    "type": "string",
+                    "example": "2024-02-01T16:30:00Z"
+                }
+            }
+        },
+        "models.UnreadCount": {
+            "type": "object",
+            "properties": {
+                "conversations": {
+                    "description": "Количество бесед с непрочитанными сообщениями",
+                    "type": "integer",
+                    "example": 2
+                },
+                "messages": {
+                    "description": "Количество непрочитанных сообщений во всех беседах",
+                    "type": "integer",
+                    "example": 5
+                }
+            }
+        },
         "models.User": {
             "type": "object",
             "properties": {
@@ -855,6 +6629,21 @@ const docTemplate = `{
                     "example": true
                 }
             }
+        },
+        "models.WSTicket": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "description": "Время, после которого билет недействителен",
+                    "type": "string",
+                    "example": "2024-02-01T15:45:30Z"
+                },
+                "ticket": {
+                    "description": "Билет для параметра ticket при подключении к /ws",
+                    "type": "string",
+                    "example": "q3Jd2u0x9Q4m1bXr7Lk5yA"
+                }
+            }
         }
     }
 }`
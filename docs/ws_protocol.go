@@ -0,0 +1,8 @@
+package docs
+
+import _ "embed"
+
+// WSProtocolSchema JSON-схема протокола WebSocket
+//
+//go:embed ws-protocol.schema.json
+var WSProtocolSchema []byte
@@ -0,0 +1,366 @@
+package handlers
+
+import (
+	"InstaSpace/internal/models"
+	"InstaSpace/internal/repositories"
+	"InstaSpace/internal/services"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+type AlbumHandler struct {
+	Service services.AlbumServiceInterface
+	Logger  *zap.Logger
+}
+
+func NewAlbumHandler(service services.AlbumServiceInterface, logger *zap.Logger) *AlbumHandler {
+	return &AlbumHandler{Service: service, Logger: logger}
+}
+
+// CreateAlbum создает альбом
+//
+// @Summary Создать альбом
+// @Description Создает альбом из собственных фото текущего пользователя. Фото из photo_ids добавляются в переданном порядке
+// @Tags Albums
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param album body models.AlbumRequest true "Альбом"
+// @Success 201 {object} models.Album
+// @Failure 400 {string} string "Некорректные данные альбома, чужие фото или обложка не из альбома"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/albums [post]
+func (h *AlbumHandler) CreateAlbum(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.AlbumRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	album, err := h.Service.CreateAlbum(r.Context(), userID, req)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.Logger.Info("Album created", zap.Int("albumID", album.ID), zap.Int("userID", userID))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(album)
+}
+
+// GetUserAlbums возвращает альбомы пользователя
+//
+// @Summary Альбомы пользователя
+// @Description Возвращает альбомы пользователя, которые может видеть текущий пользователь: публичные, для подписчиков, если он подписан, и все свои
+// @Tags Albums
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} map[string][]models.Album "albums: [список альбомов]"
+// @Failure 400 {string} string "Некорректный ID"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/users/{id}/albums [get]
+func (h *AlbumHandler) GetUserAlbums(w http.ResponseWriter, r *http.Request) {
+	viewerID, ownerID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	albums, err := h.Service.GetUserAlbums(r.Context(), ownerID, viewerID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string][]models.Album{"albums": albums})
+}
+
+// GetAlbum возвращает альбом
+//
+// @Summary Получить альбом
+// @Description Возвращает альбом, если текущий пользователь может его видеть
+// @Tags Albums
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID альбома"
+// @Success 200 {object} models.Album
+// @Failure 400 {string} string "Некорректный ID"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 404 {string} string "Альбом не найден или недоступен"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/albums/{id} [get]
+func (h *AlbumHandler) GetAlbum(w http.ResponseWriter, r *http.Request) {
+	userID, albumID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	album, err := h.Service.GetAlbum(r.Context(), albumID, userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(album)
+}
+
+// GetAlbumPhotos возвращает фото альбома
+//
+// @Summary Фото альбома
+// @Description Возвращает фото альбома в порядке, заданном автором
+// @Tags Albums
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID альбома"
+// @Param limit query int false "Количество фото (по умолчанию 50, максимум 100)"
+// @Param offset query int false "Смещение"
+// @Success 200 {array} models.Photo
+// @Failure 400 {string} string "Некорректные параметры"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 404 {string} string "Альбом не найден или недоступен"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/albums/{id}/photos [get]
+func (h *AlbumHandler) GetAlbumPhotos(w http.ResponseWriter, r *http.Request) {
+	userID, albumID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	limit, offset := 0, 0
+	var err error
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := r.URL.Query().Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Invalid offset", http.StatusBadRequest)
+			return
+		}
+	}
+
+	photos, err := h.Service.GetAlbumPhotos(r.Context(), albumID, userID, limit, offset)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(photos)
+}
+
+// UpdateAlbum изменяет альбом
+//
+// @Summary Изменить альбом
+// @Description Меняет название, описание, видимость и обложку альбома. Без cover_photo_id обложкой становится первое фото
+// @Tags Albums
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID альбома"
+// @Param album body models.AlbumRequest true "Альбом"
+// @Success 200 {object} models.Album
+// @Failure 400 {string} string "Некорректные данные альбома или обложка не из альбома"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 404 {string} string "Альбом не найден"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/albums/{id} [put]
+func (h *AlbumHandler) UpdateAlbum(w http.ResponseWriter, r *http.Request) {
+	userID, albumID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.AlbumRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	album, err := h.Service.UpdateAlbum(r.Context(), albumID, userID, req)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.Logger.Info("Album updated", zap.Int("albumID", albumID), zap.Int("userID", userID))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(album)
+}
+
+// DeleteAlbum удаляет альбом
+//
+// @Summary Удалить альбом
+// @Description Удаляет альбом текущего пользователя. Фото альбома не удаляются
+// @Tags Albums
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID альбома"
+// @Success 200 {object} map[string]string "message: Album deleted"
+// @Failure 400 {string} string "Некорректный ID"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 404 {string} string "Альбом не найден"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/albums/{id} [delete]
+func (h *AlbumHandler) DeleteAlbum(w http.ResponseWriter, r *http.Request) {
+	userID, albumID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Service.DeleteAlbum(r.Context(), albumID, userID); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.Logger.Info("Album deleted", zap.Int("albumID", albumID), zap.Int("userID", userID))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Album deleted"})
+}
+
+// AddAlbumPhotos добавляет фото в альбом
+//
+// @Summary Добавить фото в альбом
+// @Description Добавляет собственные фото в конец альбома в переданном порядке. Фото, которые уже есть в альбоме, не перемещаются
+// @Tags Albums
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID альбома"
+// @Param photos body models.AlbumPhotosRequest true "Фото"
+// @Success 200 {object} map[string]string "message: Photos added"
+// @Failure 400 {string} string "Чужие или несуществующие фото, превышен лимит"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 404 {string} string "Альбом не найден"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/albums/{id}/photos [post]
+func (h *AlbumHandler) AddAlbumPhotos(w http.ResponseWriter, r *http.Request) {
+	h.handlePhotoList(w, r, h.Service.AddAlbumPhotos, "Photos added")
+}
+
+// ReorderAlbum меняет порядок фото альбома
+//
+// @Summary Изменить порядок фото
+// @Description Задает новый порядок фото альбома. Список должен содержать каждое фото альбома ровно один раз
+// @Tags Albums
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID альбома"
+// @Param photos body models.AlbumPhotosRequest true "Фото в новом порядке"
+// @Success 200 {object} map[string]string "message: Album reordered"
+// @Failure 400 {string} string "Список не совпадает с фото альбома"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 404 {string} string "Альбом не найден"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/albums/{id}/order [put]
+func (h *AlbumHandler) ReorderAlbum(w http.ResponseWriter, r *http.Request) {
+	h.handlePhotoList(w, r, h.Service.ReorderAlbum, "Album reordered")
+}
+
+// RemoveAlbumPhoto убирает фото из альбома
+//
+// @Summary Убрать фото из альбома
+// @Description Убирает фото из альбома. Если фото было обложкой, обложкой становится первое фото. Отсутствующее фото не является ошибкой
+// @Tags Albums
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID альбома"
+// @Param photoID path int true "ID фото"
+// @Success 200 {object} map[string]string "message: Photo removed"
+// @Failure 400 {string} string "Некорректный ID"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 404 {string} string "Альбом не найден"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/albums/{id}/photos/{photoID} [delete]
+func (h *AlbumHandler) RemoveAlbumPhoto(w http.ResponseWriter, r *http.Request) {
+	userID, albumID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	photoID, err := strconv.Atoi(mux.Vars(r)["photoID"])
+	if err != nil || photoID <= 0 {
+		http.Error(w, "Invalid photo ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.RemoveAlbumPhoto(r.Context(), albumID, userID, photoID); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.Logger.Info("Album photo removed", zap.Int("albumID", albumID), zap.Int("photoID", photoID))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Photo removed"})
+}
+
+// handlePhotoList применяет к альбому из пути {id} действие со списком фото из тела запроса
+func (h *AlbumHandler) handlePhotoList(w http.ResponseWriter, r *http.Request, action func(ctx context.Context, albumID, userID int, photoIDs []int) error, message string) {
+	userID, albumID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.AlbumPhotosRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := action(r.Context(), albumID, userID, req.PhotoIDs); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.Logger.Info(message, zap.Int("albumID", albumID), zap.Int("userID", userID))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
+
+// parseID возвращает ID текущего пользователя и ID из пути {id}
+func (h *AlbumHandler) parseID(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return 0, 0, false
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return userID, id, true
+}
+
+func (h *AlbumHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidAlbumTitle), errors.Is(err, services.ErrInvalidAlbumVisibility),
+		errors.Is(err, services.ErrInvalidAlbumPhotoIDs), errors.Is(err, repositories.ErrInvalidAlbumPhotos),
+		errors.Is(err, repositories.ErrInvalidAlbumCover), errors.Is(err, repositories.ErrInvalidAlbumOrder),
+		errors.Is(err, repositories.ErrAlbumFull), errors.Is(err, repositories.ErrInvalidUserID):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repositories.ErrAlbumNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		h.Logger.Error("Failed to process album request", zap.Error(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"InstaSpace/internal/services"
+	"encoding/json"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+type BlockHandler struct {
+	Service services.BlockServiceInterface
+	Logger  *zap.Logger
+}
+
+func NewBlockHandler(service services.BlockServiceInterface, logger *zap.Logger) *BlockHandler {
+	return &BlockHandler{Service: service, Logger: logger}
+}
+
+// Block блокирует пользователя
+//
+// @Summary Заблокировать пользователя
+// @Description Блокирует пользователя: он не сможет подписаться, писать сообщения, комментировать и лайкать фото. Подписки в обе стороны удаляются
+// @Tags Blocks
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} map[string]string "message: User blocked successfully"
+// @Failure 400 {string} string "Некорректный ID"
+// @Failure 401 {string} string "Не авторизован"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/users/{id}/block [post]
+func (h *BlockHandler) Block(w http.ResponseWriter, r *http.Request) {
+	handleRelation(w, r, h.Logger, h.Service.Block, "User blocked successfully")
+}
+
+// Unblock снимает блокировку
+//
+// @Summary Разблокировать пользователя
+// @Description Снимает блокировку с пользователя. Повторный вызов не является ошибкой
+// @Tags Blocks
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} map[string]string "message: User unblocked successfully"
+// @Failure 400 {string} string "Некорректный ID"
+// @Failure 401 {string} string "Не авторизован"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/users/{id}/block [delete]
+func (h *BlockHandler) Unblock(w http.ResponseWriter, r *http.Request) {
+	handleRelation(w, r, h.Logger, h.Service.Unblock, "User unblocked successfully")
+}
+
+// GetBlocked возвращает заблокированных пользователей
+//
+// @Summary Список заблокированных
+// @Description Возвращает пользователей, заблокированных текущим пользователем
+// @Tags Blocks
+// @Produce json
+// @Success 200 {object} map[string]interface{} "users: [список пользователей]"
+// @Failure 401 {string} string "Не авторизован"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/blocks [get]
+func (h *BlockHandler) GetBlocked(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	users, err := h.Service.GetBlocked(r.Context(), userID)
+	if err != nil {
+		h.Logger.Error("Failed to get blocked users", zap.Int("userID", userID), zap.Error(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{"users": users})
+}
+
+// Mute скрывает пользователя из ленты
+//
+// @Summary Скрыть пользователя
+// @Description Скрывает публикации пользователя из ленты текущего пользователя
+// @Tags Blocks
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} map[string]string "message: User muted successfully"
+// @Failure 400 {string} string "Некорректный ID"
+// @Failure 401 {string} string "Не авторизован"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/users/{id}/mute [post]
+func (h *BlockHandler) Mute(w http.ResponseWriter, r *http.Request) {
+	handleRelation(w, r, h.Logger, h.Service.Mute, "User muted successfully")
+}
+
+// Unmute возвращает пользователя в ленту
+//
+// @Summary Вернуть пользователя в ленту
+// @Description Отменяет скрытие публикаций пользователя. Повторный вызов не является ошибкой
+// @Tags Blocks
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} map[string]string "message: User unmuted successfully"
+// @Failure 400 {string} string "Некорректный ID"
+// @Failure 401 {string} string "Не авторизован"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/users/{id}/mute [delete]
+func (h *BlockHandler) Unmute(w http.ResponseWriter, r *http.Request) {
+	handleRelation(w, r, h.Logger, h.Service.Unmute, "User unmuted successfully")
+}
+
+// GetMuted возвращает скрытых пользователей
+//
+// @Summary Список скрытых
+// @Description Возвращает пользователей, скрытых из ленты текущего пользователя
+// @Tags Blocks
+// @Produce json
+// @Success 200 {object} map[string]interface{} "users: [список пользователей]"
+// @Failure 401 {string} string "Не авторизован"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/mutes [get]
+func (h *BlockHandler) GetMuted(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	users, err := h.Service.GetMuted(r.Context(), userID)
+	if err != nil {
+		h.Logger.Error("Failed to get muted users", zap.Int("userID", userID), zap.Error(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{"users": users})
+}
@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"InstaSpace/internal/models"
+	"InstaSpace/internal/repositories"
+	"InstaSpace/internal/services"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+type CollectionHandler struct {
+	Service services.CollectionServiceInterface
+	Logger  *zap.Logger
+}
+
+func NewCollectionHandler(service services.CollectionServiceInterface, logger *zap.Logger) *CollectionHandler {
+	return &CollectionHandler{Service: service, Logger: logger}
+}
+
+// GetCollections возвращает коллекции текущего пользователя
+//
+// @Summary Список коллекций
+// @Description Возвращает коллекции сохраненных фото текущего пользователя. Первой идет коллекция по умолчанию «Saved»
+// @Tags Collections
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string][]models.Collection "collections: [список коллекций]"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/collections [get]
+func (h *CollectionHandler) GetCollections(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	collections, err := h.Service.GetCollections(r.Context(), userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string][]models.Collection{"collections": collections})
+}
+
+// CreateCollection создает коллекцию
+//
+// @Summary Создать коллекцию
+// @Description Создает именованную коллекцию сохраненных фото
+// @Tags Collections
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param collection body models.CollectionRequest true "Название коллекции"
+// @Success 201 {object} models.Collection
+// @Failure 400 {string} string "Некорректное название"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 409 {string} string "Коллекция с таким названием уже есть"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/collections [post]
+func (h *CollectionHandler) CreateCollection(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.CollectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	collection, err := h.Service.CreateCollection(r.Context(), userID, req.Name)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.Logger.Info("Collection created", zap.Int("collectionID", collection.ID), zap.Int("userID", userID))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(collection)
+}
+
+// DeleteCollection удаляет коллекцию
+//
+// @Summary Удалить коллекцию
+// @Description Удаляет именованную коллекцию вместе с сохранениями. Коллекцию «Saved» удалить нельзя
+// @Tags Collections
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID коллекции"
+// @Success 200 {object} map[string]string "message: Collection deleted"
+// @Failure 400 {string} string "Некорректный ID"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 404 {string} string "Коллекция не найдена"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/collections/{id} [delete]
+func (h *CollectionHandler) DeleteCollection(w http.ResponseWriter, r *http.Request) {
+	userID, collectionID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Service.DeleteCollection(r.Context(), collectionID, userID); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.Logger.Info("Collection deleted", zap.Int("collectionID", collectionID), zap.Int("userID", userID))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Collection deleted"})
+}
+
+// GetCollectionPhotos возвращает фото коллекции
+//
+// @Summary Фото коллекции
+// @Description Возвращает страницу фото коллекции, последние сохраненные первыми. Фото авторов, с которыми есть блокировка, не показываются
+// @Tags Collections
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID коллекции"
+// @Param limit query int false "Количество фото (по умолчанию 30, максимум 100)"
+// @Param cursor query string false "Курсор следующей страницы из next_cursor"
+// @Success 200 {object} models.SavedPhotosPage
+// @Failure 400 {string} string "Некорректный ID или параметры страницы"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 404 {string} string "Коллекция не найдена"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/collections/{id}/photos [get]
+func (h *CollectionHandler) GetCollectionPhotos(w http.ResponseWriter, r *http.Request) {
+	userID, collectionID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	params := services.SavedPhotoListParams{Cursor: r.URL.Query().Get("cursor")}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		params.Limit = limit
+	}
+
+	page, err := h.Service.GetCollectionPhotos(r.Context(), collectionID, userID, params)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(page)
+}
+
+// SavePhoto сохраняет фото в коллекцию
+//
+// @Summary Сохранить фото
+// @Description Сохраняет фото в коллекцию текущего пользователя, по умолчанию в «Saved». Повторное сохранение ничего не меняет
+// @Tags Collections
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID фото"
+// @Param save body models.SaveRequest false "Коллекция"
+// @Success 200 {object} map[string]string "message: Photo saved"
+// @Failure 400 {string} string "Некорректный ID"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 403 {string} string "Автор фото заблокировал пользователя"
+// @Failure 404 {string} string "Фото или коллекция не найдены"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/photos/{id}/save [put]
+func (h *CollectionHandler) SavePhoto(w http.ResponseWriter, r *http.Request) {
+	userID, photoID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.SaveRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := h.Service.SavePhoto(r.Context(), userID, photoID, req.CollectionID); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.Logger.Info("Photo saved", zap.Int("photoID", photoID), zap.Int("userID", userID))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Photo saved"})
+}
+
+// UnsavePhoto убирает фото из коллекции
+//
+// @Summary Убрать фото из сохраненных
+// @Description Убирает фото из указанной коллекции или, если collectionID не передан, из всех коллекций текущего пользователя
+// @Tags Collections
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID фото"
+// @Param collectionID query int false "ID коллекции"
+// @Success 200 {object} map[string]string "message: Photo unsaved"
+// @Failure 400 {string} string "Некорректный ID"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 404 {string} string "Коллекция не найдена"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/photos/{id}/save [delete]
+func (h *CollectionHandler) UnsavePhoto(w http.ResponseWriter, r *http.Request) {
+	userID, photoID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	collectionID := 0
+	if v := r.URL.Query().Get("collectionID"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			http.Error(w, "Invalid collection ID", http.StatusBadRequest)
+			return
+		}
+		collectionID = id
+	}
+
+	if err := h.Service.UnsavePhoto(r.Context(), userID, photoID, collectionID); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.Logger.Info("Photo unsaved", zap.Int("photoID", photoID), zap.Int("userID", userID))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Photo unsaved"})
+}
+
+// parseID возвращает ID текущего пользователя и ID из пути {id}
+func (h *CollectionHandler) parseID(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return 0, 0, false
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return userID, id, true
+}
+
+func (h *CollectionHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidCollectionName), errors.Is(err, services.ErrInvalidCursor),
+		errors.Is(err, repositories.ErrInvalidUserID):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrUserBlocked):
+		http.Error(w, "User is blocked", http.StatusForbidden)
+	case errors.Is(err, repositories.ErrInvalidPhotoID):
+		http.Error(w, "Photo not found", http.StatusNotFound)
+	case errors.Is(err, repositories.ErrCollectionNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, repositories.ErrCollectionExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		h.Logger.Error("Failed to process collection request", zap.Error(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
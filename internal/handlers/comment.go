@@ -2,8 +2,12 @@ package handlers
 
 import (
 	"InstaSpace/internal/models"
+	"InstaSpace/internal/repositories"
 	"InstaSpace/internal/services"
+	"InstaSpace/pkg/middleware"
+	"context"
 	"encoding/json"
+	"errors"
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 	"net/http"
@@ -22,26 +26,36 @@ func NewCommentHandler(service services.CommentServiceInterface, logger *zap.Log
 // CreateComment создает новый комментарий
 //
 // @Summary Создать комментарий
-// @Description Создает новый комментарий к фото
+// @Description Создает новый комментарий к фото от имени текущего пользователя, user_id в теле игнорируется. Если указан parent_id, комментарий становится ответом; ответ на ответ прикрепляется к комментарию верхнего уровня. Упоминания @username сохраняются и возвращаются в entities, упоминания несуществующих и заблокированных пользователей игнорируются
 // @Tags Comments
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param comment body models.Comment true "Данные комментария"
-// @Success 201 {object} map[string]interface{} "message: comment created successfully, id: 1"
+// @Success 201 {object} map[string]interface{} "message: comment created successfully, id: 1, entities: [упоминания]"
 // @Failure 400 {string} string "Некорректный ввод"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 403 {string} string "Автор фото заблокировал пользователя или ограничил комментарии"
 // @Failure 500 {string} string "Ошибка сервера"
 // @Router /api/comments [post]
 func (h *CommentHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
 	h.Logger.Info("Получен запрос на создание комментария")
 
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
 	var comment models.Comment
 	if err := json.NewDecoder(r.Body).Decode(&comment); err != nil {
 		h.Logger.Error("Ошибка декодирования тела запроса", zap.Error(err))
 		http.Error(w, "invalid request body", http.StatusBadRequest)
 		return
 	}
+	// Автор — владелец токена, user_id из тела игнорируется
+	comment.UserID = userID
 
-	if comment.PhotoID <= 0 || comment.UserID <= 0 || len(comment.Content) == 0 {
+	if comment.PhotoID <= 0 || len(comment.Content) == 0 {
 		h.Logger.Warn("Некорректные данные для комментария", zap.Any("comment", comment))
 		http.Error(w, "invalid input data", http.StatusBadRequest)
 		return
@@ -51,7 +65,16 @@ func (h *CommentHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		if err == services.ErrInvalidForeignKey {
 			h.Logger.Warn("Ошибка внешнего ключа", zap.Error(err))
-			http.Error(w, "invalid photo_id or user_id", http.StatusBadRequest)
+			http.Error(w, "invalid photo_id", http.StatusBadRequest)
+		} else if errors.Is(err, services.ErrInvalidParentComment) {
+			h.Logger.Warn("Некорректный родительский комментарий", zap.Intp("parentID", comment.ParentID))
+			http.Error(w, "invalid parent_id", http.StatusBadRequest)
+		} else if errors.Is(err, services.ErrUserBlocked) {
+			h.Logger.Warn("Комментирование недоступно из-за блокировки", zap.Int("photoID", comment.PhotoID), zap.Int("userID", comment.UserID))
+			http.Error(w, "user is blocked", http.StatusForbidden)
+		} else if errors.Is(err, services.ErrCommentsDisabled) || errors.Is(err, services.ErrCommentsFollowers) {
+			h.Logger.Warn("Комментирование ограничено автором фото", zap.Int("photoID", comment.PhotoID), zap.Int("userID", comment.UserID))
+			http.Error(w, err.Error(), http.StatusForbidden)
 		} else {
 			h.Logger.Error("Ошибка создания комментария", zap.Error(err))
 			http.Error(w, "internal server error", http.StatusInternalServerError)
@@ -62,20 +85,24 @@ func (h *CommentHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
 	h.Logger.Info("Комментарий успешно создан", zap.Int("id", id))
 	w.WriteHeader(http.StatusCreated)
 	_ = json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "comment created successfully",
-		"id":      id,
+		"message":  "comment created successfully",
+		"id":       id,
+		"entities": comment.Entities,
 	})
 }
 
 // GetCommentsByPhotoID возвращает список комментариев к фото
 //
 // @Summary Получить комментарии
-// @Description Возвращает список комментариев по photo_id
+// @Description Возвращает страницу комментариев верхнего уровня по photo_id с количеством ответов. Закрепленные комментарии идут в начале первой страницы. Комментарии пользователей, с которыми у текущего пользователя есть блокировка, и комментарии со скрываемыми словами (кроме собственных) не показываются
 // @Tags Comments
 // @Produce json
 // @Param photoID path int true "ID фото"
-// @Success 200 {array} models.Comment
-// @Failure 400 {string} string "Неверный photo_id"
+// @Param sort query string false "Сортировка: newest (по умолчанию), oldest, top"
+// @Param limit query int false "Количество комментариев (по умолчанию 20, максимум 100)"
+// @Param cursor query string false "Курсор следующей страницы из next_cursor"
+// @Success 200 {object} models.CommentPage
+// @Failure 400 {string} string "Неверный photo_id или параметры страницы"
 // @Failure 500 {string} string "Ошибка сервера"
 // @Router /api/comments/{photoID} [get]
 func (h *CommentHandler) GetCommentsByPhotoID(w http.ResponseWriter, r *http.Request) {
@@ -96,34 +123,123 @@ func (h *CommentHandler) GetCommentsByPhotoID(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	comments, err := h.Service.GetCommentsByPhotoID(r.Context(), id)
+	params, err := commentListParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.Service.GetCommentsByPhotoID(r.Context(), id, params)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidCommentSort) || errors.Is(err, services.ErrInvalidCursor) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		h.Logger.Error("Ошибка получения комментариев", zap.Int("photoID", id), zap.Error(err))
 		http.Error(w, "failed to get comments", http.StatusInternalServerError)
 		return
 	}
 
-	h.Logger.Info("Комментарии успешно получены", zap.Int("photoID", id), zap.Int("count", len(comments)))
+	h.Logger.Info("Комментарии успешно получены", zap.Int("photoID", id), zap.Int("count", len(page.Comments)))
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(page)
+}
+
+// GetReplies возвращает ответы на комментарий
+//
+// @Summary Получить ответы на комментарий
+// @Description Возвращает страницу ответов на комментарий верхнего уровня
+// @Tags Comments
+// @Produce json
+// @Param id path int true "ID комментария"
+// @Param sort query string false "Сортировка: oldest (по умолчанию), newest, top"
+// @Param limit query int false "Количество ответов (по умолчанию 20, максимум 100)"
+// @Param cursor query string false "Курсор следующей страницы из next_cursor"
+// @Success 200 {object} models.CommentPage
+// @Failure 400 {string} string "Неверный ID комментария или параметры страницы"
+// @Failure 404 {string} string "Комментарий не найден"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/comments/{id}/replies [get]
+func (h *CommentHandler) GetReplies(w http.ResponseWriter, r *http.Request) {
+	h.Logger.Info("Получен запрос на получение ответов на комментарий")
+
+	vars := mux.Vars(r)
+	commentID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.Logger.Error("Неверный формат comment_id", zap.String("comment_id", vars["id"]), zap.Error(err))
+		http.Error(w, "invalid comment_id", http.StatusBadRequest)
+		return
+	}
+
+	params, err := commentListParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.Service.GetReplies(r.Context(), commentID, params)
+	if err != nil {
+		if errors.Is(err, repositories.ErrCommentNotFound) {
+			http.Error(w, "comment not found", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, services.ErrInvalidCommentSort) || errors.Is(err, services.ErrInvalidCursor) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.Logger.Error("Ошибка получения ответов", zap.Int("comment_id", commentID), zap.Error(err))
+		http.Error(w, "failed to get replies", http.StatusInternalServerError)
+		return
+	}
+
+	h.Logger.Info("Ответы успешно получены", zap.Int("comment_id", commentID), zap.Int("count", len(page.Comments)))
 	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(comments)
+	_ = json.NewEncoder(w).Encode(page)
+}
+
+// commentListParams читает параметры страницы комментариев из запроса
+func commentListParams(r *http.Request) (services.CommentListParams, error) {
+	query := r.URL.Query()
+	params := services.CommentListParams{
+		Sort:   query.Get("sort"),
+		Cursor: query.Get("cursor"),
+	}
+	params.ViewerID, _ = middleware.UserIDFromContext(r.Context())
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			return params, errors.New("invalid limit")
+		}
+		params.Limit = limit
+	}
+	return params, nil
 }
 
 // UpdateComment обновляет комментарий
 //
 // @Summary Обновить комментарий
-// @Description Обновляет текст комментария
+// @Description Обновляет текст комментария текущего пользователя, сохраняя прежнюю версию в истории правок. user_id в теле игнорируется. Править комментарий можно только в течение окна редактирования после создания
 // @Tags Comments
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "ID комментария"
 // @Param comment body models.Comment true "Обновленные данные комментария"
 // @Success 200 {object} map[string]string "message: comment updated successfully"
 // @Failure 400 {string} string "Некорректные данные"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 403 {string} string "Окно редактирования истекло"
 // @Failure 500 {string} string "Ошибка сервера"
 // @Router /api/comments/{id}/edit [put]
 func (h *CommentHandler) UpdateComment(w http.ResponseWriter, r *http.Request) {
 	h.Logger.Info("Получен запрос на обновление комментария")
 
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
 	vars := mux.Vars(r)
 	commentID, err := strconv.Atoi(vars["id"])
 	if err != nil {
@@ -140,8 +256,15 @@ func (h *CommentHandler) UpdateComment(w http.ResponseWriter, r *http.Request) {
 	}
 
 	comment.ID = commentID
+	// Править можно только свой комментарий, user_id из тела игнорируется
+	comment.UserID = userID
 
 	err = h.Service.UpdateComment(r.Context(), &comment)
+	if errors.Is(err, repositories.ErrEditWindowClosed) {
+		h.Logger.Warn("Окно редактирования комментария истекло", zap.Int("id", comment.ID))
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
 	if err != nil {
 		h.Logger.Error("Ошибка обновления комментария", zap.Int("id", comment.ID), zap.Error(err))
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -156,18 +279,24 @@ func (h *CommentHandler) UpdateComment(w http.ResponseWriter, r *http.Request) {
 // DeleteComment удаляет комментарий
 //
 // @Summary Удалить комментарий
-// @Description Удаляет комментарий по ID
+// @Description Удаляет комментарий по ID. Удалить комментарий может его автор или автор фото. Если у комментария есть ответы, на его месте остается "[deleted]"
 // @Tags Comments
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "ID комментария"
-// @Param user_id query int true "ID пользователя"
 // @Success 200 {object} map[string]string "message: comment deleted successfully"
 // @Failure 400 {string} string "Некорректный ID"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
 // @Failure 500 {string} string "Ошибка сервера"
 // @Router /api/comments/{id}/delete [delete]
 func (h *CommentHandler) DeleteComment(w http.ResponseWriter, r *http.Request) {
 	h.Logger.Info("Получен запрос на удаление комментария")
 
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
 	vars := mux.Vars(r)
 	commentID, err := strconv.Atoi(vars["id"])
 	if err != nil {
@@ -176,28 +305,222 @@ func (h *CommentHandler) DeleteComment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userIDStr := r.URL.Query().Get("user_id")
-	if userIDStr == "" {
-		h.Logger.Warn("Отсутствует user_id")
-		http.Error(w, "missing user_id", http.StatusBadRequest)
+	err = h.Service.DeleteComment(r.Context(), commentID, userID)
+	if err != nil {
+		h.Logger.Error("Ошибка удаления комментария", zap.Int("comment_id", commentID), zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	userID, err := strconv.Atoi(userIDStr)
+	h.Logger.Info("Комментарий успешно удалён", zap.Int("comment_id", commentID))
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(map[string]string{"message": "comment deleted successfully"})
+}
+
+// GetCommentSettings возвращает настройки комментариев фото
+//
+// @Summary Получить настройки комментариев
+// @Description Возвращает политику комментирования и скрываемые слова. Доступно только автору фото
+// @Tags Comments
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID фото"
+// @Success 200 {object} models.CommentSettings
+// @Failure 400 {string} string "Неверный ID фото"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 403 {string} string "Пользователь не автор фото"
+// @Failure 404 {string} string "Фото не найдено"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/photos/{id}/comment-settings [get]
+func (h *CommentHandler) GetCommentSettings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	photoID, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
-		h.Logger.Error("Неверный формат user_id", zap.String("user_id", userIDStr), zap.Error(err))
-		http.Error(w, "invalid user_id", http.StatusBadRequest)
+		http.Error(w, "invalid photo_id", http.StatusBadRequest)
 		return
 	}
 
-	err = h.Service.DeleteComment(r.Context(), commentID, userID)
+	settings, err := h.Service.GetCommentSettings(r.Context(), photoID, userID)
 	if err != nil {
-		h.Logger.Error("Ошибка удаления комментария", zap.Int("comment_id", commentID), zap.Error(err))
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.writeSettingsError(w, photoID, err)
 		return
 	}
 
-	h.Logger.Info("Комментарий успешно удалён", zap.Int("comment_id", commentID))
 	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(map[string]string{"message": "comment deleted successfully"})
+	_ = json.NewEncoder(w).Encode(settings)
+}
+
+// UpdateCommentSettings меняет настройки комментариев фото
+//
+// @Summary Изменить настройки комментариев
+// @Description Задает, кто может комментировать фото (everyone, followers, off), и список слов, при наличии которых комментарий видят только его автор. Доступно только автору фото
+// @Tags Comments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID фото"
+// @Param settings body models.CommentSettings true "Настройки комментариев"
+// @Success 200 {object} models.CommentSettings
+// @Failure 400 {string} string "Некорректные настройки"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 403 {string} string "Пользователь не автор фото"
+// @Failure 404 {string} string "Фото не найдено"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/photos/{id}/comment-settings [put]
+func (h *CommentHandler) UpdateCommentSettings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	photoID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid photo_id", http.StatusBadRequest)
+		return
+	}
+
+	var settings models.CommentSettings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	settings.PhotoID = photoID
+	settings.OwnerID = userID
+
+	if err := h.Service.UpdateCommentSettings(r.Context(), &settings); err != nil {
+		h.writeSettingsError(w, photoID, err)
+		return
+	}
+
+	h.Logger.Info("Настройки комментариев обновлены", zap.Int("photoID", photoID), zap.String("policy", settings.CommentsPolicy))
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(settings)
+}
+
+func (h *CommentHandler) writeSettingsError(w http.ResponseWriter, photoID int, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidCommentPolicy), errors.Is(err, services.ErrInvalidKeywords):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrNotPhotoOwner):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, repositories.ErrInvalidPhotoID):
+		http.Error(w, "photo not found", http.StatusNotFound)
+	default:
+		h.Logger.Error("Ошибка настроек комментариев", zap.Int("photoID", photoID), zap.Error(err))
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+// PinComment закрепляет комментарий
+//
+// @Summary Закрепить комментарий
+// @Description Закрепляет комментарий верхнего уровня к фото текущего пользователя. Одновременно можно закрепить до 3 комментариев
+// @Tags Comments
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID комментария"
+// @Success 200 {object} map[string]string "message: comment pinned"
+// @Failure 400 {string} string "Неверный ID или достигнут лимит закреплений"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 404 {string} string "Комментарий не найден среди комментариев к фото пользователя"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/comments/{id}/pin [post]
+func (h *CommentHandler) PinComment(w http.ResponseWriter, r *http.Request) {
+	h.handlePin(w, r, h.Service.PinComment, "comment pinned")
+}
+
+// UnpinComment снимает закрепление комментария
+//
+// @Summary Открепить комментарий
+// @Description Снимает закрепление комментария к фото текущего пользователя
+// @Tags Comments
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID комментария"
+// @Success 200 {object} map[string]string "message: comment unpinned"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 404 {string} string "Комментарий не найден среди комментариев к фото пользователя"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/comments/{id}/pin [delete]
+func (h *CommentHandler) UnpinComment(w http.ResponseWriter, r *http.Request) {
+	h.handlePin(w, r, h.Service.UnpinComment, "comment unpinned")
+}
+
+func (h *CommentHandler) handlePin(w http.ResponseWriter, r *http.Request, action func(ctx context.Context, commentID, userID int) error, message string) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	commentID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid comment_id", http.StatusBadRequest)
+		return
+	}
+
+	if err := action(r.Context(), commentID, userID); err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrCommentNotFound):
+			http.Error(w, "comment not found", http.StatusNotFound)
+		case errors.Is(err, repositories.ErrPinLimitReached):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			h.Logger.Error("Ошибка закрепления комментария", zap.Int("comment_id", commentID), zap.Error(err))
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
+
+// GetCommentHistory возвращает историю правок комментария
+//
+// @Summary История правок комментария
+// @Description Возвращает все версии комментария от исходной к текущей. Доступно автору фото и модераторам
+// @Tags Comments
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID комментария"
+// @Success 200 {object} models.CommentHistory
+// @Failure 400 {string} string "Неверный ID комментария"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 403 {string} string "Пользователь не автор фото и не модератор"
+// @Failure 404 {string} string "Комментарий не найден"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/comments/{id}/history [get]
+func (h *CommentHandler) GetCommentHistory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	commentID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid comment_id", http.StatusBadRequest)
+		return
+	}
+
+	history, err := h.Service.GetCommentHistory(r.Context(), commentID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrCommentNotFound):
+			http.Error(w, "comment not found", http.StatusNotFound)
+		case errors.Is(err, services.ErrHistoryForbidden):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		default:
+			h.Logger.Error("Ошибка получения истории комментария", zap.Int("comment_id", commentID), zap.Error(err))
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(history)
 }
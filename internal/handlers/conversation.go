@@ -0,0 +1,357 @@
+package handlers
+
+import (
+	"InstaSpace/internal/models"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// CreateGroup создает групповую беседу
+//
+// @Summary Создать группу
+// @Description Создает групповую беседу с названием, аватаром и участниками. Создатель становится администратором
+// @Tags Messages
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param group body models.GroupRequest true "Группа"
+// @Success 201 {object} models.Conversation
+// @Failure 400 {string} string "Некорректное название или участники, превышен лимит участников"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 403 {string} string "Пользователь заблокирован"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/conversations/groups [post]
+func (h *MessageHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.GroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	conv, err := h.Service.CreateGroup(r.Context(), userID, req)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.Logger.Info("Group created", zap.Int("conversationID", conv.ID), zap.Int("userID", userID))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(conv)
+}
+
+// GetConversation возвращает беседу
+//
+// @Summary Получить беседу
+// @Description Возвращает беседу с участниками и их ролями. Доступно только участникам
+// @Tags Messages
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID беседы"
+// @Success 200 {object} models.Conversation
+// @Failure 400 {string} string "Некорректный ID"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 404 {string} string "Беседа не найдена или пользователь в ней не участвует"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/conversations/{id} [get]
+func (h *MessageHandler) GetConversation(w http.ResponseWriter, r *http.Request) {
+	userID, conversationID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	conv, err := h.Service.GetConversation(r.Context(), conversationID, userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(conv)
+}
+
+// UpdateGroup изменяет группу
+//
+// @Summary Изменить группу
+// @Description Меняет название и аватар группы. Доступно администраторам, участники получают служебное сообщение
+// @Tags Messages
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID беседы"
+// @Param group body models.GroupRequest true "Название и аватар"
+// @Success 200 {object} models.Conversation
+// @Failure 400 {string} string "Некорректное название или беседа не групповая"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 403 {string} string "Пользователь не администратор группы"
+// @Failure 404 {string} string "Беседа не найдена или пользователь в ней не участвует"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/conversations/{id} [put]
+func (h *MessageHandler) UpdateGroup(w http.ResponseWriter, r *http.Request) {
+	userID, conversationID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.GroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	conv, err := h.Service.UpdateGroup(r.Context(), conversationID, userID, req)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.Logger.Info("Group updated", zap.Int("conversationID", conversationID), zap.Int("userID", userID))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(conv)
+}
+
+// AddParticipants добавляет участников в группу
+//
+// @Summary Добавить участников
+// @Description Добавляет пользователей в группу. Доступно администраторам. Уже состоящие в группе пропускаются
+// @Tags Messages
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID беседы"
+// @Param participants body models.ParticipantsRequest true "Пользователи"
+// @Success 200 {object} map[string]string "message: Participants added"
+// @Failure 400 {string} string "Некорректные пользователи, беседа не групповая или превышен лимит участников"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 403 {string} string "Пользователь не администратор группы или заблокирован"
+// @Failure 404 {string} string "Беседа не найдена или пользователь в ней не участвует"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/conversations/{id}/participants [post]
+func (h *MessageHandler) AddParticipants(w http.ResponseWriter, r *http.Request) {
+	userID, conversationID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.ParticipantsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.AddParticipants(r.Context(), conversationID, userID, req.UserIDs); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.Logger.Info("Group participants added", zap.Int("conversationID", conversationID), zap.Int("userID", userID))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Participants added"})
+}
+
+// RemoveParticipant исключает участника из группы
+//
+// @Summary Исключить участника
+// @Description Исключает участника из группы. Исключать других могут администраторы, исключение себя означает выход из группы
+// @Tags Messages
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID беседы"
+// @Param userID path int true "ID участника"
+// @Success 200 {object} map[string]string "message: Participant removed"
+// @Failure 400 {string} string "Некорректный ID или беседа не групповая"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 403 {string} string "Пользователь не администратор группы"
+// @Failure 404 {string} string "Беседа или участник не найдены"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/conversations/{id}/participants/{userID} [delete]
+func (h *MessageHandler) RemoveParticipant(w http.ResponseWriter, r *http.Request) {
+	userID, conversationID, targetID, ok := h.parseParticipant(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Service.RemoveParticipant(r.Context(), conversationID, userID, targetID); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.Logger.Info("Group participant removed", zap.Int("conversationID", conversationID), zap.Int("targetID", targetID))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Participant removed"})
+}
+
+// LeaveGroup выходит из группы
+//
+// @Summary Выйти из группы
+// @Description Выводит текущего пользователя из группы. Если ушел последний администратор, им становится самый давний участник
+// @Tags Messages
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID беседы"
+// @Success 200 {object} map[string]string "message: Left the group"
+// @Failure 400 {string} string "Некорректный ID или беседа не групповая"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 404 {string} string "Беседа не найдена или пользователь в ней не участвует"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/conversations/{id}/leave [post]
+func (h *MessageHandler) LeaveGroup(w http.ResponseWriter, r *http.Request) {
+	userID, conversationID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Service.LeaveGroup(r.Context(), conversationID, userID); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.Logger.Info("Left group", zap.Int("conversationID", conversationID), zap.Int("userID", userID))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Left the group"})
+}
+
+// SetParticipantRole меняет роль участника группы
+//
+// @Summary Изменить роль участника
+// @Description Назначает или снимает администратора группы. Доступно администраторам. Последнего администратора снять нельзя
+// @Tags Messages
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID беседы"
+// @Param userID path int true "ID участника"
+// @Param role body models.RoleRequest true "Роль"
+// @Success 200 {object} map[string]string "message: Role updated"
+// @Failure 400 {string} string "Некорректная роль, беседа не групповая или это последний администратор"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 403 {string} string "Пользователь не администратор группы"
+// @Failure 404 {string} string "Беседа или участник не найдены"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/conversations/{id}/participants/{userID}/role [put]
+func (h *MessageHandler) SetParticipantRole(w http.ResponseWriter, r *http.Request) {
+	userID, conversationID, targetID, ok := h.parseParticipant(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.RoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.SetParticipantRole(r.Context(), conversationID, userID, targetID, req.Role); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.Logger.Info("Group role updated", zap.Int("conversationID", conversationID), zap.Int("targetID", targetID), zap.String("role", req.Role))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Role updated"})
+}
+
+// MarkRead отмечает беседу прочитанной
+//
+// @Summary Отметить беседу прочитанной
+// @Description Сдвигает отметку прочтения текущего пользователя до message_id включительно, без message_id — до последнего сообщения. Отметка не сдвигается назад. Остальные участники получают событие read по WebSocket
+// @Tags Messages
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID беседы"
+// @Param read body models.ReadRequest false "Последнее прочитанное сообщение"
+// @Success 200 {object} models.ReadReceipt
+// @Failure 400 {string} string "Некорректный ID"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 404 {string} string "Беседа или сообщение не найдены"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/conversations/{id}/read [post]
+func (h *MessageHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	userID, conversationID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.ReadRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.MessageID < 0 {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+	}
+
+	receipt, err := h.Service.MarkRead(r.Context(), conversationID, userID, req.MessageID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(receipt)
+}
+
+// GetUnreadCount возвращает счетчик непрочитанных сообщений
+//
+// @Summary Количество непрочитанных сообщений
+// @Description Возвращает для значка входящих количество непрочитанных сообщений других участников во всех беседах и количество бесед с ними
+// @Tags Messages
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.UnreadCount
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/conversations/unread-count [get]
+func (h *MessageHandler) GetUnreadCount(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	count, err := h.Service.CountUnread(r.Context(), userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(count)
+}
+
+// parseID возвращает ID текущего пользователя и ID беседы из пути {id}
+func (h *MessageHandler) parseID(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return 0, 0, false
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return userID, id, true
+}
+
+// parseParticipant дополнительно возвращает ID участника из пути {userID}
+func (h *MessageHandler) parseParticipant(w http.ResponseWriter, r *http.Request) (int, int, int, bool) {
+	userID, conversationID, ok := h.parseID(w, r)
+	if !ok {
+		return 0, 0, 0, false
+	}
+
+	targetID, err := strconv.Atoi(mux.Vars(r)["userID"])
+	if err != nil || targetID <= 0 {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return 0, 0, 0, false
+	}
+	return userID, conversationID, targetID, true
+}
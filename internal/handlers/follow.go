@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"InstaSpace/internal/services"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+type FollowHandler struct {
+	Service services.FollowServiceInterface
+	Logger  *zap.Logger
+}
+
+func NewFollowHandler(service services.FollowServiceInterface, logger *zap.Logger) *FollowHandler {
+	return &FollowHandler{Service: service, Logger: logger}
+}
+
+// Follow подписывает на пользователя
+//
+// @Summary Подписаться
+// @Description Подписывает текущего пользователя на другого. Недоступно при блокировке
+// @Tags Follows
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} map[string]string "message: Followed successfully"
+// @Failure 400 {string} string "Некорректный ID"
+// @Failure 401 {string} string "Не авторизован"
+// @Failure 403 {string} string "Пользователь заблокирован"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/users/{id}/follow [post]
+func (h *FollowHandler) Follow(w http.ResponseWriter, r *http.Request) {
+	handleRelation(w, r, h.Logger, h.Service.Follow, "Followed successfully")
+}
+
+// Unfollow отменяет подписку
+//
+// @Summary Отписаться
+// @Description Отменяет подписку текущего пользователя. Повторный вызов не является ошибкой
+// @Tags Follows
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} map[string]string "message: Unfollowed successfully"
+// @Failure 400 {string} string "Некорректный ID"
+// @Failure 401 {string} string "Не авторизован"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/users/{id}/follow [delete]
+func (h *FollowHandler) Unfollow(w http.ResponseWriter, r *http.Request) {
+	handleRelation(w, r, h.Logger, h.Service.Unfollow, "Unfollowed successfully")
+}
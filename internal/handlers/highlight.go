@@ -0,0 +1,367 @@
+package handlers
+
+import (
+	"InstaSpace/internal/models"
+	"InstaSpace/internal/repositories"
+	"InstaSpace/internal/services"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+type HighlightHandler struct {
+	Service services.HighlightServiceInterface
+	Logger  *zap.Logger
+}
+
+func NewHighlightHandler(service services.HighlightServiceInterface, logger *zap.Logger) *HighlightHandler {
+	return &HighlightHandler{Service: service, Logger: logger}
+}
+
+// CreateHighlight создает подборку
+//
+// @Summary Создать подборку
+// @Description Создает подборку в конце профиля из собственных фото и историй текущего пользователя. Каждый элемент содержит photo_id или story_id. Истории из подборки не удаляются по истечении срока
+// @Tags Highlights
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param highlight body models.HighlightRequest true "Подборка"
+// @Success 201 {object} models.Highlight
+// @Failure 400 {string} string "Некорректное название, чужие или несуществующие элементы, превышен лимит"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/highlights [post]
+func (h *HighlightHandler) CreateHighlight(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.HighlightRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	highlight, err := h.Service.CreateHighlight(r.Context(), userID, req)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.Logger.Info("Highlight created", zap.Int("highlightID", highlight.ID), zap.Int("userID", userID))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(highlight)
+}
+
+// GetUserHighlights возвращает подборки пользователя
+//
+// @Summary Подборки пользователя
+// @Description Возвращает подборки пользователя в порядке профиля с обложкой и количеством элементов. Заблокированным пользователям список не показывается
+// @Tags Highlights
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} map[string][]models.Highlight "highlights: [список подборок]"
+// @Failure 400 {string} string "Некорректный ID"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/users/{id}/highlights [get]
+func (h *HighlightHandler) GetUserHighlights(w http.ResponseWriter, r *http.Request) {
+	viewerID, ownerID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	highlights, err := h.Service.GetUserHighlights(r.Context(), ownerID, viewerID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string][]models.Highlight{"highlights": highlights})
+}
+
+// GetHighlight возвращает подборку
+//
+// @Summary Получить подборку
+// @Description Возвращает подборку со всеми элементами по порядку
+// @Tags Highlights
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID подборки"
+// @Success 200 {object} models.Highlight
+// @Failure 400 {string} string "Некорректный ID"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 404 {string} string "Подборка не найдена или недоступна"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/highlights/{id} [get]
+func (h *HighlightHandler) GetHighlight(w http.ResponseWriter, r *http.Request) {
+	userID, highlightID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	highlight, err := h.Service.GetHighlight(r.Context(), highlightID, userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(highlight)
+}
+
+// UpdateHighlight изменяет подборку
+//
+// @Summary Изменить подборку
+// @Description Меняет название и обложку подборки. Без cover_item_id обложкой становится первый элемент
+// @Tags Highlights
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID подборки"
+// @Param highlight body models.HighlightRequest true "Подборка"
+// @Success 200 {object} models.Highlight
+// @Failure 400 {string} string "Некорректное название или обложка не из подборки"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 404 {string} string "Подборка не найдена"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/highlights/{id} [put]
+func (h *HighlightHandler) UpdateHighlight(w http.ResponseWriter, r *http.Request) {
+	userID, highlightID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.HighlightRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	highlight, err := h.Service.UpdateHighlight(r.Context(), highlightID, userID, req)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.Logger.Info("Highlight updated", zap.Int("highlightID", highlightID), zap.Int("userID", userID))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(highlight)
+}
+
+// DeleteHighlight удаляет подборку
+//
+// @Summary Удалить подборку
+// @Description Удаляет подборку текущего пользователя. Фото не удаляются, истекшие истории удаляются при следующей очистке, если их нет в других подборках
+// @Tags Highlights
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID подборки"
+// @Success 200 {object} map[string]string "message: Highlight deleted"
+// @Failure 400 {string} string "Некорректный ID"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 404 {string} string "Подборка не найдена"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/highlights/{id} [delete]
+func (h *HighlightHandler) DeleteHighlight(w http.ResponseWriter, r *http.Request) {
+	userID, highlightID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Service.DeleteHighlight(r.Context(), highlightID, userID); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.Logger.Info("Highlight deleted", zap.Int("highlightID", highlightID), zap.Int("userID", userID))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Highlight deleted"})
+}
+
+// AddHighlightItems добавляет элементы в подборку
+//
+// @Summary Добавить элементы в подборку
+// @Description Добавляет собственные фото и истории в конец подборки в переданном порядке. Элементы, которые уже есть в подборке, не перемещаются
+// @Tags Highlights
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID подборки"
+// @Param items body models.HighlightItemsRequest true "Элементы"
+// @Success 200 {object} map[string]string "message: Items added"
+// @Failure 400 {string} string "Чужие или несуществующие элементы, превышен лимит"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 404 {string} string "Подборка не найдена"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/highlights/{id}/items [post]
+func (h *HighlightHandler) AddHighlightItems(w http.ResponseWriter, r *http.Request) {
+	userID, highlightID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.HighlightItemsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.AddHighlightItems(r.Context(), highlightID, userID, req.Items); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.Logger.Info("Highlight items added", zap.Int("highlightID", highlightID), zap.Int("userID", userID))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Items added"})
+}
+
+// RemoveHighlightItem убирает элемент из подборки
+//
+// @Summary Убрать элемент из подборки
+// @Description Убирает элемент из подборки. Если элемент был обложкой, обложкой становится первый элемент. Отсутствующий элемент не является ошибкой
+// @Tags Highlights
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID подборки"
+// @Param itemID path int true "ID элемента"
+// @Success 200 {object} map[string]string "message: Item removed"
+// @Failure 400 {string} string "Некорректный ID"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 404 {string} string "Подборка не найдена"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/highlights/{id}/items/{itemID} [delete]
+func (h *HighlightHandler) RemoveHighlightItem(w http.ResponseWriter, r *http.Request) {
+	userID, highlightID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	itemID, err := strconv.Atoi(mux.Vars(r)["itemID"])
+	if err != nil || itemID <= 0 {
+		http.Error(w, "Invalid item ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.RemoveHighlightItem(r.Context(), highlightID, userID, itemID); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.Logger.Info("Highlight item removed", zap.Int("highlightID", highlightID), zap.Int("itemID", itemID))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Item removed"})
+}
+
+// ReorderHighlightItems меняет порядок элементов подборки
+//
+// @Summary Изменить порядок элементов
+// @Description Задает новый порядок элементов подборки. Список должен содержать каждый элемент подборки ровно один раз
+// @Tags Highlights
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID подборки"
+// @Param order body models.OrderRequest true "ID элементов в новом порядке"
+// @Success 200 {object} map[string]string "message: Highlight reordered"
+// @Failure 400 {string} string "Список не совпадает с элементами подборки"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 404 {string} string "Подборка не найдена"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/highlights/{id}/order [put]
+func (h *HighlightHandler) ReorderHighlightItems(w http.ResponseWriter, r *http.Request) {
+	userID, highlightID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.OrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.ReorderHighlightItems(r.Context(), highlightID, userID, req.IDs); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.Logger.Info("Highlight reordered", zap.Int("highlightID", highlightID), zap.Int("userID", userID))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Highlight reordered"})
+}
+
+// ReorderHighlights меняет порядок подборок в профиле
+//
+// @Summary Изменить порядок подборок
+// @Description Задает новый порядок подборок текущего пользователя. Список должен содержать каждую подборку ровно один раз
+// @Tags Highlights
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param order body models.OrderRequest true "ID подборок в новом порядке"
+// @Success 200 {object} map[string]string "message: Highlights reordered"
+// @Failure 400 {string} string "Список не совпадает с подборками пользователя"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/highlights/order [put]
+func (h *HighlightHandler) ReorderHighlights(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.OrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.ReorderHighlights(r.Context(), userID, req.IDs); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.Logger.Info("Highlights reordered", zap.Int("userID", userID))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Highlights reordered"})
+}
+
+// parseID возвращает ID текущего пользователя и ID из пути {id}
+func (h *HighlightHandler) parseID(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return 0, 0, false
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return userID, id, true
+}
+
+func (h *HighlightHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidHighlightTitle), errors.Is(err, services.ErrInvalidHighlightRef),
+		errors.Is(err, repositories.ErrInvalidHighlightItems), errors.Is(err, repositories.ErrInvalidHighlightCover),
+		errors.Is(err, repositories.ErrInvalidHighlightOrder), errors.Is(err, repositories.ErrHighlightFull),
+		errors.Is(err, repositories.ErrInvalidUserID):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repositories.ErrHighlightNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		h.Logger.Error("Failed to process highlight request", zap.Error(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"InstaSpace/internal/models"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// DefaultSendQueueSize сколько исходящих событий может ждать отправки в одном подключении.
+// Подключение, которое не успевает их забирать, закрывается
+const DefaultSendQueueSize = 64
+
+const (
+	// DefaultPingInterval как часто сервер отправляет ping
+	DefaultPingInterval = 30 * time.Second
+	// DefaultPongTimeout сколько ждать pong или другого сообщения клиента, прежде чем считать подключение оборванным
+	DefaultPongTimeout = 60 * time.Second
+	// DefaultWriteTimeout сколько ждать записи одного сообщения в соединение
+	DefaultWriteTimeout = 10 * time.Second
+	// DefaultMaxMessageSize максимальный размер сообщения клиента в байтах
+	DefaultMaxMessageSize = 32 << 10
+)
+
+// closeFrameTimeout сколько ждать отправки close-фрейма перед закрытием соединения
+const closeFrameTimeout = time.Second
+
+// Hub хранит WebSocket-подключения по пользователям. У пользователя может быть несколько
+// подключений (устройств), и событие доставляется во все
+type Hub struct {
+	mu        sync.RWMutex
+	users     map[int]map[*Client]struct{}
+	closing   bool
+	active    sync.WaitGroup
+	QueueSize int
+	// PingInterval как часто отправлять ping, 0 — не отправлять
+	PingInterval time.Duration
+	// PongTimeout сколько ждать pong или сообщения клиента, 0 — без ограничения
+	PongTimeout time.Duration
+	// WriteTimeout сколько ждать записи сообщения, 0 — без ограничения
+	WriteTimeout time.Duration
+	// MaxMessageSize максимальный размер сообщения клиента в байтах, 0 — без ограничения.
+	// Подключение с сообщением больше закрывается с кодом 1009
+	MaxMessageSize int64
+	Logger         *zap.Logger
+}
+
+func NewHub(logger *zap.Logger) *Hub {
+	return &Hub{
+		users:          make(map[int]map[*Client]struct{}),
+		QueueSize:      DefaultSendQueueSize,
+		PingInterval:   DefaultPingInterval,
+		PongTimeout:    DefaultPongTimeout,
+		WriteTimeout:   DefaultWriteTimeout,
+		MaxMessageSize: DefaultMaxMessageSize,
+		Logger:         logger,
+	}
+}
+
+// Client — одно WebSocket-подключение. Запись в соединение ведет только writePump,
+// остальные отправляют события через очередь send
+type Client struct {
+	hub       *Hub
+	conn      *websocket.Conn
+	userID    int
+	send      chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewClient создает подключение пользователя userID, задает ему ограничение размера сообщений
+// и срок ожидания pong и запускает его отправку
+func (h *Hub) NewClient(conn *websocket.Conn, userID int) *Client {
+	c := &Client{
+		hub:    h,
+		conn:   conn,
+		userID: userID,
+		send:   make(chan []byte, h.QueueSize),
+		done:   make(chan struct{}),
+	}
+
+	conn.SetReadLimit(h.MaxMessageSize)
+	c.ExtendReadDeadline()
+	conn.SetPongHandler(func(string) error {
+		c.ExtendReadDeadline()
+		return nil
+	})
+
+	go c.writePump()
+	return c
+}
+
+// Register добавляет подключение в набор его пользователя и возвращает true. После Shutdown подключение
+// не регистрируется, а сразу закрывается
+func (h *Hub) Register(c *Client) bool {
+	h.mu.Lock()
+	if h.closing {
+		h.mu.Unlock()
+		c.CloseWithReason(websocket.CloseServiceRestart, "Server restarting")
+		return false
+	}
+	defer h.mu.Unlock()
+
+	clients, ok := h.users[c.userID]
+	if !ok {
+		clients = make(map[*Client]struct{})
+		h.users[c.userID] = clients
+	}
+	clients[c] = struct{}{}
+	h.active.Add(1)
+	return true
+}
+
+// Unregister убирает подключение из набора его пользователя. Возвращает false, если подключение
+// не было зарегистрировано
+func (h *Hub) Unregister(c *Client) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	clients, ok := h.users[c.userID]
+	if !ok {
+		return false
+	}
+	if _, ok := clients[c]; !ok {
+		return false
+	}
+	delete(clients, c)
+	h.active.Done()
+	if len(clients) == 0 {
+		delete(h.users, c.userID)
+	}
+	return true
+}
+
+// Shutdown закрывает все подключения с кодом 1012 "Server restarting", чтобы клиенты переподключились
+// к другому экземпляру, и ждет, пока обработчики подключений завершатся или истечет ctx.
+// Новые подключения после вызова сразу закрываются
+func (h *Hub) Shutdown(ctx context.Context) error {
+	h.mu.Lock()
+	h.closing = true
+	var clients []*Client
+	for _, userClients := range h.users {
+		for c := range userClients {
+			clients = append(clients, c)
+		}
+	}
+	h.mu.Unlock()
+
+	h.Logger.Info("Closing WebSocket connections", zap.Int("connections", len(clients)))
+	for _, c := range clients {
+		go c.CloseWithReason(websocket.CloseServiceRestart, "Server restarting")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		h.active.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// IsOnline сообщает, есть ли у пользователя открытые подключения к этому экземпляру
+func (h *Hub) IsOnline(userID int) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.users[userID]) > 0
+}
+
+// SendToUser ставит событие eventType в очередь всех подключений пользователя
+func (h *Hub) SendToUser(userID int, eventType string, payload interface{}) {
+	h.SendToUsers([]int{userID}, eventType, payload)
+}
+
+// SendToUsers ставит событие eventType в очередь всех подключений пользователей userIDs. Событие кодируется один раз
+func (h *Hub) SendToUsers(userIDs []int, eventType string, payload interface{}) {
+	data, err := encodeEnvelope(eventType, "", payload)
+	if err != nil {
+		h.Logger.Error("Failed to encode event", zap.Ints("userIDs", userIDs), zap.String("type", eventType), zap.Error(err))
+		return
+	}
+
+	h.mu.RLock()
+	var clients []*Client
+	for _, userID := range userIDs {
+		for c := range h.users[userID] {
+			clients = append(clients, c)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, c := range clients {
+		c.enqueue(data)
+	}
+}
+
+// Send ставит в очередь подключения сообщение eventType. id связывает ответ с запросом клиента
+func (c *Client) Send(eventType, id string, payload interface{}) {
+	data, err := encodeEnvelope(eventType, id, payload)
+	if err != nil {
+		c.hub.Logger.Error("Failed to encode event", zap.Int("userID", c.userID), zap.String("type", eventType), zap.Error(err))
+		return
+	}
+	c.enqueue(data)
+}
+
+// SendError ставит в очередь подключения ошибку обработки запроса id
+func (c *Client) SendError(id, code, message string) {
+	c.Send(models.WSError, id, models.WSErrorPayload{Code: code, Message: message})
+}
+
+// encodeEnvelope упаковывает содержимое в конверт текущей версии протокола
+func encodeEnvelope(eventType, id string, payload interface{}) ([]byte, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(models.WSEnvelope{V: models.WSProtocolVersion, Type: eventType, ID: id, Payload: raw})
+}
+
+// enqueue ставит данные в очередь без ожидания. Если очередь заполнена, клиент не успевает
+// читать, и подключение закрывается, чтобы не задерживать доставку остальным
+func (c *Client) enqueue(data []byte) {
+	select {
+	case <-c.done:
+		return
+	default:
+	}
+
+	select {
+	case c.send <- data:
+	default:
+		c.hub.Logger.Warn("Dropping slow WebSocket client", zap.Int("userID", c.userID))
+		c.Close()
+	}
+}
+
+// Close закрывает подключение. Безопасно вызывать несколько раз и из разных горутин
+func (c *Client) Close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+// CloseWithReason отправляет клиенту close-фрейм с кодом и причиной и закрывает подключение
+func (c *Client) CloseWithReason(code int, reason string) {
+	deadline := time.Now().Add(closeFrameTimeout)
+	if err := c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), deadline); err != nil {
+		c.hub.Logger.Warn("Failed to send WebSocket close frame", zap.Int("userID", c.userID), zap.Error(err))
+	}
+	c.Close()
+}
+
+// ExtendReadDeadline продлевает ожидание сообщений клиента на PongTimeout. Вызывается при каждом pong и сообщении
+func (c *Client) ExtendReadDeadline() {
+	var deadline time.Time
+	if c.hub.PongTimeout > 0 {
+		deadline = time.Now().Add(c.hub.PongTimeout)
+	}
+	c.conn.SetReadDeadline(deadline)
+}
+
+// write записывает сообщение в соединение, ожидая не дольше WriteTimeout
+func (c *Client) write(messageType int, data []byte) error {
+	var deadline time.Time
+	if c.hub.WriteTimeout > 0 {
+		deadline = time.Now().Add(c.hub.WriteTimeout)
+	}
+	if err := c.conn.SetWriteDeadline(deadline); err != nil {
+		return err
+	}
+	return c.conn.WriteMessage(messageType, data)
+}
+
+// writePump отправляет события из очереди и ping в соединение, пока подключение не закрыто
+func (c *Client) writePump() {
+	defer c.Close()
+
+	var ping <-chan time.Time
+	if c.hub.PingInterval > 0 {
+		ticker := time.NewTicker(c.hub.PingInterval)
+		defer ticker.Stop()
+		ping = ticker.C
+	}
+
+	for {
+		select {
+		case data := <-c.send:
+			if err := c.write(websocket.TextMessage, data); err != nil {
+				c.hub.Logger.Warn("Failed to write WebSocket event", zap.Int("userID", c.userID), zap.Error(err))
+				return
+			}
+		case <-ping:
+			if err := c.write(websocket.PingMessage, nil); err != nil {
+				c.hub.Logger.Warn("Failed to send WebSocket ping", zap.Int("userID", c.userID), zap.Error(err))
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
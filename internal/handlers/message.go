@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"InstaSpace/internal/models"
+	"InstaSpace/internal/repositories"
 	"InstaSpace/internal/services"
 	"encoding/json"
 	"errors"
@@ -20,23 +22,111 @@ func NewMessageHandler(service *services.MessageService, logger *zap.Logger) *Me
 	return &MessageHandler{Service: service, Logger: logger}
 }
 
+// CreateConversation создает беседу
+//
+// @Summary Начать беседу
+// @Description Возвращает беседу текущего пользователя с собеседником, создавая ее при необходимости. Пара (A,B) и (B,A) дает одну беседу
+// @Tags Messages
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param conversation body models.ConversationRequest true "Собеседник"
+// @Success 200 {object} models.Conversation
+// @Failure 400 {string} string "Некорректный собеседник"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 403 {string} string "Пользователь заблокирован"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/conversations [post]
+func (h *MessageHandler) CreateConversation(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.ConversationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.UserID <= 0 {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	conv, err := h.Service.GetOrCreateConversation(r.Context(), userID, req.UserID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.Logger.Info("Conversation opened", zap.Int("conversationID", conv.ID), zap.Int("userID", userID))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(conv)
+}
+
+// GetInbox возвращает входящие
+//
+// @Summary Входящие
+// @Description Возвращает беседы текущего пользователя, последние активные первыми, с профилем собеседника, последним сообщением и количеством непрочитанных
+// @Tags Messages
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Количество бесед (по умолчанию 20, максимум 50)"
+// @Param cursor query string false "Курсор следующей страницы из next_cursor"
+// @Success 200 {object} models.InboxPage
+// @Failure 400 {string} string "Некорректные параметры страницы"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/conversations [get]
+func (h *MessageHandler) GetInbox(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	params := services.InboxListParams{Cursor: r.URL.Query().Get("cursor")}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		params.Limit = limit
+	}
+
+	page, err := h.Service.GetInbox(r.Context(), userID, params)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(page)
+}
+
 // SendMessage отправляет сообщение в беседу
 //
 // @Summary Отправить сообщение
-// @Description Отправляет новое сообщение в указанную беседу
+// @Description Отправляет новое сообщение в беседу от имени текущего пользователя. sender_id из тела игнорируется. Повторная отправка с тем же client_message_id в ту же беседу возвращает ID уже сохраненного сообщения
 // @Tags Messages
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param message body models.Message true "Данные сообщения"
 // @Success 200 {object} map[string]int "message_id: ID созданного сообщения"
 // @Failure 400 {string} string "Некорректный запрос"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 403 {string} string "Участник беседы заблокирован"
+// @Failure 404 {string} string "Беседа не найдена или пользователь в ней не участвует"
+// @Failure 409 {string} string "client_message_id уже использован в другой беседе"
 // @Failure 500 {string} string "Ошибка сервера"
 // @Router /api/messages [post]
 func (h *MessageHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
 	var req struct {
-		ConversationID int    `json:"conversation_id"`
-		SenderID       int    `json:"sender_id"`
-		Content        string `json:"content"`
+		ConversationID  int    `json:"conversation_id"`
+		Content         string `json:"content"`
+		ClientMessageID string `json:"client_message_id"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -51,35 +141,37 @@ func (h *MessageHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	messageID, err := h.Service.SendMessage(r.Context(), req.ConversationID, req.SenderID, req.Content)
+	msg, _, err := h.Service.SendMessage(r.Context(), req.ConversationID, userID, req.Content, req.ClientMessageID)
 	if err != nil {
-		if errors.Is(err, services.ErrConversationNotFound) {
-			http.Error(w, "Conversation not found", http.StatusBadRequest)
-			h.Logger.Error("Failed to send message - conversation not found", zap.Error(err))
-			return
-		}
-		http.Error(w, "Failed to send message", http.StatusInternalServerError)
-		h.Logger.Error("Failed to send message", zap.Error(err))
+		h.writeError(w, err)
 		return
 	}
 
-	h.Logger.Info("Message sent successfully", zap.Int("messageID", messageID))
+	h.Logger.Info("Message sent successfully", zap.Int("messageID", msg.ID))
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]int{"message_id": messageID})
+	json.NewEncoder(w).Encode(map[string]int{"message_id": msg.ID})
 }
 
 // GetMessages возвращает сообщения из беседы
 //
 // @Summary Получить сообщения
-// @Description Возвращает список сообщений по conversation_id
+// @Description Возвращает список сообщений беседы. Доступно только участникам. Сообщения не отмечаются прочитанными, для этого есть POST /api/conversations/{id}/read
 // @Tags Messages
 // @Produce json
+// @Security BearerAuth
 // @Param conversationID path int true "ID беседы"
 // @Success 200 {array} models.Message
 // @Failure 400 {string} string "Некорректный conversation_id"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 404 {string} string "Беседа не найдена или пользователь в ней не участвует"
 // @Failure 500 {string} string "Ошибка сервера"
 // @Router /api/messages/{conversationID} [get]
 func (h *MessageHandler) GetMessages(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
 	vars := mux.Vars(r)
 	conversationIDStr, ok := vars["conversationID"]
 	if !ok || conversationIDStr == "" {
@@ -95,15 +187,9 @@ func (h *MessageHandler) GetMessages(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	messages, err := h.Service.GetMessages(r.Context(), conversationID)
+	messages, err := h.Service.GetMessages(r.Context(), conversationID, userID)
 	if err != nil {
-		if err.Error() == "conversation not found" {
-			http.Error(w, "Conversation not found", http.StatusBadRequest)
-			h.Logger.Warn("Conversation not found", zap.Int("conversationID", conversationID))
-			return
-		}
-		http.Error(w, "Failed to get messages", http.StatusInternalServerError)
-		h.Logger.Error("Failed to get messages", zap.Error(err))
+		h.writeError(w, err)
 		return
 	}
 
@@ -115,15 +201,24 @@ func (h *MessageHandler) GetMessages(w http.ResponseWriter, r *http.Request) {
 // DeleteMessageHandler удаляет сообщение
 //
 // @Summary Удалить сообщение
-// @Description Удаляет сообщение по его ID
+// @Description Удаляет сообщение по его ID. Удалить сообщение может только отправитель
 // @Tags Messages
 // @Produce json
+// @Security BearerAuth
 // @Param messageID path int true "ID сообщения"
 // @Success 200 {object} map[string]string "message: Message deleted successfully"
 // @Failure 400 {string} string "Некорректный ID"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 403 {string} string "Сообщение отправил другой участник"
+// @Failure 404 {string} string "Сообщение не найдено или пользователь не участвует в беседе"
 // @Failure 500 {string} string "Ошибка сервера"
 // @Router /api/messages/{messageID} [delete]
 func (h *MessageHandler) DeleteMessageHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
 	messageID, err := strconv.Atoi(mux.Vars(r)["messageID"])
 	if err != nil {
 		h.Logger.Error("Invalid message ID", zap.Error(err))
@@ -131,15 +226,9 @@ func (h *MessageHandler) DeleteMessageHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	err = h.Service.DeleteMessage(r.Context(), messageID)
+	err = h.Service.DeleteMessage(r.Context(), messageID, userID)
 	if err != nil {
-		if err.Error() == "message not found" {
-			http.Error(w, "Message not found", http.StatusBadRequest)
-			h.Logger.Warn("Message not found", zap.Int("messageID", messageID))
-			return
-		}
-		h.Logger.Error("Failed to delete message", zap.Error(err))
-		http.Error(w, "Failed to delete message", http.StatusInternalServerError)
+		h.writeError(w, err)
 		return
 	}
 
@@ -147,3 +236,32 @@ func (h *MessageHandler) DeleteMessageHandler(w http.ResponseWriter, r *http.Req
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Message deleted successfully"})
 }
+
+func (h *MessageHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidCursor), errors.Is(err, services.ErrSelfAction),
+		errors.Is(err, services.ErrInvalidClientMessageID),
+		errors.Is(err, repositories.ErrInvalidUserID), errors.Is(err, services.ErrInvalidGroupTitle),
+		errors.Is(err, services.ErrInvalidParticipants), errors.Is(err, services.ErrInvalidRole),
+		errors.Is(err, services.ErrNotGroupConversation), errors.Is(err, repositories.ErrGroupFull),
+		errors.Is(err, repositories.ErrLastAdmin):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repositories.ErrParticipantNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, services.ErrNotGroupAdmin):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, services.ErrConversationNotFound):
+		http.Error(w, "Conversation not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrMessageNotFound):
+		http.Error(w, "Message not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrUserBlocked):
+		http.Error(w, "User is blocked", http.StatusForbidden)
+	case errors.Is(err, services.ErrNotMessageSender):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, repositories.ErrClientMessageIDReused):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		h.Logger.Error("Failed to process message request", zap.Error(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"InstaSpace/internal/services"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"go.uber.org/zap"
+)
+
+type NotificationHandler struct {
+	Service services.NotificationServiceInterface
+	Logger  *zap.Logger
+}
+
+func NewNotificationHandler(service services.NotificationServiceInterface, logger *zap.Logger) *NotificationHandler {
+	return &NotificationHandler{Service: service, Logger: logger}
+}
+
+// GetNotifications возвращает уведомления текущего пользователя
+//
+// @Summary Получить уведомления
+// @Description Возвращает уведомления о лайках, комментариях, подписках и упоминаниях от новых к старым. Однотипные непрочитанные события объединены. Участники, связанные с пользователем блокировкой, не учитываются, а уведомления только от них не возвращаются
+// @Tags Notifications
+// @Produce json
+// @Param limit query int false "Количество уведомлений (по умолчанию 20, максимум 100)"
+// @Param offset query int false "Смещение"
+// @Success 200 {array} models.Notification
+// @Failure 400 {string} string "Некорректные параметры"
+// @Failure 401 {string} string "Не авторизован"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/notifications [get]
+func (h *NotificationHandler) GetNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	limit, offset := 0, 0
+	var err error
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := r.URL.Query().Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Invalid offset", http.StatusBadRequest)
+			return
+		}
+	}
+
+	notifications, err := h.Service.GetNotifications(r.Context(), userID, limit, offset)
+	if err != nil {
+		h.Logger.Error("Failed to get notifications", zap.Int("userID", userID), zap.Error(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(notifications)
+}
+
+// GetUnreadCount возвращает количество непрочитанных уведомлений
+//
+// @Summary Количество непрочитанных уведомлений
+// @Description Возвращает количество непрочитанных уведомлений текущего пользователя
+// @Tags Notifications
+// @Produce json
+// @Success 200 {object} map[string]int "unread_count: Количество непрочитанных"
+// @Failure 401 {string} string "Не авторизован"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/notifications/unread-count [get]
+func (h *NotificationHandler) GetUnreadCount(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	count, err := h.Service.CountUnread(r.Context(), userID)
+	if err != nil {
+		h.Logger.Error("Failed to count unread notifications", zap.Int("userID", userID), zap.Error(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]int{"unread_count": count})
+}
+
+// MarkRead отмечает уведомления прочитанными
+//
+// @Summary Отметить уведомления прочитанными
+// @Description Отмечает прочитанными уведомления из списка ids. Без тела запроса или с пустым списком отмечает все
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Param request body object false "ids: [ID уведомлений]"
+// @Success 200 {object} map[string]int64 "updated: Количество отмеченных уведомлений"
+// @Failure 400 {string} string "Некорректный запрос"
+// @Failure 401 {string} string "Не авторизован"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/notifications/read [post]
+func (h *NotificationHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		IDs []int `json:"ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.Logger.Warn("Failed to parse request", zap.Error(err))
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	updated, err := h.Service.MarkRead(r.Context(), userID, req.IDs)
+	if err != nil {
+		h.Logger.Error("Failed to mark notifications read", zap.Int("userID", userID), zap.Error(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]int64{"updated": updated})
+}
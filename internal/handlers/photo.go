@@ -14,6 +14,13 @@ import (
 	"go.uber.org/zap"
 )
 
+const (
+	// MaxUploadSize максимальный размер загружаемого изображения
+	MaxUploadSize = 5 * 1024 * 1024
+
+	uploadDir = "uploads/"
+)
+
 type PhotoHandler struct {
 	Service services.PhotoServiceInterface
 	Logger  *zap.Logger
@@ -26,7 +33,7 @@ func NewPhotoHandler(service services.PhotoServiceInterface, logger *zap.Logger)
 // UploadPhoto загружает фото
 //
 // @Summary Загрузить фото
-// @Description Загружает фото в систему и сохраняет в базе данных
+// @Description Загружает фото в систему и сохраняет в базе данных. Упоминания @username в описании возвращаются в entities
 // @Tags Photos
 // @Accept multipart/form-data
 // @Produce json
@@ -47,19 +54,49 @@ func (h *PhotoHandler) UploadPhoto(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	filePath, ok := saveUploadedImage(w, r, h.Logger)
+	if !ok {
+		return
+	}
+
+	description := r.FormValue("description")
+
+	photo := models.Photo{
+		UserID:      userID,
+		URL:         filePath,
+		Description: description,
+	}
+
+	if err := h.Service.SavePhoto(r.Context(), &photo); err != nil {
+		if errors.Is(err, services.ErrInvalidPhotoData) {
+			h.Logger.Warn("Некорректные данные фото", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.Logger.Error("Ошибка сохранения фото в базе данных", zap.Error(err))
+		http.Error(w, "Could not save photo", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(photo)
+}
+
+// saveUploadedImage проверяет изображение из поля file и сохраняет его в uploadDir.
+// Возвращает путь к файлу или отвечает ошибкой и возвращает false
+func saveUploadedImage(w http.ResponseWriter, r *http.Request, logger *zap.Logger) (string, bool) {
 	file, header, err := r.FormFile("file")
 	if err != nil {
-		h.Logger.Warn("Файл не найден", zap.Error(err))
+		logger.Warn("Файл не найден", zap.Error(err))
 		http.Error(w, "File is required", http.StatusBadRequest)
-		return
+		return "", false
 	}
 	defer file.Close()
 
-	const MaxUploadSize = 5 * 1024 * 1024
 	if header.Size > MaxUploadSize {
-		h.Logger.Warn("Файл превышает максимальный размер")
+		logger.Warn("Файл превышает максимальный размер")
 		http.Error(w, "File size exceeds 5MB", http.StatusBadRequest)
-		return
+		return "", false
 	}
 
 	allowedFormats := map[string]bool{
@@ -69,67 +106,98 @@ func (h *PhotoHandler) UploadPhoto(w http.ResponseWriter, r *http.Request) {
 	}
 	fileExt := filepath.Ext(header.Filename)
 	if !allowedFormats[fileExt] {
-		h.Logger.Warn("Неподдерживаемый формат файла", zap.String("format", fileExt))
+		logger.Warn("Неподдерживаемый формат файла", zap.String("format", fileExt))
 		http.Error(w, "Unsupported file format", http.StatusBadRequest)
-		return
+		return "", false
 	}
 
-	uploadDir := "uploads/"
 	if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
-		h.Logger.Error("Не удалось создать директорию", zap.Error(err))
+		logger.Error("Не удалось создать директорию", zap.Error(err))
 		http.Error(w, "Could not create directory", http.StatusInternalServerError)
-		return
+		return "", false
 	}
 
-	uniqueFileName := time.Now().Format("20060102150405") + "_" + header.Filename
-	filePath := filepath.Join(uploadDir, uniqueFileName)
-
 	if _, err := os.Stat(uploadDir); os.IsPermission(err) {
-		h.Logger.Error("Недостаточно прав для записи в директорию", zap.Error(err))
+		logger.Error("Недостаточно прав для записи в директорию", zap.Error(err))
 		http.Error(w, "Permission denied for directory", http.StatusInternalServerError)
-		return
+		return "", false
 	}
 
-	dst, err := os.Create(filePath)
+	// CreateTemp создает файл со случайной частью имени и не открывает существующий, поэтому две загрузки
+	// никогда не перезапишут друг друга: файлы историй удаляются, и общий файл пропал бы вместе с чужой историей
+	dst, err := os.CreateTemp(uploadDir, time.Now().Format("20060102150405")+"_*_"+filepath.Base(header.Filename))
 	if err != nil {
-		h.Logger.Error("Не удалось сохранить файл", zap.Error(err))
+		logger.Error("Не удалось сохранить файл", zap.Error(err))
 		http.Error(w, "Could not save file", http.StatusInternalServerError)
-		return
+		return "", false
 	}
 	defer dst.Close()
+	filePath := dst.Name()
+
+	// CreateTemp создает файл с правами 0600, загруженные файлы должны читаться как раньше
+	if err := dst.Chmod(0o644); err != nil {
+		logger.Error("Не удалось сохранить файл", zap.Error(err))
+		http.Error(w, "Could not save file", http.StatusInternalServerError)
+		os.Remove(filePath)
+		return "", false
+	}
 
 	if _, err := file.Seek(0, 0); err != nil {
-		h.Logger.Error("Ошибка при обработке файла", zap.Error(err))
+		logger.Error("Ошибка при обработке файла", zap.Error(err))
 		http.Error(w, "File processing error", http.StatusInternalServerError)
-		return
+		return "", false
 	}
 	if _, err := dst.ReadFrom(file); err != nil {
-		h.Logger.Error("Ошибка записи файла", zap.Error(err))
+		logger.Error("Ошибка записи файла", zap.Error(err))
 		http.Error(w, "File write error", http.StatusInternalServerError)
-		return
+		return "", false
 	}
 
-	h.Logger.Info("Файл успешно загружен", zap.String("file", filePath))
-
-	description := r.FormValue("description")
+	logger.Info("Файл успешно загружен", zap.String("file", filePath))
+	return filePath, true
+}
 
-	photo := models.Photo{
-		UserID:      userID,
-		URL:         filePath,
-		Description: description,
+// GetFeed возвращает ленту текущего пользователя
+//
+// @Summary Лента
+// @Description Возвращает фото пользователя и его подписок от новых к старым. Скрытые и заблокированные авторы исключаются
+// @Tags Photos
+// @Produce json
+// @Param limit query int false "Количество фото (по умолчанию 20, максимум 100)"
+// @Param offset query int false "Смещение"
+// @Success 200 {array} models.Photo
+// @Failure 400 {string} string "Некорректные параметры"
+// @Failure 401 {string} string "Не авторизован"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/feed [get]
+func (h *PhotoHandler) GetFeed(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
 	}
 
-	if err := h.Service.SavePhoto(&photo); err != nil {
-		if errors.Is(err, services.ErrInvalidPhotoData) {
-			h.Logger.Warn("Некорректные данные фото", zap.Error(err))
-			http.Error(w, err.Error(), http.StatusBadRequest)
+	limit, offset := 0, 0
+	var err error
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
 			return
 		}
-		h.Logger.Error("Ошибка сохранения фото в базе данных", zap.Error(err))
-		http.Error(w, "Could not save photo", http.StatusInternalServerError)
+	}
+	if v := r.URL.Query().Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Invalid offset", http.StatusBadRequest)
+			return
+		}
+	}
+
+	photos, err := h.Service.GetFeed(r.Context(), userID, limit, offset)
+	if err != nil {
+		h.Logger.Error("Ошибка получения ленты", zap.Int("userID", userID), zap.Error(err))
+		http.Error(w, "Could not get feed", http.StatusInternalServerError)
 		return
 	}
 
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(photo)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(photos)
 }
@@ -1,13 +1,17 @@
 package handlers
 
 import (
+	"InstaSpace/internal/models"
 	"InstaSpace/internal/repositories"
 	"InstaSpace/internal/services"
+	"InstaSpace/pkg/middleware"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 )
 
@@ -23,16 +27,24 @@ func NewLikeHandler(service *services.LikeService, logger *zap.Logger) *LikeHand
 // AddLikeHandler добавляет лайк
 //
 // @Summary Добавить лайк
-// @Description Добавляет лайк к фото от имени пользователя
+// @Description Ставит на фото реакцию ❤️ от имени текущего пользователя. Повторный запрос ничего не меняет. Совместимый вариант PUT /api/photos/{id}/reaction
 // @Tags Likes
 // @Produce json
+// @Security BearerAuth
 // @Param photoID query int true "ID фото"
-// @Param userID query int true "ID пользователя"
 // @Success 200 {object} map[string]string "message: Like added successfully"
 // @Failure 400 {string} string "Некорректные параметры"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 403 {string} string "Автор фото заблокировал пользователя"
 // @Failure 500 {string} string "Ошибка сервера"
 // @Router /api/likes [post]
+// @Router /api/likes [put]
 func (h *LikeHandler) AddLikeHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
 	photoID, err := strconv.Atoi(r.URL.Query().Get("photoID"))
 	if err != nil || photoID <= 0 {
 		http.Error(w, "Invalid photo ID", http.StatusBadRequest)
@@ -40,13 +52,6 @@ func (h *LikeHandler) AddLikeHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID, err := strconv.Atoi(r.URL.Query().Get("userID"))
-	if err != nil || userID <= 0 {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
-		h.Logger.Error("Failed to parse user ID", zap.Error(err))
-		return
-	}
-
 	err = h.Service.AddLike(r.Context(), photoID, userID)
 	if errors.Is(err, repositories.ErrInvalidPhotoID) {
 		http.Error(w, "Invalid photo ID", http.StatusBadRequest)
@@ -56,6 +61,10 @@ func (h *LikeHandler) AddLikeHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid user ID", http.StatusBadRequest)
 		return
 	}
+	if errors.Is(err, services.ErrUserBlocked) {
+		http.Error(w, "User is blocked", http.StatusForbidden)
+		return
+	}
 	if err != nil {
 		h.Logger.Error("Failed to add like", zap.Error(err))
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
@@ -70,16 +79,22 @@ func (h *LikeHandler) AddLikeHandler(w http.ResponseWriter, r *http.Request) {
 // RemoveLikeHandler удаляет лайк
 //
 // @Summary Удалить лайк
-// @Description Удаляет лайк с фото, поставленный пользователем
+// @Description Снимает с фото реакцию ❤️, поставленную текущим пользователем. Если ее не было, запрос ничего не меняет
 // @Tags Likes
 // @Produce json
+// @Security BearerAuth
 // @Param photoID query int true "ID фото"
-// @Param userID query int true "ID пользователя"
 // @Success 200 {object} map[string]string "message: Like removed successfully"
 // @Failure 400 {string} string "Некорректные параметры"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
 // @Failure 500 {string} string "Ошибка сервера"
 // @Router /api/likes [delete]
 func (h *LikeHandler) RemoveLikeHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
 	photoID, err := strconv.Atoi(r.URL.Query().Get("photoID"))
 	if err != nil || photoID <= 0 {
 		http.Error(w, "Invalid photo ID", http.StatusBadRequest)
@@ -87,21 +102,10 @@ func (h *LikeHandler) RemoveLikeHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	userID, err := strconv.Atoi(r.URL.Query().Get("userID"))
-	if err != nil || userID <= 0 {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
-		h.Logger.Error("Failed to parse user ID", zap.Error(err))
-		return
-	}
-
 	err = h.Service.RemoveLike(r.Context(), photoID, userID)
 	if err != nil {
-		if err.Error() == "like not found" {
-			http.Error(w, "Like not found", http.StatusBadRequest)
-		} else {
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-		}
 		h.Logger.Error("Failed to remove like", zap.Error(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
@@ -113,38 +117,101 @@ func (h *LikeHandler) RemoveLikeHandler(w http.ResponseWriter, r *http.Request)
 // GetLikesHandler получает список пользователей, поставивших лайк
 //
 // @Summary Получить список лайков
-// @Description Возвращает список пользователей, поставивших лайк на фото
+// @Description Возвращает страницу пользователей, поставивших на фото реакцию ❤️. Сначала идут те, на кого подписан текущий пользователь, затем остальные, новые лайки первыми
 // @Tags Likes
 // @Produce json
 // @Param photoID query int true "ID фото"
-// @Success 200 {object} map[string]interface{} "users: [список пользователей]"
+// @Param limit query int false "Количество пользователей (по умолчанию 20, максимум 100)"
+// @Param cursor query string false "Курсор следующей страницы из next_cursor"
+// @Success 200 {object} models.LikersPage
 // @Failure 400 {string} string "Некорректные параметры"
 // @Failure 500 {string} string "Ошибка сервера"
 // @Router /api/likes/users [get]
 func (h *LikeHandler) GetLikesHandler(w http.ResponseWriter, r *http.Request) {
-	photoID, err := strconv.Atoi(r.URL.Query().Get("photoID"))
+	query := r.URL.Query()
+	photoID, err := strconv.Atoi(query.Get("photoID"))
 	if err != nil {
 		http.Error(w, "invalid photoID", http.StatusBadRequest)
 		h.Logger.Error("Invalid photoID", zap.Error(err))
 		return
 	}
 
-	users, err := h.Service.GetLikes(r.Context(), photoID)
+	params := services.LikeListParams{Cursor: query.Get("cursor")}
+	params.ViewerID, _ = middleware.UserIDFromContext(r.Context())
+	if v := query.Get("limit"); v != "" {
+		if params.Limit, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	page, err := h.Service.GetLikes(r.Context(), photoID, params)
+	if errors.Is(err, services.ErrInvalidCursor) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		h.Logger.Error("Failed to get likes", zap.Error(err))
 		return
 	}
 
-	h.Logger.Info("Fetched likes successfully", zap.Int("photoID", photoID))
+	h.Logger.Info("Fetched likes successfully", zap.Int("photoID", photoID), zap.Int("count", len(page.Users)))
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{"users": users})
+	json.NewEncoder(w).Encode(page)
+}
+
+// GetViewerStatesHandler возвращает состояние нескольких фото для текущего пользователя
+//
+// @Summary Состояние фото для зрителя
+// @Description Для каждого фото из списка возвращает количество лайков, лайк текущего пользователя и количество комментариев. Несуществующие фото и фото авторов, связанных с пользователем блокировкой, пропускаются
+// @Tags Likes
+// @Produce json
+// @Security BearerAuth
+// @Param photoIDs query string true "ID фото через запятую, не больше 100"
+// @Success 200 {object} map[string][]models.PhotoViewerState "photos: [состояния фото]"
+// @Failure 400 {string} string "Некорректный список ID"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/likes/state [get]
+func (h *LikeHandler) GetViewerStatesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var photoIDs []int
+	for _, part := range strings.Split(r.URL.Query().Get("photoIDs"), ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		id, err := strconv.Atoi(part)
+		if err != nil {
+			http.Error(w, services.ErrInvalidPhotoIDs.Error(), http.StatusBadRequest)
+			return
+		}
+		photoIDs = append(photoIDs, id)
+	}
+
+	states, err := h.Service.GetViewerStates(r.Context(), photoIDs, userID)
+	if errors.Is(err, services.ErrInvalidPhotoIDs) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		h.Logger.Error("Failed to get viewer states", zap.Error(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string][]models.PhotoViewerState{"photos": states})
 }
 
 // GetLikeCountHandler получает количество лайков у фото
 //
 // @Summary Получить количество лайков
-// @Description Возвращает количество лайков у фото
+// @Description Возвращает количество реакций ❤️ у фото
 // @Tags Likes
 // @Produce json
 // @Param photoID query int true "ID фото"
@@ -171,3 +238,91 @@ func (h *LikeHandler) GetLikeCountHandler(w http.ResponseWriter, r *http.Request
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{"likes_count": count})
 }
+
+// AddCommentLikeHandler добавляет лайк к комментарию
+//
+// @Summary Лайкнуть комментарий
+// @Description Добавляет лайк текущего пользователя к комментарию. Повторный лайк ничего не меняет
+// @Tags Likes
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID комментария"
+// @Success 200 {object} map[string]string "message: Like added successfully"
+// @Failure 400 {string} string "Некорректный ID"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 403 {string} string "Автор комментария заблокировал пользователя"
+// @Failure 404 {string} string "Комментарий не найден"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/comments/{id}/like [post]
+func (h *LikeHandler) AddCommentLikeHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	commentID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || commentID <= 0 {
+		http.Error(w, "Invalid comment ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.Service.AddCommentLike(r.Context(), commentID, userID)
+	if errors.Is(err, repositories.ErrCommentNotFound) {
+		http.Error(w, "Comment not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, repositories.ErrInvalidUserID) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, services.ErrUserBlocked) {
+		http.Error(w, "User is blocked", http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		h.Logger.Error("Failed to add comment like", zap.Error(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	h.Logger.Info("Comment like added successfully", zap.Int("commentID", commentID), zap.Int("userID", userID))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Like added successfully"})
+}
+
+// RemoveCommentLikeHandler удаляет лайк с комментария
+//
+// @Summary Убрать лайк с комментария
+// @Description Удаляет лайк текущего пользователя с комментария. Если лайка не было, запрос ничего не меняет
+// @Tags Likes
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID комментария"
+// @Success 200 {object} map[string]string "message: Like removed successfully"
+// @Failure 400 {string} string "Некорректный ID"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/comments/{id}/like [delete]
+func (h *LikeHandler) RemoveCommentLikeHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	commentID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || commentID <= 0 {
+		http.Error(w, "Invalid comment ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.Service.RemoveCommentLike(r.Context(), commentID, userID)
+	if err != nil {
+		h.Logger.Error("Failed to remove comment like", zap.Error(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	h.Logger.Info("Comment like removed successfully", zap.Int("commentID", commentID), zap.Int("userID", userID))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Like removed successfully"})
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"InstaSpace/internal/models"
+	"InstaSpace/internal/repositories"
+	"InstaSpace/internal/services"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+type PresenceHandler struct {
+	Service services.PresenceServiceInterface
+	Logger  *zap.Logger
+}
+
+func NewPresenceHandler(service services.PresenceServiceInterface, logger *zap.Logger) *PresenceHandler {
+	return &PresenceHandler{Service: service, Logger: logger}
+}
+
+// GetPresence возвращает присутствие пользователей в сети
+//
+// @Summary Присутствие пользователей
+// @Description Для каждого пользователя из списка возвращает, в сети ли он, и время последнего выхода из сети. Присутствие видно только собеседникам — пользователям с общей беседой без блокировки, остальные пропускаются. Если пользователь скрыл время выхода, оно не возвращается
+// @Tags Presence
+// @Produce json
+// @Security BearerAuth
+// @Param user_ids query string true "ID пользователей через запятую, не больше 100"
+// @Success 200 {object} map[string][]models.Presence "users: [присутствие пользователей]"
+// @Failure 400 {string} string "Некорректный список ID"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/presence [get]
+func (h *PresenceHandler) GetPresence(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var userIDs []int
+	for _, part := range strings.Split(r.URL.Query().Get("user_ids"), ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		id, err := strconv.Atoi(part)
+		if err != nil {
+			http.Error(w, services.ErrInvalidUserIDs.Error(), http.StatusBadRequest)
+			return
+		}
+		userIDs = append(userIDs, id)
+	}
+
+	presence, err := h.Service.GetPresence(r.Context(), userID, userIDs)
+	if errors.Is(err, services.ErrInvalidUserIDs) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		h.Logger.Error("Failed to get presence", zap.Int("userID", userID), zap.Error(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string][]models.Presence{"users": presence})
+}
+
+// GetSettings возвращает настройки приватности присутствия
+//
+// @Summary Настройки присутствия
+// @Description Возвращает настройки приватности присутствия текущего пользователя
+// @Tags Presence
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.PresenceSettings
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 404 {string} string "Пользователь не найден"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/presence/settings [get]
+func (h *PresenceHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	settings, err := h.Service.GetSettings(r.Context(), userID)
+	if err != nil {
+		h.writeError(w, userID, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(settings)
+}
+
+// UpdateSettings меняет настройки приватности присутствия
+//
+// @Summary Изменить настройки присутствия
+// @Description Задает, видят ли собеседники время последнего выхода текущего пользователя из сети. Сам статус "в сети" виден всегда
+// @Tags Presence
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param settings body models.PresenceSettings true "Настройки присутствия"
+// @Success 200 {object} models.PresenceSettings
+// @Failure 400 {string} string "Некорректный запрос"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 404 {string} string "Пользователь не найден"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/presence/settings [put]
+func (h *PresenceHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var settings models.PresenceSettings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.UpdateSettings(r.Context(), userID, settings); err != nil {
+		h.writeError(w, userID, err)
+		return
+	}
+
+	h.Logger.Info("Presence settings updated", zap.Int("userID", userID), zap.Bool("hideLastSeen", settings.HideLastSeen))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(settings)
+}
+
+func (h *PresenceHandler) writeError(w http.ResponseWriter, userID int, err error) {
+	switch {
+	case errors.Is(err, repositories.ErrInvalidUserID):
+		http.Error(w, "User not found", http.StatusNotFound)
+	default:
+		h.Logger.Error("Failed to process presence settings", zap.Int("userID", userID), zap.Error(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"InstaSpace/internal/models"
+	"InstaSpace/internal/repositories"
+	"InstaSpace/internal/services"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+type ReactionHandler struct {
+	Service services.ReactionServiceInterface
+	Logger  *zap.Logger
+}
+
+func NewReactionHandler(service services.ReactionServiceInterface, logger *zap.Logger) *ReactionHandler {
+	return &ReactionHandler{Service: service, Logger: logger}
+}
+
+// GetAllowedReactions возвращает набор разрешенных реакций
+//
+// @Summary Список реакций
+// @Description Возвращает реакции, которые можно ставить на фото и сообщения
+// @Tags Reactions
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string][]string "reactions: [список реакций]"
+// @Router /api/reactions [get]
+func (h *ReactionHandler) GetAllowedReactions(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string][]string{"reactions": h.Service.AllowedReactions()})
+}
+
+// SetPhotoReaction ставит реакцию на фото
+//
+// @Summary Поставить реакцию на фото
+// @Description Ставит реакцию текущего пользователя на фото. Повторный запрос с другой реакцией меняет ее
+// @Tags Reactions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID фото"
+// @Param reaction body models.ReactionRequest true "Реакция"
+// @Success 200 {object} map[string]string "message: Reaction set"
+// @Failure 400 {string} string "Некорректный ID или недопустимая реакция"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 403 {string} string "Автор фото заблокировал пользователя"
+// @Failure 404 {string} string "Фото не найдено"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/photos/{id}/reaction [put]
+func (h *ReactionHandler) SetPhotoReaction(w http.ResponseWriter, r *http.Request) {
+	userID, photoID, ok := h.parseTarget(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.ReactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.SetPhotoReaction(r.Context(), photoID, userID, req.Reaction); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.Logger.Info("Reaction set", zap.Int("photoID", photoID), zap.Int("userID", userID))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Reaction set"})
+}
+
+// RemovePhotoReaction снимает реакцию с фото
+//
+// @Summary Убрать реакцию с фото
+// @Description Снимает реакцию текущего пользователя с фото. Если реакции не было, запрос ничего не меняет
+// @Tags Reactions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID фото"
+// @Success 200 {object} map[string]string "message: Reaction removed"
+// @Failure 400 {string} string "Некорректный ID"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/photos/{id}/reaction [delete]
+func (h *ReactionHandler) RemovePhotoReaction(w http.ResponseWriter, r *http.Request) {
+	userID, photoID, ok := h.parseTarget(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Service.RemovePhotoReaction(r.Context(), photoID, userID, ""); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.Logger.Info("Reaction removed", zap.Int("photoID", photoID), zap.Int("userID", userID))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Reaction removed"})
+}
+
+// GetPhotoReactions возвращает реакции на фото
+//
+// @Summary Реакции на фото
+// @Description Возвращает количество реакций каждого вида и реакцию текущего пользователя
+// @Tags Reactions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID фото"
+// @Success 200 {object} models.ReactionSummary
+// @Failure 400 {string} string "Некорректный ID"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 404 {string} string "Фото не найдено"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/photos/{id}/reactions [get]
+func (h *ReactionHandler) GetPhotoReactions(w http.ResponseWriter, r *http.Request) {
+	userID, photoID, ok := h.parseTarget(w, r)
+	if !ok {
+		return
+	}
+
+	summary, err := h.Service.GetPhotoReactions(r.Context(), photoID, userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(summary)
+}
+
+// SetMessageReaction ставит реакцию на сообщение
+//
+// @Summary Поставить реакцию на сообщение
+// @Description Ставит реакцию участника беседы на сообщение. Повторный запрос с другой реакцией меняет ее
+// @Tags Reactions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID сообщения"
+// @Param reaction body models.ReactionRequest true "Реакция"
+// @Success 200 {object} map[string]string "message: Reaction set"
+// @Failure 400 {string} string "Некорректный ID или недопустимая реакция"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 403 {string} string "Отправитель заблокировал пользователя"
+// @Failure 404 {string} string "Сообщение не найдено"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/messages/{id}/reaction [put]
+func (h *ReactionHandler) SetMessageReaction(w http.ResponseWriter, r *http.Request) {
+	userID, messageID, ok := h.parseTarget(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.ReactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.SetMessageReaction(r.Context(), messageID, userID, req.Reaction); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.Logger.Info("Message reaction set", zap.Int("messageID", messageID), zap.Int("userID", userID))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Reaction set"})
+}
+
+// RemoveMessageReaction снимает реакцию с сообщения
+//
+// @Summary Убрать реакцию с сообщения
+// @Description Снимает реакцию участника беседы с сообщения
+// @Tags Reactions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID сообщения"
+// @Success 200 {object} map[string]string "message: Reaction removed"
+// @Failure 400 {string} string "Некорректный ID"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 404 {string} string "Сообщение не найдено"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/messages/{id}/reaction [delete]
+func (h *ReactionHandler) RemoveMessageReaction(w http.ResponseWriter, r *http.Request) {
+	userID, messageID, ok := h.parseTarget(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Service.RemoveMessageReaction(r.Context(), messageID, userID); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.Logger.Info("Message reaction removed", zap.Int("messageID", messageID), zap.Int("userID", userID))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Reaction removed"})
+}
+
+// GetMessageReactions возвращает реакции на сообщение
+//
+// @Summary Реакции на сообщение
+// @Description Возвращает количество реакций каждого вида и реакцию текущего пользователя. Доступно участникам беседы
+// @Tags Reactions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID сообщения"
+// @Success 200 {object} models.ReactionSummary
+// @Failure 400 {string} string "Некорректный ID"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 404 {string} string "Сообщение не найдено"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/messages/{id}/reactions [get]
+func (h *ReactionHandler) GetMessageReactions(w http.ResponseWriter, r *http.Request) {
+	userID, messageID, ok := h.parseTarget(w, r)
+	if !ok {
+		return
+	}
+
+	summary, err := h.Service.GetMessageReactions(r.Context(), messageID, userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(summary)
+}
+
+// parseTarget возвращает ID текущего пользователя и ID объекта из пути {id}
+func (h *ReactionHandler) parseTarget(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return 0, 0, false
+	}
+
+	targetID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || targetID <= 0 {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return userID, targetID, true
+}
+
+func (h *ReactionHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidReaction), errors.Is(err, repositories.ErrInvalidUserID):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrUserBlocked):
+		http.Error(w, "User is blocked", http.StatusForbidden)
+	case errors.Is(err, repositories.ErrInvalidPhotoID):
+		http.Error(w, "Photo not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrMessageNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		h.Logger.Error("Failed to process reaction", zap.Error(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
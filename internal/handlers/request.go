@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"InstaSpace/internal/repositories"
+	"InstaSpace/internal/services"
+	"InstaSpace/pkg/middleware"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// requireUserID возвращает ID пользователя из JWT или отвечает 401
+func requireUserID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return 0, false
+	}
+	return userID, true
+}
+
+// handleRelation выполняет действие текущего пользователя над пользователем из пути {id}
+func handleRelation(w http.ResponseWriter, r *http.Request, logger *zap.Logger, action func(ctx context.Context, userID, targetID int) error, message string) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	targetID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || targetID <= 0 {
+		logger.Warn("Invalid target user ID", zap.String("id", mux.Vars(r)["id"]))
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	err = action(r.Context(), userID, targetID)
+	if errors.Is(err, repositories.ErrInvalidUserID) || errors.Is(err, services.ErrSelfAction) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, services.ErrUserBlocked) {
+		http.Error(w, "User is blocked", http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to update user relation", zap.Int("userID", userID), zap.Int("targetID", targetID), zap.Error(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info(message, zap.Int("userID", userID), zap.Int("targetID", targetID))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"InstaSpace/internal/models"
+	"InstaSpace/internal/repositories"
+	"InstaSpace/internal/services"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+type StoryHandler struct {
+	Service services.StoryServiceInterface
+	Logger  *zap.Logger
+}
+
+func NewStoryHandler(service services.StoryServiceInterface, logger *zap.Logger) *StoryHandler {
+	return &StoryHandler{Service: service, Logger: logger}
+}
+
+// CreateStory публикует историю
+//
+// @Summary Опубликовать историю
+// @Description Загружает изображение истории с теми же ограничениями, что и у фото. История исчезает через 24 часа
+// @Tags Stories
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "Файл изображения"
+// @Param caption formData string false "Подпись"
+// @Success 201 {object} models.Story
+// @Failure 400 {string} string "Некорректный ввод"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/stories [post]
+func (h *StoryHandler) CreateStory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	filePath, ok := saveUploadedImage(w, r, h.Logger)
+	if !ok {
+		return
+	}
+
+	story := models.Story{UserID: userID, URL: filePath, Caption: r.FormValue("caption")}
+	if err := h.Service.CreateStory(r.Context(), &story); err != nil {
+		os.Remove(filePath)
+		h.writeError(w, err)
+		return
+	}
+
+	h.Logger.Info("Story created", zap.Int("storyID", story.ID), zap.Int("userID", userID))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(story)
+}
+
+// GetTray возвращает ленту историй
+//
+// @Summary Лента историй
+// @Description Возвращает подписки текущего пользователя с активными историями: сначала с непросмотренными, затем по свежести. Скрытые и заблокированные авторы исключаются
+// @Tags Stories
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string][]models.StoryTrayItem "tray: [авторы с историями]"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/stories/tray [get]
+func (h *StoryHandler) GetTray(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	tray, err := h.Service.GetTray(r.Context(), userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string][]models.StoryTrayItem{"tray": tray})
+}
+
+// GetUserStories возвращает истории пользователя
+//
+// @Summary Истории пользователя
+// @Description Возвращает активные истории пользователя от старых к новым с отметкой просмотра. Автор также видит количество просмотров
+// @Tags Stories
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} map[string][]models.Story "stories: [список историй]"
+// @Failure 400 {string} string "Некорректный ID"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/users/{id}/stories [get]
+func (h *StoryHandler) GetUserStories(w http.ResponseWriter, r *http.Request) {
+	viewerID, ownerID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	stories, err := h.Service.GetUserStories(r.Context(), ownerID, viewerID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string][]models.Story{"stories": stories})
+}
+
+// MarkSeen отмечает историю просмотренной
+//
+// @Summary Отметить просмотр истории
+// @Description Отмечает историю просмотренной текущим пользователем. Повторный просмотр ничего не меняет
+// @Tags Stories
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID истории"
+// @Success 200 {object} map[string]string "message: Story seen"
+// @Failure 400 {string} string "Некорректный ID"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 404 {string} string "История не найдена или истекла"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/stories/{id}/seen [post]
+func (h *StoryHandler) MarkSeen(w http.ResponseWriter, r *http.Request) {
+	userID, storyID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Service.MarkSeen(r.Context(), storyID, userID); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Story seen"})
+}
+
+// GetViewers возвращает просмотревших историю
+//
+// @Summary Просмотры истории
+// @Description Возвращает пользователей, просмотревших историю, последние первыми. Доступно только автору
+// @Tags Stories
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID истории"
+// @Success 200 {object} map[string][]models.StoryViewer "viewers: [список просмотревших]"
+// @Failure 400 {string} string "Некорректный ID"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 404 {string} string "История не найдена"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/stories/{id}/viewers [get]
+func (h *StoryHandler) GetViewers(w http.ResponseWriter, r *http.Request) {
+	userID, storyID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	viewers, err := h.Service.GetViewers(r.Context(), storyID, userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string][]models.StoryViewer{"viewers": viewers})
+}
+
+// DeleteStory удаляет историю
+//
+// @Summary Удалить историю
+// @Description Удаляет историю текущего пользователя вместе с файлом
+// @Tags Stories
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID истории"
+// @Success 200 {object} map[string]string "message: Story deleted"
+// @Failure 400 {string} string "Некорректный ID"
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 404 {string} string "История не найдена"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/stories/{id} [delete]
+func (h *StoryHandler) DeleteStory(w http.ResponseWriter, r *http.Request) {
+	userID, storyID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Service.DeleteStory(r.Context(), storyID, userID); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.Logger.Info("Story deleted", zap.Int("storyID", storyID), zap.Int("userID", userID))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Story deleted"})
+}
+
+// parseID возвращает ID текущего пользователя и ID из пути {id}
+func (h *StoryHandler) parseID(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return 0, 0, false
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return userID, id, true
+}
+
+func (h *StoryHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidPhotoData), errors.Is(err, services.ErrInvalidStoryCaption),
+		errors.Is(err, repositories.ErrInvalidUserID):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repositories.ErrStoryNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		h.Logger.Error("Failed to process story request", zap.Error(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -1,120 +1,357 @@
 package handlers
 
 import (
+	"InstaSpace/docs"
+	"InstaSpace/internal/models"
+	"InstaSpace/internal/repositories"
 	"InstaSpace/internal/services"
+	"InstaSpace/pkg/middleware"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
-	"sync"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/gorilla/websocket"
 	"go.uber.org/zap"
 )
 
+// WSProtocol подпротокол, который сервер выбирает при подключении. Клиент, передающий токен
+// через Sec-WebSocket-Protocol, предлагает его вместе с "bearer.<JWT>"
+const WSProtocol = "instaspace"
+
+// wsTokenProtocolPrefix префикс подпротокола, в котором передается JWT
+const wsTokenProtocolPrefix = "bearer."
+
+// presenceUpdateTimeout сколько ждать сохранения времени выхода из сети после закрытия подключения
+const presenceUpdateTimeout = 5 * time.Second
+
+var ErrWSUnauthorized = errors.New("missing websocket credentials")
+
 type WebSocketHandler struct {
-	Clients        map[*websocket.Conn]bool
-	Mutex          sync.Mutex
+	Hub            *Hub
 	Logger         *zap.Logger
 	MessageService *services.MessageService
+	Tickets        services.WSTicketServiceInterface
+	JWTSecret      string
+	Upgrader       websocket.Upgrader
+	// Presence узнает о появлении пользователей в сети и выходе из нее. Задается после создания,
+	// так как сервис присутствия сам зависит от обработчика
+	Presence *services.PresenceService
 }
 
-func NewWebSocketHandler(logger *zap.Logger, messageService *services.MessageService) *WebSocketHandler {
+// NewWebSocketHandler создает обработчик, принимающий подключения с источников allowedOrigins.
+// Пустой список разрешает только тот же хост, "*" — любые источники
+func NewWebSocketHandler(logger *zap.Logger, messageService *services.MessageService, tickets services.WSTicketServiceInterface, jwtSecret string, allowedOrigins []string) *WebSocketHandler {
 	return &WebSocketHandler{
-		Clients:        make(map[*websocket.Conn]bool),
+		Hub:            NewHub(logger),
 		Logger:         logger,
 		MessageService: messageService,
+		Tickets:        tickets,
+		JWTSecret:      jwtSecret,
+		Upgrader: websocket.Upgrader{
+			Subprotocols: []string{WSProtocol},
+			CheckOrigin:  originChecker(allowedOrigins),
+		},
+	}
+}
+
+// SendToUser отправляет событие во все подключения пользователя
+func (h *WebSocketHandler) SendToUser(userID int, eventType string, payload interface{}) {
+	h.Hub.SendToUser(userID, eventType, payload)
+}
+
+// SendToUsers отправляет одно событие во все подключения пользователей userIDs
+func (h *WebSocketHandler) SendToUsers(userIDs []int, eventType string, payload interface{}) {
+	h.Hub.SendToUsers(userIDs, eventType, payload)
+}
+
+// IsOnline сообщает, есть ли у пользователя открытые подключения к этому экземпляру
+func (h *WebSocketHandler) IsOnline(userID int) bool {
+	return h.Hub.IsOnline(userID)
+}
+
+// originChecker возвращает проверку заголовка Origin по списку разрешенных источников.
+// Запросы без Origin приходят не из браузера и пропускаются: доступ к сокету все равно требует токена
+func originChecker(allowedOrigins []string) func(r *http.Request) bool {
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		if origin == "*" {
+			return func(r *http.Request) bool { return true }
+		}
+		allowed[strings.ToLower(strings.TrimSuffix(origin, "/"))] = true
+	}
+
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		if len(allowed) == 0 {
+			u, err := url.Parse(origin)
+			return err == nil && strings.EqualFold(u.Host, r.Host)
+		}
+		return allowed[strings.ToLower(origin)]
+	}
+}
+
+// authenticate возвращает пользователя подключения и время, когда истекает его токен (нулевое для токена без срока).
+// Токен берется из заголовка Authorization, подпротокола bearer.<JWT> или одноразового билета в параметре ticket
+func (h *WebSocketHandler) authenticate(r *http.Request) (int, time.Time, error) {
+	tokenString := ""
+	if authHeader := r.Header.Get("Authorization"); strings.HasPrefix(authHeader, "Bearer ") {
+		tokenString = strings.TrimPrefix(authHeader, "Bearer ")
+	}
+	if tokenString == "" {
+		for _, protocol := range websocket.Subprotocols(r) {
+			if strings.HasPrefix(protocol, wsTokenProtocolPrefix) {
+				tokenString = strings.TrimPrefix(protocol, wsTokenProtocolPrefix)
+				break
+			}
+		}
+	}
+	if tokenString != "" {
+		return middleware.ParseTokenExpiry(tokenString, h.JWTSecret)
+	}
+
+	if ticket := r.URL.Query().Get("ticket"); ticket != "" {
+		return h.Tickets.RedeemTicket(r.Context(), ticket)
 	}
+	return 0, time.Time{}, ErrWSUnauthorized
 }
 
-var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		return true
-	},
+// IssueTicket выдает билет для подключения к WebSocket
+//
+// @Summary Получить билет для WebSocket
+// @Description Выдает одноразовый короткоживущий билет для подключения к /ws?ticket=... из браузера, где нельзя передать заголовок Authorization. Подключение по билету закрывается, когда истекает токен, которым билет получен
+// @Tags WebSocket
+// @Produce json
+// @Security BearerAuth
+// @Success 201 {object} models.WSTicket
+// @Failure 401 {string} string "Пользователь не аутентифицирован"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api/ws-ticket [post]
+func (h *WebSocketHandler) IssueTicket(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	expiresAt, _ := middleware.TokenExpiresAtFromContext(r.Context())
+	ticket, err := h.Tickets.IssueTicket(r.Context(), userID, expiresAt)
+	if err != nil {
+		h.Logger.Error("Failed to issue ws ticket", zap.Int("userID", userID), zap.Error(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ticket)
+}
+
+// Schema возвращает JSON-схему протокола WebSocket
+//
+// @Summary Схема протокола WebSocket
+// @Description Возвращает JSON-схему конвертов и типов сообщений протокола WebSocket текущей версии
+// @Tags WebSocket
+// @Produce json
+// @Success 200 {object} map[string]interface{} "JSON-схема"
+// @Router /ws/schema [get]
+func (h *WebSocketHandler) Schema(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(docs.WSProtocolSchema)
 }
 
 // HandleWS обрабатывает WebSocket соединение
 //
 // @Summary Установить WebSocket соединение
-// @Description Устанавливает WebSocket соединение и отправляет/получает сообщения в режиме реального времени
+// @Description Устанавливает WebSocket соединение для сообщений в реальном времени. Подключение требует токена: заголовок Authorization, подпротоколы "instaspace" и "bearer.<JWT>" в Sec-WebSocket-Protocol или билет из POST /api/ws-ticket. При истечении токена соединение закрывается. Сервер периодически отправляет ping: клиент, не ответивший pong и не приславший сообщений за отведенное время, отключается. Сообщение больше допустимого размера закрывает соединение с кодом 1009, при перезапуске сервера соединение закрывается с кодом 1012 — клиенту следует переподключиться. Все сообщения — конверты {"v":1,"type":...,"id":...,"payload":...}, схема протокола доступна по GET /ws/schema. Клиент отправляет send, read и typing; на send приходит ack с ID сохраненного сообщения, на ошибку — error с кодом и тем же id. ID конверта send хранится как client_message_id: повторная отправка не создает дубликат. Сервер присылает message, read, typing, presence, unread_count и notification. typing с typing=true клиент повторяет, пока пользователь набирает текст: участникам он пересылается не чаще раза в 3 секунды, а если не повторился за 6 секунд, участники получают typing=false. presence приходит собеседникам, когда пользователь подключается первым устройством и отключается последним
 // @Tags WebSocket
 // @Accept json
 // @Produce json
+// @Param ticket query string false "Билет из POST /api/ws-ticket"
+// @Param Sec-WebSocket-Protocol header string false "instaspace, bearer.<JWT>"
 // @Success 101 {string} string "WebSocket connection established"
+// @Failure 401 {string} string "Токен отсутствует, недействителен или билет уже использован"
+// @Failure 403 {string} string "Источник не разрешен"
 // @Failure 500 {string} string "Ошибка сервера"
 // @Router /ws [get]
 func (h *WebSocketHandler) HandleWS(w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+	// Источник проверяется до билета, чтобы запрос с чужой страницы не погашал его
+	if !h.Upgrader.CheckOrigin(r) {
+		h.Logger.Warn("WebSocket origin not allowed", zap.String("origin", r.Header.Get("Origin")))
+		http.Error(w, "Origin not allowed", http.StatusForbidden)
+		return
+	}
+
+	userID, expiresAt, err := h.authenticate(r)
+	if errors.Is(err, ErrWSUnauthorized) {
+		http.Error(w, "Missing token", http.StatusUnauthorized)
+		return
+	}
+	if errors.Is(err, services.ErrInvalidWSTicket) {
+		http.Error(w, "Invalid ticket", http.StatusUnauthorized)
+		return
+	}
 	if err != nil {
-		h.Logger.Error("WebSocket upgrade failed", zap.Error(err))
-		http.Error(w, "Failed to upgrade connection", http.StatusInternalServerError)
+		h.Logger.Warn("Invalid WebSocket credentials", zap.Error(err))
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
 		return
 	}
-	defer conn.Close()
 
-	h.Mutex.Lock()
-	h.Clients[conn] = true
-	h.Mutex.Unlock()
+	conn, err := h.Upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		h.Logger.Error("WebSocket upgrade failed", zap.Error(err))
+		return
+	}
 
+	client := h.Hub.NewClient(conn, userID)
+	// Каждое подключение учитывается в общем присутствии: в сети ли пользователь, сервис решает
+	// по подключениям ко всем экземплярам
+	if h.Hub.Register(client) && h.Presence != nil {
+		h.Presence.Connected(r.Context(), userID)
+	}
 	defer func() {
-		h.Mutex.Lock()
-		delete(h.Clients, conn)
-		h.Mutex.Unlock()
+		registered := h.Hub.Unregister(client)
+		client.Close()
+		if registered && h.Presence != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), presenceUpdateTimeout)
+			h.Presence.Disconnected(ctx, userID)
+			cancel()
+		}
 		h.Logger.Info("WebSocket connection closed")
 	}()
 
-	h.Logger.Info("New WebSocket connection established")
+	if !expiresAt.IsZero() {
+		expiry := time.AfterFunc(time.Until(expiresAt), func() {
+			h.Logger.Info("WebSocket token expired", zap.Int("userID", userID))
+			client.CloseWithReason(websocket.ClosePolicyViolation, "Token expired")
+		})
+		defer expiry.Stop()
+	}
 
-	for {
-		var msg struct {
-			ConversationID int    `json:"conversation_id"`
-			SenderID       int    `json:"sender_id"`
-			Content        string `json:"content"`
-		}
+	h.Logger.Info("New WebSocket connection established", zap.Int("userID", userID))
 
-		err := conn.ReadJSON(&msg)
+	for {
+		_, data, err := conn.ReadMessage()
 		if err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				h.Logger.Error("Unexpected WebSocket disconnection", zap.Error(err))
-			} else {
-				h.Logger.Warn("WebSocket client disconnected")
-			}
+			h.logReadError(userID, err)
 			break
 		}
+		client.ExtendReadDeadline()
 
-		if msg.ConversationID == 0 || msg.SenderID == 0 || msg.Content == "" {
-			h.Logger.Warn("Received invalid message data", zap.Any("message", msg))
-			_ = conn.WriteJSON(map[string]string{"error": "Invalid message data"})
+		var env models.WSEnvelope
+		if err := json.Unmarshal(data, &env); err != nil || env.Type == "" {
+			client.SendError("", models.WSErrInvalidEnvelope, "Invalid envelope")
 			continue
 		}
-
-		messageID, err := h.MessageService.SendMessage(r.Context(), msg.ConversationID, msg.SenderID, msg.Content)
-		if err != nil {
-			h.Logger.Error("Failed to save message", zap.Error(err))
-			_ = conn.WriteJSON(map[string]string{"error": "Failed to save message"})
+		if env.V != 0 && env.V != models.WSProtocolVersion {
+			client.SendError(env.ID, models.WSErrUnsupportedVersion, fmt.Sprintf("Unsupported protocol version %d", env.V))
 			continue
 		}
 
-		h.Logger.Info("Message received and saved",
-			zap.Int("conversation_id", msg.ConversationID),
-			zap.Int("sender_id", msg.SenderID),
-			zap.String("content", msg.Content),
-			zap.Int("message_id", messageID),
-		)
-
-		response := map[string]interface{}{
-			"message_id":      messageID,
-			"conversation_id": msg.ConversationID,
-			"sender_id":       msg.SenderID,
-			"content":         msg.Content,
+		switch env.Type {
+		case models.WSSend:
+			h.handleSend(r.Context(), client, userID, env)
+		case models.WSRead:
+			h.handleRead(r.Context(), client, userID, env)
+		case models.WSTyping:
+			h.handleTyping(r.Context(), client, userID, env)
+		default:
+			client.SendError(env.ID, models.WSErrUnknownType, fmt.Sprintf("Unknown message type %q", env.Type))
 		}
+	}
+}
 
-		h.Mutex.Lock()
-		for client := range h.Clients {
-			err = client.WriteJSON(response)
-			if err != nil {
-				h.Logger.Error("Failed to send message", zap.Error(err))
-				client.Close()
-				delete(h.Clients, client)
-			}
-		}
-		h.Mutex.Unlock()
+// logReadError записывает причину, по которой чтение из подключения прекратилось
+func (h *WebSocketHandler) logReadError(userID int, err error) {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, websocket.ErrReadLimit):
+		h.Logger.Warn("WebSocket message too large", zap.Int("userID", userID), zap.Int64("limit", h.Hub.MaxMessageSize))
+	case errors.As(err, &netErr) && netErr.Timeout():
+		h.Logger.Warn("WebSocket client stopped responding", zap.Int("userID", userID))
+	case websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure, websocket.CloseServiceRestart):
+		h.Logger.Error("Unexpected WebSocket disconnection", zap.Error(err))
+	default:
+		h.Logger.Warn("WebSocket client disconnected")
+	}
+}
+
+// handleSend сохраняет сообщение и подтверждает его отправителю. ID конверта хранится как ID сообщения
+// на клиенте, поэтому повтор после обрыва связи не создает дубликат. Участники получают сообщение от сервиса
+func (h *WebSocketHandler) handleSend(ctx context.Context, client *Client, userID int, env models.WSEnvelope) {
+	var payload models.WSSendPayload
+	if err := json.Unmarshal(env.Payload, &payload); err != nil || payload.ConversationID <= 0 || payload.Content == "" {
+		client.SendError(env.ID, models.WSErrInvalidPayload, "Invalid message data")
+		return
+	}
+
+	msg, created, err := h.MessageService.SendMessage(ctx, payload.ConversationID, userID, payload.Content, env.ID)
+	if err != nil {
+		h.sendServiceError(client, env.ID, err)
+		return
+	}
+
+	h.Logger.Info("Message received and saved",
+		zap.Int("conversation_id", msg.ConversationID),
+		zap.Int("sender_id", userID),
+		zap.Int("message_id", msg.ID),
+		zap.Bool("duplicate", !created),
+	)
+	client.Send(models.WSAck, env.ID, models.WSAckPayload{
+		MessageID:      msg.ID,
+		ConversationID: msg.ConversationID,
+		CreatedAt:      msg.CreatedAt,
+		Duplicate:      !created,
+	})
+}
+
+// handleRead отмечает беседу прочитанной владельцем подключения. Событие остальным участникам рассылает сервис
+func (h *WebSocketHandler) handleRead(ctx context.Context, client *Client, userID int, env models.WSEnvelope) {
+	var payload models.WSReadPayload
+	if err := json.Unmarshal(env.Payload, &payload); err != nil || payload.ConversationID <= 0 || payload.MessageID < 0 {
+		client.SendError(env.ID, models.WSErrInvalidPayload, "Invalid read data")
+		return
+	}
+
+	if _, err := h.MessageService.MarkRead(ctx, payload.ConversationID, userID, payload.MessageID); err != nil {
+		h.sendServiceError(client, env.ID, err)
+	}
+}
+
+// handleTyping пересылает остальным участникам беседы, что пользователь набирает сообщение или перестал.
+// Частоту и истечение набора контролирует сервис
+func (h *WebSocketHandler) handleTyping(ctx context.Context, client *Client, userID int, env models.WSEnvelope) {
+	var payload models.WSTypingPayload
+	if err := json.Unmarshal(env.Payload, &payload); err != nil || payload.ConversationID <= 0 {
+		client.SendError(env.ID, models.WSErrInvalidPayload, "Invalid typing data")
+		return
+	}
+
+	if err := h.MessageService.SendTyping(ctx, payload.ConversationID, userID, payload.Typing); err != nil {
+		h.sendServiceError(client, env.ID, err)
+	}
+}
+
+// sendServiceError отправляет клиенту код ошибки сервиса
+func (h *WebSocketHandler) sendServiceError(client *Client, id string, err error) {
+	switch {
+	case errors.Is(err, services.ErrConversationNotFound):
+		client.SendError(id, models.WSErrConversationNotFound, "Conversation not found")
+	case errors.Is(err, services.ErrMessageNotFound):
+		client.SendError(id, models.WSErrMessageNotFound, "Message not found")
+	case errors.Is(err, services.ErrUserBlocked):
+		client.SendError(id, models.WSErrUserBlocked, "User is blocked")
+	case errors.Is(err, services.ErrInvalidClientMessageID), errors.Is(err, repositories.ErrClientMessageIDReused):
+		client.SendError(id, models.WSErrInvalidPayload, err.Error())
+	default:
+		h.Logger.Error("Failed to process WebSocket message", zap.Error(err))
+		client.SendError(id, models.WSErrInternal, "Internal server error")
 	}
 }
@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// Видимость альбома
+const (
+	AlbumPublic    = "public"
+	AlbumFollowers = "followers"
+	AlbumPrivate   = "private"
+)
+
+// Album представляет собой альбом из фото его автора
+//
+// @swagger:model
+type Album struct {
+	// ID альбома
+	ID int `json:"id" example:"1"`
+	// ID автора
+	UserID int `json:"user_id" example:"42"`
+	// Название альбома
+	Title string `json:"title" example:"Лето"`
+	// Описание альбома
+	Description string `json:"description" example:"Поездка на море"`
+	// Кто видит альбом: public, followers, private
+	Visibility string `json:"visibility" example:"followers"`
+	// ID обложки. Если не выбрана, обложкой служит первое фото альбома
+	CoverPhotoID *int `json:"cover_photo_id,omitempty" example:"7"`
+	// URL обложки
+	CoverURL string `json:"cover_url,omitempty" example:"https://example.com/uploads/photo7.jpg"`
+	// Количество фото в альбоме
+	PhotosCount int `json:"photos_count" example:"12"`
+	// Дата создания
+	CreatedAt time.Time `json:"created_at" example:"2024-02-01T16:30:00Z"`
+	// Дата последнего изменения
+	UpdatedAt time.Time `json:"updated_at" example:"2024-02-01T16:45:00Z"`
+}
+
+// AlbumRequest представляет собой запрос на создание или изменение альбома
+//
+// @swagger:model
+type AlbumRequest struct {
+	// Название альбома
+	Title string `json:"title" example:"Лето"`
+	// Описание альбома
+	Description string `json:"description" example:"Поездка на море"`
+	// Видимость: public (по умолчанию), followers, private
+	Visibility string `json:"visibility" example:"followers"`
+	// ID обложки из фото альбома
+	CoverPhotoID *int `json:"cover_photo_id,omitempty" example:"7"`
+	// Фото альбома при создании, в нужном порядке
+	PhotoIDs []int `json:"photo_ids,omitempty" example:"7,8,9"`
+}
+
+// AlbumPhotosRequest представляет собой список фото для добавления в альбом или новый порядок фото
+//
+// @swagger:model
+type AlbumPhotosRequest struct {
+	// ID фото
+	PhotoIDs []int `json:"photo_ids" example:"9,7,8"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// DefaultCollectionName название коллекции, в которую фото сохраняются по умолчанию
+const DefaultCollectionName = "Saved"
+
+// Collection представляет собой коллекцию сохраненных фото. Коллекции видны только их владельцу
+//
+// @swagger:model
+type Collection struct {
+	// ID коллекции
+	ID int `json:"id" example:"1"`
+	// ID владельца
+	UserID int `json:"user_id" example:"42"`
+	// Название коллекции
+	Name string `json:"name" example:"Путешествия"`
+	// Коллекция по умолчанию «Saved»
+	IsDefault bool `json:"is_default" example:"false"`
+	// Количество доступных фото в коллекции
+	PhotosCount int `json:"photos_count" example:"12"`
+	// Дата создания
+	CreatedAt time.Time `json:"created_at" example:"2024-02-01T16:30:00Z"`
+}
+
+// CollectionRequest представляет собой запрос на создание коллекции
+//
+// @swagger:model
+type CollectionRequest struct {
+	// Название коллекции
+	Name string `json:"name" example:"Путешествия"`
+}
+
+// SaveRequest представляет собой запрос на сохранение фото
+//
+// @swagger:model
+type SaveRequest struct {
+	// ID коллекции. Если не указан, фото сохраняется в «Saved»
+	CollectionID int `json:"collection_id,omitempty" example:"3"`
+}
+
+// SavedPhoto представляет собой фото в коллекции
+//
+// @swagger:model
+type SavedPhoto struct {
+	Photo
+	// Дата сохранения
+	SavedAt time.Time `json:"saved_at" example:"2024-02-01T16:30:00Z"`
+}
+
+// SavedPhotosPage представляет собой страницу фото коллекции
+//
+// @swagger:model
+type SavedPhotosPage struct {
+	// Фото страницы, последние сохраненные первыми
+	Photos []SavedPhoto `json:"photos"`
+	// Курсор следующей страницы, отсутствует на последней странице
+	NextCursor string `json:"next_cursor,omitempty" example:"eyJpZCI6MTJ9"`
+}
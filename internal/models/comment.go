@@ -12,7 +12,9 @@ type Comment struct {
 	UserID int `json:"user_id" example:"42"`
 	// ID фото, к которому относится комментарий
 	PhotoID int `json:"photo_id" example:"101"`
-	// Текст комментария
+	// ID родительского комментария, если это ответ
+	ParentID *int `json:"parent_id,omitempty" example:"3"`
+	// Текст комментария ("[deleted]" для удаленного комментария с ответами)
 	Content string `json:"content" example:"Отличное фото!"`
 	// Дата создания комментария
 	CreatedAt time.Time `json:"created_at" example:"2024-01-31T12:45:00Z"`
@@ -20,4 +22,52 @@ type Comment struct {
 	UpdatedAt time.Time `json:"updated_at" example:"2024-01-31T12:50:00Z"`
 	// Имя пользователя, оставившего комментарий
 	Username string `json:"username" example:"johndoe"`
+	// Аватар пользователя, оставившего комментарий
+	AvatarURL string `json:"avatar_url" example:"https://example.com/uploads/avatar.jpg"`
+	// Флаг редактирования комментария
+	Edited bool `json:"edited" example:"true"`
+	// Количество сохраненных правок комментария
+	RevisionCount int `json:"revision_count" example:"1"`
+	// Количество лайков комментария
+	LikesCount int `json:"likes_count" example:"5"`
+	// Флаг лайка текущего пользователя
+	LikedByViewer bool `json:"liked_by_viewer" example:"false"`
+	// Количество видимых текущему пользователю ответов (только для комментариев верхнего уровня)
+	ReplyCount int `json:"reply_count" example:"2"`
+	// Флаг комментария, закрепленного автором фото
+	Pinned bool `json:"pinned" example:"false"`
+	// Флаг удаленного комментария, оставленного ради ответов
+	Deleted bool `json:"deleted" example:"false"`
+	// Упоминания пользователей в тексте
+	Entities []Mention `json:"entities"`
+}
+
+// CommentPage представляет собой страницу комментариев
+//
+// @swagger:model
+type CommentPage struct {
+	// Комментарии страницы
+	Comments []Comment `json:"comments"`
+	// Курсор следующей страницы, отсутствует на последней странице
+	NextCursor string `json:"next_cursor,omitempty" example:"eyJpZCI6MTJ9"`
+}
+
+// CommentRevision представляет собой одну версию текста комментария
+//
+// @swagger:model
+type CommentRevision struct {
+	// Текст версии
+	Content string `json:"content" example:"Первый вариант"`
+	// Время, когда версия была написана
+	CreatedAt time.Time `json:"created_at" example:"2025-01-01T12:00:00Z"`
+}
+
+// CommentHistory представляет собой историю правок комментария, от исходного текста к текущему
+//
+// @swagger:model
+type CommentHistory struct {
+	// ID комментария
+	CommentID int `json:"comment_id" example:"1"`
+	// Версии комментария, последняя — текущая
+	Revisions []CommentRevision `json:"revisions"`
 }
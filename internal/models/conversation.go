@@ -2,16 +2,176 @@ package models
 
 import "time"
 
-// Conversation представляет собой диалог между двумя пользователями
+// Роли участников групповой беседы
+const (
+	ParticipantAdmin  = "admin"
+	ParticipantMember = "member"
+)
+
+// Conversation представляет собой личную или групповую беседу
 //
 // @swagger:model
 type Conversation struct {
 	// ID беседы
 	ID int `json:"id" example:"1"`
-	// ID первого пользователя
-	User1ID int `json:"user1_id" example:"42"`
-	// ID второго пользователя
-	User2ID int `json:"user2_id" example:"58"`
+	// ID первого пользователя личной беседы
+	User1ID int `json:"user1_id,omitempty" example:"42"`
+	// ID второго пользователя личной беседы
+	User2ID int `json:"user2_id,omitempty" example:"58"`
+	// Групповая беседа
+	IsGroup bool `json:"is_group" example:"false"`
+	// Название группы
+	Title string `json:"title,omitempty" example:"Поход"`
+	// URL аватара группы
+	AvatarURL string `json:"avatar_url,omitempty" example:"https://example.com/groups/1.jpg"`
 	// Дата создания беседы
 	CreatedAt time.Time `json:"created_at" example:"2024-02-01T14:30:00Z"`
+	// Участники, только при запросе одной беседы
+	Participants []ConversationParticipant `json:"participants,omitempty"`
+}
+
+// ConversationParticipant представляет собой участника беседы
+//
+// @swagger:model
+type ConversationParticipant struct {
+	// ID пользователя
+	UserID int `json:"user_id" example:"58"`
+	// Имя пользователя
+	Username string `json:"username" example:"janedoe"`
+	// URL аватара
+	AvatarURL string `json:"avatar_url" example:"https://example.com/avatars/58.jpg"`
+	// Роль: admin или member
+	Role string `json:"role" example:"member"`
+	// Дата вступления
+	JoinedAt time.Time `json:"joined_at" example:"2024-02-01T14:30:00Z"`
+	// ID последнего прочитанного сообщения, 0 если участник еще ничего не читал
+	LastReadMessageID int `json:"last_read_message_id" example:"15"`
+	// Время последней отметки прочтения
+	LastReadAt *time.Time `json:"last_read_at,omitempty" example:"2024-02-01T15:50:00Z"`
+}
+
+// ReadRequest представляет собой запрос на отметку прочтения беседы
+//
+// @swagger:model
+type ReadRequest struct {
+	// ID последнего прочитанного сообщения. Если не указан, беседа читается до последнего сообщения
+	MessageID int `json:"message_id,omitempty" example:"15"`
+}
+
+// ReadReceipt представляет собой отметку прочтения участника беседы
+//
+// @swagger:model
+type ReadReceipt struct {
+	// ID беседы
+	ConversationID int `json:"conversation_id" example:"1"`
+	// ID прочитавшего пользователя
+	UserID int `json:"user_id" example:"58"`
+	// ID последнего прочитанного сообщения
+	LastReadMessageID int `json:"last_read_message_id" example:"15"`
+	// Время отметки, отсутствует, если участник еще ничего не читал
+	ReadAt *time.Time `json:"read_at,omitempty" example:"2024-02-01T15:50:00Z"`
+}
+
+// UnreadCount представляет собой счетчик непрочитанного для значка входящих
+//
+// @swagger:model
+type UnreadCount struct {
+	// Количество непрочитанных сообщений во всех беседах
+	Messages int `json:"messages" example:"5"`
+	// Количество бесед с непрочитанными сообщениями
+	Conversations int `json:"conversations" example:"2"`
+}
+
+// GroupRequest представляет собой запрос на создание или изменение группы
+//
+// @swagger:model
+type GroupRequest struct {
+	// Название группы
+	Title string `json:"title" example:"Поход"`
+	// URL аватара группы
+	AvatarURL string `json:"avatar_url,omitempty" example:"https://example.com/groups/1.jpg"`
+	// Участники при создании, кроме создателя
+	UserIDs []int `json:"user_ids,omitempty" example:"58,73"`
+}
+
+// ParticipantsRequest представляет собой список пользователей для добавления в группу
+//
+// @swagger:model
+type ParticipantsRequest struct {
+	// ID пользователей
+	UserIDs []int `json:"user_ids" example:"58,73"`
+}
+
+// RoleRequest представляет собой новую роль участника группы
+//
+// @swagger:model
+type RoleRequest struct {
+	// Роль: admin или member
+	Role string `json:"role" example:"admin"`
+}
+
+// ConversationRequest представляет собой запрос на создание беседы
+//
+// @swagger:model
+type ConversationRequest struct {
+	// ID собеседника
+	UserID int `json:"user_id" example:"58"`
+}
+
+// ConversationUser представляет собой собеседника во входящих
+//
+// @swagger:model
+type ConversationUser struct {
+	// ID пользователя
+	ID int `json:"id" example:"58"`
+	// Имя пользователя
+	Username string `json:"username" example:"janedoe"`
+	// URL аватара
+	AvatarURL string `json:"avatar_url" example:"https://example.com/avatars/58.jpg"`
+}
+
+// MessagePreview представляет собой последнее сообщение беседы
+//
+// @swagger:model
+type MessagePreview struct {
+	// ID сообщения
+	ID int `json:"id" example:"15"`
+	// ID отправителя
+	SenderID int `json:"sender_id" example:"58"`
+	// Начало текста сообщения
+	Content string `json:"content" example:"До встречи!"`
+	// Дата отправки
+	CreatedAt time.Time `json:"created_at" example:"2024-02-01T15:45:00Z"`
+}
+
+// InboxEntry представляет собой беседу во входящих
+//
+// @swagger:model
+type InboxEntry struct {
+	// ID беседы
+	ID int `json:"id" example:"1"`
+	// Групповая беседа
+	IsGroup bool `json:"is_group" example:"false"`
+	// Название группы
+	Title string `json:"title,omitempty" example:"Поход"`
+	// URL аватара группы
+	AvatarURL string `json:"avatar_url,omitempty" example:"https://example.com/groups/1.jpg"`
+	// Собеседник личной беседы
+	User *ConversationUser `json:"user,omitempty"`
+	// Последнее сообщение, отсутствует в пустой беседе
+	LastMessage *MessagePreview `json:"last_message,omitempty"`
+	// Количество непрочитанных сообщений собеседника
+	UnreadCount int `json:"unread_count" example:"2"`
+	// Время последней активности
+	LastActivityAt time.Time `json:"last_activity_at" example:"2024-02-01T15:45:00Z"`
+}
+
+// InboxPage представляет собой страницу входящих
+//
+// @swagger:model
+type InboxPage struct {
+	// Беседы, последние активные первыми
+	Conversations []InboxEntry `json:"conversations"`
+	// Курсор следующей страницы, отсутствует на последней странице
+	NextCursor string `json:"next_cursor,omitempty" example:"eyJpZCI6MTJ9"`
 }
@@ -0,0 +1,91 @@
+package models
+
+import "time"
+
+// Виды элементов подборки
+const (
+	HighlightItemPhoto = "photo"
+	HighlightItemStory = "story"
+)
+
+// Highlight представляет собой постоянную подборку фото и историй в профиле
+//
+// @swagger:model
+type Highlight struct {
+	// ID подборки
+	ID int `json:"id" example:"1"`
+	// ID автора
+	UserID int `json:"user_id" example:"42"`
+	// Название подборки
+	Title string `json:"title" example:"Путешествия"`
+	// ID элемента-обложки. Если не выбран, обложкой служит первый элемент
+	CoverItemID *int `json:"cover_item_id,omitempty" example:"7"`
+	// URL обложки
+	CoverURL string `json:"cover_url,omitempty" example:"uploads/20240201160000_story.jpg"`
+	// Количество элементов
+	ItemsCount int `json:"items_count" example:"5"`
+	// Дата создания
+	CreatedAt time.Time `json:"created_at" example:"2024-02-01T16:30:00Z"`
+	// Дата последнего изменения
+	UpdatedAt time.Time `json:"updated_at" example:"2024-02-01T16:45:00Z"`
+	// Элементы подборки по порядку, только при запросе одной подборки
+	Items []HighlightItem `json:"items,omitempty"`
+}
+
+// HighlightItem представляет собой фото или историю в подборке
+//
+// @swagger:model
+type HighlightItem struct {
+	// ID элемента
+	ID int `json:"id" example:"7"`
+	// Вид элемента: photo или story
+	Type string `json:"type" example:"story"`
+	// ID фото
+	PhotoID *int `json:"photo_id,omitempty" example:"101"`
+	// ID истории
+	StoryID *int `json:"story_id,omitempty" example:"12"`
+	// URL изображения
+	URL string `json:"url" example:"uploads/20240201160000_story.jpg"`
+	// Описание фото или подпись истории
+	Caption string `json:"caption" example:"Закат"`
+	// Дата добавления в подборку
+	AddedAt time.Time `json:"added_at" example:"2024-02-01T16:30:00Z"`
+}
+
+// HighlightItemRef представляет собой ссылку на фото или историю при добавлении в подборку
+//
+// @swagger:model
+type HighlightItemRef struct {
+	// ID фото
+	PhotoID int `json:"photo_id,omitempty" example:"101"`
+	// ID истории
+	StoryID int `json:"story_id,omitempty" example:"12"`
+}
+
+// HighlightRequest представляет собой запрос на создание или изменение подборки
+//
+// @swagger:model
+type HighlightRequest struct {
+	// Название подборки
+	Title string `json:"title" example:"Путешествия"`
+	// ID элемента-обложки, только при изменении
+	CoverItemID *int `json:"cover_item_id,omitempty" example:"7"`
+	// Элементы при создании, в нужном порядке
+	Items []HighlightItemRef `json:"items,omitempty"`
+}
+
+// HighlightItemsRequest представляет собой список элементов для добавления в подборку
+//
+// @swagger:model
+type HighlightItemsRequest struct {
+	// Элементы в нужном порядке
+	Items []HighlightItemRef `json:"items"`
+}
+
+// OrderRequest представляет собой новый порядок объектов
+//
+// @swagger:model
+type OrderRequest struct {
+	// ID в новом порядке
+	IDs []int `json:"ids" example:"3,1,2"`
+}
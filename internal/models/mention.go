@@ -0,0 +1,15 @@
+package models
+
+// Mention представляет собой упоминание пользователя в тексте комментария или описании фото
+//
+// @swagger:model
+type Mention struct {
+	// ID упомянутого пользователя
+	UserID int `json:"user_id" example:"58"`
+	// Имя упомянутого пользователя
+	Username string `json:"username" example:"alice"`
+	// Позиция символа @ в тексте (в символах)
+	Offset int `json:"offset" example:"8"`
+	// Длина упоминания вместе с @ (в символах)
+	Length int `json:"length" example:"6"`
+}
@@ -2,6 +2,17 @@ package models
 
 import "time"
 
+// Виды сообщений. Все виды, кроме text, — служебные сообщения об изменениях группы
+const (
+	MessageText          = "text"
+	MessageGroupCreated  = "group_created"
+	MessageGroupUpdated  = "group_updated"
+	MessageMemberAdded   = "member_added"
+	MessageMemberRemoved = "member_removed"
+	MessageMemberLeft    = "member_left"
+	MessageRoleChanged   = "role_changed"
+)
+
 // Message представляет собой сообщение в беседе
 //
 // @swagger:model
@@ -12,8 +23,14 @@ type Message struct {
 	ConversationID int `json:"conversation_id" example:"101"`
 	// ID отправителя сообщения
 	SenderID int `json:"sender_id" example:"42"`
+	// Вид сообщения: text или вид служебного сообщения
+	Kind string `json:"kind" example:"text"`
+	// ID пользователя, над которым совершено действие служебного сообщения
+	TargetUserID *int `json:"target_user_id,omitempty" example:"73"`
 	// Содержимое сообщения
 	Content string `json:"content" example:"Привет! Как дела?"`
+	// ID, присвоенный сообщению клиентом-отправителем
+	ClientMessageID string `json:"client_message_id,omitempty" example:"6f1c2a9e-3b7d-4c41-9a55-0e8f2d7b1c3a"`
 	// Дата и время отправки сообщения
 	CreatedAt time.Time `json:"created_at" example:"2024-02-01T15:45:00Z"`
 }
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Типы уведомлений
+const (
+	NotificationLike    = "like"
+	NotificationComment = "comment"
+	NotificationFollow  = "follow"
+	NotificationMention = "mention"
+)
+
+// Notification представляет собой уведомление пользователя. Однотипные непрочитанные события объединяются
+//
+// @swagger:model
+type Notification struct {
+	// ID уведомления
+	ID int `json:"id" example:"1"`
+	// ID получателя
+	UserID int `json:"user_id" example:"42"`
+	// Тип уведомления: like, comment, follow, mention
+	Type string `json:"type" example:"like"`
+	// ID фото, к которому относится событие
+	PhotoID *int `json:"photo_id,omitempty" example:"101"`
+	// ID комментария, к которому относится событие
+	CommentID *int `json:"comment_id,omitempty" example:"7"`
+	// ID последнего пользователя, вызвавшего событие
+	ActorID int `json:"actor_id" example:"58"`
+	// Имя последнего пользователя, вызвавшего событие
+	ActorUsername string `json:"actor_username" example:"alice"`
+	// Количество разных пользователей в уведомлении
+	ActorsCount int `json:"actors_count" example:"13"`
+	// Текст уведомления
+	Text string `json:"text" example:"alice and 12 others liked your photo"`
+	// Флаг прочтения
+	Read bool `json:"read" example:"false"`
+	// Дата создания уведомления
+	CreatedAt time.Time `json:"created_at" example:"2024-02-01T16:30:00Z"`
+	// Дата последнего события в уведомлении
+	UpdatedAt time.Time `json:"updated_at" example:"2024-02-01T16:45:00Z"`
+}
@@ -14,4 +14,27 @@ type Photo struct {
 	Description string `json:"description" example:"Закат на пляже"`
 	// Дата загрузки фото (в формате ISO 8601)
 	CreatedAt string `json:"created_at" example:"2024-02-01T16:00:00Z"`
+	// Упоминания пользователей в описании
+	Entities []Mention `json:"entities"`
+}
+
+// Политики комментирования фото
+const (
+	CommentsEveryone  = "everyone"
+	CommentsFollowers = "followers"
+	CommentsOff       = "off"
+)
+
+// CommentSettings представляет собой настройки комментариев к фото, задаваемые автором
+//
+// @swagger:model
+type CommentSettings struct {
+	// ID фотографии
+	PhotoID int `json:"photo_id" example:"1"`
+	// ID автора фотографии
+	OwnerID int `json:"owner_id" example:"42"`
+	// Кто может комментировать: everyone, followers, off
+	CommentsPolicy string `json:"comments_policy" example:"followers"`
+	// Комментарии с этими словами видны только их авторам
+	HiddenKeywords []string `json:"hidden_keywords" example:"spam,реклама"`
 }
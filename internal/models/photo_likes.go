@@ -15,3 +15,43 @@ type Like struct {
 	// Дата и время добавления лайка
 	CreatedAt time.Time `json:"created_at" example:"2024-02-01T16:30:00Z"`
 }
+
+// Liker представляет собой пользователя, поставившего лайк
+//
+// @swagger:model
+type Liker struct {
+	// ID пользователя
+	ID int `json:"id" example:"42"`
+	// Имя пользователя
+	Username string `json:"username" example:"johndoe"`
+	// URL аватара
+	AvatarURL string `json:"avatar_url" example:"https://example.com/avatars/42.jpg"`
+	// Текущий пользователь подписан на этого пользователя
+	FollowedByViewer bool `json:"followed_by_viewer" example:"true"`
+	// Дата и время лайка
+	LikedAt time.Time `json:"liked_at" example:"2024-02-01T16:30:00Z"`
+}
+
+// LikersPage представляет собой страницу пользователей, поставивших лайк
+//
+// @swagger:model
+type LikersPage struct {
+	// Пользователи страницы: сначала те, на кого подписан текущий пользователь
+	Users []Liker `json:"users"`
+	// Курсор следующей страницы, отсутствует на последней странице
+	NextCursor string `json:"next_cursor,omitempty" example:"eyJpZCI6MTJ9"`
+}
+
+// PhotoViewerState представляет собой состояние фото для текущего пользователя
+//
+// @swagger:model
+type PhotoViewerState struct {
+	// ID фотографии
+	PhotoID int `json:"photo_id" example:"1"`
+	// Количество лайков
+	LikesCount int `json:"likes_count" example:"12"`
+	// Текущий пользователь поставил лайк
+	LikedByViewer bool `json:"liked_by_viewer" example:"true"`
+	// Количество комментариев
+	CommentsCount int `json:"comments_count" example:"3"`
+}
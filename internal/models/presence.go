@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Presence представляет собой присутствие пользователя в сети
+//
+// @swagger:model
+type Presence struct {
+	// ID пользователя
+	UserID int `json:"user_id" example:"58"`
+	// Пользователь в сети
+	Online bool `json:"online" example:"false"`
+	// Время последнего выхода из сети, если пользователь его не скрыл
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty" example:"2024-02-01T15:45:00Z"`
+}
+
+// PresenceSettings представляет собой настройки приватности присутствия
+//
+// @swagger:model
+type PresenceSettings struct {
+	// Скрывать время последнего выхода из сети от других пользователей
+	HideLastSeen bool `json:"hide_last_seen" example:"true"`
+}
@@ -0,0 +1,36 @@
+package models
+
+// ReactionHeart реакция, которой соответствует обычный лайк
+const ReactionHeart = "❤️"
+
+// ReactionSummary представляет собой реакции на фото или сообщение
+//
+// @swagger:model
+type ReactionSummary struct {
+	// Количество реакций каждого вида
+	Counts map[string]int `json:"counts"`
+	// Реакция текущего пользователя, отсутствует, если он не реагировал
+	ViewerReaction string `json:"viewer_reaction,omitempty" example:"🔥"`
+}
+
+// ReactionRequest представляет собой запрос на установку реакции
+//
+// @swagger:model
+type ReactionRequest struct {
+	// Реакция из разрешенного набора
+	Reaction string `json:"reaction" example:"😂"`
+}
+
+// CountDrift представляет собой расхождение сохраненного счетчика с фактическим числом реакций
+type CountDrift struct {
+	// ID фото
+	PhotoID int `json:"photo_id" example:"1"`
+	// Счетчик: likes_count или reaction_count
+	Counter string `json:"counter" example:"likes_count"`
+	// Реакция, к которой относится счетчик
+	Reaction string `json:"reaction" example:"❤️"`
+	// Значение до исправления
+	Stored int `json:"stored" example:"5"`
+	// Фактическое значение
+	Actual int `json:"actual" example:"4"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// Story представляет собой историю, которая исчезает после истечения срока
+//
+// @swagger:model
+type Story struct {
+	// ID истории
+	ID int `json:"id" example:"1"`
+	// ID автора
+	UserID int `json:"user_id" example:"42"`
+	// URL изображения
+	URL string `json:"url" example:"uploads/20240201160000_story.jpg"`
+	// Подпись
+	Caption string `json:"caption" example:"Доброе утро"`
+	// Дата публикации
+	CreatedAt time.Time `json:"created_at" example:"2024-02-01T16:00:00Z"`
+	// Дата, после которой история исчезает
+	ExpiresAt time.Time `json:"expires_at" example:"2024-02-02T16:00:00Z"`
+	// Текущий пользователь уже просмотрел историю
+	Seen bool `json:"seen" example:"false"`
+	// Количество просмотров, только для автора
+	ViewsCount *int `json:"views_count,omitempty" example:"15"`
+}
+
+// StoryTrayItem представляет собой автора с активными историями в ленте историй
+//
+// @swagger:model
+type StoryTrayItem struct {
+	// ID автора
+	UserID int `json:"user_id" example:"42"`
+	// Имя автора
+	Username string `json:"username" example:"johndoe"`
+	// URL аватара автора
+	AvatarURL string `json:"avatar_url" example:"https://example.com/avatars/42.jpg"`
+	// Количество активных историй
+	StoriesCount int `json:"stories_count" example:"3"`
+	// Дата последней истории
+	LatestAt time.Time `json:"latest_at" example:"2024-02-01T16:00:00Z"`
+	// Есть непросмотренные истории
+	HasUnseen bool `json:"has_unseen" example:"true"`
+}
+
+// StoryViewer представляет собой пользователя, просмотревшего историю
+//
+// @swagger:model
+type StoryViewer struct {
+	// ID пользователя
+	UserID int `json:"user_id" example:"58"`
+	// Имя пользователя
+	Username string `json:"username" example:"alice"`
+	// URL аватара
+	AvatarURL string `json:"avatar_url" example:"https://example.com/avatars/58.jpg"`
+	// Дата просмотра
+	ViewedAt time.Time `json:"viewed_at" example:"2024-02-01T16:30:00Z"`
+}
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WSProtocolVersion текущая версия протокола WebSocket. Схема протокола — docs/ws-protocol.schema.json
+const WSProtocolVersion = 1
+
+// Типы сообщений протокола WebSocket
+const (
+	// Клиент отправляет сообщение в беседу, id конверта — ID сообщения на клиенте
+	WSSend = "send"
+	// Сервер подтверждает сохранение сообщения из send с тем же id
+	WSAck = "ack"
+	// Сервер сообщает об ошибке обработки сообщения клиента с тем же id
+	WSError = "error"
+	// Участник набирает сообщение
+	WSTyping = "typing"
+	// Клиент отмечает беседу прочитанной, сервер сообщает об отметке участника
+	WSRead = "read"
+	// Сервер сообщает, что собеседник появился в сети или вышел из нее, содержимое — Presence
+	WSPresence = "presence"
+	// Сервер доставляет новое сообщение беседы
+	WSMessage = "message"
+	// Сервер присылает обновленный счетчик непрочитанных сообщений
+	WSUnreadCount = "unread_count"
+	// Сервер доставляет уведомление
+	WSNotification = "notification"
+)
+
+// Коды ошибок протокола WebSocket
+const (
+	WSErrInvalidEnvelope      = "invalid_envelope"
+	WSErrUnsupportedVersion   = "unsupported_version"
+	WSErrUnknownType          = "unknown_type"
+	WSErrInvalidPayload       = "invalid_payload"
+	WSErrConversationNotFound = "conversation_not_found"
+	WSErrMessageNotFound      = "message_not_found"
+	WSErrUserBlocked          = "user_blocked"
+	WSErrInternal             = "internal_error"
+)
+
+// WSEnvelope представляет собой конверт любого сообщения протокола WebSocket
+//
+// @swagger:model
+type WSEnvelope struct {
+	// Версия протокола, 0 считается текущей
+	V int `json:"v" example:"1"`
+	// Тип сообщения
+	Type string `json:"type" example:"send"`
+	// ID запроса клиента. Ответы ack и error приходят с тем же id
+	ID string `json:"id,omitempty" example:"6f1c2a9e-3b7d-4c41-9a55-0e8f2d7b1c3a"`
+	// Содержимое, зависит от типа
+	Payload json.RawMessage `json:"payload,omitempty" swaggertype:"object"`
+}
+
+// WSSendPayload представляет собой содержимое send
+//
+// @swagger:model
+type WSSendPayload struct {
+	// ID беседы
+	ConversationID int `json:"conversation_id" example:"1"`
+	// Текст сообщения
+	Content string `json:"content" example:"Привет!"`
+}
+
+// WSAckPayload представляет собой содержимое ack
+//
+// @swagger:model
+type WSAckPayload struct {
+	// ID сохраненного сообщения
+	MessageID int `json:"message_id" example:"15"`
+	// ID беседы
+	ConversationID int `json:"conversation_id" example:"1"`
+	// Дата отправки
+	CreatedAt time.Time `json:"created_at" example:"2024-02-01T15:45:00Z"`
+	// Сообщение с этим ID уже было сохранено раньше
+	Duplicate bool `json:"duplicate" example:"false"`
+}
+
+// WSErrorPayload представляет собой содержимое error
+//
+// @swagger:model
+type WSErrorPayload struct {
+	// Код ошибки
+	Code string `json:"code" example:"conversation_not_found"`
+	// Описание ошибки
+	Message string `json:"message" example:"Conversation not found"`
+}
+
+// WSTypingPayload представляет собой содержимое typing
+//
+// @swagger:model
+type WSTypingPayload struct {
+	// ID беседы
+	ConversationID int `json:"conversation_id" example:"1"`
+	// ID набирающего пользователя, только в событиях сервера
+	UserID int `json:"user_id,omitempty" example:"58"`
+	// true — пользователь набирает сообщение, false — перестал
+	Typing bool `json:"typing" example:"true"`
+	// Время, после которого набор считается прекращенным, если событие не повторится. Только в событиях сервера
+	ExpiresAt *time.Time `json:"expires_at,omitempty" example:"2024-02-01T15:45:06Z"`
+}
+
+// WSReadPayload представляет собой содержимое read от клиента
+//
+// @swagger:model
+type WSReadPayload struct {
+	// ID беседы
+	ConversationID int `json:"conversation_id" example:"1"`
+	// ID последнего прочитанного сообщения. Если не указан, беседа читается до последнего сообщения
+	MessageID int `json:"message_id,omitempty" example:"15"`
+}
+
+// WSTicket представляет собой одноразовый билет для подключения к WebSocket
+//
+// @swagger:model
+type WSTicket struct {
+	// Билет для параметра ticket при подключении к /ws
+	Ticket string `json:"ticket" example:"q3Jd2u0x9Q4m1bXr7Lk5yA"`
+	// Время, после которого билет недействителен
+	ExpiresAt time.Time `json:"expires_at" example:"2024-02-01T15:45:30Z"`
+}
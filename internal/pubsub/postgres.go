@@ -0,0 +1,145 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultChannel канал NOTIFY для событий WebSocket
+	DefaultChannel = "instaspace_events"
+	// DefaultRetryInterval пауза перед повторным LISTEN после обрыва соединения
+	DefaultRetryInterval = time.Second
+
+	// maxNotifyPayload наибольшее уведомление, которое передается целиком. Postgres ограничивает его 8000 байт
+	maxNotifyPayload = 7900
+	// storedEventTTL сколько хранится событие, не поместившееся в уведомление
+	storedEventTTL = time.Minute
+)
+
+// notification уведомление в канале: событие целиком или ID события в таблице pubsub_events
+type notification struct {
+	Event
+	Ref int64 `json:"ref,omitempty"`
+}
+
+// Postgres доставляет события через LISTEN/NOTIFY. Для подписки держит одно соединение пула.
+// События, опубликованные, пока подписка переподключается, этим экземпляром не получаются
+type Postgres struct {
+	DB            *pgxpool.Pool
+	Channel       string
+	RetryInterval time.Duration
+	Logger        *zap.Logger
+}
+
+func NewPostgres(db *pgxpool.Pool, logger *zap.Logger) *Postgres {
+	return &Postgres{DB: db, Channel: DefaultChannel, RetryInterval: DefaultRetryInterval, Logger: logger}
+}
+
+// Publish отправляет событие в канал. Событие больше лимита NOTIFY сохраняется в pubsub_events,
+// а в канал уходит только его ID
+func (p *Postgres) Publish(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(notification{Event: event})
+	if err != nil {
+		return err
+	}
+
+	if len(payload) > maxNotifyPayload {
+		// Время сравнивается в базе: created_at заполняется ее часами
+		_, err := p.DB.Exec(ctx, "DELETE FROM pubsub_events WHERE created_at < NOW() - make_interval(secs => $1::float8)", storedEventTTL.Seconds())
+		if err != nil {
+			return err
+		}
+		var ref int64
+		if err := p.DB.QueryRow(ctx, "INSERT INTO pubsub_events (payload) VALUES ($1) RETURNING id", string(payload)).Scan(&ref); err != nil {
+			return err
+		}
+		if payload, err = json.Marshal(notification{Ref: ref}); err != nil {
+			return err
+		}
+	}
+
+	_, err = p.DB.Exec(ctx, "SELECT pg_notify($1, $2)", p.Channel, string(payload))
+	return err
+}
+
+// Subscribe подписывается на канал и доставляет события в handler, пока не отменен ctx.
+// При обрыве соединения подписка восстанавливается
+func (p *Postgres) Subscribe(ctx context.Context, handler Handler) error {
+	conn, err := p.listen(ctx)
+	if err != nil {
+		return err
+	}
+	go p.run(ctx, conn, handler)
+	return nil
+}
+
+// listen берет соединение из пула и подписывает его на канал
+func (p *Postgres) listen(ctx context.Context) (*pgxpool.Conn, error) {
+	conn, err := p.DB.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{p.Channel}.Sanitize()); err != nil {
+		conn.Release()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (p *Postgres) run(ctx context.Context, conn *pgxpool.Conn, handler Handler) {
+	for {
+		n, err := conn.Conn().WaitForNotification(ctx)
+		if err == nil {
+			p.deliver(ctx, n.Payload, handler)
+			continue
+		}
+
+		// Подписанное соединение не возвращается в пул, чтобы уведомления не копились в нем
+		conn.Hijack().Close(context.Background())
+		if ctx.Err() != nil {
+			return
+		}
+		p.Logger.Warn("Подписка на события прервана, переподключение", zap.String("channel", p.Channel), zap.Error(err))
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(p.RetryInterval):
+			}
+			if conn, err = p.listen(ctx); err == nil {
+				break
+			}
+			p.Logger.Warn("Не удалось подписаться на события", zap.String("channel", p.Channel), zap.Error(err))
+		}
+	}
+}
+
+// deliver разбирает уведомление и передает событие в handler
+func (p *Postgres) deliver(ctx context.Context, payload string, handler Handler) {
+	var n notification
+	if err := json.Unmarshal([]byte(payload), &n); err != nil {
+		p.Logger.Error("Некорректное уведомление о событии", zap.String("channel", p.Channel), zap.Error(err))
+		return
+	}
+
+	if n.Ref != 0 {
+		var stored string
+		if err := p.DB.QueryRow(ctx, "SELECT payload FROM pubsub_events WHERE id = $1", n.Ref).Scan(&stored); err != nil {
+			p.Logger.Error("Не удалось прочитать событие", zap.Int64("ref", n.Ref), zap.Error(err))
+			return
+		}
+		if err := json.Unmarshal([]byte(stored), &n); err != nil {
+			p.Logger.Error("Некорректное событие", zap.Int64("ref", n.Ref), zap.Error(err))
+			return
+		}
+	}
+
+	handler(n.Event)
+}
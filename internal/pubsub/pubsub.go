@@ -0,0 +1,73 @@
+// Package pubsub доставляет события WebSocket всем экземплярам сервера, чтобы событие дошло
+// до подключений пользователя независимо от того, к какому экземпляру они открыты
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// Event событие для подключений пользователей. Одно событие доставляется всем получателям сразу
+type Event struct {
+	// ID получателей
+	UserIDs []int `json:"user_ids"`
+	// Тип сообщения протокола WebSocket
+	Type string `json:"type"`
+	// Содержимое в JSON
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Handler обрабатывает полученное событие
+type Handler func(Event)
+
+type PubSub interface {
+	// Publish отправляет событие всем подписчикам, в том числе на этом экземпляре
+	Publish(ctx context.Context, event Event) error
+	// Subscribe начинает доставлять события в handler и возвращается, когда подписка активна.
+	// Доставка продолжается, пока не отменен ctx
+	Subscribe(ctx context.Context, handler Handler) error
+}
+
+// Memory доставляет события подписчикам в том же процессе. Подходит для одного экземпляра и тестов
+type Memory struct {
+	mu       sync.RWMutex
+	handlers map[int]Handler
+	nextID   int
+}
+
+func NewMemory() *Memory {
+	return &Memory{handlers: make(map[int]Handler)}
+}
+
+// Publish синхронно передает событие всем подписчикам
+func (m *Memory) Publish(ctx context.Context, event Event) error {
+	m.mu.RLock()
+	handlers := make([]Handler, 0, len(m.handlers))
+	for _, h := range m.handlers {
+		handlers = append(handlers, h)
+	}
+	m.mu.RUnlock()
+
+	for _, h := range handlers {
+		h(event)
+	}
+	return nil
+}
+
+// Subscribe добавляет подписчика до отмены ctx
+func (m *Memory) Subscribe(ctx context.Context, handler Handler) error {
+	m.mu.Lock()
+	id := m.nextID
+	m.nextID++
+	m.handlers[id] = handler
+	m.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		m.mu.Lock()
+		delete(m.handlers, id)
+		m.mu.Unlock()
+	}()
+	return nil
+}
@@ -0,0 +1,329 @@
+package repositories
+
+import (
+	"InstaSpace/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// MaxAlbumPhotos максимальное количество фото в альбоме
+const MaxAlbumPhotos = 500
+
+var (
+	ErrAlbumNotFound      = errors.New("album not found")
+	ErrAlbumFull          = errors.New("album photo limit reached")
+	ErrInvalidAlbumPhotos = errors.New("photos must exist and belong to the album owner")
+	ErrInvalidAlbumCover  = errors.New("cover photo must be in the album")
+	ErrInvalidAlbumOrder  = errors.New("order must list every album photo exactly once")
+)
+
+// albumVisibleSQL возвращает SQL-условие, при котором альбом с псевдонимом album доступен пользователю viewer
+func albumVisibleSQL(album, viewer string) string {
+	return `(` + album + `.user_id = ` + viewer + ` OR (` + photoVisibleSQL(album, viewer) + ` AND (
+		` + album + `.visibility = '` + models.AlbumPublic + `'
+		OR (` + album + `.visibility = '` + models.AlbumFollowers + `' AND EXISTS (
+			SELECT 1 FROM follows f WHERE f.follower_id = ` + viewer + ` AND f.followee_id = ` + album + `.user_id
+		))
+	)))`
+}
+
+const albumColumns = `
+	a.id, a.user_id, a.title, a.description, a.visibility, a.cover_photo_id,
+	COALESCE((SELECT p.url FROM photos p WHERE p.id = COALESCE(a.cover_photo_id, (
+		SELECT ap.photo_id FROM album_photos ap WHERE ap.album_id = a.id ORDER BY ap.position LIMIT 1
+	))), ''),
+	(SELECT COUNT(*) FROM album_photos ap WHERE ap.album_id = a.id),
+	a.created_at, a.updated_at`
+
+func scanAlbum(row pgx.Row) (*models.Album, error) {
+	var album models.Album
+	err := row.Scan(&album.ID, &album.UserID, &album.Title, &album.Description, &album.Visibility, &album.CoverPhotoID,
+		&album.CoverURL, &album.PhotosCount, &album.CreatedAt, &album.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &album, nil
+}
+
+// CreateAlbum создает альбом и добавляет в него photoIDs в переданном порядке
+func (r *PhotoRepository) CreateAlbum(ctx context.Context, album *models.Album, photoIDs []int) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO albums (user_id, title, description, visibility)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`, album.UserID, album.Title, album.Description, album.Visibility).Scan(&album.ID)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return ErrInvalidUserID
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := addAlbumPhotos(ctx, tx, album.ID, album.UserID, photoIDs); err != nil {
+		return err
+	}
+	if album.CoverPhotoID != nil {
+		if err := setAlbumCover(ctx, tx, album.ID, *album.CoverPhotoID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// GetAlbum возвращает альбом, если он доступен зрителю
+func (r *PhotoRepository) GetAlbum(ctx context.Context, albumID, viewerID int) (*models.Album, error) {
+	album, err := scanAlbum(r.DB.QueryRow(ctx, `
+		SELECT `+albumColumns+`
+		FROM albums a
+		WHERE a.id = $1 AND `+albumVisibleSQL("a", "$2"), albumID, viewerID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrAlbumNotFound
+	}
+	return album, err
+}
+
+// GetUserAlbums возвращает доступные зрителю альбомы пользователя, новые первыми
+func (r *PhotoRepository) GetUserAlbums(ctx context.Context, ownerID, viewerID int) ([]models.Album, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT `+albumColumns+`
+		FROM albums a
+		WHERE a.user_id = $1 AND `+albumVisibleSQL("a", "$2")+`
+		ORDER BY a.created_at DESC, a.id DESC`, ownerID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	albums := []models.Album{}
+	for rows.Next() {
+		album, err := scanAlbum(rows)
+		if err != nil {
+			return nil, err
+		}
+		albums = append(albums, *album)
+	}
+	return albums, rows.Err()
+}
+
+// GetAlbumPhotos возвращает фото альбома в заданном автором порядке. Доступ к альбому проверяется отдельно
+func (r *PhotoRepository) GetAlbumPhotos(ctx context.Context, albumID, limit, offset int) ([]models.Photo, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT p.id, p.user_id, p.url, COALESCE(p.description, ''), p.created_at
+		FROM album_photos ap
+		JOIN photos p ON p.id = ap.photo_id
+		WHERE ap.album_id = $1
+		ORDER BY ap.position, ap.photo_id
+		LIMIT $2 OFFSET $3`, albumID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	photos := []models.Photo{}
+	for rows.Next() {
+		var photo models.Photo
+		var createdAt time.Time
+		if err := rows.Scan(&photo.ID, &photo.UserID, &photo.URL, &photo.Description, &createdAt); err != nil {
+			return nil, err
+		}
+		photo.CreatedAt = createdAt.Format(time.RFC3339)
+		photos = append(photos, photo)
+	}
+	return photos, rows.Err()
+}
+
+// UpdateAlbum сохраняет название, описание, видимость и обложку альбома, если он принадлежит album.UserID.
+// Nil CoverPhotoID возвращает обложку по умолчанию
+func (r *PhotoRepository) UpdateAlbum(ctx context.Context, album *models.Album) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE albums SET title = $3, description = $4, visibility = $5, cover_photo_id = NULL, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2`,
+		album.ID, album.UserID, album.Title, album.Description, album.Visibility)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrAlbumNotFound
+	}
+
+	if album.CoverPhotoID != nil {
+		if err := setAlbumCover(ctx, tx, album.ID, *album.CoverPhotoID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// DeleteAlbum удаляет альбом. Сами фото остаются
+func (r *PhotoRepository) DeleteAlbum(ctx context.Context, albumID, ownerID int) error {
+	tag, err := r.DB.Exec(ctx, "DELETE FROM albums WHERE id = $1 AND user_id = $2", albumID, ownerID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrAlbumNotFound
+	}
+	return nil
+}
+
+// AddAlbumPhotos добавляет фото в конец альбома. Фото, которые уже есть в альбоме, остаются на своих местах
+func (r *PhotoRepository) AddAlbumPhotos(ctx context.Context, albumID, ownerID int, photoIDs []int) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockAlbum(ctx, tx, albumID, ownerID); err != nil {
+		return err
+	}
+	if err := addAlbumPhotos(ctx, tx, albumID, ownerID, photoIDs); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, "UPDATE albums SET updated_at = NOW() WHERE id = $1", albumID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// RemoveAlbumPhoto убирает фото из альбома. Если фото было обложкой, обложка возвращается к первому фото.
+// Удаление отсутствующего фото ничего не меняет
+func (r *PhotoRepository) RemoveAlbumPhoto(ctx context.Context, albumID, ownerID, photoID int) error {
+	var found bool
+	err := r.DB.QueryRow(ctx, `
+		WITH album AS (
+			SELECT id FROM albums WHERE id = $1 AND user_id = $2
+		), del AS (
+			DELETE FROM album_photos
+			WHERE album_id IN (SELECT id FROM album) AND photo_id = $3
+			RETURNING photo_id
+		), upd AS (
+			UPDATE albums SET updated_at = NOW(),
+			       cover_photo_id = CASE WHEN cover_photo_id IN (SELECT photo_id FROM del) THEN NULL ELSE cover_photo_id END
+			WHERE id IN (SELECT id FROM album) AND EXISTS (SELECT 1 FROM del)
+		)
+		SELECT EXISTS (SELECT 1 FROM album)`, albumID, ownerID, photoID).Scan(&found)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrAlbumNotFound
+	}
+	return nil
+}
+
+// ReorderAlbum задает новый порядок фото. photoIDs должен содержать каждое фото альбома ровно один раз
+func (r *PhotoRepository) ReorderAlbum(ctx context.Context, albumID, ownerID int, photoIDs []int) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockAlbum(ctx, tx, albumID, ownerID); err != nil {
+		return err
+	}
+
+	var matches bool
+	err = tx.QueryRow(ctx, `
+		SELECT COALESCE(array_agg(photo_id ORDER BY photo_id), '{}') = (
+			SELECT COALESCE(array_agg(id ORDER BY id), '{}') FROM unnest($2::int[]) AS id
+		)
+		FROM album_photos WHERE album_id = $1`, albumID, photoIDs).Scan(&matches)
+	if err != nil {
+		return err
+	}
+	if !matches {
+		return ErrInvalidAlbumOrder
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE album_photos ap SET position = o.ord
+		FROM unnest($2::int[]) WITH ORDINALITY AS o(photo_id, ord)
+		WHERE ap.album_id = $1 AND ap.photo_id = o.photo_id`, albumID, photoIDs)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, "UPDATE albums SET updated_at = NOW() WHERE id = $1", albumID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// lockAlbum блокирует альбом владельца до конца транзакции
+func lockAlbum(ctx context.Context, tx pgx.Tx, albumID, ownerID int) error {
+	var id int
+	err := tx.QueryRow(ctx, "SELECT id FROM albums WHERE id = $1 AND user_id = $2 FOR UPDATE", albumID, ownerID).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrAlbumNotFound
+	}
+	return err
+}
+
+// addAlbumPhotos добавляет фото владельца в конец альбома, сохраняя порядок photoIDs
+func addAlbumPhotos(ctx context.Context, tx pgx.Tx, albumID, ownerID int, photoIDs []int) error {
+	if len(photoIDs) == 0 {
+		return nil
+	}
+
+	var owned int
+	err := tx.QueryRow(ctx, `
+		SELECT COUNT(*) FROM photos WHERE id = ANY($1::int[]) AND user_id = $2`, photoIDs, ownerID).Scan(&owned)
+	if err != nil {
+		return err
+	}
+	if owned != len(photoIDs) {
+		return ErrInvalidAlbumPhotos
+	}
+
+	var total int
+	err = tx.QueryRow(ctx, `
+		WITH ins AS (
+			INSERT INTO album_photos (album_id, photo_id, position)
+			SELECT $1, o.photo_id, m.last + o.ord
+			FROM unnest($2::int[]) WITH ORDINALITY AS o(photo_id, ord),
+			     (SELECT COALESCE(MAX(position), 0) AS last FROM album_photos WHERE album_id = $1) m
+			ON CONFLICT (album_id, photo_id) DO NOTHING
+			RETURNING 1
+		)
+		SELECT (SELECT COUNT(*) FROM album_photos WHERE album_id = $1) + (SELECT COUNT(*) FROM ins)`,
+		albumID, photoIDs).Scan(&total)
+	if err != nil {
+		return err
+	}
+	if total > MaxAlbumPhotos {
+		return ErrAlbumFull
+	}
+	return nil
+}
+
+// setAlbumCover делает фото альбома его обложкой
+func setAlbumCover(ctx context.Context, tx pgx.Tx, albumID, photoID int) error {
+	tag, err := tx.Exec(ctx, `
+		UPDATE albums SET cover_photo_id = $2
+		WHERE id = $1 AND EXISTS (SELECT 1 FROM album_photos WHERE album_id = $1 AND photo_id = $2)`,
+		albumID, photoID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrInvalidAlbumCover
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package repositories
+
+import (
+	"InstaSpace/internal/models"
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type BlockRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewBlockRepository(db *pgxpool.Pool) *BlockRepository {
+	return &BlockRepository{DB: db}
+}
+
+type BlockRepositoryInterface interface {
+	Block(ctx context.Context, blockerID, blockedID int) error
+	Unblock(ctx context.Context, blockerID, blockedID int) error
+	GetBlocked(ctx context.Context, blockerID int) ([]models.User, error)
+	Mute(ctx context.Context, muterID, mutedID int) error
+	Unmute(ctx context.Context, muterID, mutedID int) error
+	GetMuted(ctx context.Context, muterID int) ([]models.User, error)
+	BlockExists(ctx context.Context, userA, userB int) (bool, error)
+}
+
+// Block блокирует пользователя и разрывает подписки в обе стороны
+func (r *BlockRepository) Block(ctx context.Context, blockerID, blockedID int) error {
+	var exists bool
+	err := r.DB.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM users WHERE id=$1)", blockedID).Scan(&exists)
+	if err != nil || !exists {
+		return ErrInvalidUserID
+	}
+
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO user_blocks (blocker_id, blocked_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`, blockerID, blockedID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		DELETE FROM follows
+		WHERE (follower_id = $1 AND followee_id = $2)
+		   OR (follower_id = $2 AND followee_id = $1)`, blockerID, blockedID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Unblock снимает блокировку, если она была
+func (r *BlockRepository) Unblock(ctx context.Context, blockerID, blockedID int) error {
+	_, err := r.DB.Exec(ctx, "DELETE FROM user_blocks WHERE blocker_id = $1 AND blocked_id = $2", blockerID, blockedID)
+	return err
+}
+
+// GetBlocked возвращает пользователей, заблокированных blockerID
+func (r *BlockRepository) GetBlocked(ctx context.Context, blockerID int) ([]models.User, error) {
+	return r.listUsers(ctx, `
+		SELECT u.id, u.username FROM user_blocks b
+		JOIN users u ON b.blocked_id = u.id
+		WHERE b.blocker_id = $1
+		ORDER BY b.created_at DESC`, blockerID)
+}
+
+// Mute скрывает публикации пользователя из ленты muterID
+func (r *BlockRepository) Mute(ctx context.Context, muterID, mutedID int) error {
+	var exists bool
+	err := r.DB.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM users WHERE id=$1)", mutedID).Scan(&exists)
+	if err != nil || !exists {
+		return ErrInvalidUserID
+	}
+
+	_, err = r.DB.Exec(ctx, `
+		INSERT INTO user_mutes (muter_id, muted_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`, muterID, mutedID)
+	return err
+}
+
+// Unmute возвращает публикации пользователя в ленту
+func (r *BlockRepository) Unmute(ctx context.Context, muterID, mutedID int) error {
+	_, err := r.DB.Exec(ctx, "DELETE FROM user_mutes WHERE muter_id = $1 AND muted_id = $2", muterID, mutedID)
+	return err
+}
+
+// GetMuted возвращает пользователей, скрытых muterID
+func (r *BlockRepository) GetMuted(ctx context.Context, muterID int) ([]models.User, error) {
+	return r.listUsers(ctx, `
+		SELECT u.id, u.username FROM user_mutes m
+		JOIN users u ON m.muted_id = u.id
+		WHERE m.muter_id = $1
+		ORDER BY m.created_at DESC`, muterID)
+}
+
+// BlockExists проверяет, заблокировал ли один из пользователей другого
+func (r *BlockRepository) BlockExists(ctx context.Context, userA, userB int) (bool, error) {
+	var exists bool
+	err := r.DB.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM user_blocks
+			WHERE (blocker_id = $1 AND blocked_id = $2)
+			   OR (blocker_id = $2 AND blocked_id = $1)
+		)`, userA, userB).Scan(&exists)
+	return exists, err
+}
+
+func (r *BlockRepository) listUsers(ctx context.Context, query string, userID int) ([]models.User, error) {
+	rows, err := r.DB.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.ID, &user.Username); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
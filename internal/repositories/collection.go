@@ -0,0 +1,228 @@
+package repositories
+
+import (
+	"InstaSpace/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CollectionRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewCollectionRepository(db *pgxpool.Pool) *CollectionRepository {
+	return &CollectionRepository{DB: db}
+}
+
+var (
+	ErrCollectionNotFound = errors.New("collection not found")
+	ErrCollectionExists   = errors.New("collection with this name already exists")
+)
+
+// SavedPhotoCursor позиция последнего фото страницы коллекции для keyset-пагинации
+type SavedPhotoCursor struct {
+	SavedAt time.Time `json:"t"`
+	PhotoID int       `json:"id"`
+}
+
+// SavedPhotoListOptions параметры выборки фото коллекции
+type SavedPhotoListOptions struct {
+	Limit int
+	After *SavedPhotoCursor
+}
+
+type CollectionRepositoryInterface interface {
+	EnsureDefault(ctx context.Context, userID int) (int, error)
+	Create(ctx context.Context, collection *models.Collection) error
+	Delete(ctx context.Context, collectionID, userID int) error
+	GetCollections(ctx context.Context, userID int) ([]models.Collection, error)
+	SavePhoto(ctx context.Context, collectionID, userID, photoID int) error
+	UnsavePhoto(ctx context.Context, collectionID, userID, photoID int) error
+	GetSavedPhotos(ctx context.Context, collectionID, userID int, opts SavedPhotoListOptions) ([]models.SavedPhoto, *SavedPhotoCursor, error)
+}
+
+// EnsureDefault создает коллекцию «Saved», если ее еще нет, и возвращает ее ID
+func (r *CollectionRepository) EnsureDefault(ctx context.Context, userID int) (int, error) {
+	var id int
+	err := r.DB.QueryRow(ctx, `
+		WITH ins AS (
+			INSERT INTO collections (user_id, name, is_default)
+			VALUES ($1, $2, TRUE)
+			ON CONFLICT DO NOTHING
+			RETURNING id
+		)
+		SELECT id FROM ins
+		UNION ALL
+		SELECT id FROM collections WHERE user_id = $1 AND is_default
+		LIMIT 1`, userID, models.DefaultCollectionName).Scan(&id)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return 0, ErrInvalidUserID
+	}
+	return id, err
+}
+
+// Create создает именованную коллекцию пользователя
+func (r *CollectionRepository) Create(ctx context.Context, collection *models.Collection) error {
+	err := r.DB.QueryRow(ctx, `
+		INSERT INTO collections (user_id, name)
+		VALUES ($1, $2)
+		RETURNING id, created_at`, collection.UserID, collection.Name).Scan(&collection.ID, &collection.CreatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505":
+			return ErrCollectionExists
+		case "23503":
+			return ErrInvalidUserID
+		}
+	}
+	return err
+}
+
+// Delete удаляет именованную коллекцию пользователя вместе с сохранениями. Коллекцию «Saved» удалить нельзя
+func (r *CollectionRepository) Delete(ctx context.Context, collectionID, userID int) error {
+	tag, err := r.DB.Exec(ctx, `
+		DELETE FROM collections
+		WHERE id = $1 AND user_id = $2 AND NOT is_default`, collectionID, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrCollectionNotFound
+	}
+	return nil
+}
+
+// GetCollections возвращает коллекции пользователя: сначала «Saved», затем остальные в порядке создания.
+// В количестве фото учитываются только доступные пользователю фото
+func (r *CollectionRepository) GetCollections(ctx context.Context, userID int) ([]models.Collection, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT c.id, c.user_id, c.name, c.is_default, c.created_at,
+		       (SELECT COUNT(*) FROM saved_photos s
+		        JOIN photos p ON p.id = s.photo_id
+		        WHERE s.collection_id = c.id AND `+photoVisibleSQL("p", "$1")+`)
+		FROM collections c
+		WHERE c.user_id = $1
+		ORDER BY c.is_default DESC, c.created_at, c.id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	collections := []models.Collection{}
+	for rows.Next() {
+		var c models.Collection
+		if err := rows.Scan(&c.ID, &c.UserID, &c.Name, &c.IsDefault, &c.CreatedAt, &c.PhotosCount); err != nil {
+			return nil, err
+		}
+		collections = append(collections, c)
+	}
+	return collections, rows.Err()
+}
+
+// owns проверяет, что коллекция принадлежит пользователю
+func (r *CollectionRepository) owns(ctx context.Context, collectionID, userID int) error {
+	var exists bool
+	err := r.DB.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM collections WHERE id = $1 AND user_id = $2)`,
+		collectionID, userID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrCollectionNotFound
+	}
+	return nil
+}
+
+// SavePhoto добавляет фото в коллекцию пользователя. Повторное сохранение ничего не меняет
+func (r *CollectionRepository) SavePhoto(ctx context.Context, collectionID, userID, photoID int) error {
+	var inserted int
+	err := r.DB.QueryRow(ctx, `
+		WITH ins AS (
+			INSERT INTO saved_photos (collection_id, photo_id)
+			SELECT id, $3 FROM collections WHERE id = $1 AND user_id = $2
+			ON CONFLICT DO NOTHING
+			RETURNING 1
+		)
+		SELECT COUNT(*) FROM ins`, collectionID, userID, photoID).Scan(&inserted)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return ErrInvalidPhotoID
+	}
+	if err != nil || inserted > 0 {
+		return err
+	}
+	return r.owns(ctx, collectionID, userID)
+}
+
+// UnsavePhoto убирает фото из коллекции пользователя. collectionID = 0 убирает фото из всех коллекций.
+// Удаление отсутствующего сохранения ничего не меняет
+func (r *CollectionRepository) UnsavePhoto(ctx context.Context, collectionID, userID, photoID int) error {
+	if collectionID != 0 {
+		if err := r.owns(ctx, collectionID, userID); err != nil {
+			return err
+		}
+	}
+
+	_, err := r.DB.Exec(ctx, `
+		DELETE FROM saved_photos s
+		USING collections c
+		WHERE c.id = s.collection_id AND c.user_id = $2 AND s.photo_id = $3
+		  AND ($1 = 0 OR c.id = $1)`, collectionID, userID, photoID)
+	return err
+}
+
+// GetSavedPhotos возвращает страницу фото коллекции, последние сохраненные первыми. Фото, которые
+// стали недоступны пользователю, пропускаются. Второе значение — курсор следующей страницы или nil
+func (r *CollectionRepository) GetSavedPhotos(ctx context.Context, collectionID, userID int, opts SavedPhotoListOptions) ([]models.SavedPhoto, *SavedPhotoCursor, error) {
+	if err := r.owns(ctx, collectionID, userID); err != nil {
+		return nil, nil, err
+	}
+
+	args := []interface{}{collectionID, userID, opts.Limit + 1}
+	cursorFilter := ""
+	if opts.After != nil {
+		cursorFilter = "AND (s.created_at, s.photo_id) < ($4, $5)"
+		args = append(args, opts.After.SavedAt, opts.After.PhotoID)
+	}
+
+	rows, err := r.DB.Query(ctx, `
+		SELECT p.id, p.user_id, p.url, COALESCE(p.description, ''), p.created_at, s.created_at
+		FROM saved_photos s
+		JOIN photos p ON p.id = s.photo_id
+		WHERE s.collection_id = $1 AND `+photoVisibleSQL("p", "$2")+`
+		`+cursorFilter+`
+		ORDER BY s.created_at DESC, s.photo_id DESC
+		LIMIT $3`, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	photos := []models.SavedPhoto{}
+	for rows.Next() {
+		var photo models.SavedPhoto
+		var createdAt time.Time
+		if err := rows.Scan(&photo.ID, &photo.UserID, &photo.URL, &photo.Description, &createdAt, &photo.SavedAt); err != nil {
+			return nil, nil, err
+		}
+		photo.CreatedAt = createdAt.Format(time.RFC3339)
+		photos = append(photos, photo)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(photos) <= opts.Limit {
+		return photos, nil, nil
+	}
+	photos = photos[:opts.Limit]
+	last := photos[len(photos)-1]
+	return photos, &SavedPhotoCursor{SavedAt: last.SavedAt, PhotoID: last.ID}, nil
+}
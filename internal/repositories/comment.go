@@ -3,8 +3,11 @@ package repositories
 import (
 	"InstaSpace/internal/models"
 	"context"
+	"errors"
 	"fmt"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"time"
 )
 
 type CommentRepository struct {
@@ -15,82 +18,409 @@ func NewCommentRepository(db *pgxpool.Pool) *CommentRepository {
 	return &CommentRepository{DB: db}
 }
 
+// DeletedCommentContent текст, который остается на месте удаленного комментария с ответами
+const DeletedCommentContent = "[deleted]"
+
+// MaxPinnedComments сколько комментариев автор фото может закрепить одновременно
+const MaxPinnedComments = 3
+
+var (
+	ErrCommentNotFound  = errors.New("comment not found")
+	ErrPinLimitReached  = errors.New("pinned comments limit reached")
+	ErrEditWindowClosed = errors.New("comment can no longer be edited")
+)
+
 type CommentRepositoryInterface interface {
 	CreateComment(ctx context.Context, comment *models.Comment) (int, error)
-	GetCommentsByPhotoID(ctx context.Context, photoID int) ([]models.Comment, error)
-	UpdateComment(ctx context.Context, comment *models.Comment) error
+	GetCommentByID(ctx context.Context, commentID int) (*models.Comment, error)
+	GetCommentsByPhotoID(ctx context.Context, photoID int, opts CommentListOptions) ([]models.Comment, *CommentCursor, error)
+	GetReplies(ctx context.Context, parentID int, opts CommentListOptions) ([]models.Comment, *CommentCursor, error)
+	GetPinnedComments(ctx context.Context, photoID, viewerID int) ([]models.Comment, error)
+	PinComment(ctx context.Context, commentID, ownerID int) error
+	UnpinComment(ctx context.Context, commentID, ownerID int) error
+	UpdateComment(ctx context.Context, comment *models.Comment, editWindow time.Duration) error
 	DeleteComment(ctx context.Context, commentID, userID int) error
+	GetRevisions(ctx context.Context, commentID int) ([]models.CommentRevision, error)
+	CanModerate(ctx context.Context, commentID, userID int) (bool, error)
 }
 
 func (r *CommentRepository) CreateComment(ctx context.Context, comment *models.Comment) (int, error) {
 	query := `
-		INSERT INTO comments (user_id, photo_id, content, created_at)
-		VALUES ($1, $2, $3, NOW())
+		INSERT INTO comments (user_id, photo_id, parent_id, content, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
 		RETURNING id`
-	err := r.DB.QueryRow(ctx, query, comment.UserID, comment.PhotoID, comment.Content).Scan(&comment.ID)
+	err := r.DB.QueryRow(ctx, query, comment.UserID, comment.PhotoID, comment.ParentID, comment.Content).Scan(&comment.ID)
 	if err != nil {
 		return 0, err
 	}
 	return comment.ID, nil
 }
 
-func (r *CommentRepository) GetCommentsByPhotoID(ctx context.Context, photoID int) ([]models.Comment, error) {
-	query := `
-    SELECT c.id, c.content, c.created_at, u.username 
-    FROM comments c 
-    JOIN users u ON c.user_id = u.id 
-    WHERE c.photo_id = $1
-`
-	rows, err := r.DB.Query(ctx, query, photoID)
+// GetCommentByID возвращает комментарий без учета блокировок
+func (r *CommentRepository) GetCommentByID(ctx context.Context, commentID int) (*models.Comment, error) {
+	var comment models.Comment
+	err := r.DB.QueryRow(ctx, `
+		SELECT id, user_id, photo_id, parent_id, content, deleted_at IS NOT NULL, created_at, updated_at, revision_count
+		FROM comments WHERE id = $1`, commentID).Scan(&comment.ID, &comment.UserID, &comment.PhotoID, &comment.ParentID,
+		&comment.Content, &comment.Deleted, &comment.CreatedAt, &comment.UpdatedAt, &comment.RevisionCount)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrCommentNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
+	return &comment, nil
+}
+
+// Режимы сортировки комментариев
+const (
+	CommentSortNewest = "newest"
+	CommentSortOldest = "oldest"
+	CommentSortTop    = "top"
+
+	commentSortPinned = "pinned"
+)
+
+// CommentCursor позиция последнего комментария страницы для keyset-пагинации
+type CommentCursor struct {
+	CreatedAt  time.Time `json:"t,omitempty"`
+	LikesCount int       `json:"l,omitempty"`
+	ID         int       `json:"id"`
+}
+
+// CommentListOptions параметры выборки комментариев
+type CommentListOptions struct {
+	ViewerID int
+	Sort     string
+	Limit    int
+	After    *CommentCursor
+}
+
+const commentListColumns = `
+    c.id, c.user_id, c.photo_id, c.parent_id, c.content, c.deleted_at IS NOT NULL,
+    c.created_at, c.updated_at, u.username, COALESCE(u.avatar_url, ''), c.likes_count, c.pinned_at IS NOT NULL,
+    c.revision_count,
+    EXISTS (SELECT 1 FROM comment_likes cl WHERE cl.comment_id = c.id AND cl.user_id = $2)`
+
+// GetCommentsByPhotoID возвращает страницу незакрепленных комментариев верхнего уровня к фото, скрывая авторов,
+// с которыми у зрителя есть блокировка. Второе значение — курсор следующей страницы или nil
+func (r *CommentRepository) GetCommentsByPhotoID(ctx context.Context, photoID int, opts CommentListOptions) ([]models.Comment, *CommentCursor, error) {
+	return r.listComments(ctx, "c.photo_id = $1 AND c.parent_id IS NULL AND c.pinned_at IS NULL", photoID, true, opts)
+}
+
+// GetPinnedComments возвращает закрепленные комментарии фото в порядке закрепления
+func (r *CommentRepository) GetPinnedComments(ctx context.Context, photoID, viewerID int) ([]models.Comment, error) {
+	comments, _, err := r.listComments(ctx, "c.photo_id = $1 AND c.parent_id IS NULL AND c.pinned_at IS NOT NULL", photoID, true,
+		CommentListOptions{ViewerID: viewerID, Sort: commentSortPinned, Limit: MaxPinnedComments})
+	return comments, err
+}
+
+// GetReplies возвращает страницу ответов на комментарий
+func (r *CommentRepository) GetReplies(ctx context.Context, parentID int, opts CommentListOptions) ([]models.Comment, *CommentCursor, error) {
+	return r.listComments(ctx, "c.parent_id = $1", parentID, false, opts)
+}
+
+// commentVisibleSQL возвращает SQL-условие, при котором комментарий с псевдонимом comment к фото photo
+// виден пользователю viewer: между зрителем и автором нет блокировки, и текст не содержит скрытых автором
+// фото слов. Свои комментарии зритель видит всегда
+func commentVisibleSQL(comment, photo, viewer string) string {
+	return `NOT EXISTS (
+		SELECT 1 FROM user_blocks b
+		WHERE (b.blocker_id = ` + viewer + ` AND b.blocked_id = ` + comment + `.user_id)
+		   OR (b.blocker_id = ` + comment + `.user_id AND b.blocked_id = ` + viewer + `)
+	)
+	AND (` + comment + `.user_id = ` + viewer + ` OR NOT EXISTS (
+		SELECT 1 FROM unnest(` + photo + `.hidden_keywords) k
+		WHERE position(k IN lower(` + comment + `.content)) > 0
+	))`
+}
+
+func (r *CommentRepository) listComments(ctx context.Context, filter string, filterID int, withReplyCount bool, opts CommentListOptions) ([]models.Comment, *CommentCursor, error) {
+	replyCount := "0"
+	if withReplyCount {
+		// Считаются только ответы, которые зритель увидит в списке, без удаленных
+		replyCount = "(SELECT COUNT(*) FROM comments r WHERE r.parent_id = c.id AND r.deleted_at IS NULL AND " +
+			commentVisibleSQL("r", "p", "$2") + ")"
+	}
+
+	args := []any{filterID, opts.ViewerID, opts.Limit + 1}
+	var orderBy, cursorFilter string
+	switch opts.Sort {
+	case commentSortPinned:
+		orderBy = "c.pinned_at, c.id"
+	case CommentSortTop:
+		orderBy = "c.likes_count DESC, c.id DESC"
+		if opts.After != nil {
+			cursorFilter = "AND (c.likes_count, c.id) < ($4, $5)"
+			args = append(args, opts.After.LikesCount, opts.After.ID)
+		}
+	case CommentSortOldest:
+		orderBy = "c.created_at, c.id"
+		if opts.After != nil {
+			cursorFilter = "AND (c.created_at, c.id) > ($4, $5)"
+			args = append(args, opts.After.CreatedAt, opts.After.ID)
+		}
+	default:
+		orderBy = "c.created_at DESC, c.id DESC"
+		if opts.After != nil {
+			cursorFilter = "AND (c.created_at, c.id) < ($4, $5)"
+			args = append(args, opts.After.CreatedAt, opts.After.ID)
+		}
+	}
+
+	query := `
+    SELECT` + commentListColumns + `, ` + replyCount + `
+    FROM comments c
+    JOIN users u ON c.user_id = u.id
+    JOIN photos p ON c.photo_id = p.id
+    WHERE ` + filter + `
+      AND ` + commentVisibleSQL("c", "p", "$2") + `
+      ` + cursorFilter + `
+    ORDER BY ` + orderBy + `
+    LIMIT $3`
+
+	rows, err := r.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
 	defer rows.Close()
 
-	var comments []models.Comment
+	comments := []models.Comment{}
 	for rows.Next() {
 		var comment models.Comment
-		if err := rows.Scan(&comment.ID, &comment.Content, &comment.CreatedAt, &comment.Username); err != nil {
-			return nil, err
+		if err := rows.Scan(&comment.ID, &comment.UserID, &comment.PhotoID, &comment.ParentID, &comment.Content,
+			&comment.Deleted, &comment.CreatedAt, &comment.UpdatedAt, &comment.Username, &comment.AvatarURL,
+			&comment.LikesCount, &comment.Pinned, &comment.RevisionCount, &comment.LikedByViewer, &comment.ReplyCount); err != nil {
+			return nil, nil, err
+		}
+		comment.Edited = comment.RevisionCount > 0 || comment.UpdatedAt.After(comment.CreatedAt)
+		if comment.Deleted {
+			comment.UserID = 0
+			comment.Username = ""
+			comment.AvatarURL = ""
 		}
 		comments = append(comments, comment)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	if len(comments) <= opts.Limit {
+		return comments, nil, nil
 	}
 
-	return comments, nil
+	comments = comments[:opts.Limit]
+	last := comments[len(comments)-1]
+	return comments, &CommentCursor{CreatedAt: last.CreatedAt, LikesCount: last.LikesCount, ID: last.ID}, nil
 }
 
-func (r *CommentRepository) UpdateComment(ctx context.Context, comment *models.Comment) error {
-	query := `
-        UPDATE comments
-        SET content = $1, updated_at = NOW()
-        WHERE id = $2 AND user_id = $3`
-	cmdTag, err := r.DB.Exec(ctx, query, comment.Content, comment.ID, comment.UserID)
+// UpdateComment меняет текст комментария автора и сохраняет прежнюю версию в comment_revisions.
+// Если editWindow больше нуля и с создания комментария прошло больше, возвращает ErrEditWindowClosed
+func (r *CommentRepository) UpdateComment(ctx context.Context, comment *models.Comment, editWindow time.Duration) error {
+	tx, err := r.DB.Begin(ctx)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback(ctx)
 
-	if cmdTag.RowsAffected() == 0 {
+	var content string
+	var writtenAt time.Time
+	var expired bool
+	err = tx.QueryRow(ctx, `
+		SELECT content, COALESCE(updated_at, created_at),
+		       $3::float8 > 0 AND created_at < NOW() - make_interval(secs => $3::float8)
+		FROM comments
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+		FOR UPDATE`, comment.ID, comment.UserID, editWindow.Seconds()).Scan(&content, &writtenAt, &expired)
+	if errors.Is(err, pgx.ErrNoRows) {
 		return fmt.Errorf("no rows updated")
 	}
+	if err != nil {
+		return err
+	}
+	if expired {
+		return ErrEditWindowClosed
+	}
 
-	return nil
+	if content == comment.Content {
+		return tx.QueryRow(ctx, "SELECT photo_id FROM comments WHERE id = $1", comment.ID).Scan(&comment.PhotoID)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO comment_revisions (comment_id, content, created_at)
+		VALUES ($1, $2, $3)`, comment.ID, content, writtenAt)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(ctx, `
+		UPDATE comments
+		SET content = $2, updated_at = NOW(), revision_count = revision_count + 1
+		WHERE id = $1
+		RETURNING photo_id`, comment.ID, comment.Content).Scan(&comment.PhotoID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetRevisions возвращает прежние версии комментария от старых к новым
+func (r *CommentRepository) GetRevisions(ctx context.Context, commentID int) ([]models.CommentRevision, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT content, created_at
+		FROM comment_revisions
+		WHERE comment_id = $1
+		ORDER BY id`, commentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []models.CommentRevision{}
+	for rows.Next() {
+		var revision models.CommentRevision
+		if err := rows.Scan(&revision.Content, &revision.CreatedAt); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, rows.Err()
+}
+
+// CanModerate проверяет, является ли пользователь автором фото с этим комментарием или модератором
+func (r *CommentRepository) CanModerate(ctx context.Context, commentID, userID int) (bool, error) {
+	var allowed bool
+	err := r.DB.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM comments c JOIN photos p ON c.photo_id = p.id
+			WHERE c.id = $1 AND p.user_id = $2
+		) OR EXISTS (
+			SELECT 1 FROM users WHERE id = $2 AND is_moderator
+		)`, commentID, userID).Scan(&allowed)
+	return allowed, err
 }
 
+// DeleteComment удаляет комментарий по запросу его автора или автора фото. Если у комментария есть ответы, вместо него остается "[deleted]".
+// Удаление последнего ответа убирает и такую заглушку родителя
 func (r *CommentRepository) DeleteComment(ctx context.Context, commentID, userID int) error {
-	query := `
-		DELETE FROM comments
-		WHERE id = $1 AND user_id = $2`
-	cmdTag, err := r.DB.Exec(ctx, query, commentID, userID)
+	tx, err := r.DB.Begin(ctx)
 	if err != nil {
 		return err
 	}
-	if cmdTag.RowsAffected() == 0 {
+	defer tx.Rollback(ctx)
+
+	var parentID *int
+	var hasReplies bool
+	err = tx.QueryRow(ctx, `
+		SELECT c.parent_id, EXISTS (SELECT 1 FROM comments r WHERE r.parent_id = c.id)
+		FROM comments c
+		JOIN photos p ON c.photo_id = p.id
+		WHERE c.id = $1 AND (c.user_id = $2 OR p.user_id = $2) AND c.deleted_at IS NULL
+		FOR UPDATE OF c`, commentID, userID).Scan(&parentID, &hasReplies)
+	if errors.Is(err, pgx.ErrNoRows) {
 		return fmt.Errorf("no rows deleted")
 	}
+	if err != nil {
+		return err
+	}
+
+	if parentID != nil {
+		// Блокируем родителя, чтобы параллельное удаление ответов не оставило пустую заглушку
+		if _, err = tx.Exec(ctx, "SELECT 1 FROM comments WHERE id = $1 FOR UPDATE", *parentID); err != nil {
+			return err
+		}
+	}
+
+	if hasReplies {
+		_, err = tx.Exec(ctx, `
+			UPDATE comments SET content = $2, deleted_at = NOW(), pinned_at = NULL
+			WHERE id = $1`, commentID, DeletedCommentContent)
+		if err != nil {
+			return err
+		}
+		if _, err = tx.Exec(ctx, "DELETE FROM mentions WHERE comment_id = $1", commentID); err != nil {
+			return err
+		}
+		return tx.Commit(ctx)
+	}
+
+	if _, err = tx.Exec(ctx, "DELETE FROM comments WHERE id = $1", commentID); err != nil {
+		return err
+	}
+
+	if parentID != nil {
+		_, err = tx.Exec(ctx, `
+			DELETE FROM comments p
+			WHERE p.id = $1 AND p.deleted_at IS NOT NULL
+			  AND NOT EXISTS (SELECT 1 FROM comments r WHERE r.parent_id = p.id)`, *parentID)
+		if err != nil {
+			return err
+		}
+	}
 
-	return err
+	return tx.Commit(ctx)
+}
+
+// PinComment закрепляет комментарий верхнего уровня, если ownerID — автор фото.
+// Повторное закрепление ничего не меняет
+func (r *CommentRepository) PinComment(ctx context.Context, commentID, ownerID int) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var photoID int
+	var pinned bool
+	err = tx.QueryRow(ctx, `
+		SELECT c.photo_id, c.pinned_at IS NOT NULL
+		FROM comments c
+		JOIN photos p ON c.photo_id = p.id
+		WHERE c.id = $1 AND p.user_id = $2 AND c.parent_id IS NULL AND c.deleted_at IS NULL`,
+		commentID, ownerID).Scan(&photoID, &pinned)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrCommentNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if pinned {
+		return nil
+	}
+
+	// Блокируем фото, чтобы параллельные закрепления не превысили лимит
+	if _, err = tx.Exec(ctx, "SELECT 1 FROM photos WHERE id = $1 FOR UPDATE", photoID); err != nil {
+		return err
+	}
+
+	var count int
+	err = tx.QueryRow(ctx, "SELECT COUNT(*) FROM comments WHERE photo_id = $1 AND pinned_at IS NOT NULL", photoID).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count >= MaxPinnedComments {
+		return ErrPinLimitReached
+	}
+
+	if _, err = tx.Exec(ctx, "UPDATE comments SET pinned_at = NOW() WHERE id = $1", commentID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// UnpinComment снимает закрепление комментария, если ownerID — автор фото
+func (r *CommentRepository) UnpinComment(ctx context.Context, commentID, ownerID int) error {
+	tag, err := r.DB.Exec(ctx, `
+		UPDATE comments c SET pinned_at = NULL
+		FROM photos p
+		WHERE c.id = $1 AND c.photo_id = p.id AND p.user_id = $2`, commentID, ownerID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrCommentNotFound
+	}
+	return nil
 }
@@ -0,0 +1,372 @@
+package repositories
+
+import (
+	"InstaSpace/internal/models"
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// MaxGroupParticipants максимальное количество участников групповой беседы
+const MaxGroupParticipants = 32
+
+var (
+	ErrGroupFull           = errors.New("group participant limit reached")
+	ErrParticipantNotFound = errors.New("user is not a participant of the conversation")
+	ErrLastAdmin           = errors.New("group must keep at least one admin")
+	ErrNotGroupAdmin       = errors.New("actor is not a group admin")
+)
+
+// systemMessageTemplates тексты служебных сообщений: первый %s — автор действия, второй — участник, над которым оно совершено
+var systemMessageTemplates = map[string]string{
+	models.MessageGroupCreated:  "%s created the group",
+	models.MessageGroupUpdated:  "%s changed the group info",
+	models.MessageMemberAdded:   "%s added %s",
+	models.MessageMemberRemoved: "%s removed %s",
+	models.MessageMemberLeft:    "%s left the group",
+	models.MessageRoleChanged:   "%s changed the role of %s",
+}
+
+// GetParticipantRole возвращает роль пользователя в беседе или пустую строку, если он в ней не участвует
+func (r *MessageRepository) GetParticipantRole(ctx context.Context, conversationID, userID int) (string, error) {
+	var role string
+	err := r.DB.QueryRow(ctx, `
+		SELECT role FROM conversation_participants
+		WHERE conversation_id = $1 AND user_id = $2`, conversationID, userID).Scan(&role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	return role, err
+}
+
+// GetParticipants возвращает участников беседы: сначала администраторы, затем остальные в порядке вступления
+func (r *MessageRepository) GetParticipants(ctx context.Context, conversationID int) ([]models.ConversationParticipant, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT u.id, u.username, COALESCE(u.avatar_url, ''), p.role, p.joined_at, p.last_read_message_id, p.last_read_at
+		FROM conversation_participants p
+		JOIN users u ON u.id = p.user_id
+		WHERE p.conversation_id = $1
+		ORDER BY p.role = 'admin' DESC, p.joined_at, u.id`, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	participants := []models.ConversationParticipant{}
+	for rows.Next() {
+		var p models.ConversationParticipant
+		if err := rows.Scan(&p.UserID, &p.Username, &p.AvatarURL, &p.Role, &p.JoinedAt, &p.LastReadMessageID, &p.LastReadAt); err != nil {
+			return nil, err
+		}
+		participants = append(participants, p)
+	}
+	return participants, rows.Err()
+}
+
+// GetParticipantIDs возвращает ID участников беседы
+func (r *MessageRepository) GetParticipantIDs(ctx context.Context, conversationID int) ([]int, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT user_id FROM conversation_participants
+		WHERE conversation_id = $1 ORDER BY user_id`, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// CreateGroup создает групповую беседу, в которой создатель — администратор, а memberIDs — участники
+func (r *MessageRepository) CreateGroup(ctx context.Context, conv *models.Conversation, creatorID int, memberIDs []int) error {
+	if len(memberIDs)+1 > MaxGroupParticipants {
+		return ErrGroupFull
+	}
+
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO conversations (is_group, title, avatar_url)
+		VALUES (TRUE, $1, NULLIF($2, ''))
+		RETURNING id, created_at`, conv.Title, conv.AvatarURL).Scan(&conv.ID, &conv.CreatedAt)
+	if err != nil {
+		return err
+	}
+	conv.IsGroup = true
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO conversation_participants (conversation_id, user_id, role)
+		SELECT $1, u.id, CASE WHEN u.id = $2 THEN 'admin' ELSE 'member' END
+		FROM unnest(array_prepend($2::int, $3::int[])) AS u(id)
+		ON CONFLICT DO NOTHING`, conv.ID, creatorID, memberIDs)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return ErrInvalidUserID
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := insertSystemMessage(ctx, tx, conv.ID, creatorID, models.MessageGroupCreated, 0); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// UpdateGroup меняет название и аватар группы и сообщает об этом участникам
+func (r *MessageRepository) UpdateGroup(ctx context.Context, conv *models.Conversation, actorID int) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE conversations SET title = $2, avatar_url = NULLIF($3, '')
+		WHERE id = $1 AND is_group`, conv.ID, conv.Title, conv.AvatarURL)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	if err := insertSystemMessage(ctx, tx, conv.ID, actorID, models.MessageGroupUpdated, 0); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// AddParticipants добавляет пользователей в группу и возвращает ID тех, кого в ней еще не было
+func (r *MessageRepository) AddParticipants(ctx context.Context, conversationID, actorID int, userIDs []int) ([]int, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockGroupForAdmin(ctx, tx, conversationID, actorID); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, `
+		INSERT INTO conversation_participants (conversation_id, user_id)
+		SELECT $1, u.id FROM unnest($2::int[]) WITH ORDINALITY AS u(id, ord)
+		ORDER BY u.ord
+		ON CONFLICT DO NOTHING
+		RETURNING user_id`, conversationID, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	var added []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		added = append(added, id)
+	}
+	rows.Close()
+	var pgErr *pgconn.PgError
+	if errors.As(rows.Err(), &pgErr) && pgErr.Code == "23503" {
+		return nil, ErrInvalidUserID
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var total int
+	if err := tx.QueryRow(ctx, "SELECT COUNT(*) FROM conversation_participants WHERE conversation_id = $1", conversationID).Scan(&total); err != nil {
+		return nil, err
+	}
+	if total > MaxGroupParticipants {
+		return nil, ErrGroupFull
+	}
+
+	for _, userID := range added {
+		if err := insertSystemMessage(ctx, tx, conversationID, actorID, models.MessageMemberAdded, userID); err != nil {
+			return nil, err
+		}
+	}
+	return added, tx.Commit(ctx)
+}
+
+// RemoveParticipant исключает участника из группы. Если actorID совпадает с userID, участник выходит сам.
+// Если ушел последний администратор, администратором становится самый давний участник,
+// а группа без участников удаляется
+func (r *MessageRepository) RemoveParticipant(ctx context.Context, conversationID, actorID, userID int) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Исключать других может только администратор, выйти может любой участник
+	if actorID == userID {
+		err = lockConversation(ctx, tx, conversationID)
+	} else {
+		err = lockGroupForAdmin(ctx, tx, conversationID, actorID)
+	}
+	if err != nil {
+		return err
+	}
+
+	tag, err := tx.Exec(ctx, `
+		DELETE FROM conversation_participants
+		WHERE conversation_id = $1 AND user_id = $2`, conversationID, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrParticipantNotFound
+	}
+
+	var remaining int
+	if err := tx.QueryRow(ctx, "SELECT COUNT(*) FROM conversation_participants WHERE conversation_id = $1", conversationID).Scan(&remaining); err != nil {
+		return err
+	}
+	if remaining == 0 {
+		if _, err := tx.Exec(ctx, "DELETE FROM conversations WHERE id = $1", conversationID); err != nil {
+			return err
+		}
+		return tx.Commit(ctx)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE conversation_participants SET role = 'admin'
+		WHERE conversation_id = $1
+		  AND NOT EXISTS (SELECT 1 FROM conversation_participants WHERE conversation_id = $1 AND role = 'admin')
+		  AND user_id = (
+			SELECT user_id FROM conversation_participants WHERE conversation_id = $1
+			ORDER BY joined_at, user_id LIMIT 1
+		  )`, conversationID)
+	if err != nil {
+		return err
+	}
+
+	kind, target := models.MessageMemberRemoved, userID
+	if actorID == userID {
+		kind, target = models.MessageMemberLeft, 0
+	}
+	if err := insertSystemMessage(ctx, tx, conversationID, actorID, kind, target); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// SetParticipantRole меняет роль участника группы. Последнего администратора нельзя разжаловать
+func (r *MessageRepository) SetParticipantRole(ctx context.Context, conversationID, actorID, userID int, role string) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockGroupForAdmin(ctx, tx, conversationID, actorID); err != nil {
+		return err
+	}
+
+	var current string
+	err = tx.QueryRow(ctx, `
+		SELECT role FROM conversation_participants
+		WHERE conversation_id = $1 AND user_id = $2`, conversationID, userID).Scan(&current)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrParticipantNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if current == role {
+		return nil
+	}
+
+	if role != models.ParticipantAdmin {
+		var admins int
+		err := tx.QueryRow(ctx, `
+			SELECT COUNT(*) FROM conversation_participants
+			WHERE conversation_id = $1 AND role = 'admin'`, conversationID).Scan(&admins)
+		if err != nil {
+			return err
+		}
+		if admins <= 1 {
+			return ErrLastAdmin
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE conversation_participants SET role = $3
+		WHERE conversation_id = $1 AND user_id = $2`, conversationID, userID, role)
+	if err != nil {
+		return err
+	}
+
+	if err := insertSystemMessage(ctx, tx, conversationID, actorID, models.MessageRoleChanged, userID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// lockConversation блокирует групповую беседу до конца транзакции, чтобы изменения состава шли по очереди
+func lockConversation(ctx context.Context, tx pgx.Tx, conversationID int) error {
+	var id int
+	err := tx.QueryRow(ctx, "SELECT id FROM conversations WHERE id = $1 AND is_group FOR UPDATE", conversationID).Scan(&id)
+	return err
+}
+
+// lockGroupForAdmin блокирует группу и проверяет, что actorID все еще ее администратор. Права проверяются
+// и в сервисе, но до начала транзакции, а роль могли отозвать в промежутке
+func lockGroupForAdmin(ctx context.Context, tx pgx.Tx, conversationID, actorID int) error {
+	if err := lockConversation(ctx, tx, conversationID); err != nil {
+		return err
+	}
+
+	var role string
+	err := tx.QueryRow(ctx, `
+		SELECT role FROM conversation_participants
+		WHERE conversation_id = $1 AND user_id = $2`, conversationID, actorID).Scan(&role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotGroupAdmin
+	}
+	if err != nil {
+		return err
+	}
+	if role != models.ParticipantAdmin {
+		return ErrNotGroupAdmin
+	}
+	return nil
+}
+
+// insertSystemMessage добавляет служебное сообщение вида kind и поднимает беседу во входящих.
+// targetID = 0 означает, что действие не касается другого участника
+func insertSystemMessage(ctx context.Context, tx pgx.Tx, conversationID, actorID int, kind string, targetID int) error {
+	var target *int
+	if targetID != 0 {
+		target = &targetID
+	}
+
+	_, err := tx.Exec(ctx, `
+		WITH msg AS (
+			INSERT INTO messages (conversation_id, sender_id, kind, target_user_id, content)
+			SELECT $1, a.id, $3, $4, format($5, a.username, COALESCE(t.username, ''))
+			FROM users a LEFT JOIN users t ON t.id = $4
+			WHERE a.id = $2
+			RETURNING created_at
+		)
+		UPDATE conversations c SET last_activity_at = msg.created_at
+		FROM msg WHERE c.id = $1`, conversationID, actorID, kind, target, systemMessageTemplates[kind])
+	return err
+}
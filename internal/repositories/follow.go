@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type FollowRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewFollowRepository(db *pgxpool.Pool) *FollowRepository {
+	return &FollowRepository{DB: db}
+}
+
+type FollowRepositoryInterface interface {
+	Follow(ctx context.Context, followerID, followeeID int) error
+	Unfollow(ctx context.Context, followerID, followeeID int) error
+	IsFollowing(ctx context.Context, followerID, followeeID int) (bool, error)
+}
+
+// Follow подписывает followerID на followeeID, повторная подписка ничего не меняет
+func (r *FollowRepository) Follow(ctx context.Context, followerID, followeeID int) error {
+	var exists bool
+	err := r.DB.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM users WHERE id=$1)", followeeID).Scan(&exists)
+	if err != nil || !exists {
+		return ErrInvalidUserID
+	}
+
+	_, err = r.DB.Exec(ctx, `
+		INSERT INTO follows (follower_id, followee_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`, followerID, followeeID)
+	return err
+}
+
+// Unfollow удаляет подписку, если она была
+func (r *FollowRepository) Unfollow(ctx context.Context, followerID, followeeID int) error {
+	_, err := r.DB.Exec(ctx, "DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2", followerID, followeeID)
+	return err
+}
+
+// IsFollowing проверяет, подписан ли followerID на followeeID
+func (r *FollowRepository) IsFollowing(ctx context.Context, followerID, followeeID int) (bool, error) {
+	var exists bool
+	err := r.DB.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM follows WHERE follower_id = $1 AND followee_id = $2)`,
+		followerID, followeeID).Scan(&exists)
+	return exists, err
+}
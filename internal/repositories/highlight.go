@@ -0,0 +1,365 @@
+package repositories
+
+import (
+	"InstaSpace/internal/models"
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type HighlightRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewHighlightRepository(db *pgxpool.Pool) *HighlightRepository {
+	return &HighlightRepository{DB: db}
+}
+
+// MaxHighlightItems максимальное количество элементов в подборке
+const MaxHighlightItems = 100
+
+var (
+	ErrHighlightNotFound     = errors.New("highlight not found")
+	ErrHighlightFull         = errors.New("highlight item limit reached")
+	ErrInvalidHighlightItems = errors.New("items must be existing photos or stories of the highlight owner")
+	ErrInvalidHighlightCover = errors.New("cover must be an item of the highlight")
+	ErrInvalidHighlightOrder = errors.New("order must list every item exactly once")
+)
+
+type HighlightRepositoryInterface interface {
+	Create(ctx context.Context, highlight *models.Highlight, items []models.HighlightItemRef) error
+	GetHighlight(ctx context.Context, highlightID, viewerID int) (*models.Highlight, error)
+	GetUserHighlights(ctx context.Context, ownerID, viewerID int) ([]models.Highlight, error)
+	Update(ctx context.Context, highlight *models.Highlight) error
+	Delete(ctx context.Context, highlightID, ownerID int) error
+	AddItems(ctx context.Context, highlightID, ownerID int, items []models.HighlightItemRef) error
+	RemoveItem(ctx context.Context, highlightID, ownerID, itemID int) error
+	ReorderItems(ctx context.Context, highlightID, ownerID int, itemIDs []int) error
+	ReorderHighlights(ctx context.Context, ownerID int, highlightIDs []int) error
+}
+
+const highlightColumns = `
+	h.id, h.user_id, h.title, h.cover_item_id,
+	COALESCE((
+		SELECT COALESCE(p.url, s.url) FROM highlight_items hi
+		LEFT JOIN photos p ON p.id = hi.photo_id
+		LEFT JOIN stories s ON s.id = hi.story_id
+		WHERE hi.id = COALESCE(h.cover_item_id, (
+			SELECT first.id FROM highlight_items first WHERE first.highlight_id = h.id ORDER BY first.position, first.id LIMIT 1
+		))
+	), ''),
+	(SELECT COUNT(*) FROM highlight_items hi WHERE hi.highlight_id = h.id),
+	h.created_at, h.updated_at`
+
+func scanHighlight(row pgx.Row) (*models.Highlight, error) {
+	var highlight models.Highlight
+	err := row.Scan(&highlight.ID, &highlight.UserID, &highlight.Title, &highlight.CoverItemID, &highlight.CoverURL,
+		&highlight.ItemsCount, &highlight.CreatedAt, &highlight.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &highlight, nil
+}
+
+// Create создает подборку в конце профиля и добавляет в нее элементы в переданном порядке
+func (r *HighlightRepository) Create(ctx context.Context, highlight *models.Highlight, items []models.HighlightItemRef) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO highlights (user_id, title, position)
+		SELECT $1, $2, COALESCE(MAX(position), 0) + 1 FROM highlights WHERE user_id = $1
+		RETURNING id`, highlight.UserID, highlight.Title).Scan(&highlight.ID)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return ErrInvalidUserID
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := addHighlightItems(ctx, tx, highlight.ID, highlight.UserID, items); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// GetHighlight возвращает подборку с элементами, если между зрителем и автором нет блокировки
+func (r *HighlightRepository) GetHighlight(ctx context.Context, highlightID, viewerID int) (*models.Highlight, error) {
+	highlight, err := scanHighlight(r.DB.QueryRow(ctx, `
+		SELECT `+highlightColumns+`
+		FROM highlights h
+		WHERE h.id = $1 AND `+photoVisibleSQL("h", "$2"), highlightID, viewerID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrHighlightNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.DB.Query(ctx, `
+		SELECT hi.id, hi.photo_id, hi.story_id, COALESCE(p.url, s.url), COALESCE(p.description, s.caption, ''), hi.added_at
+		FROM highlight_items hi
+		LEFT JOIN photos p ON p.id = hi.photo_id
+		LEFT JOIN stories s ON s.id = hi.story_id
+		WHERE hi.highlight_id = $1
+		ORDER BY hi.position, hi.id`, highlightID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	highlight.Items = []models.HighlightItem{}
+	for rows.Next() {
+		var item models.HighlightItem
+		if err := rows.Scan(&item.ID, &item.PhotoID, &item.StoryID, &item.URL, &item.Caption, &item.AddedAt); err != nil {
+			return nil, err
+		}
+		item.Type = models.HighlightItemPhoto
+		if item.StoryID != nil {
+			item.Type = models.HighlightItemStory
+		}
+		highlight.Items = append(highlight.Items, item)
+	}
+	return highlight, rows.Err()
+}
+
+// GetUserHighlights возвращает подборки пользователя в порядке профиля
+func (r *HighlightRepository) GetUserHighlights(ctx context.Context, ownerID, viewerID int) ([]models.Highlight, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT `+highlightColumns+`
+		FROM highlights h
+		WHERE h.user_id = $1 AND `+photoVisibleSQL("h", "$2")+`
+		ORDER BY h.position, h.id`, ownerID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	highlights := []models.Highlight{}
+	for rows.Next() {
+		highlight, err := scanHighlight(rows)
+		if err != nil {
+			return nil, err
+		}
+		highlights = append(highlights, *highlight)
+	}
+	return highlights, rows.Err()
+}
+
+// Update сохраняет название и обложку подборки, если она принадлежит highlight.UserID.
+// Nil CoverItemID возвращает обложку по умолчанию
+func (r *HighlightRepository) Update(ctx context.Context, highlight *models.Highlight) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE highlights SET title = $3, cover_item_id = NULL, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2`, highlight.ID, highlight.UserID, highlight.Title)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrHighlightNotFound
+	}
+
+	if highlight.CoverItemID != nil {
+		tag, err = tx.Exec(ctx, `
+			UPDATE highlights SET cover_item_id = $2
+			WHERE id = $1 AND EXISTS (SELECT 1 FROM highlight_items WHERE id = $2 AND highlight_id = $1)`,
+			highlight.ID, *highlight.CoverItemID)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrInvalidHighlightCover
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// Delete удаляет подборку. Истекшие истории из нее будут удалены при следующей очистке
+func (r *HighlightRepository) Delete(ctx context.Context, highlightID, ownerID int) error {
+	tag, err := r.DB.Exec(ctx, "DELETE FROM highlights WHERE id = $1 AND user_id = $2", highlightID, ownerID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrHighlightNotFound
+	}
+	return nil
+}
+
+// AddItems добавляет элементы в конец подборки. Элементы, которые уже есть в подборке, остаются на своих местах
+func (r *HighlightRepository) AddItems(ctx context.Context, highlightID, ownerID int, items []models.HighlightItemRef) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockHighlight(ctx, tx, highlightID, ownerID); err != nil {
+		return err
+	}
+	if err := addHighlightItems(ctx, tx, highlightID, ownerID, items); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, "UPDATE highlights SET updated_at = NOW() WHERE id = $1", highlightID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// RemoveItem убирает элемент из подборки. Удаление отсутствующего элемента ничего не меняет
+func (r *HighlightRepository) RemoveItem(ctx context.Context, highlightID, ownerID, itemID int) error {
+	var found bool
+	err := r.DB.QueryRow(ctx, `
+		WITH highlight AS (
+			SELECT id FROM highlights WHERE id = $1 AND user_id = $2
+		), del AS (
+			DELETE FROM highlight_items
+			WHERE highlight_id IN (SELECT id FROM highlight) AND id = $3
+			RETURNING id
+		), upd AS (
+			UPDATE highlights SET updated_at = NOW()
+			WHERE id IN (SELECT id FROM highlight) AND EXISTS (SELECT 1 FROM del)
+		)
+		SELECT EXISTS (SELECT 1 FROM highlight)`, highlightID, ownerID, itemID).Scan(&found)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrHighlightNotFound
+	}
+	return nil
+}
+
+// ReorderItems задает новый порядок элементов. itemIDs должен содержать каждый элемент подборки ровно один раз
+func (r *HighlightRepository) ReorderItems(ctx context.Context, highlightID, ownerID int, itemIDs []int) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockHighlight(ctx, tx, highlightID, ownerID); err != nil {
+		return err
+	}
+	if err := reorderPositions(ctx, tx, "highlight_items", "highlight_id", highlightID, itemIDs); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, "UPDATE highlights SET updated_at = NOW() WHERE id = $1", highlightID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ReorderHighlights задает новый порядок подборок в профиле. highlightIDs должен содержать каждую подборку ровно один раз
+func (r *HighlightRepository) ReorderHighlights(ctx context.Context, ownerID int, highlightIDs []int) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "SELECT 1 FROM highlights WHERE user_id = $1 FOR UPDATE", ownerID); err != nil {
+		return err
+	}
+	if err := reorderPositions(ctx, tx, "highlights", "user_id", ownerID, highlightIDs); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// lockHighlight блокирует подборку владельца до конца транзакции
+func lockHighlight(ctx context.Context, tx pgx.Tx, highlightID, ownerID int) error {
+	var id int
+	err := tx.QueryRow(ctx, "SELECT id FROM highlights WHERE id = $1 AND user_id = $2 FOR UPDATE", highlightID, ownerID).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrHighlightNotFound
+	}
+	return err
+}
+
+// reorderPositions присваивает строкам table с scopeColumn = scopeID позиции по порядку ids,
+// если ids совпадает с множеством этих строк
+func reorderPositions(ctx context.Context, tx pgx.Tx, table, scopeColumn string, scopeID int, ids []int) error {
+	var matches bool
+	err := tx.QueryRow(ctx, `
+		SELECT COALESCE(array_agg(id ORDER BY id), '{}') = (
+			SELECT COALESCE(array_agg(x ORDER BY x), '{}') FROM unnest($2::int[]) AS x
+		)
+		FROM `+table+` WHERE `+scopeColumn+` = $1`, scopeID, ids).Scan(&matches)
+	if err != nil {
+		return err
+	}
+	if !matches {
+		return ErrInvalidHighlightOrder
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE `+table+` t SET position = o.ord
+		FROM unnest($2::int[]) WITH ORDINALITY AS o(id, ord)
+		WHERE t.`+scopeColumn+` = $1 AND t.id = o.id`, scopeID, ids)
+	return err
+}
+
+// addHighlightItems добавляет фото и истории владельца в конец подборки, сохраняя порядок items
+func addHighlightItems(ctx context.Context, tx pgx.Tx, highlightID, ownerID int, items []models.HighlightItemRef) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	photoIDs := make([]int, len(items))
+	storyIDs := make([]int, len(items))
+	for i, item := range items {
+		photoIDs[i], storyIDs[i] = item.PhotoID, item.StoryID
+	}
+
+	var owned int
+	err := tx.QueryRow(ctx, `
+		SELECT COUNT(*) FROM unnest($1::int[], $2::int[]) AS o(photo_id, story_id)
+		WHERE EXISTS (SELECT 1 FROM photos p WHERE p.id = o.photo_id AND p.user_id = $3)
+		   OR EXISTS (SELECT 1 FROM stories s WHERE s.id = o.story_id AND s.user_id = $3)`,
+		photoIDs, storyIDs, ownerID).Scan(&owned)
+	if err != nil {
+		return err
+	}
+	if owned != len(items) {
+		return ErrInvalidHighlightItems
+	}
+
+	var total int
+	err = tx.QueryRow(ctx, `
+		WITH ins AS (
+			INSERT INTO highlight_items (highlight_id, photo_id, story_id, position)
+			SELECT $1, NULLIF(o.photo_id, 0), NULLIF(o.story_id, 0), m.last + o.ord
+			FROM unnest($2::int[], $3::int[]) WITH ORDINALITY AS o(photo_id, story_id, ord),
+			     (SELECT COALESCE(MAX(position), 0) AS last FROM highlight_items WHERE highlight_id = $1) m
+			ON CONFLICT DO NOTHING
+			RETURNING 1
+		)
+		SELECT (SELECT COUNT(*) FROM highlight_items WHERE highlight_id = $1) + (SELECT COUNT(*) FROM ins)`,
+		highlightID, photoIDs, storyIDs).Scan(&total)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		// История могла быть удалена очисткой между проверкой и вставкой
+		return ErrInvalidHighlightItems
+	}
+	if err != nil {
+		return err
+	}
+	if total > MaxHighlightItems {
+		return ErrHighlightFull
+	}
+	return nil
+}
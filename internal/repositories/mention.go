@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"InstaSpace/internal/models"
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type MentionRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewMentionRepository(db *pgxpool.Pool) *MentionRepository {
+	return &MentionRepository{DB: db}
+}
+
+type MentionRepositoryInterface interface {
+	ResolveUsernames(ctx context.Context, authorID int, usernames []string) (map[string]models.User, error)
+	ReplaceCommentMentions(ctx context.Context, commentID int, mentions []models.Mention) error
+	ReplacePhotoMentions(ctx context.Context, photoID int, mentions []models.Mention) error
+	GetByCommentIDs(ctx context.Context, commentIDs []int) (map[int][]models.Mention, error)
+	GetByPhotoIDs(ctx context.Context, photoIDs []int) (map[int][]models.Mention, error)
+}
+
+// ResolveUsernames находит пользователей по именам без учета регистра.
+// Пользователи, с которыми у автора есть блокировка, не возвращаются. Ключ результата — имя в нижнем регистре
+func (r *MentionRepository) ResolveUsernames(ctx context.Context, authorID int, usernames []string) (map[string]models.User, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT DISTINCT ON (LOWER(u.username)) LOWER(u.username), u.id, u.username
+		FROM users u
+		WHERE LOWER(u.username) = ANY($1)
+		  AND NOT EXISTS (
+			SELECT 1 FROM user_blocks b
+			WHERE (b.blocker_id = $2 AND b.blocked_id = u.id)
+			   OR (b.blocker_id = u.id AND b.blocked_id = $2)
+		  )
+		ORDER BY LOWER(u.username), u.id`, usernames, authorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make(map[string]models.User)
+	for rows.Next() {
+		var key string
+		var user models.User
+		if err := rows.Scan(&key, &user.ID, &user.Username); err != nil {
+			return nil, err
+		}
+		users[key] = user
+	}
+
+	return users, rows.Err()
+}
+
+// ReplaceCommentMentions заменяет упоминания комментария
+func (r *MentionRepository) ReplaceCommentMentions(ctx context.Context, commentID int, mentions []models.Mention) error {
+	return r.replace(ctx, "comment_id", commentID, mentions)
+}
+
+// ReplacePhotoMentions заменяет упоминания в описании фото
+func (r *MentionRepository) ReplacePhotoMentions(ctx context.Context, photoID int, mentions []models.Mention) error {
+	return r.replace(ctx, "photo_id", photoID, mentions)
+}
+
+func (r *MentionRepository) replace(ctx context.Context, column string, sourceID int, mentions []models.Mention) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "DELETE FROM mentions WHERE "+column+" = $1", sourceID); err != nil {
+		return err
+	}
+
+	for _, m := range mentions {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO mentions (`+column+`, user_id, "offset", length)
+			VALUES ($1, $2, $3, $4)`, sourceID, m.UserID, m.Offset, m.Length)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetByCommentIDs возвращает упоминания для набора комментариев
+func (r *MentionRepository) GetByCommentIDs(ctx context.Context, commentIDs []int) (map[int][]models.Mention, error) {
+	return r.getBySources(ctx, "comment_id", commentIDs)
+}
+
+// GetByPhotoIDs возвращает упоминания для набора фото
+func (r *MentionRepository) GetByPhotoIDs(ctx context.Context, photoIDs []int) (map[int][]models.Mention, error) {
+	return r.getBySources(ctx, "photo_id", photoIDs)
+}
+
+func (r *MentionRepository) getBySources(ctx context.Context, column string, sourceIDs []int) (map[int][]models.Mention, error) {
+	result := make(map[int][]models.Mention)
+	if len(sourceIDs) == 0 {
+		return result, nil
+	}
+
+	rows, err := r.DB.Query(ctx, `
+		SELECT m.`+column+`, m.user_id, u.username, m."offset", m.length
+		FROM mentions m
+		JOIN users u ON m.user_id = u.id
+		WHERE m.`+column+` = ANY($1)
+		ORDER BY m."offset"`, sourceIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sourceID int
+		var m models.Mention
+		if err := rows.Scan(&sourceID, &m.UserID, &m.Username, &m.Offset, &m.Length); err != nil {
+			return nil, err
+		}
+		result[sourceID] = append(result[sourceID], m)
+	}
+
+	return result, rows.Err()
+}
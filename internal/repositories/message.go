@@ -4,16 +4,50 @@ import (
 	"InstaSpace/internal/models"
 	"context"
 	"errors"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// ErrClientMessageIDReused ID клиента уже занят сообщением отправителя в другой беседе
+var ErrClientMessageIDReused = errors.New("client message id is already used in another conversation")
+
 type MessageRepositoryInterface interface {
 	CreateConversation(ctx context.Context, user1ID, user2ID int) (int, error)
-	SendMessage(ctx context.Context, conversationID, senderID int, content string) (int, error)
+	SendMessage(ctx context.Context, conversationID, senderID int, content, clientMessageID string) (*models.Message, bool, error)
 	GetMessages(ctx context.Context, conversationID int) ([]models.Message, error)
 	DeleteMessage(ctx context.Context, messageID int) error
-	ConversationExists(ctx context.Context, conversationID int, exists *bool) error
+	GetConversation(ctx context.Context, conversationID int) (*models.Conversation, error)
+	GetMessage(ctx context.Context, messageID int) (*models.Message, error)
+	MarkRead(ctx context.Context, conversationID, userID, messageID int) (*models.ReadReceipt, bool, error)
+	GetLastMessageID(ctx context.Context, conversationID int) (int, error)
+	CountUnread(ctx context.Context, userID int) (*models.UnreadCount, error)
+	GetParticipantRole(ctx context.Context, conversationID, userID int) (string, error)
+	GetParticipants(ctx context.Context, conversationID int) ([]models.ConversationParticipant, error)
+	GetParticipantIDs(ctx context.Context, conversationID int) ([]int, error)
+	CreateGroup(ctx context.Context, conv *models.Conversation, creatorID int, memberIDs []int) error
+	UpdateGroup(ctx context.Context, conv *models.Conversation, actorID int) error
+	AddParticipants(ctx context.Context, conversationID, actorID int, userIDs []int) ([]int, error)
+	RemoveParticipant(ctx context.Context, conversationID, actorID, userID int) error
+	SetParticipantRole(ctx context.Context, conversationID, actorID, userID int, role string) error
+	GetInbox(ctx context.Context, userID int, opts InboxListOptions) ([]models.InboxEntry, *InboxCursor, error)
+}
+
+// MessagePreviewLength сколько символов сообщения показывать во входящих
+const MessagePreviewLength = 100
+
+// InboxCursor позиция последней беседы страницы входящих для keyset-пагинации
+type InboxCursor struct {
+	LastActivityAt time.Time `json:"t"`
+	ID             int       `json:"id"`
+}
+
+// InboxListOptions параметры выборки входящих
+type InboxListOptions struct {
+	Limit int
+	After *InboxCursor
 }
 
 type MessageRepository struct {
@@ -24,46 +58,85 @@ func NewMessageRepository(db *pgxpool.Pool) MessageRepositoryInterface {
 	return &MessageRepository{DB: db}
 }
 
+// CreateConversation возвращает беседу пары пользователей, создавая ее при необходимости.
+// Пара хранится упорядоченной, поэтому (A,B) и (B,A) дают одну беседу
 func (r *MessageRepository) CreateConversation(ctx context.Context, user1ID, user2ID int) (int, error) {
 	var conversationID int
 	err := r.DB.QueryRow(ctx, `
-		INSERT INTO conversations (user1_id, user2_id) 
-		VALUES ($1, $2) 
-		ON CONFLICT (user1_id, user2_id) 
-		DO UPDATE SET user1_id = EXCLUDED.user1_id
-		RETURNING id`, user1ID, user2ID).Scan(&conversationID)
-
+		WITH conv AS (
+			INSERT INTO conversations (user1_id, user2_id) 
+			VALUES (LEAST($1::int, $2::int), GREATEST($1::int, $2::int)) 
+			ON CONFLICT (user1_id, user2_id) 
+			DO UPDATE SET user1_id = EXCLUDED.user1_id
+			RETURNING id
+		), participants AS (
+			INSERT INTO conversation_participants (conversation_id, user_id)
+			SELECT conv.id, u.id FROM conv, unnest(ARRAY[$1::int, $2::int]) AS u(id)
+			ON CONFLICT DO NOTHING
+		)
+		SELECT id FROM conv`, user1ID, user2ID).Scan(&conversationID)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return 0, ErrInvalidUserID
+	}
 	if err != nil {
 		return 0, err
 	}
 	return conversationID, nil
 }
 
-func (r *MessageRepository) SendMessage(ctx context.Context, conversationID, senderID int, content string) (int, error) {
-	var messageID int
+// SendMessage сохраняет сообщение, поднимает беседу во входящих и отмечает его прочитанным для отправителя.
+// Если у отправителя уже есть сообщение с тем же clientMessageID, возвращает его, а второе значение равно false.
+// Если то сообщение отправлено в другую беседу, возвращает ErrClientMessageIDReused
+func (r *MessageRepository) SendMessage(ctx context.Context, conversationID, senderID int, content, clientMessageID string) (*models.Message, bool, error) {
+	msg := models.Message{
+		ConversationID:  conversationID,
+		SenderID:        senderID,
+		Kind:            models.MessageText,
+		Content:         content,
+		ClientMessageID: clientMessageID,
+	}
 	err := r.DB.QueryRow(ctx, `
-		INSERT INTO messages (conversation_id, sender_id, content) 
-		VALUES ($1, $2, $3) RETURNING id`, conversationID, senderID, content).Scan(&messageID)
-
-	if err != nil {
-		return 0, err
+		WITH msg AS (
+			INSERT INTO messages (conversation_id, sender_id, content, client_message_id)
+			VALUES ($1, $2, $3, NULLIF($4, ''))
+			ON CONFLICT (sender_id, client_message_id) WHERE client_message_id IS NOT NULL DO NOTHING
+			RETURNING id, created_at
+		), conv AS (
+			UPDATE conversations c SET last_activity_at = msg.created_at
+			FROM msg
+			WHERE c.id = $1
+		), participant AS (
+			UPDATE conversation_participants p SET last_read_message_id = msg.id, last_read_at = msg.created_at
+			FROM msg
+			WHERE p.conversation_id = $1 AND p.user_id = $2
+		)
+		SELECT id, created_at FROM msg`, conversationID, senderID, content, clientMessageID).Scan(&msg.ID, &msg.CreatedAt)
+	if err == nil {
+		return &msg, true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, err
 	}
-	return messageID, nil
-}
 
-func (r *MessageRepository) GetMessages(ctx context.Context, conversationID int) ([]models.Message, error) {
-	var exists bool
-	err := r.ConversationExists(ctx, conversationID, &exists)
+	// Сообщение с этим ID уже отправлено раньше
+	err = r.DB.QueryRow(ctx, `
+		SELECT id, conversation_id, content, created_at FROM messages
+		WHERE sender_id = $1 AND client_message_id = $2`, senderID, clientMessageID).Scan(&msg.ID, &msg.ConversationID, &msg.Content, &msg.CreatedAt)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
-	if !exists {
-		return nil, errors.New("conversation not found")
+	if msg.ConversationID != conversationID {
+		return nil, false, ErrClientMessageIDReused
 	}
+	return &msg, false, nil
+}
 
+// GetMessages возвращает сообщения беседы по порядку отправки. Доступ к беседе проверяет сервис
+func (r *MessageRepository) GetMessages(ctx context.Context, conversationID int) ([]models.Message, error) {
 	rows, err := r.DB.Query(ctx, `
-		SELECT id, conversation_id, sender_id, content, created_at 
-		FROM messages WHERE conversation_id = $1 ORDER BY created_at ASC`, conversationID)
+		SELECT id, conversation_id, sender_id, kind, target_user_id, content, COALESCE(client_message_id, ''), created_at
+		FROM messages WHERE conversation_id = $1 ORDER BY created_at ASC, id ASC`, conversationID)
 	if err != nil {
 		return nil, err
 	}
@@ -72,12 +145,12 @@ func (r *MessageRepository) GetMessages(ctx context.Context, conversationID int)
 	var messages []models.Message
 	for rows.Next() {
 		var msg models.Message
-		if err := rows.Scan(&msg.ID, &msg.ConversationID, &msg.SenderID, &msg.Content, &msg.CreatedAt); err != nil {
+		if err := rows.Scan(&msg.ID, &msg.ConversationID, &msg.SenderID, &msg.Kind, &msg.TargetUserID, &msg.Content, &msg.ClientMessageID, &msg.CreatedAt); err != nil {
 			return nil, err
 		}
 		messages = append(messages, msg)
 	}
-	return messages, nil
+	return messages, rows.Err()
 }
 
 func (r *MessageRepository) DeleteMessage(ctx context.Context, messageID int) error {
@@ -94,6 +167,144 @@ func (r *MessageRepository) DeleteMessage(ctx context.Context, messageID int) er
 	return err
 }
 
-func (r *MessageRepository) ConversationExists(ctx context.Context, conversationID int, exists *bool) error {
-	return r.DB.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM conversations WHERE id=$1)", conversationID).Scan(exists)
+// GetConversation возвращает беседу по ID без участников. Для групп User1ID и User2ID равны 0
+func (r *MessageRepository) GetConversation(ctx context.Context, conversationID int) (*models.Conversation, error) {
+	var conv models.Conversation
+	err := r.DB.QueryRow(ctx, `
+		SELECT id, COALESCE(user1_id, 0), COALESCE(user2_id, 0), is_group, COALESCE(title, ''), COALESCE(avatar_url, ''), created_at
+		FROM conversations WHERE id = $1`, conversationID).Scan(&conv.ID, &conv.User1ID, &conv.User2ID,
+		&conv.IsGroup, &conv.Title, &conv.AvatarURL, &conv.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &conv, nil
+}
+
+// GetMessage возвращает сообщение по ID. Для несуществующего сообщения возвращает pgx.ErrNoRows
+func (r *MessageRepository) GetMessage(ctx context.Context, messageID int) (*models.Message, error) {
+	var msg models.Message
+	err := r.DB.QueryRow(ctx, `
+		SELECT id, conversation_id, sender_id, kind, target_user_id, content, COALESCE(client_message_id, ''), created_at
+		FROM messages WHERE id = $1`, messageID).Scan(&msg.ID, &msg.ConversationID, &msg.SenderID, &msg.Kind, &msg.TargetUserID, &msg.Content, &msg.ClientMessageID, &msg.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// MarkRead отмечает сообщения беседы до messageID включительно прочитанными для участника и возвращает
+// его отметку прочтения. Отметка не сдвигается назад; второе значение сообщает, сдвинулась ли она
+func (r *MessageRepository) MarkRead(ctx context.Context, conversationID, userID, messageID int) (*models.ReadReceipt, bool, error) {
+	receipt := models.ReadReceipt{ConversationID: conversationID, UserID: userID}
+	err := r.DB.QueryRow(ctx, `
+		UPDATE conversation_participants SET last_read_message_id = $3, last_read_at = NOW()
+		WHERE conversation_id = $1 AND user_id = $2 AND last_read_message_id < $3
+		RETURNING last_read_message_id, last_read_at`, conversationID, userID, messageID).Scan(&receipt.LastReadMessageID, &receipt.ReadAt)
+	if err == nil {
+		return &receipt, true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, err
+	}
+
+	err = r.DB.QueryRow(ctx, `
+		SELECT last_read_message_id, last_read_at FROM conversation_participants
+		WHERE conversation_id = $1 AND user_id = $2`, conversationID, userID).Scan(&receipt.LastReadMessageID, &receipt.ReadAt)
+	if err != nil {
+		return nil, false, err
+	}
+	return &receipt, false, nil
+}
+
+// GetLastMessageID возвращает ID последнего сообщения беседы или 0 для пустой беседы
+func (r *MessageRepository) GetLastMessageID(ctx context.Context, conversationID int) (int, error) {
+	var id int
+	err := r.DB.QueryRow(ctx, "SELECT COALESCE(MAX(id), 0) FROM messages WHERE conversation_id = $1", conversationID).Scan(&id)
+	return id, err
+}
+
+// CountUnread возвращает количество непрочитанных сообщений других участников во всех беседах пользователя
+// и количество бесед, в которых они есть
+func (r *MessageRepository) CountUnread(ctx context.Context, userID int) (*models.UnreadCount, error) {
+	var count models.UnreadCount
+	err := r.DB.QueryRow(ctx, `
+		SELECT COALESCE(SUM(n), 0), COUNT(*) FILTER (WHERE n > 0)
+		FROM (
+			SELECT (SELECT COUNT(*) FROM messages m
+			        WHERE m.conversation_id = me.conversation_id AND m.sender_id <> $1 AND m.id > me.last_read_message_id) AS n
+			FROM conversation_participants me
+			WHERE me.user_id = $1
+		) unread`, userID).Scan(&count.Messages, &count.Conversations)
+	if err != nil {
+		return nil, err
+	}
+	return &count, nil
+}
+
+// GetInbox возвращает страницу бесед пользователя, последние активные первыми, с собеседником
+// личной беседы, последним сообщением и количеством непрочитанных. Второе значение — курсор следующей страницы или nil
+func (r *MessageRepository) GetInbox(ctx context.Context, userID int, opts InboxListOptions) ([]models.InboxEntry, *InboxCursor, error) {
+	args := []interface{}{userID, opts.Limit + 1, MessagePreviewLength}
+	after := ""
+	if opts.After != nil {
+		args = append(args, opts.After.LastActivityAt, opts.After.ID)
+		after = "AND (c.last_activity_at, c.id) < ($4, $5)"
+	}
+
+	rows, err := r.DB.Query(ctx, `
+		SELECT c.id, c.is_group, COALESCE(c.title, ''), COALESCE(c.avatar_url, ''),
+		       u.id, u.username, COALESCE(u.avatar_url, ''),
+		       m.id, m.sender_id, LEFT(m.content, $3), m.created_at,
+		       (SELECT COUNT(*) FROM messages um
+		        WHERE um.conversation_id = c.id AND um.sender_id <> $1 AND um.id > me.last_read_message_id),
+		       c.last_activity_at
+		FROM conversation_participants me
+		JOIN conversations c ON c.id = me.conversation_id
+		LEFT JOIN users u ON NOT c.is_group AND u.id = CASE WHEN c.user1_id = $1 THEN c.user2_id ELSE c.user1_id END
+		LEFT JOIN LATERAL (
+			SELECT id, sender_id, content, created_at FROM messages
+			WHERE conversation_id = c.id ORDER BY id DESC LIMIT 1
+		) m ON TRUE
+		WHERE me.user_id = $1 `+after+`
+		ORDER BY c.last_activity_at DESC, c.id DESC
+		LIMIT $2`, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.InboxEntry{}
+	for rows.Next() {
+		var (
+			entry                  models.InboxEntry
+			otherID                *int
+			otherName, otherAvatar *string
+			lastID, senderID       *int
+			content                *string
+			sentAt                 *time.Time
+		)
+		if err := rows.Scan(&entry.ID, &entry.IsGroup, &entry.Title, &entry.AvatarURL,
+			&otherID, &otherName, &otherAvatar,
+			&lastID, &senderID, &content, &sentAt, &entry.UnreadCount, &entry.LastActivityAt); err != nil {
+			return nil, nil, err
+		}
+		if otherID != nil {
+			entry.User = &models.ConversationUser{ID: *otherID, Username: *otherName, AvatarURL: *otherAvatar}
+		}
+		if lastID != nil {
+			entry.LastMessage = &models.MessagePreview{ID: *lastID, SenderID: *senderID, Content: *content, CreatedAt: *sentAt}
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	var next *InboxCursor
+	if len(entries) > opts.Limit {
+		entries = entries[:opts.Limit]
+		last := entries[len(entries)-1]
+		next = &InboxCursor{LastActivityAt: last.LastActivityAt, ID: last.ID}
+	}
+	return entries, next, nil
 }
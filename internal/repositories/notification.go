@@ -0,0 +1,162 @@
+package repositories
+
+import (
+	"InstaSpace/internal/models"
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type NotificationRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewNotificationRepository(db *pgxpool.Pool) *NotificationRepository {
+	return &NotificationRepository{DB: db}
+}
+
+type NotificationRepositoryInterface interface {
+	Upsert(ctx context.Context, n *models.Notification, groupKey string) (bool, error)
+	GetByID(ctx context.Context, notificationID int) (*models.Notification, error)
+	GetNotifications(ctx context.Context, userID, limit, offset int) ([]models.Notification, error)
+	CountUnread(ctx context.Context, userID int) (int, error)
+	MarkRead(ctx context.Context, userID int, ids []int) (int64, error)
+}
+
+// Upsert добавляет событие в непрочитанное уведомление группы groupKey или создает новое.
+// Возвращает false, если этот пользователь уже учтен в уведомлении
+func (r *NotificationRepository) Upsert(ctx context.Context, n *models.Notification, groupKey string) (bool, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO notifications (user_id, type, group_key, photo_id, comment_id, actor_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, group_key) WHERE read_at IS NULL
+		DO UPDATE SET user_id = EXCLUDED.user_id
+		RETURNING id`, n.UserID, n.Type, groupKey, n.PhotoID, n.CommentID, n.ActorID).Scan(&n.ID)
+	if err != nil {
+		return false, err
+	}
+
+	cmdTag, err := tx.Exec(ctx, `
+		INSERT INTO notification_actors (notification_id, actor_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`, n.ID, n.ActorID)
+	if err != nil {
+		return false, err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return false, tx.Commit(ctx)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE notifications
+		SET actor_id = $2, actors_count = actors_count + 1,
+		    comment_id = COALESCE($3, comment_id), updated_at = NOW()
+		WHERE id = $1`, n.ID, n.ActorID, n.CommentID)
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit(ctx)
+}
+
+const notificationColumns = `
+	n.id, n.user_id, n.type, n.photo_id, n.comment_id, v.actor_id, u.username,
+	v.actors_count, n.read_at IS NOT NULL, n.created_at, n.updated_at`
+
+// notificationActorVisible условие, что участник a не связан блокировкой с получателем уведомления n
+const notificationActorVisible = `
+	NOT EXISTS (
+		SELECT 1 FROM user_blocks b
+		WHERE (b.blocker_id = n.user_id AND b.blocked_id = a.actor_id)
+		   OR (b.blocker_id = a.actor_id AND b.blocked_id = n.user_id)
+	)`
+
+// notificationActorsJoin считает видимых участников уведомления и выбирает последнего из них.
+// Уведомление, все участники которого скрыты блокировкой, не возвращается
+const notificationActorsJoin = `
+	JOIN LATERAL (
+		SELECT COUNT(*) AS actors_count,
+		       (ARRAY_AGG(a.actor_id ORDER BY a.created_at DESC, a.actor_id DESC))[1] AS actor_id
+		FROM notification_actors a
+		WHERE a.notification_id = n.id AND` + notificationActorVisible + `
+	) v ON v.actors_count > 0
+	JOIN users u ON v.actor_id = u.id`
+
+func scanNotification(row interface{ Scan(dest ...any) error }) (*models.Notification, error) {
+	var n models.Notification
+	err := row.Scan(&n.ID, &n.UserID, &n.Type, &n.PhotoID, &n.CommentID, &n.ActorID, &n.ActorUsername,
+		&n.ActorsCount, &n.Read, &n.CreatedAt, &n.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// GetByID возвращает уведомление по ID
+func (r *NotificationRepository) GetByID(ctx context.Context, notificationID int) (*models.Notification, error) {
+	row := r.DB.QueryRow(ctx, `
+		SELECT`+notificationColumns+`
+		FROM notifications n`+notificationActorsJoin+`
+		WHERE n.id = $1`, notificationID)
+	return scanNotification(row)
+}
+
+// GetNotifications возвращает уведомления пользователя от новых к старым. Участники, связанные
+// с пользователем блокировкой, не учитываются
+func (r *NotificationRepository) GetNotifications(ctx context.Context, userID, limit, offset int) ([]models.Notification, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT`+notificationColumns+`
+		FROM notifications n`+notificationActorsJoin+`
+		WHERE n.user_id = $1
+		ORDER BY n.updated_at DESC, n.id DESC
+		LIMIT $2 OFFSET $3`, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []models.Notification{}
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, *n)
+	}
+
+	return notifications, rows.Err()
+}
+
+// CountUnread возвращает количество непрочитанных уведомлений, в которых есть участники без блокировки
+func (r *NotificationRepository) CountUnread(ctx context.Context, userID int) (int, error) {
+	var count int
+	err := r.DB.QueryRow(ctx, `
+		SELECT COUNT(*) FROM notifications n
+		WHERE n.user_id = $1 AND n.read_at IS NULL
+		  AND EXISTS (
+			SELECT 1 FROM notification_actors a
+			WHERE a.notification_id = n.id AND`+notificationActorVisible+`
+		  )`, userID).Scan(&count)
+	return count, err
+}
+
+// MarkRead отмечает прочитанными уведомления из ids или все, если ids пуст
+func (r *NotificationRepository) MarkRead(ctx context.Context, userID int, ids []int) (int64, error) {
+	if len(ids) == 0 {
+		ids = nil
+	}
+	cmdTag, err := r.DB.Exec(ctx, `
+		UPDATE notifications SET read_at = NOW()
+		WHERE user_id = $1 AND read_at IS NULL
+		  AND ($2::int[] IS NULL OR id = ANY($2))`, userID, ids)
+	if err != nil {
+		return 0, err
+	}
+	return cmdTag.RowsAffected(), nil
+}
@@ -3,7 +3,9 @@ package repositories
 import (
 	"InstaSpace/internal/models"
 	"context"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -17,6 +19,19 @@ func NewPhotoRepository(db *pgxpool.Pool) *PhotoRepository {
 
 type PhotoRepositoryInterface interface {
 	Create(photo *models.Photo) error
+	GetOwnerID(ctx context.Context, photoID int) (int, error)
+	GetFeed(ctx context.Context, userID, limit, offset int) ([]models.Photo, error)
+	GetCommentSettings(ctx context.Context, photoID int) (*models.CommentSettings, error)
+	UpdateCommentSettings(ctx context.Context, settings *models.CommentSettings) error
+	CreateAlbum(ctx context.Context, album *models.Album, photoIDs []int) error
+	GetAlbum(ctx context.Context, albumID, viewerID int) (*models.Album, error)
+	GetUserAlbums(ctx context.Context, ownerID, viewerID int) ([]models.Album, error)
+	GetAlbumPhotos(ctx context.Context, albumID, limit, offset int) ([]models.Photo, error)
+	UpdateAlbum(ctx context.Context, album *models.Album) error
+	DeleteAlbum(ctx context.Context, albumID, ownerID int) error
+	AddAlbumPhotos(ctx context.Context, albumID, ownerID int, photoIDs []int) error
+	RemoveAlbumPhoto(ctx context.Context, albumID, ownerID, photoID int) error
+	ReorderAlbum(ctx context.Context, albumID, ownerID int, photoIDs []int) error
 }
 
 func (r *PhotoRepository) Create(photo *models.Photo) error {
@@ -26,3 +41,80 @@ func (r *PhotoRepository) Create(photo *models.Photo) error {
 		RETURNING id`
 	return r.DB.QueryRow(context.Background(), query, photo.UserID, photo.URL, photo.Description).Scan(&photo.ID)
 }
+
+// GetOwnerID возвращает ID автора фотографии
+func (r *PhotoRepository) GetOwnerID(ctx context.Context, photoID int) (int, error) {
+	var ownerID int
+	err := r.DB.QueryRow(ctx, "SELECT user_id FROM photos WHERE id = $1", photoID).Scan(&ownerID)
+	return ownerID, err
+}
+
+// GetCommentSettings возвращает настройки комментариев фото. Для несуществующего фото возвращает pgx.ErrNoRows
+func (r *PhotoRepository) GetCommentSettings(ctx context.Context, photoID int) (*models.CommentSettings, error) {
+	settings := models.CommentSettings{PhotoID: photoID}
+	err := r.DB.QueryRow(ctx, `
+		SELECT user_id, comments_policy, hidden_keywords
+		FROM photos WHERE id = $1`, photoID).Scan(&settings.OwnerID, &settings.CommentsPolicy, &settings.HiddenKeywords)
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// UpdateCommentSettings сохраняет настройки комментариев, если фото принадлежит settings.OwnerID
+func (r *PhotoRepository) UpdateCommentSettings(ctx context.Context, settings *models.CommentSettings) error {
+	tag, err := r.DB.Exec(ctx, `
+		UPDATE photos SET comments_policy = $3, hidden_keywords = $4
+		WHERE id = $1 AND user_id = $2`,
+		settings.PhotoID, settings.OwnerID, settings.CommentsPolicy, settings.HiddenKeywords)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// GetFeed возвращает фотографии пользователя и его подписок без скрытых и заблокированных авторов
+func (r *PhotoRepository) GetFeed(ctx context.Context, userID, limit, offset int) ([]models.Photo, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT p.id, p.user_id, p.url, COALESCE(p.description, ''), p.created_at
+		FROM photos p
+		WHERE (p.user_id = $1 OR p.user_id IN (SELECT followee_id FROM follows WHERE follower_id = $1))
+		  AND NOT EXISTS (SELECT 1 FROM user_mutes m WHERE m.muter_id = $1 AND m.muted_id = p.user_id)
+		  AND NOT EXISTS (
+			SELECT 1 FROM user_blocks b
+			WHERE (b.blocker_id = $1 AND b.blocked_id = p.user_id)
+			   OR (b.blocker_id = p.user_id AND b.blocked_id = $1)
+		  )
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $2 OFFSET $3`, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	photos := []models.Photo{}
+	for rows.Next() {
+		var photo models.Photo
+		var createdAt time.Time
+		if err := rows.Scan(&photo.ID, &photo.UserID, &photo.URL, &photo.Description, &createdAt); err != nil {
+			return nil, err
+		}
+		photo.CreatedAt = createdAt.Format(time.RFC3339)
+		photos = append(photos, photo)
+	}
+
+	return photos, rows.Err()
+}
+
+// photoVisibleSQL возвращает SQL-условие, при котором фото с псевдонимом photo доступно пользователю viewer:
+// между зрителем и автором нет блокировки
+func photoVisibleSQL(photo, viewer string) string {
+	return `NOT EXISTS (
+		SELECT 1 FROM user_blocks b
+		WHERE (b.blocker_id = ` + viewer + ` AND b.blocked_id = ` + photo + `.user_id)
+		   OR (b.blocker_id = ` + photo + `.user_id AND b.blocked_id = ` + viewer + `)
+	)`
+}
@@ -4,7 +4,9 @@ import (
 	"InstaSpace/internal/models"
 	"context"
 	"errors"
+	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -21,96 +23,156 @@ var (
 	ErrInvalidUserID  = errors.New("invalid user ID")
 )
 
-func (r *LikeRepository) AddLike(ctx context.Context, photoID, userID int) error {
-	// Проверяем, существует ли запись
-	var exists bool
-	err := r.DB.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM photos WHERE id=$1)", photoID).Scan(&exists)
-	if err != nil || !exists {
-		return ErrInvalidPhotoID
-	}
-
-	err = r.DB.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM users WHERE id=$1)", userID).Scan(&exists)
-	if err != nil || !exists {
+// AddCommentLike добавляет лайк к комментарию. Вставка и счетчик меняются одним запросом,
+// поэтому повторный лайк ничего не меняет
+func (r *LikeRepository) AddCommentLike(ctx context.Context, commentID, userID int) error {
+	var inserted int
+	err := r.DB.QueryRow(ctx, `
+		WITH ins AS (
+			INSERT INTO comment_likes (comment_id, user_id)
+			SELECT $1, $2
+			WHERE EXISTS (SELECT 1 FROM comments WHERE id = $1 AND deleted_at IS NULL)
+			ON CONFLICT DO NOTHING
+			RETURNING comment_id
+		), upd AS (
+			UPDATE comments SET likes_count = likes_count + 1
+			WHERE id IN (SELECT comment_id FROM ins)
+		)
+		SELECT COUNT(*) FROM ins`, commentID, userID).Scan(&inserted)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
 		return ErrInvalidUserID
 	}
-
-	// Выполняем добавление лайка
-	tx, err := r.DB.Begin(ctx)
-	if err != nil {
+	if err != nil || inserted > 0 {
 		return err
 	}
-	defer tx.Rollback(ctx)
 
-	_, err = tx.Exec(ctx, "INSERT INTO photo_likes (photo_id, user_id) VALUES ($1, $2)", photoID, userID)
+	// Ничего не вставлено: либо лайк уже есть, либо комментария нет
+	var exists bool
+	err = r.DB.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM comments WHERE id = $1 AND deleted_at IS NULL)", commentID).Scan(&exists)
 	if err != nil {
 		return err
 	}
-
-	_, err = tx.Exec(ctx, "UPDATE photos SET likes_count = likes_count + 1 WHERE id = $1", photoID)
-	if err != nil {
-		return err
+	if !exists {
+		return ErrCommentNotFound
 	}
+	return nil
+}
 
-	return tx.Commit(ctx)
+// RemoveCommentLike удаляет лайк с комментария и уменьшает его счетчик одним запросом. Отсутствующий лайк ничего не меняет
+func (r *LikeRepository) RemoveCommentLike(ctx context.Context, commentID, userID int) error {
+	_, err := r.DB.Exec(ctx, `
+		WITH del AS (
+			DELETE FROM comment_likes WHERE comment_id = $1 AND user_id = $2
+			RETURNING comment_id
+		)
+		UPDATE comments SET likes_count = likes_count - 1
+		WHERE id IN (SELECT comment_id FROM del)`, commentID, userID)
+	return err
 }
 
-// RemoveLike удаляет лайк из таблицы photo_likes и уменьшает счетчик
-func (r *LikeRepository) RemoveLike(ctx context.Context, photoID, userID int) error {
-	var exists bool
-	err := r.DB.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM photo_likes WHERE photo_id=$1 AND user_id=$2)", photoID, userID).Scan(&exists)
-	if err != nil || !exists {
-		return errors.New("like not found")
-	}
+// LikerCursor позиция последнего пользователя страницы лайков для keyset-пагинации
+type LikerCursor struct {
+	Followed bool      `json:"f,omitempty"`
+	LikedAt  time.Time `json:"t"`
+	ID       int       `json:"id"`
+}
 
-	tx, err := r.DB.Begin(ctx)
-	if err != nil {
-		return err
+// LikerListOptions параметры выборки пользователей, поставивших лайк
+type LikerListOptions struct {
+	ViewerID int
+	Limit    int
+	After    *LikerCursor
+}
+
+// GetLikers возвращает страницу пользователей, поставивших сердечко на фото: сначала те, на кого подписан зритель,
+// затем остальные, новые лайки первыми. Пользователи, с которыми у зрителя есть блокировка, не показываются.
+// Второе значение — курсор следующей страницы или nil
+func (r *LikeRepository) GetLikers(ctx context.Context, photoID int, opts LikerListOptions) ([]models.Liker, *LikerCursor, error) {
+	args := []interface{}{photoID, opts.ViewerID, models.ReactionHeart, opts.Limit + 1}
+	cursorFilter := ""
+	if opts.After != nil {
+		followed := 0
+		if opts.After.Followed {
+			followed = 1
+		}
+		cursorFilter = "WHERE (l.followed, l.updated_at, l.user_id) < ($5, $6, $7)"
+		args = append(args, followed, opts.After.LikedAt, opts.After.ID)
 	}
-	defer tx.Rollback(ctx)
 
-	_, err = tx.Exec(ctx, "DELETE FROM photo_likes WHERE photo_id = $1 AND user_id = $2", photoID, userID)
+	rows, err := r.DB.Query(ctx, `
+		SELECT l.user_id, u.username, COALESCE(u.avatar_url, ''), l.followed = 1, l.updated_at
+		FROM (
+			SELECT pr.user_id, pr.updated_at,
+			       CASE WHEN EXISTS (
+			           SELECT 1 FROM follows f WHERE f.follower_id = $2 AND f.followee_id = pr.user_id
+			       ) THEN 1 ELSE 0 END AS followed
+			FROM photo_reactions pr
+			WHERE pr.photo_id = $1 AND pr.reaction = $3
+			  AND NOT EXISTS (
+			      SELECT 1 FROM user_blocks b
+			      WHERE (b.blocker_id = $2 AND b.blocked_id = pr.user_id)
+			         OR (b.blocker_id = pr.user_id AND b.blocked_id = $2)
+			  )
+		) l
+		JOIN users u ON u.id = l.user_id
+		`+cursorFilter+`
+		ORDER BY l.followed DESC, l.updated_at DESC, l.user_id DESC
+		LIMIT $4`, args...)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
+	defer rows.Close()
 
-	_, err = tx.Exec(ctx, "UPDATE photos SET likes_count = likes_count - 1 WHERE id = $1", photoID)
-	if err != nil {
-		return err
+	likers := []models.Liker{}
+	for rows.Next() {
+		var liker models.Liker
+		if err := rows.Scan(&liker.ID, &liker.Username, &liker.AvatarURL, &liker.FollowedByViewer, &liker.LikedAt); err != nil {
+			return nil, nil, err
+		}
+		likers = append(likers, liker)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
 	}
 
-	return tx.Commit(ctx)
+	if len(likers) <= opts.Limit {
+		return likers, nil, nil
+	}
+	likers = likers[:opts.Limit]
+	last := likers[len(likers)-1]
+	return likers, &LikerCursor{Followed: last.FollowedByViewer, LikedAt: last.LikedAt, ID: last.ID}, nil
 }
 
-// GetLikes возвращает количество лайков и список пользователей
-func (r *LikeRepository) GetLikes(ctx context.Context, photoID int) ([]models.User, error) {
+// GetViewerStates возвращает для каждого существующего фото из photoIDs количество лайков, лайк зрителя
+// и количество комментариев. Результат идет в порядке photoIDs, несуществующие фото и фото авторов,
+// связанных со зрителем блокировкой, пропускаются
+func (r *LikeRepository) GetViewerStates(ctx context.Context, photoIDs []int, viewerID int) ([]models.PhotoViewerState, error) {
 	rows, err := r.DB.Query(ctx, `
-		SELECT u.id, u.username FROM photo_likes pl
-		JOIN users u ON pl.user_id = u.id
-		WHERE pl.photo_id = $1
-	`, photoID)
+		WITH comment_counts AS (
+			SELECT photo_id, COUNT(*) AS n
+			FROM comments
+			WHERE photo_id = ANY($1) AND deleted_at IS NULL
+			GROUP BY photo_id
+		)
+		SELECT p.id, COALESCE(p.likes_count, 0), pr.user_id IS NOT NULL, COALESCE(cc.n, 0)
+		FROM photos p
+		LEFT JOIN photo_reactions pr ON pr.photo_id = p.id AND pr.user_id = $2 AND pr.reaction = $3
+		LEFT JOIN comment_counts cc ON cc.photo_id = p.id
+		WHERE p.id = ANY($1) AND `+photoVisibleSQL("p", "$2")+`
+		ORDER BY array_position($1, p.id)`, photoIDs, viewerID, models.ReactionHeart)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var users []models.User
+	states := []models.PhotoViewerState{}
 	for rows.Next() {
-		var user models.User
-		if err := rows.Scan(&user.ID, &user.Username); err != nil {
+		var state models.PhotoViewerState
+		if err := rows.Scan(&state.PhotoID, &state.LikesCount, &state.LikedByViewer, &state.CommentsCount); err != nil {
 			return nil, err
 		}
-		users = append(users, user)
-	}
-
-	return users, nil
-}
-
-// GetLikeCount возвращает количество лайков для фотографии
-func (r *LikeRepository) GetLikeCount(ctx context.Context, photoID int) (int, error) {
-	var count int
-	err := r.DB.QueryRow(ctx, "SELECT likes_count FROM photos WHERE id = $1", photoID).Scan(&count)
-	if err != nil {
-		return 0, err
+		states = append(states, state)
 	}
-	return count, nil
+	return states, rows.Err()
 }
@@ -0,0 +1,216 @@
+package repositories
+
+import (
+	"InstaSpace/internal/models"
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PresenceRecord сохраненное присутствие пользователя
+type PresenceRecord struct {
+	UserID       int
+	Online       bool
+	LastSeenAt   *time.Time
+	HideLastSeen bool
+}
+
+type PresenceRepositoryInterface interface {
+	AddConnection(ctx context.Context, instanceID string, userID int, ttl time.Duration) (bool, error)
+	RemoveConnection(ctx context.Context, instanceID string, userID int, ttl time.Duration, at time.Time) (bool, error)
+	Heartbeat(ctx context.Context, instanceID string, ttl time.Duration) error
+	GetPresence(ctx context.Context, viewerID int, userIDs []int, ttl time.Duration) ([]PresenceRecord, error)
+	GetContactIDs(ctx context.Context, userID int) ([]int, error)
+	GetSettings(ctx context.Context, userID int) (*models.PresenceSettings, error)
+	UpdateSettings(ctx context.Context, userID int, settings models.PresenceSettings) error
+}
+
+type PresenceRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewPresenceRepository(db *pgxpool.Pool) *PresenceRepository {
+	return &PresenceRepository{DB: db}
+}
+
+// contactCondition условие "пользователь u.id — собеседник $1": у них есть общая беседа и нет блокировки ни с одной стороны
+const contactCondition = `
+	EXISTS (
+		SELECT 1 FROM conversation_participants p1
+		JOIN conversation_participants p2 ON p2.conversation_id = p1.conversation_id
+		WHERE p1.user_id = $1 AND p2.user_id = u.id
+	)
+	AND NOT EXISTS (
+		SELECT 1 FROM user_blocks b
+		WHERE (b.blocker_id = $1 AND b.blocked_id = u.id)
+		   OR (b.blocker_id = u.id AND b.blocked_id = $1)
+	)`
+
+// onlineCondition условие "у пользователя u.id есть подключения к живому экземпляру сервера".
+// Экземпляр жив, пока продлевает свои записи чаще, чем раз в $ttl секунд
+func onlineCondition(ttl string) string {
+	return `EXISTS (
+		SELECT 1 FROM presence_connections pc
+		WHERE pc.user_id = u.id AND pc.heartbeat_at > NOW() - make_interval(secs => ` + ttl + `::float8)
+	)`
+}
+
+// lockUser блокирует пользователя до конца транзакции, чтобы подключения и отключения одного пользователя
+// на разных экземплярах учитывались по очереди
+func lockUser(ctx context.Context, tx pgx.Tx, userID int) error {
+	var id int
+	err := tx.QueryRow(ctx, "SELECT id FROM users WHERE id = $1 FOR UPDATE", userID).Scan(&id)
+	if err == pgx.ErrNoRows {
+		return ErrInvalidUserID
+	}
+	return err
+}
+
+// AddConnection учитывает новое подключение пользователя к экземпляру instanceID. Возвращает true,
+// если до него у пользователя не было подключений ни к одному живому экземпляру
+func (r *PresenceRepository) AddConnection(ctx context.Context, instanceID string, userID int, ttl time.Duration) (bool, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockUser(ctx, tx, userID); err != nil {
+		return false, err
+	}
+
+	var online bool
+	err = tx.QueryRow(ctx, "SELECT "+onlineCondition("$2")+" FROM users u WHERE u.id = $1", userID, ttl.Seconds()).Scan(&online)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO presence_connections (instance_id, user_id, connections)
+		VALUES ($1, $2, 1)
+		ON CONFLICT (instance_id, user_id)
+		DO UPDATE SET connections = presence_connections.connections + 1, heartbeat_at = NOW()`, instanceID, userID)
+	if err != nil {
+		return false, err
+	}
+	return !online, tx.Commit(ctx)
+}
+
+// RemoveConnection снимает с учета подключение пользователя к экземпляру instanceID. Если у пользователя
+// не осталось подключений ни к одному живому экземпляру, сохраняет at как время выхода из сети и возвращает true
+func (r *PresenceRepository) RemoveConnection(ctx context.Context, instanceID string, userID int, ttl time.Duration, at time.Time) (bool, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockUser(ctx, tx, userID); err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE presence_connections SET connections = connections - 1
+		WHERE instance_id = $1 AND user_id = $2`, instanceID, userID)
+	if err != nil {
+		return false, err
+	}
+	_, err = tx.Exec(ctx, `
+		DELETE FROM presence_connections
+		WHERE instance_id = $1 AND user_id = $2 AND connections <= 0`, instanceID, userID)
+	if err != nil {
+		return false, err
+	}
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE users u SET last_seen_at = $3
+		WHERE u.id = $1 AND NOT `+onlineCondition("$2"), userID, ttl.Seconds(), at.UTC())
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, tx.Commit(ctx)
+}
+
+// Heartbeat продлевает записи о подключениях экземпляра instanceID и удаляет записи экземпляров,
+// которые не продлевали их дольше ttl
+func (r *PresenceRepository) Heartbeat(ctx context.Context, instanceID string, ttl time.Duration) error {
+	if _, err := r.DB.Exec(ctx, "UPDATE presence_connections SET heartbeat_at = NOW() WHERE instance_id = $1", instanceID); err != nil {
+		return err
+	}
+	_, err := r.DB.Exec(ctx, `
+		DELETE FROM presence_connections
+		WHERE heartbeat_at < NOW() - make_interval(secs => $1::float8)`, ttl.Seconds())
+	return err
+}
+
+// GetPresence возвращает присутствие пользователей userIDs, которые видны viewerID: его самого
+// и собеседников. Остальные пропускаются. ttl — срок, после которого записи экземпляра не учитываются
+func (r *PresenceRepository) GetPresence(ctx context.Context, viewerID int, userIDs []int, ttl time.Duration) ([]PresenceRecord, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT u.id, `+onlineCondition("$3")+`, u.last_seen_at, u.hide_last_seen FROM users u
+		WHERE u.id = ANY($2) AND (u.id = $1 OR (`+contactCondition+`))
+		ORDER BY u.id`, viewerID, userIDs, ttl.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []PresenceRecord
+	for rows.Next() {
+		var rec PresenceRecord
+		if err := rows.Scan(&rec.UserID, &rec.Online, &rec.LastSeenAt, &rec.HideLastSeen); err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
+
+// GetContactIDs возвращает собеседников пользователя — тех, кому видно его присутствие
+func (r *PresenceRepository) GetContactIDs(ctx context.Context, userID int) ([]int, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT u.id FROM users u
+		WHERE u.id <> $1 AND `+contactCondition+`
+		ORDER BY u.id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// GetSettings возвращает настройки приватности присутствия пользователя
+func (r *PresenceRepository) GetSettings(ctx context.Context, userID int) (*models.PresenceSettings, error) {
+	var settings models.PresenceSettings
+	err := r.DB.QueryRow(ctx, "SELECT hide_last_seen FROM users WHERE id = $1", userID).Scan(&settings.HideLastSeen)
+	if err == pgx.ErrNoRows {
+		return nil, ErrInvalidUserID
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// UpdateSettings сохраняет настройки приватности присутствия пользователя
+func (r *PresenceRepository) UpdateSettings(ctx context.Context, userID int, settings models.PresenceSettings) error {
+	tag, err := r.DB.Exec(ctx, "UPDATE users SET hide_last_seen = $2 WHERE id = $1", userID, settings.HideLastSeen)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrInvalidUserID
+	}
+	return nil
+}
@@ -0,0 +1,290 @@
+package repositories
+
+import (
+	"InstaSpace/internal/models"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ReactionRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewReactionRepository(db *pgxpool.Pool) *ReactionRepository {
+	return &ReactionRepository{DB: db}
+}
+
+// ReactionTarget вид объекта, на который ставится реакция
+type ReactionTarget string
+
+const (
+	ReactionTargetPhoto   ReactionTarget = "photo"
+	ReactionTargetMessage ReactionTarget = "message"
+)
+
+var ErrInvalidTarget = errors.New("reaction target not found")
+
+type reactionTables struct {
+	reactions string
+	counts    string
+	column    string
+}
+
+var reactionTablesByTarget = map[ReactionTarget]reactionTables{
+	ReactionTargetPhoto:   {reactions: "photo_reactions", counts: "photo_reaction_counts", column: "photo_id"},
+	ReactionTargetMessage: {reactions: "message_reactions", counts: "message_reaction_counts", column: "message_id"},
+}
+
+type ReactionRepositoryInterface interface {
+	SetReaction(ctx context.Context, target ReactionTarget, targetID, userID int, reaction string) (string, error)
+	RemoveReaction(ctx context.Context, target ReactionTarget, targetID, userID int, reaction string) error
+	GetSummary(ctx context.Context, target ReactionTarget, targetID, viewerID int) (*models.ReactionSummary, error)
+	GetCount(ctx context.Context, target ReactionTarget, targetID int, reaction string) (int, error)
+	ReconcilePhotoCounts(ctx context.Context) ([]models.CountDrift, error)
+}
+
+// SetReaction ставит или меняет реакцию пользователя и обновляет счетчики. Повторная установка той же реакции ничего не меняет.
+// Возвращает прежнюю реакцию или пустую строку, если ее не было
+func (r *ReactionRepository) SetReaction(ctx context.Context, target ReactionTarget, targetID, userID int, reaction string) (string, error) {
+	t := reactionTablesByTarget[target]
+
+	// Вставка и счетчики в одном запросе: счетчик растет, только если ON CONFLICT действительно вставил строку
+	var inserted int
+	err := r.DB.QueryRow(ctx, `
+		WITH ins AS (
+			INSERT INTO `+t.reactions+` (`+t.column+`, user_id, reaction)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (`+t.column+`, user_id) DO NOTHING
+			RETURNING reaction
+		)`+countsCTE(target, "ins", 1)+`
+		SELECT COUNT(*) FROM ins`, targetID, userID, reaction).Scan(&inserted)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		if strings.Contains(pgErr.ConstraintName, "user") {
+			return "", ErrInvalidUserID
+		}
+		return "", ErrInvalidTarget
+	}
+	if err != nil || inserted > 0 {
+		return "", err
+	}
+
+	return r.changeReaction(ctx, target, targetID, userID, reaction)
+}
+
+// changeReaction меняет уже поставленную реакцию, перенося счетчик
+func (r *ReactionRepository) changeReaction(ctx context.Context, target ReactionTarget, targetID, userID int, reaction string) (string, error) {
+	t := reactionTablesByTarget[target]
+
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	// Блокируем строку реакции, чтобы параллельная смена не сбила счетчики
+	var previous string
+	err = tx.QueryRow(ctx, `
+		SELECT reaction FROM `+t.reactions+`
+		WHERE `+t.column+` = $1 AND user_id = $2
+		FOR UPDATE`, targetID, userID).Scan(&previous)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Реакцию сняли между вставкой и блокировкой — ставим заново
+		tx.Rollback(ctx)
+		return r.SetReaction(ctx, target, targetID, userID, reaction)
+	}
+	if err != nil {
+		return "", err
+	}
+	if previous == reaction {
+		return previous, nil
+	}
+
+	_, err = tx.Exec(ctx, `
+		WITH old AS (SELECT $4::varchar AS reaction),
+		upd AS (
+			UPDATE `+t.reactions+` SET reaction = $3, updated_at = NOW()
+			WHERE `+t.column+` = $1 AND user_id = $2
+			RETURNING reaction
+		)`+countsCTE(target, "old", -1)+countsCTE(target, "upd", 1)+`
+		SELECT 1`, targetID, userID, reaction, previous)
+	if err != nil {
+		return "", err
+	}
+	return previous, tx.Commit(ctx)
+}
+
+// RemoveReaction снимает реакцию пользователя. Если reaction не пустая, снимается только такая реакция.
+// Снятие отсутствующей реакции ничего не меняет
+func (r *ReactionRepository) RemoveReaction(ctx context.Context, target ReactionTarget, targetID, userID int, reaction string) error {
+	t := reactionTablesByTarget[target]
+
+	_, err := r.DB.Exec(ctx, `
+		WITH del AS (
+			DELETE FROM `+t.reactions+`
+			WHERE `+t.column+` = $1 AND user_id = $2 AND ($3 = '' OR reaction = $3)
+			RETURNING reaction
+		)`+countsCTE(target, "del", -1)+`
+		SELECT 1`, targetID, userID, reaction)
+	return err
+}
+
+// countsCTE возвращает продолжение WITH, меняющее счетчики на delta (1 или -1) для каждой строки из source.
+// photos.likes_count по-прежнему считает сердечки. Ожидает ID объекта в $1
+func countsCTE(target ReactionTarget, source string, delta int) string {
+	t := reactionTablesByTarget[target]
+
+	var cte string
+	if delta > 0 {
+		cte = `,
+		` + source + `_counts AS (
+			INSERT INTO ` + t.counts + ` (` + t.column + `, reaction, count)
+			SELECT $1, reaction, 1 FROM ` + source + `
+			ON CONFLICT (` + t.column + `, reaction) DO UPDATE SET count = ` + t.counts + `.count + 1
+		)`
+	} else {
+		// Уменьшаем через UPDATE: вставляемая строка с отрицательным count не прошла бы CHECK (count >= 0)
+		// еще до разбора ON CONFLICT. Строка счетчика для снимаемой реакции уже есть
+		cte = `,
+		` + source + `_counts AS (
+			UPDATE ` + t.counts + ` c SET count = c.count - 1
+			FROM ` + source + ` s
+			WHERE c.` + t.column + ` = $1 AND c.reaction = s.reaction
+		)`
+	}
+	if target == ReactionTargetPhoto {
+		cte += `,
+		` + source + `_likes AS (
+			UPDATE photos SET likes_count = likes_count + ` + strconv.Itoa(delta) + `
+			WHERE id = $1 AND EXISTS (SELECT 1 FROM ` + source + ` WHERE reaction = '` + models.ReactionHeart + `')
+		)`
+	}
+	return cte
+}
+
+// GetSummary возвращает ненулевые счетчики реакций и реакцию зрителя
+func (r *ReactionRepository) GetSummary(ctx context.Context, target ReactionTarget, targetID, viewerID int) (*models.ReactionSummary, error) {
+	t := reactionTablesByTarget[target]
+
+	rows, err := r.DB.Query(ctx, `
+		SELECT reaction, count FROM `+t.counts+`
+		WHERE `+t.column+` = $1 AND count > 0`, targetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summary := &models.ReactionSummary{Counts: map[string]int{}}
+	for rows.Next() {
+		var reaction string
+		var count int
+		if err := rows.Scan(&reaction, &count); err != nil {
+			return nil, err
+		}
+		summary.Counts[reaction] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if viewerID > 0 {
+		err = r.DB.QueryRow(ctx, `
+			SELECT reaction FROM `+t.reactions+`
+			WHERE `+t.column+` = $1 AND user_id = $2`, targetID, viewerID).Scan(&summary.ViewerReaction)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+	}
+	return summary, nil
+}
+
+// GetCount возвращает количество реакций одного вида
+func (r *ReactionRepository) GetCount(ctx context.Context, target ReactionTarget, targetID int, reaction string) (int, error) {
+	t := reactionTablesByTarget[target]
+
+	var count int
+	err := r.DB.QueryRow(ctx, `
+		SELECT COALESCE((SELECT count FROM `+t.counts+` WHERE `+t.column+` = $1 AND reaction = $2), 0)`,
+		targetID, reaction).Scan(&count)
+	return count, err
+}
+
+// ReconcilePhotoCounts пересчитывает photos.likes_count и photo_reaction_counts по photo_reactions
+// и возвращает исправленные расхождения. На время пересчета запись реакций блокируется
+func (r *ReactionRepository) ReconcilePhotoCounts(ctx context.Context) ([]models.CountDrift, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, "LOCK TABLE photo_reactions IN SHARE MODE"); err != nil {
+		return nil, err
+	}
+
+	drifts := []models.CountDrift{}
+	scan := func(rows pgx.Rows, counter string) error {
+		defer rows.Close()
+		for rows.Next() {
+			drift := models.CountDrift{Counter: counter}
+			if err := rows.Scan(&drift.PhotoID, &drift.Reaction, &drift.Stored, &drift.Actual); err != nil {
+				return err
+			}
+			drifts = append(drifts, drift)
+		}
+		return rows.Err()
+	}
+
+	rows, err := tx.Query(ctx, `
+		WITH actual AS (
+			SELECT p.id, COUNT(r.user_id) FILTER (WHERE r.reaction = $1)::int AS n
+			FROM photos p
+			LEFT JOIN photo_reactions r ON r.photo_id = p.id
+			GROUP BY p.id
+		), drift AS (
+			SELECT p.id, COALESCE(p.likes_count, 0) AS stored, a.n AS actual
+			FROM photos p JOIN actual a ON a.id = p.id
+			WHERE p.likes_count IS DISTINCT FROM a.n
+		), fix AS (
+			UPDATE photos p SET likes_count = d.actual FROM drift d WHERE p.id = d.id
+		)
+		SELECT id, $1, stored, actual FROM drift ORDER BY id`, models.ReactionHeart)
+	if err != nil {
+		return nil, err
+	}
+	if err = scan(rows, "likes_count"); err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.Query(ctx, `
+		WITH actual AS (
+			SELECT photo_id, reaction, COUNT(*)::int AS n
+			FROM photo_reactions
+			GROUP BY photo_id, reaction
+		), drift AS (
+			SELECT COALESCE(a.photo_id, c.photo_id) AS photo_id, COALESCE(a.reaction, c.reaction) AS reaction,
+			       COALESCE(c.count, 0) AS stored, COALESCE(a.n, 0) AS actual
+			FROM actual a
+			FULL JOIN photo_reaction_counts c ON c.photo_id = a.photo_id AND c.reaction = a.reaction
+			WHERE COALESCE(c.count, 0) <> COALESCE(a.n, 0)
+		), fix AS (
+			INSERT INTO photo_reaction_counts (photo_id, reaction, count)
+			SELECT photo_id, reaction, actual FROM drift
+			ON CONFLICT (photo_id, reaction) DO UPDATE SET count = EXCLUDED.count
+		)
+		SELECT photo_id, reaction, stored, actual FROM drift ORDER BY photo_id, reaction`)
+	if err != nil {
+		return nil, err
+	}
+	if err = scan(rows, "reaction_count"); err != nil {
+		return nil, err
+	}
+
+	return drifts, tx.Commit(ctx)
+}
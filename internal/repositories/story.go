@@ -0,0 +1,210 @@
+package repositories
+
+import (
+	"InstaSpace/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type StoryRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewStoryRepository(db *pgxpool.Pool) *StoryRepository {
+	return &StoryRepository{DB: db}
+}
+
+var ErrStoryNotFound = errors.New("story not found")
+
+type StoryRepositoryInterface interface {
+	Create(ctx context.Context, story *models.Story, ttl time.Duration) error
+	GetStory(ctx context.Context, storyID, viewerID int) (*models.Story, error)
+	GetUserStories(ctx context.Context, ownerID, viewerID int) ([]models.Story, error)
+	GetTray(ctx context.Context, viewerID int) ([]models.StoryTrayItem, error)
+	MarkSeen(ctx context.Context, storyID, viewerID int) error
+	GetViewers(ctx context.Context, storyID, authorID int) ([]models.StoryViewer, error)
+	Delete(ctx context.Context, storyID, authorID int) (string, error)
+	DeleteExpired(ctx context.Context) ([]string, error)
+}
+
+// Create сохраняет историю, которая исчезнет через ttl
+func (r *StoryRepository) Create(ctx context.Context, story *models.Story, ttl time.Duration) error {
+	err := r.DB.QueryRow(ctx, `
+		INSERT INTO stories (user_id, url, caption, expires_at)
+		VALUES ($1, $2, $3, NOW() + make_interval(secs => $4))
+		RETURNING id, created_at, expires_at`,
+		story.UserID, story.URL, story.Caption, ttl.Seconds()).Scan(&story.ID, &story.CreatedAt, &story.ExpiresAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return ErrInvalidUserID
+	}
+	return err
+}
+
+const storyColumns = `
+	s.id, s.user_id, s.url, s.caption, s.created_at, s.expires_at,
+	EXISTS (SELECT 1 FROM story_views v WHERE v.story_id = s.id AND v.viewer_id = $2),
+	CASE WHEN s.user_id = $2 THEN (SELECT COUNT(*) FROM story_views v WHERE v.story_id = s.id)::int END`
+
+func scanStory(row pgx.Row) (*models.Story, error) {
+	var story models.Story
+	err := row.Scan(&story.ID, &story.UserID, &story.URL, &story.Caption, &story.CreatedAt, &story.ExpiresAt,
+		&story.Seen, &story.ViewsCount)
+	if err != nil {
+		return nil, err
+	}
+	return &story, nil
+}
+
+// GetStory возвращает активную историю, если между зрителем и автором нет блокировки
+func (r *StoryRepository) GetStory(ctx context.Context, storyID, viewerID int) (*models.Story, error) {
+	story, err := scanStory(r.DB.QueryRow(ctx, `
+		SELECT `+storyColumns+`
+		FROM stories s
+		WHERE s.id = $1 AND s.expires_at > NOW() AND `+photoVisibleSQL("s", "$2"), storyID, viewerID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrStoryNotFound
+	}
+	return story, err
+}
+
+// GetUserStories возвращает активные истории пользователя от старых к новым
+func (r *StoryRepository) GetUserStories(ctx context.Context, ownerID, viewerID int) ([]models.Story, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT `+storyColumns+`
+		FROM stories s
+		WHERE s.user_id = $1 AND s.expires_at > NOW() AND `+photoVisibleSQL("s", "$2")+`
+		ORDER BY s.created_at, s.id`, ownerID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stories := []models.Story{}
+	for rows.Next() {
+		story, err := scanStory(rows)
+		if err != nil {
+			return nil, err
+		}
+		stories = append(stories, *story)
+	}
+	return stories, rows.Err()
+}
+
+// GetTray возвращает подписки зрителя с активными историями: сначала с непросмотренными, затем по свежести.
+// Скрытые и заблокированные авторы исключаются
+func (r *StoryRepository) GetTray(ctx context.Context, viewerID int) ([]models.StoryTrayItem, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT s.user_id, u.username, COALESCE(u.avatar_url, ''), COUNT(*), MAX(s.created_at),
+		       bool_or(NOT EXISTS (SELECT 1 FROM story_views v WHERE v.story_id = s.id AND v.viewer_id = $1)) AS has_unseen
+		FROM stories s
+		JOIN follows f ON f.followee_id = s.user_id AND f.follower_id = $1
+		JOIN users u ON u.id = s.user_id
+		WHERE s.expires_at > NOW()
+		  AND NOT EXISTS (SELECT 1 FROM user_mutes m WHERE m.muter_id = $1 AND m.muted_id = s.user_id)
+		  AND `+photoVisibleSQL("s", "$1")+`
+		GROUP BY s.user_id, u.username, u.avatar_url
+		ORDER BY has_unseen DESC, MAX(s.created_at) DESC, s.user_id`, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tray := []models.StoryTrayItem{}
+	for rows.Next() {
+		var item models.StoryTrayItem
+		if err := rows.Scan(&item.UserID, &item.Username, &item.AvatarURL, &item.StoriesCount, &item.LatestAt, &item.HasUnseen); err != nil {
+			return nil, err
+		}
+		tray = append(tray, item)
+	}
+	return tray, rows.Err()
+}
+
+// MarkSeen отмечает историю просмотренной. Повторный просмотр ничего не меняет
+func (r *StoryRepository) MarkSeen(ctx context.Context, storyID, viewerID int) error {
+	_, err := r.DB.Exec(ctx, `
+		INSERT INTO story_views (story_id, viewer_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`, storyID, viewerID)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return ErrStoryNotFound
+	}
+	return err
+}
+
+// GetViewers возвращает просмотревших историю, последние первыми. Доступно только автору
+func (r *StoryRepository) GetViewers(ctx context.Context, storyID, authorID int) ([]models.StoryViewer, error) {
+	var exists bool
+	err := r.DB.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM stories WHERE id = $1 AND user_id = $2)`, storyID, authorID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrStoryNotFound
+	}
+
+	rows, err := r.DB.Query(ctx, `
+		SELECT v.viewer_id, u.username, COALESCE(u.avatar_url, ''), v.viewed_at
+		FROM story_views v
+		JOIN users u ON u.id = v.viewer_id
+		WHERE v.story_id = $1
+		ORDER BY v.viewed_at DESC, v.viewer_id DESC`, storyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	viewers := []models.StoryViewer{}
+	for rows.Next() {
+		var viewer models.StoryViewer
+		if err := rows.Scan(&viewer.UserID, &viewer.Username, &viewer.AvatarURL, &viewer.ViewedAt); err != nil {
+			return nil, err
+		}
+		viewers = append(viewers, viewer)
+	}
+	return viewers, rows.Err()
+}
+
+// Delete удаляет историю автора и возвращает путь к ее файлу
+func (r *StoryRepository) Delete(ctx context.Context, storyID, authorID int) (string, error) {
+	var url string
+	err := r.DB.QueryRow(ctx, `
+		DELETE FROM stories WHERE id = $1 AND user_id = $2
+		RETURNING url`, storyID, authorID).Scan(&url)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrStoryNotFound
+	}
+	return url, err
+}
+
+// DeleteExpired удаляет истекшие истории и возвращает пути к их файлам.
+// Истории из подборок остаются, пока их не уберут из всех подборок
+func (r *StoryRepository) DeleteExpired(ctx context.Context) ([]string, error) {
+	rows, err := r.DB.Query(ctx, `
+		DELETE FROM stories s
+		WHERE s.expires_at <= NOW()
+		  AND NOT EXISTS (SELECT 1 FROM highlight_items hi WHERE hi.story_id = s.id)
+		RETURNING s.url`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var urls []string
+	for rows.Next() {
+		var url string
+		if err := rows.Scan(&url); err != nil {
+			return nil, err
+		}
+		urls = append(urls, url)
+	}
+	return urls, rows.Err()
+}
@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrWSTicketNotFound = errors.New("ws ticket not found or expired")
+
+type WSTicketRepositoryInterface interface {
+	Create(ctx context.Context, ticketHash string, userID int, sessionExpiresAt time.Time, ttl time.Duration) error
+	Consume(ctx context.Context, ticketHash string) (int, time.Time, error)
+}
+
+type WSTicketRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewWSTicketRepository(db *pgxpool.Pool) *WSTicketRepository {
+	return &WSTicketRepository{DB: db}
+}
+
+// Create сохраняет билет, действующий ttl, и заодно удаляет истекшие. Время истечения сессии хранится в UTC,
+// нулевое время означает сессию без срока
+func (r *WSTicketRepository) Create(ctx context.Context, ticketHash string, userID int, sessionExpiresAt time.Time, ttl time.Duration) error {
+	var session *time.Time
+	if !sessionExpiresAt.IsZero() {
+		utc := sessionExpiresAt.UTC()
+		session = &utc
+	}
+
+	if _, err := r.DB.Exec(ctx, "DELETE FROM ws_tickets WHERE expires_at < NOW()"); err != nil {
+		return err
+	}
+
+	_, err := r.DB.Exec(ctx, `
+		INSERT INTO ws_tickets (ticket_hash, user_id, session_expires_at, expires_at)
+		VALUES ($1, $2, $3, NOW() + make_interval(secs => $4))`, ticketHash, userID, session, ttl.Seconds())
+	return err
+}
+
+// Consume удаляет билет и возвращает ID его пользователя и время истечения сессии, нулевое для сессии без срока.
+// Билет используется один раз: для повторного или истекшего возвращает ErrWSTicketNotFound
+func (r *WSTicketRepository) Consume(ctx context.Context, ticketHash string) (int, time.Time, error) {
+	var (
+		userID           int
+		sessionExpiresAt *time.Time
+		valid            bool
+	)
+	err := r.DB.QueryRow(ctx, `
+		DELETE FROM ws_tickets WHERE ticket_hash = $1
+		RETURNING user_id, session_expires_at, expires_at >= NOW()`, ticketHash).Scan(&userID, &sessionExpiresAt, &valid)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && !valid) {
+		return 0, time.Time{}, ErrWSTicketNotFound
+	}
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	if sessionExpiresAt == nil {
+		return userID, time.Time{}, nil
+	}
+	return userID, *sessionExpiresAt, nil
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"InstaSpace/internal/models"
+	"InstaSpace/internal/repositories"
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidAlbumTitle      = errors.New("album title must be from 1 to 100 characters")
+	ErrInvalidAlbumVisibility = errors.New("invalid visibility: expected public, followers or private")
+	ErrInvalidAlbumPhotoIDs   = errors.New("photo_ids must contain positive IDs")
+)
+
+const (
+	MaxAlbumTitleLength = 100
+
+	DefaultAlbumPhotosLimit = 50
+	MaxAlbumPhotosLimit     = 100
+)
+
+type AlbumServiceInterface interface {
+	CreateAlbum(ctx context.Context, userID int, req models.AlbumRequest) (*models.Album, error)
+	GetAlbum(ctx context.Context, albumID, viewerID int) (*models.Album, error)
+	GetUserAlbums(ctx context.Context, ownerID, viewerID int) ([]models.Album, error)
+	GetAlbumPhotos(ctx context.Context, albumID, viewerID, limit, offset int) ([]models.Photo, error)
+	UpdateAlbum(ctx context.Context, albumID, userID int, req models.AlbumRequest) (*models.Album, error)
+	DeleteAlbum(ctx context.Context, albumID, userID int) error
+	AddAlbumPhotos(ctx context.Context, albumID, userID int, photoIDs []int) error
+	RemoveAlbumPhoto(ctx context.Context, albumID, userID, photoID int) error
+	ReorderAlbum(ctx context.Context, albumID, userID int, photoIDs []int) error
+}
+
+type AlbumService struct {
+	Photos   repositories.PhotoRepositoryInterface
+	Mentions MentionServiceInterface
+}
+
+func NewAlbumService(photos repositories.PhotoRepositoryInterface, mentions MentionServiceInterface) *AlbumService {
+	return &AlbumService{Photos: photos, Mentions: mentions}
+}
+
+// albumFromRequest проверяет поля альбома. Пустая видимость означает public
+func albumFromRequest(userID int, req models.AlbumRequest) (*models.Album, error) {
+	album := &models.Album{
+		UserID:       userID,
+		Title:        strings.TrimSpace(req.Title),
+		Description:  strings.TrimSpace(req.Description),
+		Visibility:   req.Visibility,
+		CoverPhotoID: req.CoverPhotoID,
+	}
+	if album.Title == "" || utf8.RuneCountInString(album.Title) > MaxAlbumTitleLength {
+		return nil, ErrInvalidAlbumTitle
+	}
+	switch album.Visibility {
+	case "":
+		album.Visibility = models.AlbumPublic
+	case models.AlbumPublic, models.AlbumFollowers, models.AlbumPrivate:
+	default:
+		return nil, ErrInvalidAlbumVisibility
+	}
+	return album, nil
+}
+
+// uniquePhotoIDs убирает повторы, сохраняя порядок первого вхождения
+func uniquePhotoIDs(photoIDs []int) ([]int, error) {
+	if len(photoIDs) > repositories.MaxAlbumPhotos {
+		return nil, repositories.ErrAlbumFull
+	}
+
+	seen := make(map[int]bool, len(photoIDs))
+	unique := make([]int, 0, len(photoIDs))
+	for _, id := range photoIDs {
+		if id <= 0 {
+			return nil, ErrInvalidAlbumPhotoIDs
+		}
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique, nil
+}
+
+// CreateAlbum создает альбом из собственных фото пользователя
+func (s *AlbumService) CreateAlbum(ctx context.Context, userID int, req models.AlbumRequest) (*models.Album, error) {
+	album, err := albumFromRequest(userID, req)
+	if err != nil {
+		return nil, err
+	}
+	photoIDs, err := uniquePhotoIDs(req.PhotoIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Photos.CreateAlbum(ctx, album, photoIDs); err != nil {
+		return nil, err
+	}
+	return s.Photos.GetAlbum(ctx, album.ID, userID)
+}
+
+// GetAlbum возвращает альбом, если зритель может его видеть
+func (s *AlbumService) GetAlbum(ctx context.Context, albumID, viewerID int) (*models.Album, error) {
+	return s.Photos.GetAlbum(ctx, albumID, viewerID)
+}
+
+// GetUserAlbums возвращает альбомы пользователя, которые может видеть зритель
+func (s *AlbumService) GetUserAlbums(ctx context.Context, ownerID, viewerID int) ([]models.Album, error) {
+	return s.Photos.GetUserAlbums(ctx, ownerID, viewerID)
+}
+
+// GetAlbumPhotos возвращает фото альбома в порядке автора, если зритель может видеть альбом
+func (s *AlbumService) GetAlbumPhotos(ctx context.Context, albumID, viewerID, limit, offset int) ([]models.Photo, error) {
+	if _, err := s.Photos.GetAlbum(ctx, albumID, viewerID); err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = DefaultAlbumPhotosLimit
+	}
+	if limit > MaxAlbumPhotosLimit {
+		limit = MaxAlbumPhotosLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	photos, err := s.Photos.GetAlbumPhotos(ctx, albumID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Mentions.AttachToPhotos(ctx, photos); err != nil {
+		return nil, err
+	}
+	return photos, nil
+}
+
+// UpdateAlbum меняет название, описание, видимость и обложку альбома автора
+func (s *AlbumService) UpdateAlbum(ctx context.Context, albumID, userID int, req models.AlbumRequest) (*models.Album, error) {
+	album, err := albumFromRequest(userID, req)
+	if err != nil {
+		return nil, err
+	}
+	album.ID = albumID
+
+	if err := s.Photos.UpdateAlbum(ctx, album); err != nil {
+		return nil, err
+	}
+	return s.Photos.GetAlbum(ctx, albumID, userID)
+}
+
+// DeleteAlbum удаляет альбом автора
+func (s *AlbumService) DeleteAlbum(ctx context.Context, albumID, userID int) error {
+	return s.Photos.DeleteAlbum(ctx, albumID, userID)
+}
+
+// AddAlbumPhotos добавляет собственные фото автора в конец альбома
+func (s *AlbumService) AddAlbumPhotos(ctx context.Context, albumID, userID int, photoIDs []int) error {
+	unique, err := uniquePhotoIDs(photoIDs)
+	if err != nil {
+		return err
+	}
+	if len(unique) == 0 {
+		return ErrInvalidAlbumPhotoIDs
+	}
+	return s.Photos.AddAlbumPhotos(ctx, albumID, userID, unique)
+}
+
+// RemoveAlbumPhoto убирает фото из альбома автора
+func (s *AlbumService) RemoveAlbumPhoto(ctx context.Context, albumID, userID, photoID int) error {
+	return s.Photos.RemoveAlbumPhoto(ctx, albumID, userID, photoID)
+}
+
+// ReorderAlbum задает новый порядок всех фото альбома
+func (s *AlbumService) ReorderAlbum(ctx context.Context, albumID, userID int, photoIDs []int) error {
+	unique, err := uniquePhotoIDs(photoIDs)
+	if err != nil {
+		return err
+	}
+	if len(unique) != len(photoIDs) {
+		return repositories.ErrInvalidAlbumOrder
+	}
+	return s.Photos.ReorderAlbum(ctx, albumID, userID, unique)
+}
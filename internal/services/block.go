@@ -0,0 +1,81 @@
+package services
+
+import (
+	"InstaSpace/internal/models"
+	"InstaSpace/internal/repositories"
+	"context"
+	"errors"
+)
+
+var (
+	ErrUserBlocked = errors.New("user is blocked")
+	ErrSelfAction  = errors.New("action on yourself is not allowed")
+)
+
+type BlockServiceInterface interface {
+	Block(ctx context.Context, blockerID, blockedID int) error
+	Unblock(ctx context.Context, blockerID, blockedID int) error
+	GetBlocked(ctx context.Context, blockerID int) ([]models.User, error)
+	Mute(ctx context.Context, muterID, mutedID int) error
+	Unmute(ctx context.Context, muterID, mutedID int) error
+	GetMuted(ctx context.Context, muterID int) ([]models.User, error)
+}
+
+type BlockService struct {
+	Repo repositories.BlockRepositoryInterface
+}
+
+func NewBlockService(repo repositories.BlockRepositoryInterface) *BlockService {
+	return &BlockService{Repo: repo}
+}
+
+// Block блокирует пользователя: он больше не сможет подписаться, написать или прокомментировать
+func (s *BlockService) Block(ctx context.Context, blockerID, blockedID int) error {
+	if blockerID == blockedID {
+		return ErrSelfAction
+	}
+	return s.Repo.Block(ctx, blockerID, blockedID)
+}
+
+// Unblock снимает блокировку
+func (s *BlockService) Unblock(ctx context.Context, blockerID, blockedID int) error {
+	return s.Repo.Unblock(ctx, blockerID, blockedID)
+}
+
+// GetBlocked возвращает список заблокированных пользователей
+func (s *BlockService) GetBlocked(ctx context.Context, blockerID int) ([]models.User, error) {
+	return s.Repo.GetBlocked(ctx, blockerID)
+}
+
+// Mute скрывает публикации пользователя из ленты
+func (s *BlockService) Mute(ctx context.Context, muterID, mutedID int) error {
+	if muterID == mutedID {
+		return ErrSelfAction
+	}
+	return s.Repo.Mute(ctx, muterID, mutedID)
+}
+
+// Unmute возвращает публикации пользователя в ленту
+func (s *BlockService) Unmute(ctx context.Context, muterID, mutedID int) error {
+	return s.Repo.Unmute(ctx, muterID, mutedID)
+}
+
+// GetMuted возвращает список скрытых пользователей
+func (s *BlockService) GetMuted(ctx context.Context, muterID int) ([]models.User, error) {
+	return s.Repo.GetMuted(ctx, muterID)
+}
+
+// checkNotBlocked возвращает ErrUserBlocked, если между пользователями есть блокировка
+func checkNotBlocked(ctx context.Context, blocks repositories.BlockRepositoryInterface, userA, userB int) error {
+	if userA == userB {
+		return nil
+	}
+	blocked, err := blocks.BlockExists(ctx, userA, userB)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrUserBlocked
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"InstaSpace/internal/models"
+	"InstaSpace/internal/repositories"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrInvalidCollectionName = errors.New("collection name must be from 1 to 100 characters and not reserved")
+
+const (
+	MaxCollectionNameLength = 100
+
+	DefaultSavedPhotosLimit = 30
+	MaxSavedPhotosLimit     = 100
+)
+
+// SavedPhotoListParams параметры запроса страницы фото коллекции
+type SavedPhotoListParams struct {
+	Cursor string
+	Limit  int
+}
+
+type CollectionServiceInterface interface {
+	GetCollections(ctx context.Context, userID int) ([]models.Collection, error)
+	CreateCollection(ctx context.Context, userID int, name string) (*models.Collection, error)
+	DeleteCollection(ctx context.Context, collectionID, userID int) error
+	SavePhoto(ctx context.Context, userID, photoID, collectionID int) error
+	UnsavePhoto(ctx context.Context, userID, photoID, collectionID int) error
+	GetCollectionPhotos(ctx context.Context, collectionID, userID int, params SavedPhotoListParams) (*models.SavedPhotosPage, error)
+}
+
+type CollectionService struct {
+	Repo     repositories.CollectionRepositoryInterface
+	Photos   repositories.PhotoRepositoryInterface
+	Blocks   repositories.BlockRepositoryInterface
+	Mentions MentionServiceInterface
+}
+
+func NewCollectionService(repo repositories.CollectionRepositoryInterface, photos repositories.PhotoRepositoryInterface, blocks repositories.BlockRepositoryInterface, mentions MentionServiceInterface) *CollectionService {
+	return &CollectionService{Repo: repo, Photos: photos, Blocks: blocks, Mentions: mentions}
+}
+
+// GetCollections возвращает коллекции пользователя, при необходимости создавая «Saved»
+func (s *CollectionService) GetCollections(ctx context.Context, userID int) ([]models.Collection, error) {
+	if _, err := s.Repo.EnsureDefault(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.Repo.GetCollections(ctx, userID)
+}
+
+// CreateCollection создает именованную коллекцию. Название «Saved» зарезервировано за коллекцией по умолчанию
+func (s *CollectionService) CreateCollection(ctx context.Context, userID int, name string) (*models.Collection, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > MaxCollectionNameLength || strings.EqualFold(name, models.DefaultCollectionName) {
+		return nil, ErrInvalidCollectionName
+	}
+
+	collection := &models.Collection{UserID: userID, Name: name}
+	if err := s.Repo.Create(ctx, collection); err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
+
+// DeleteCollection удаляет именованную коллекцию пользователя
+func (s *CollectionService) DeleteCollection(ctx context.Context, collectionID, userID int) error {
+	return s.Repo.Delete(ctx, collectionID, userID)
+}
+
+// SavePhoto сохраняет фото в коллекцию. collectionID = 0 означает «Saved».
+// Сохранить фото автора, с которым есть блокировка, нельзя
+func (s *CollectionService) SavePhoto(ctx context.Context, userID, photoID, collectionID int) error {
+	ownerID, err := s.Photos.GetOwnerID(ctx, photoID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repositories.ErrInvalidPhotoID
+	}
+	if err != nil {
+		return err
+	}
+	if err := checkNotBlocked(ctx, s.Blocks, userID, ownerID); err != nil {
+		return err
+	}
+
+	if collectionID == 0 {
+		if collectionID, err = s.Repo.EnsureDefault(ctx, userID); err != nil {
+			return err
+		}
+	}
+	return s.Repo.SavePhoto(ctx, collectionID, userID, photoID)
+}
+
+// UnsavePhoto убирает фото из коллекции. collectionID = 0 убирает фото из всех коллекций пользователя
+func (s *CollectionService) UnsavePhoto(ctx context.Context, userID, photoID, collectionID int) error {
+	return s.Repo.UnsavePhoto(ctx, collectionID, userID, photoID)
+}
+
+// GetCollectionPhotos возвращает страницу доступных фото коллекции
+func (s *CollectionService) GetCollectionPhotos(ctx context.Context, collectionID, userID int, params SavedPhotoListParams) (*models.SavedPhotosPage, error) {
+	opts := repositories.SavedPhotoListOptions{Limit: params.Limit}
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultSavedPhotosLimit
+	}
+	if opts.Limit > MaxSavedPhotosLimit {
+		opts.Limit = MaxSavedPhotosLimit
+	}
+	if params.Cursor != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(params.Cursor)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		var cursor repositories.SavedPhotoCursor
+		if err := json.Unmarshal(raw, &cursor); err != nil || cursor.PhotoID <= 0 {
+			return nil, ErrInvalidCursor
+		}
+		opts.After = &cursor
+	}
+
+	saved, next, err := s.Repo.GetSavedPhotos(ctx, collectionID, userID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	photos := make([]models.Photo, len(saved))
+	for i := range saved {
+		photos[i] = saved[i].Photo
+	}
+	if err := s.Mentions.AttachToPhotos(ctx, photos); err != nil {
+		return nil, err
+	}
+	for i := range saved {
+		saved[i].Entities = photos[i].Entities
+	}
+
+	page := &models.SavedPhotosPage{Photos: saved}
+	if next != nil {
+		raw, err := json.Marshal(next)
+		if err != nil {
+			return nil, err
+		}
+		page.NextCursor = base64.RawURLEncoding.EncodeToString(raw)
+	}
+	return page, nil
+}
@@ -4,42 +4,255 @@ import (
 	"InstaSpace/internal/models"
 	"InstaSpace/internal/repositories"
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
 )
 
 type CommentService struct {
-	Repo repositories.CommentRepositoryInterface
+	Repo          repositories.CommentRepositoryInterface
+	Photos        repositories.PhotoRepositoryInterface
+	Blocks        repositories.BlockRepositoryInterface
+	Follows       repositories.FollowRepositoryInterface
+	Notifications NotificationServiceInterface
+	Mentions      MentionServiceInterface
+	// EditWindow время после создания, в течение которого комментарий можно править. 0 — без ограничений
+	EditWindow time.Duration
 }
 
-func NewCommentService(repo repositories.CommentRepositoryInterface) *CommentService {
-	return &CommentService{Repo: repo}
+func NewCommentService(repo repositories.CommentRepositoryInterface, photos repositories.PhotoRepositoryInterface, blocks repositories.BlockRepositoryInterface, follows repositories.FollowRepositoryInterface, notifications NotificationServiceInterface, mentions MentionServiceInterface) *CommentService {
+	return &CommentService{Repo: repo, Photos: photos, Blocks: blocks, Follows: follows, Notifications: notifications, Mentions: mentions, EditWindow: DefaultCommentEditWindow}
 }
 
 type CommentServiceInterface interface {
 	CreateComment(ctx context.Context, comment *models.Comment) (int, error)
-	GetCommentsByPhotoID(ctx context.Context, photoID int) ([]models.Comment, error)
+	GetCommentsByPhotoID(ctx context.Context, photoID int, params CommentListParams) (*models.CommentPage, error)
+	GetReplies(ctx context.Context, commentID int, params CommentListParams) (*models.CommentPage, error)
 	UpdateComment(ctx context.Context, comment *models.Comment) error
 	DeleteComment(ctx context.Context, commentID, userID int) error
+	GetCommentSettings(ctx context.Context, photoID, userID int) (*models.CommentSettings, error)
+	UpdateCommentSettings(ctx context.Context, settings *models.CommentSettings) error
+	PinComment(ctx context.Context, commentID, userID int) error
+	UnpinComment(ctx context.Context, commentID, userID int) error
+	GetCommentHistory(ctx context.Context, commentID, userID int) (*models.CommentHistory, error)
 }
 
 var (
-	ErrInvalidForeignKey = errors.New("violates foreign key constraint")
+	ErrInvalidForeignKey    = errors.New("violates foreign key constraint")
+	ErrInvalidParentComment = errors.New("parent comment not found on this photo")
+	ErrInvalidCommentSort   = errors.New("invalid sort: expected newest, oldest or top")
+	ErrInvalidCursor        = errors.New("invalid cursor")
+	ErrCommentsDisabled     = errors.New("comments are turned off for this photo")
+	ErrCommentsFollowers    = errors.New("only followers can comment on this photo")
+	ErrNotPhotoOwner        = errors.New("only the photo owner can change comment settings")
+	ErrInvalidCommentPolicy = errors.New("invalid comments_policy: expected everyone, followers or off")
+	ErrInvalidKeywords      = errors.New("too many or too long hidden keywords")
+	ErrHistoryForbidden     = errors.New("only the photo owner or a moderator can view edit history")
 )
 
+const (
+	DefaultCommentsLimit = 20
+	MaxCommentsLimit     = 100
+
+	MaxHiddenKeywords      = 100
+	MaxHiddenKeywordLength = 50
+
+	DefaultCommentEditWindow = 15 * time.Minute
+)
+
+// CommentListParams параметры запроса страницы комментариев
+type CommentListParams struct {
+	ViewerID int
+	Sort     string
+	Cursor   string
+	Limit    int
+}
+
 func (s *CommentService) CreateComment(ctx context.Context, comment *models.Comment) (int, error) {
 	if comment.UserID == 0 || comment.PhotoID == 0 || comment.Content == "" {
 		return 0, errors.New("missing required fields")
 	}
 
-	return s.Repo.CreateComment(ctx, comment)
+	settings, err := s.Photos.GetCommentSettings(ctx, comment.PhotoID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrInvalidForeignKey
+	}
+	if err != nil {
+		return 0, err
+	}
+	ownerID := settings.OwnerID
+	if err := checkNotBlocked(ctx, s.Blocks, comment.UserID, ownerID); err != nil {
+		return 0, err
+	}
+	if err := s.checkCommentPolicy(ctx, settings, comment.UserID); err != nil {
+		return 0, err
+	}
+
+	if comment.ParentID != nil {
+		if err := s.resolveParent(ctx, comment); err != nil {
+			return 0, err
+		}
+	}
+
+	id, err := s.Repo.CreateComment(ctx, comment)
+	if err != nil {
+		return 0, err
+	}
+
+	s.Notifications.Notify(ctx, ownerID, comment.UserID, models.NotificationComment, comment.PhotoID, id)
+
+	s.Mentions.SaveCommentMentions(ctx, comment)
+	return id, nil
+}
+
+// checkCommentPolicy проверяет, может ли пользователь комментировать фото. Автор фото может всегда
+func (s *CommentService) checkCommentPolicy(ctx context.Context, settings *models.CommentSettings, userID int) error {
+	if userID == settings.OwnerID {
+		return nil
+	}
+
+	switch settings.CommentsPolicy {
+	case models.CommentsOff:
+		return ErrCommentsDisabled
+	case models.CommentsFollowers:
+		following, err := s.Follows.IsFollowing(ctx, userID, settings.OwnerID)
+		if err != nil {
+			return err
+		}
+		if !following {
+			return ErrCommentsFollowers
+		}
+	}
+	return nil
 }
 
-func (s *CommentService) GetCommentsByPhotoID(ctx context.Context, photoID int) ([]models.Comment, error) {
+// GetCommentsByPhotoID возвращает страницу комментариев верхнего уровня. По умолчанию сначала новые.
+// Закрепленные комментарии идут в начале первой страницы
+func (s *CommentService) GetCommentsByPhotoID(ctx context.Context, photoID int, params CommentListParams) (*models.CommentPage, error) {
 	if photoID <= 0 {
 		return nil, errors.New("invalid photo ID")
 	}
+	if params.Sort == "" {
+		params.Sort = repositories.CommentSortNewest
+	}
+
+	opts, err := commentListOptions(params)
+	if err != nil {
+		return nil, err
+	}
+
+	comments, next, err := s.Repo.GetCommentsByPhotoID(ctx, photoID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.After == nil {
+		pinned, err := s.Repo.GetPinnedComments(ctx, photoID, params.ViewerID)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(pinned, comments...)
+	}
+	return s.commentPage(ctx, comments, next)
+}
+
+// GetReplies возвращает страницу ответов на комментарий верхнего уровня. По умолчанию сначала старые
+func (s *CommentService) GetReplies(ctx context.Context, commentID int, params CommentListParams) (*models.CommentPage, error) {
+	if commentID <= 0 {
+		return nil, errors.New("invalid comment ID")
+	}
+	if params.Sort == "" {
+		params.Sort = repositories.CommentSortOldest
+	}
+
+	opts, err := commentListOptions(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.Repo.GetCommentByID(ctx, commentID); err != nil {
+		return nil, err
+	}
+
+	replies, next, err := s.Repo.GetReplies(ctx, commentID, opts)
+	if err != nil {
+		return nil, err
+	}
+	return s.commentPage(ctx, replies, next)
+}
+
+func (s *CommentService) commentPage(ctx context.Context, comments []models.Comment, next *repositories.CommentCursor) (*models.CommentPage, error) {
+	if err := s.Mentions.AttachToComments(ctx, comments); err != nil {
+		return nil, err
+	}
+
+	page := &models.CommentPage{Comments: comments}
+	if next != nil {
+		raw, err := json.Marshal(next)
+		if err != nil {
+			return nil, err
+		}
+		page.NextCursor = base64.RawURLEncoding.EncodeToString(raw)
+	}
+	return page, nil
+}
+
+func commentListOptions(params CommentListParams) (repositories.CommentListOptions, error) {
+	opts := repositories.CommentListOptions{
+		ViewerID: params.ViewerID,
+		Sort:     params.Sort,
+		Limit:    params.Limit,
+	}
+
+	switch opts.Sort {
+	case repositories.CommentSortNewest, repositories.CommentSortOldest, repositories.CommentSortTop:
+	default:
+		return opts, ErrInvalidCommentSort
+	}
 
-	return s.Repo.GetCommentsByPhotoID(ctx, photoID)
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultCommentsLimit
+	}
+	if opts.Limit > MaxCommentsLimit {
+		opts.Limit = MaxCommentsLimit
+	}
+
+	if params.Cursor != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(params.Cursor)
+		if err != nil {
+			return opts, ErrInvalidCursor
+		}
+		var cursor repositories.CommentCursor
+		if err := json.Unmarshal(raw, &cursor); err != nil || cursor.ID <= 0 {
+			return opts, ErrInvalidCursor
+		}
+		opts.After = &cursor
+	}
+	return opts, nil
+}
+
+// resolveParent проверяет родительский комментарий. Ответ на ответ прикрепляется к комментарию верхнего уровня
+func (s *CommentService) resolveParent(ctx context.Context, comment *models.Comment) error {
+	parent, err := s.Repo.GetCommentByID(ctx, *comment.ParentID)
+	if errors.Is(err, repositories.ErrCommentNotFound) {
+		return ErrInvalidParentComment
+	}
+	if err != nil {
+		return err
+	}
+	if parent.PhotoID != comment.PhotoID || parent.Deleted {
+		return ErrInvalidParentComment
+	}
+
+	if parent.ParentID != nil {
+		comment.ParentID = parent.ParentID
+	}
+	return nil
 }
 
 func (s *CommentService) UpdateComment(ctx context.Context, comment *models.Comment) error {
@@ -47,7 +260,12 @@ func (s *CommentService) UpdateComment(ctx context.Context, comment *models.Comm
 		return errors.New("invalid comment data")
 	}
 
-	return s.Repo.UpdateComment(ctx, comment)
+	if err := s.Repo.UpdateComment(ctx, comment, s.EditWindow); err != nil {
+		return err
+	}
+
+	s.Mentions.SaveCommentMentions(ctx, comment)
+	return nil
 }
 
 func (s *CommentService) DeleteComment(ctx context.Context, commentID, userID int) error {
@@ -57,3 +275,98 @@ func (s *CommentService) DeleteComment(ctx context.Context, commentID, userID in
 
 	return s.Repo.DeleteComment(ctx, commentID, userID)
 }
+
+// GetCommentSettings возвращает настройки комментариев фото его автору
+func (s *CommentService) GetCommentSettings(ctx context.Context, photoID, userID int) (*models.CommentSettings, error) {
+	settings, err := s.Photos.GetCommentSettings(ctx, photoID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repositories.ErrInvalidPhotoID
+	}
+	if err != nil {
+		return nil, err
+	}
+	if settings.OwnerID != userID {
+		return nil, ErrNotPhotoOwner
+	}
+	return settings, nil
+}
+
+// UpdateCommentSettings меняет политику комментирования и список скрываемых слов.
+// Слова приводятся к нижнему регистру, пустые и повторы отбрасываются
+func (s *CommentService) UpdateCommentSettings(ctx context.Context, settings *models.CommentSettings) error {
+	switch settings.CommentsPolicy {
+	case models.CommentsEveryone, models.CommentsFollowers, models.CommentsOff:
+	default:
+		return ErrInvalidCommentPolicy
+	}
+
+	keywords := []string{}
+	seen := make(map[string]bool)
+	for _, keyword := range settings.HiddenKeywords {
+		keyword = strings.ToLower(strings.TrimSpace(keyword))
+		if keyword == "" || seen[keyword] {
+			continue
+		}
+		if utf8.RuneCountInString(keyword) > MaxHiddenKeywordLength {
+			return ErrInvalidKeywords
+		}
+		seen[keyword] = true
+		keywords = append(keywords, keyword)
+	}
+	if len(keywords) > MaxHiddenKeywords {
+		return ErrInvalidKeywords
+	}
+	settings.HiddenKeywords = keywords
+
+	if _, err := s.GetCommentSettings(ctx, settings.PhotoID, settings.OwnerID); err != nil {
+		return err
+	}
+	return s.Photos.UpdateCommentSettings(ctx, settings)
+}
+
+// PinComment закрепляет комментарий к фото пользователя
+func (s *CommentService) PinComment(ctx context.Context, commentID, userID int) error {
+	if commentID <= 0 || userID <= 0 {
+		return errors.New("invalid IDs")
+	}
+	return s.Repo.PinComment(ctx, commentID, userID)
+}
+
+// UnpinComment снимает закрепление комментария к фото пользователя
+func (s *CommentService) UnpinComment(ctx context.Context, commentID, userID int) error {
+	if commentID <= 0 || userID <= 0 {
+		return errors.New("invalid IDs")
+	}
+	return s.Repo.UnpinComment(ctx, commentID, userID)
+}
+
+// GetCommentHistory возвращает все версии комментария автору фото или модератору
+func (s *CommentService) GetCommentHistory(ctx context.Context, commentID, userID int) (*models.CommentHistory, error) {
+	if commentID <= 0 || userID <= 0 {
+		return nil, errors.New("invalid IDs")
+	}
+
+	comment, err := s.Repo.GetCommentByID(ctx, commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed, err := s.Repo.CanModerate(ctx, commentID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrHistoryForbidden
+	}
+
+	revisions, err := s.Repo.GetRevisions(ctx, commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	current := models.CommentRevision{Content: comment.Content, CreatedAt: comment.CreatedAt}
+	if len(revisions) > 0 {
+		current.CreatedAt = comment.UpdatedAt
+	}
+	return &models.CommentHistory{CommentID: commentID, Revisions: append(revisions, current)}, nil
+}
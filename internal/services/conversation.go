@@ -0,0 +1,200 @@
+package services
+
+import (
+	"InstaSpace/internal/models"
+	"InstaSpace/internal/repositories"
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrInvalidGroupTitle    = errors.New("group title must be from 1 to 100 characters")
+	ErrInvalidParticipants  = errors.New("user_ids must contain positive IDs of other users")
+	ErrInvalidRole          = errors.New("invalid role: expected admin or member")
+	ErrNotGroupConversation = errors.New("operation is only available in group conversations")
+	ErrNotGroupAdmin        = errors.New("only group admins can do this")
+)
+
+const MaxGroupTitleLength = 100
+
+func groupFromRequest(req models.GroupRequest) (*models.Conversation, error) {
+	conv := &models.Conversation{
+		IsGroup:   true,
+		Title:     strings.TrimSpace(req.Title),
+		AvatarURL: strings.TrimSpace(req.AvatarURL),
+	}
+	if conv.Title == "" || utf8.RuneCountInString(conv.Title) > MaxGroupTitleLength {
+		return nil, ErrInvalidGroupTitle
+	}
+	return conv, nil
+}
+
+// otherUserIDs убирает повторы и ID самого пользователя, сохраняя порядок первого вхождения
+func otherUserIDs(userID int, ids []int) ([]int, error) {
+	if len(ids) > repositories.MaxGroupParticipants {
+		return nil, repositories.ErrGroupFull
+	}
+
+	seen := map[int]bool{userID: true}
+	unique := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if id <= 0 {
+			return nil, ErrInvalidParticipants
+		}
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique, nil
+}
+
+// groupForAdmin возвращает групповую беседу, если пользователь в ней администратор
+func (s *MessageService) groupForAdmin(ctx context.Context, conversationID, userID int) (*models.Conversation, error) {
+	conv, role, err := s.conversationForParticipant(ctx, conversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !conv.IsGroup {
+		return nil, ErrNotGroupConversation
+	}
+	if role != models.ParticipantAdmin {
+		return nil, ErrNotGroupAdmin
+	}
+	return conv, nil
+}
+
+// checkCanAdd проверяет, что между пользователем и каждым из добавляемых нет блокировки
+func (s *MessageService) checkCanAdd(ctx context.Context, userID int, ids []int) error {
+	for _, id := range ids {
+		if err := checkNotBlocked(ctx, s.Blocks, userID, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// groupError переводит исчезновение группы во время изменения в ErrConversationNotFound,
+// а потерю прав администратора — в ErrNotGroupAdmin
+func groupError(err error) error {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return ErrConversationNotFound
+	case errors.Is(err, repositories.ErrNotGroupAdmin):
+		return ErrNotGroupAdmin
+	}
+	return err
+}
+
+// GetConversation возвращает беседу с участниками ее участнику
+func (s *MessageService) GetConversation(ctx context.Context, conversationID, userID int) (*models.Conversation, error) {
+	conv, _, err := s.conversationForParticipant(ctx, conversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	conv.Participants, err = s.Repo.GetParticipants(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	return conv, nil
+}
+
+// CreateGroup создает групповую беседу, в которой создатель становится администратором
+func (s *MessageService) CreateGroup(ctx context.Context, userID int, req models.GroupRequest) (*models.Conversation, error) {
+	conv, err := groupFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	members, err := otherUserIDs(userID, req.UserIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return nil, ErrInvalidParticipants
+	}
+	if err := s.checkCanAdd(ctx, userID, members); err != nil {
+		return nil, err
+	}
+
+	if err := s.Repo.CreateGroup(ctx, conv, userID, members); err != nil {
+		return nil, err
+	}
+	return s.GetConversation(ctx, conv.ID, userID)
+}
+
+// UpdateGroup меняет название и аватар группы. Доступно администраторам
+func (s *MessageService) UpdateGroup(ctx context.Context, conversationID, userID int, req models.GroupRequest) (*models.Conversation, error) {
+	update, err := groupFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.groupForAdmin(ctx, conversationID, userID); err != nil {
+		return nil, err
+	}
+
+	update.ID = conversationID
+	if err := s.Repo.UpdateGroup(ctx, update, userID); err != nil {
+		return nil, groupError(err)
+	}
+	return s.GetConversation(ctx, conversationID, userID)
+}
+
+// AddParticipants добавляет пользователей в группу. Доступно администраторам
+func (s *MessageService) AddParticipants(ctx context.Context, conversationID, userID int, userIDs []int) error {
+	ids, err := otherUserIDs(userID, userIDs)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return ErrInvalidParticipants
+	}
+	if _, err := s.groupForAdmin(ctx, conversationID, userID); err != nil {
+		return err
+	}
+	if err := s.checkCanAdd(ctx, userID, ids); err != nil {
+		return err
+	}
+
+	_, err = s.Repo.AddParticipants(ctx, conversationID, userID, ids)
+	return groupError(err)
+}
+
+// RemoveParticipant исключает участника из группы. Исключать других могут администраторы,
+// исключение самого себя означает выход из группы
+func (s *MessageService) RemoveParticipant(ctx context.Context, conversationID, userID, targetID int) error {
+	if targetID == userID {
+		return s.LeaveGroup(ctx, conversationID, userID)
+	}
+	if _, err := s.groupForAdmin(ctx, conversationID, userID); err != nil {
+		return err
+	}
+	return groupError(s.Repo.RemoveParticipant(ctx, conversationID, userID, targetID))
+}
+
+// LeaveGroup выводит пользователя из группы. Из личной беседы выйти нельзя
+func (s *MessageService) LeaveGroup(ctx context.Context, conversationID, userID int) error {
+	conv, _, err := s.conversationForParticipant(ctx, conversationID, userID)
+	if err != nil {
+		return err
+	}
+	if !conv.IsGroup {
+		return ErrNotGroupConversation
+	}
+	return groupError(s.Repo.RemoveParticipant(ctx, conversationID, userID, userID))
+}
+
+// SetParticipantRole назначает или снимает администратора группы. Доступно администраторам
+func (s *MessageService) SetParticipantRole(ctx context.Context, conversationID, userID, targetID int, role string) error {
+	if role != models.ParticipantAdmin && role != models.ParticipantMember {
+		return ErrInvalidRole
+	}
+	if _, err := s.groupForAdmin(ctx, conversationID, userID); err != nil {
+		return err
+	}
+	return groupError(s.Repo.SetParticipantRole(ctx, conversationID, userID, targetID, role))
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"InstaSpace/internal/pubsub"
+	"context"
+	"encoding/json"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// eventPublishTimeout сколько ждать публикации события
+const eventPublishTimeout = 5 * time.Second
+
+// EventBus доставляет события подключениям пользователя на всех экземплярах сервера. Сервисы публикуют
+// события в pub/sub, а каждый экземпляр передает полученные события своему хабу
+type EventBus struct {
+	PubSub pubsub.PubSub
+	Logger *zap.Logger
+}
+
+func NewEventBus(ps pubsub.PubSub, logger *zap.Logger) *EventBus {
+	return &EventBus{PubSub: ps, Logger: logger}
+}
+
+// SendToUser публикует событие для пользователя. Ошибки только логируются, как и при локальной доставке
+func (b *EventBus) SendToUser(userID int, eventType string, payload interface{}) {
+	b.SendToUsers([]int{userID}, eventType, payload)
+}
+
+// SendToUsers публикует одно событие для всех userIDs, чтобы рассылка участникам беседы или собеседникам
+// стоила одной публикации, а не одной на получателя. Ошибки только логируются
+func (b *EventBus) SendToUsers(userIDs []int, eventType string, payload interface{}) {
+	if len(userIDs) == 0 {
+		return
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		b.Logger.Error("Не удалось закодировать событие", zap.Ints("userIDs", userIDs), zap.String("type", eventType), zap.Error(err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), eventPublishTimeout)
+	defer cancel()
+	if err := b.PubSub.Publish(ctx, pubsub.Event{UserIDs: userIDs, Type: eventType, Payload: raw}); err != nil {
+		b.Logger.Error("Не удалось опубликовать событие", zap.Ints("userIDs", userIDs), zap.String("type", eventType), zap.Error(err))
+	}
+}
+
+// Run подписывает локальный хаб на события всех экземпляров. Возвращается, когда подписка активна,
+// доставка продолжается до отмены ctx
+func (b *EventBus) Run(ctx context.Context, local NotificationPublisher) error {
+	return b.PubSub.Subscribe(ctx, func(event pubsub.Event) {
+		local.SendToUsers(event.UserIDs, event.Type, event.Payload)
+	})
+}
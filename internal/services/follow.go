@@ -0,0 +1,43 @@
+package services
+
+import (
+	"InstaSpace/internal/models"
+	"InstaSpace/internal/repositories"
+	"context"
+)
+
+type FollowServiceInterface interface {
+	Follow(ctx context.Context, followerID, followeeID int) error
+	Unfollow(ctx context.Context, followerID, followeeID int) error
+}
+
+type FollowService struct {
+	Repo          repositories.FollowRepositoryInterface
+	Blocks        repositories.BlockRepositoryInterface
+	Notifications NotificationServiceInterface
+}
+
+func NewFollowService(repo repositories.FollowRepositoryInterface, blocks repositories.BlockRepositoryInterface, notifications NotificationServiceInterface) *FollowService {
+	return &FollowService{Repo: repo, Blocks: blocks, Notifications: notifications}
+}
+
+// Follow подписывает пользователя на другого, если между ними нет блокировки
+func (s *FollowService) Follow(ctx context.Context, followerID, followeeID int) error {
+	if followerID == followeeID {
+		return ErrSelfAction
+	}
+	if err := checkNotBlocked(ctx, s.Blocks, followerID, followeeID); err != nil {
+		return err
+	}
+	if err := s.Repo.Follow(ctx, followerID, followeeID); err != nil {
+		return err
+	}
+
+	s.Notifications.Notify(ctx, followeeID, followerID, models.NotificationFollow, 0, 0)
+	return nil
+}
+
+// Unfollow отменяет подписку
+func (s *FollowService) Unfollow(ctx context.Context, followerID, followeeID int) error {
+	return s.Repo.Unfollow(ctx, followerID, followeeID)
+}
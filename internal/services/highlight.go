@@ -0,0 +1,157 @@
+package services
+
+import (
+	"InstaSpace/internal/models"
+	"InstaSpace/internal/repositories"
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidHighlightTitle = errors.New("highlight title must be from 1 to 50 characters")
+	ErrInvalidHighlightRef   = errors.New("each item must reference exactly one positive photo_id or story_id")
+)
+
+const MaxHighlightTitleLength = 50
+
+type HighlightServiceInterface interface {
+	CreateHighlight(ctx context.Context, userID int, req models.HighlightRequest) (*models.Highlight, error)
+	GetHighlight(ctx context.Context, highlightID, viewerID int) (*models.Highlight, error)
+	GetUserHighlights(ctx context.Context, ownerID, viewerID int) ([]models.Highlight, error)
+	UpdateHighlight(ctx context.Context, highlightID, userID int, req models.HighlightRequest) (*models.Highlight, error)
+	DeleteHighlight(ctx context.Context, highlightID, userID int) error
+	AddHighlightItems(ctx context.Context, highlightID, userID int, items []models.HighlightItemRef) error
+	RemoveHighlightItem(ctx context.Context, highlightID, userID, itemID int) error
+	ReorderHighlightItems(ctx context.Context, highlightID, userID int, itemIDs []int) error
+	ReorderHighlights(ctx context.Context, userID int, highlightIDs []int) error
+}
+
+type HighlightService struct {
+	Repo repositories.HighlightRepositoryInterface
+}
+
+func NewHighlightService(repo repositories.HighlightRepositoryInterface) *HighlightService {
+	return &HighlightService{Repo: repo}
+}
+
+func highlightTitle(title string) (string, error) {
+	title = strings.TrimSpace(title)
+	if title == "" || utf8.RuneCountInString(title) > MaxHighlightTitleLength {
+		return "", ErrInvalidHighlightTitle
+	}
+	return title, nil
+}
+
+// uniqueHighlightItems проверяет ссылки и убирает повторы, сохраняя порядок первого вхождения
+func uniqueHighlightItems(items []models.HighlightItemRef) ([]models.HighlightItemRef, error) {
+	if len(items) > repositories.MaxHighlightItems {
+		return nil, repositories.ErrHighlightFull
+	}
+
+	seen := make(map[models.HighlightItemRef]bool, len(items))
+	unique := make([]models.HighlightItemRef, 0, len(items))
+	for _, item := range items {
+		if item.PhotoID < 0 || item.StoryID < 0 || (item.PhotoID == 0) == (item.StoryID == 0) {
+			return nil, ErrInvalidHighlightRef
+		}
+		if !seen[item] {
+			seen[item] = true
+			unique = append(unique, item)
+		}
+	}
+	return unique, nil
+}
+
+// uniqueIDs проверяет, что ID положительные и не повторяются
+func uniqueIDs(ids []int) bool {
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if id <= 0 || seen[id] {
+			return false
+		}
+		seen[id] = true
+	}
+	return true
+}
+
+// CreateHighlight создает подборку из собственных фото и историй пользователя
+func (s *HighlightService) CreateHighlight(ctx context.Context, userID int, req models.HighlightRequest) (*models.Highlight, error) {
+	title, err := highlightTitle(req.Title)
+	if err != nil {
+		return nil, err
+	}
+	items, err := uniqueHighlightItems(req.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	highlight := &models.Highlight{UserID: userID, Title: title}
+	if err := s.Repo.Create(ctx, highlight, items); err != nil {
+		return nil, err
+	}
+	return s.Repo.GetHighlight(ctx, highlight.ID, userID)
+}
+
+// GetHighlight возвращает подборку с элементами
+func (s *HighlightService) GetHighlight(ctx context.Context, highlightID, viewerID int) (*models.Highlight, error) {
+	return s.Repo.GetHighlight(ctx, highlightID, viewerID)
+}
+
+// GetUserHighlights возвращает подборки пользователя в порядке профиля
+func (s *HighlightService) GetUserHighlights(ctx context.Context, ownerID, viewerID int) ([]models.Highlight, error) {
+	return s.Repo.GetUserHighlights(ctx, ownerID, viewerID)
+}
+
+// UpdateHighlight меняет название и обложку подборки автора
+func (s *HighlightService) UpdateHighlight(ctx context.Context, highlightID, userID int, req models.HighlightRequest) (*models.Highlight, error) {
+	title, err := highlightTitle(req.Title)
+	if err != nil {
+		return nil, err
+	}
+
+	highlight := &models.Highlight{ID: highlightID, UserID: userID, Title: title, CoverItemID: req.CoverItemID}
+	if err := s.Repo.Update(ctx, highlight); err != nil {
+		return nil, err
+	}
+	return s.Repo.GetHighlight(ctx, highlightID, userID)
+}
+
+// DeleteHighlight удаляет подборку автора
+func (s *HighlightService) DeleteHighlight(ctx context.Context, highlightID, userID int) error {
+	return s.Repo.Delete(ctx, highlightID, userID)
+}
+
+// AddHighlightItems добавляет собственные фото и истории автора в конец подборки
+func (s *HighlightService) AddHighlightItems(ctx context.Context, highlightID, userID int, items []models.HighlightItemRef) error {
+	unique, err := uniqueHighlightItems(items)
+	if err != nil {
+		return err
+	}
+	if len(unique) == 0 {
+		return ErrInvalidHighlightRef
+	}
+	return s.Repo.AddItems(ctx, highlightID, userID, unique)
+}
+
+// RemoveHighlightItem убирает элемент из подборки автора
+func (s *HighlightService) RemoveHighlightItem(ctx context.Context, highlightID, userID, itemID int) error {
+	return s.Repo.RemoveItem(ctx, highlightID, userID, itemID)
+}
+
+// ReorderHighlightItems задает новый порядок всех элементов подборки
+func (s *HighlightService) ReorderHighlightItems(ctx context.Context, highlightID, userID int, itemIDs []int) error {
+	if !uniqueIDs(itemIDs) {
+		return repositories.ErrInvalidHighlightOrder
+	}
+	return s.Repo.ReorderItems(ctx, highlightID, userID, itemIDs)
+}
+
+// ReorderHighlights задает новый порядок всех подборок в профиле
+func (s *HighlightService) ReorderHighlights(ctx context.Context, userID int, highlightIDs []int) error {
+	if !uniqueIDs(highlightIDs) {
+		return repositories.ErrInvalidHighlightOrder
+	}
+	return s.Repo.ReorderHighlights(ctx, userID, highlightIDs)
+}
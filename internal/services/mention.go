@@ -0,0 +1,187 @@
+package services
+
+import (
+	"InstaSpace/internal/models"
+	"InstaSpace/internal/repositories"
+	"context"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+// MaxMentionsPerText ограничивает количество упоминаний, которые разбираются в одном тексте
+const MaxMentionsPerText = 20
+
+var mentionPattern = regexp.MustCompile(`@([A-Za-z0-9_][A-Za-z0-9_.]*)`)
+
+// ParseMentions находит упоминания вида @username. UserID в результате не заполнен,
+// Offset и Length указаны в символах. Точки в конце имени не считаются его частью
+func ParseMentions(text string) []models.Mention {
+	var mentions []models.Mention
+	for _, loc := range mentionPattern.FindAllStringSubmatchIndex(text, -1) {
+		if len(mentions) == MaxMentionsPerText {
+			break
+		}
+
+		start := loc[0]
+		if start > 0 {
+			prev, _ := utf8.DecodeLastRuneInString(text[:start])
+			if prev == '_' || prev == '@' || unicode.IsLetter(prev) || unicode.IsDigit(prev) {
+				continue
+			}
+		}
+
+		username := strings.TrimRight(text[loc[2]:loc[3]], ".")
+		if username == "" {
+			continue
+		}
+
+		mentions = append(mentions, models.Mention{
+			Username: username,
+			Offset:   utf8.RuneCountInString(text[:start]),
+			Length:   utf8.RuneCountInString(username) + 1,
+		})
+	}
+	return mentions
+}
+
+type MentionServiceInterface interface {
+	SaveCommentMentions(ctx context.Context, comment *models.Comment)
+	SavePhotoMentions(ctx context.Context, photo *models.Photo)
+	AttachToComments(ctx context.Context, comments []models.Comment) error
+	AttachToPhotos(ctx context.Context, photos []models.Photo) error
+}
+
+type MentionService struct {
+	Repo          repositories.MentionRepositoryInterface
+	Notifications NotificationServiceInterface
+	Logger        *zap.Logger
+}
+
+func NewMentionService(repo repositories.MentionRepositoryInterface, notifications NotificationServiceInterface, logger *zap.Logger) *MentionService {
+	return &MentionService{Repo: repo, Notifications: notifications, Logger: logger}
+}
+
+// SaveCommentMentions сохраняет упоминания комментария, заполняет comment.Entities и уведомляет упомянутых.
+// Комментарий к этому моменту уже сохранен, поэтому ошибки только логируются
+func (s *MentionService) SaveCommentMentions(ctx context.Context, comment *models.Comment) {
+	comment.Entities = []models.Mention{}
+	mentions, err := s.resolve(ctx, comment.UserID, comment.Content)
+	if err == nil {
+		err = s.Repo.ReplaceCommentMentions(ctx, comment.ID, mentions)
+	}
+	if err != nil {
+		s.Logger.Error("Не удалось сохранить упоминания в комментарии", zap.Int("commentID", comment.ID), zap.Error(err))
+		return
+	}
+
+	comment.Entities = mentions
+	for _, userID := range mentionedUserIDs(mentions) {
+		s.Notifications.Notify(ctx, userID, comment.UserID, models.NotificationMention, comment.PhotoID, comment.ID)
+	}
+}
+
+// SavePhotoMentions сохраняет упоминания в описании фото, заполняет photo.Entities и уведомляет упомянутых.
+// Фото к этому моменту уже сохранено, поэтому ошибки только логируются
+func (s *MentionService) SavePhotoMentions(ctx context.Context, photo *models.Photo) {
+	photo.Entities = []models.Mention{}
+	mentions, err := s.resolve(ctx, photo.UserID, photo.Description)
+	if err == nil {
+		err = s.Repo.ReplacePhotoMentions(ctx, photo.ID, mentions)
+	}
+	if err != nil {
+		s.Logger.Error("Не удалось сохранить упоминания в описании фото", zap.Int("photoID", photo.ID), zap.Error(err))
+		return
+	}
+
+	photo.Entities = mentions
+	for _, userID := range mentionedUserIDs(mentions) {
+		s.Notifications.Notify(ctx, userID, photo.UserID, models.NotificationMention, photo.ID, 0)
+	}
+}
+
+// AttachToComments заполняет Entities у комментариев одним запросом
+func (s *MentionService) AttachToComments(ctx context.Context, comments []models.Comment) error {
+	ids := make([]int, len(comments))
+	for i := range comments {
+		ids[i] = comments[i].ID
+	}
+
+	byComment, err := s.Repo.GetByCommentIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range comments {
+		comments[i].Entities = nonNilMentions(byComment[comments[i].ID])
+	}
+	return nil
+}
+
+// AttachToPhotos заполняет Entities у фото одним запросом
+func (s *MentionService) AttachToPhotos(ctx context.Context, photos []models.Photo) error {
+	ids := make([]int, len(photos))
+	for i := range photos {
+		ids[i] = photos[i].ID
+	}
+
+	byPhoto, err := s.Repo.GetByPhotoIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range photos {
+		photos[i].Entities = nonNilMentions(byPhoto[photos[i].ID])
+	}
+	return nil
+}
+
+// resolve разбирает текст и оставляет только существующих и не заблокированных пользователей
+func (s *MentionService) resolve(ctx context.Context, authorID int, text string) ([]models.Mention, error) {
+	parsed := ParseMentions(text)
+	if len(parsed) == 0 {
+		return []models.Mention{}, nil
+	}
+
+	usernames := make([]string, 0, len(parsed))
+	for _, m := range parsed {
+		usernames = append(usernames, strings.ToLower(m.Username))
+	}
+
+	users, err := s.Repo.ResolveUsernames(ctx, authorID, usernames)
+	if err != nil {
+		return nil, err
+	}
+
+	mentions := []models.Mention{}
+	for _, m := range parsed {
+		user, ok := users[strings.ToLower(m.Username)]
+		if !ok {
+			continue
+		}
+		m.UserID = user.ID
+		m.Username = user.Username
+		mentions = append(mentions, m)
+	}
+	return mentions, nil
+}
+
+func mentionedUserIDs(mentions []models.Mention) []int {
+	seen := make(map[int]bool)
+	var ids []int
+	for _, m := range mentions {
+		if !seen[m.UserID] {
+			seen[m.UserID] = true
+			ids = append(ids, m.UserID)
+		}
+	}
+	return ids
+}
+
+func nonNilMentions(mentions []models.Mention) []models.Mention {
+	if mentions == nil {
+		return []models.Mention{}
+	}
+	return mentions
+}
@@ -4,56 +4,236 @@ import (
 	"InstaSpace/internal/models"
 	"InstaSpace/internal/repositories"
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
 )
 
 type MessageServiceInterface interface {
-	GetOrCreateConversation(ctx context.Context, user1ID, user2ID int) (int, error)
-	SendMessage(ctx context.Context, conversationID, senderID int, content string) (int, error)
-	GetMessages(ctx context.Context, conversationID int) ([]models.Message, error)
-	DeleteMessage(ctx context.Context, messageID int) error
+	GetOrCreateConversation(ctx context.Context, userID, otherID int) (*models.Conversation, error)
+	GetInbox(ctx context.Context, userID int, params InboxListParams) (*models.InboxPage, error)
+	SendMessage(ctx context.Context, conversationID, senderID int, content, clientMessageID string) (*models.Message, bool, error)
+	GetMessages(ctx context.Context, conversationID, userID int) ([]models.Message, error)
+	MarkRead(ctx context.Context, conversationID, userID, messageID int) (*models.ReadReceipt, error)
+	CountUnread(ctx context.Context, userID int) (*models.UnreadCount, error)
+	DeleteMessage(ctx context.Context, messageID, userID int) error
+	SendTyping(ctx context.Context, conversationID, userID int, typing bool) error
+	GetConversation(ctx context.Context, conversationID, userID int) (*models.Conversation, error)
+	CreateGroup(ctx context.Context, userID int, req models.GroupRequest) (*models.Conversation, error)
+	UpdateGroup(ctx context.Context, conversationID, userID int, req models.GroupRequest) (*models.Conversation, error)
+	AddParticipants(ctx context.Context, conversationID, userID int, userIDs []int) error
+	RemoveParticipant(ctx context.Context, conversationID, userID, targetID int) error
+	LeaveGroup(ctx context.Context, conversationID, userID int) error
+	SetParticipantRole(ctx context.Context, conversationID, userID, targetID int, role string) error
 }
 
 type MessageService struct {
-	Repo repositories.MessageRepositoryInterface
+	Repo   repositories.MessageRepositoryInterface
+	Blocks repositories.BlockRepositoryInterface
+	// Publisher доставляет участникам события бесед. Задается после создания, так как WebSocket-обработчик сам зависит от сервиса
+	Publisher NotificationPublisher
+	Logger    *zap.Logger
+	// TypingTTL сколько действует событие набора, если клиент его не повторил
+	TypingTTL time.Duration
+	// TypingThrottle как часто участникам пересылается набор одного пользователя в беседе
+	TypingThrottle time.Duration
+
+	typingMu sync.Mutex
+	typing   map[typingKey]*typingState
 }
 
-func NewMessageService(repo repositories.MessageRepositoryInterface) *MessageService {
-	return &MessageService{Repo: repo}
+func NewMessageService(repo repositories.MessageRepositoryInterface, blocks repositories.BlockRepositoryInterface, logger *zap.Logger) *MessageService {
+	return &MessageService{
+		Repo:           repo,
+		Blocks:         blocks,
+		Logger:         logger,
+		TypingTTL:      DefaultTypingTTL,
+		TypingThrottle: DefaultTypingThrottle,
+		typing:         make(map[typingKey]*typingState),
+	}
 }
 
-func (s *MessageService) GetOrCreateConversation(ctx context.Context, user1ID, user2ID int) (int, error) {
-	return s.Repo.CreateConversation(ctx, user1ID, user2ID)
+const (
+	DefaultInboxLimit = 20
+	MaxInboxLimit     = 50
+
+	// MaxClientMessageIDLength максимальная длина ID сообщения, присвоенного клиентом
+	MaxClientMessageIDLength = 64
+)
+
+// InboxListParams параметры запроса страницы входящих
+type InboxListParams struct {
+	Cursor string
+	Limit  int
 }
 
-var ErrConversationNotFound = errors.New("conversation not found")
+// GetOrCreateConversation возвращает беседу пользователя с собеседником, создавая ее при необходимости
+func (s *MessageService) GetOrCreateConversation(ctx context.Context, userID, otherID int) (*models.Conversation, error) {
+	if userID == otherID {
+		return nil, ErrSelfAction
+	}
+	if err := checkNotBlocked(ctx, s.Blocks, userID, otherID); err != nil {
+		return nil, err
+	}
 
-func (s *MessageService) SendMessage(ctx context.Context, conversationID, senderID int, content string) (int, error) {
-	var exists bool
-	err := s.Repo.ConversationExists(ctx, conversationID, &exists)
+	conversationID, err := s.Repo.CreateConversation(ctx, userID, otherID)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-	if !exists {
-		return 0, ErrConversationNotFound
+	return s.Repo.GetConversation(ctx, conversationID)
+}
+
+// GetInbox возвращает страницу бесед пользователя, последние активные первыми
+func (s *MessageService) GetInbox(ctx context.Context, userID int, params InboxListParams) (*models.InboxPage, error) {
+	opts := repositories.InboxListOptions{Limit: params.Limit}
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultInboxLimit
+	}
+	if opts.Limit > MaxInboxLimit {
+		opts.Limit = MaxInboxLimit
+	}
+	if params.Cursor != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(params.Cursor)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		var cursor repositories.InboxCursor
+		if err := json.Unmarshal(raw, &cursor); err != nil || cursor.ID <= 0 {
+			return nil, ErrInvalidCursor
+		}
+		opts.After = &cursor
 	}
 
-	return s.Repo.SendMessage(ctx, conversationID, senderID, content)
+	entries, next, err := s.Repo.GetInbox(ctx, userID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.InboxPage{Conversations: entries}
+	if next != nil {
+		raw, err := json.Marshal(next)
+		if err != nil {
+			return nil, err
+		}
+		page.NextCursor = base64.RawURLEncoding.EncodeToString(raw)
+	}
+	return page, nil
 }
 
-func (s *MessageService) GetMessages(ctx context.Context, conversationID int) ([]models.Message, error) {
-	var exists bool
-	err := s.Repo.ConversationExists(ctx, conversationID, &exists)
+var (
+	ErrConversationNotFound   = errors.New("conversation not found")
+	ErrNotMessageSender       = errors.New("only the sender can delete a message")
+	ErrInvalidClientMessageID = errors.New("client message id is too long")
+)
+
+// conversationForParticipant возвращает беседу и роль пользователя в ней, если он в ней участвует.
+// Для чужой беседы возвращает ErrConversationNotFound, чтобы нельзя было узнать, какие ID существуют
+func (s *MessageService) conversationForParticipant(ctx context.Context, conversationID, userID int) (*models.Conversation, string, error) {
+	conv, err := s.Repo.GetConversation(ctx, conversationID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, "", ErrConversationNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	role, err := s.Repo.GetParticipantRole(ctx, conversationID, userID)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
-	if !exists {
-		return nil, ErrConversationNotFound
+	if role == "" {
+		return nil, "", ErrConversationNotFound
+	}
+	return conv, role, nil
+}
+
+// SendMessage сохраняет сообщение участника беседы и доставляет его участникам. Повторная отправка
+// с тем же clientMessageID возвращает уже сохраненное сообщение, а второе значение равно false
+func (s *MessageService) SendMessage(ctx context.Context, conversationID, senderID int, content, clientMessageID string) (*models.Message, bool, error) {
+	if len(clientMessageID) > MaxClientMessageIDLength {
+		return nil, false, ErrInvalidClientMessageID
+	}
+
+	conv, _, err := s.conversationForParticipant(ctx, conversationID, senderID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// В группе блокировка между участниками не мешает писать остальным
+	if !conv.IsGroup {
+		for _, participantID := range []int{conv.User1ID, conv.User2ID} {
+			if err := checkNotBlocked(ctx, s.Blocks, senderID, participantID); err != nil {
+				return nil, false, err
+			}
+		}
+	}
+
+	msg, created, err := s.Repo.SendMessage(ctx, conversationID, senderID, content, clientMessageID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if created {
+		s.stopTyping(ctx, conversationID, senderID)
+		s.publishToParticipants(ctx, conversationID, 0, models.WSMessage, msg)
+	}
+	return msg, created, nil
+}
+
+// publishToParticipants доставляет событие всем участникам беседы, кроме exceptUserID.
+// Ошибки только логируются, чтобы не срывать основное действие
+func (s *MessageService) publishToParticipants(ctx context.Context, conversationID, exceptUserID int, eventType string, payload interface{}) {
+	if s.Publisher == nil {
+		return
+	}
+
+	participantIDs, err := s.Repo.GetParticipantIDs(ctx, conversationID)
+	if err != nil {
+		s.Logger.Error("Не удалось получить участников беседы", zap.Int("conversationID", conversationID), zap.Error(err))
+		return
+	}
+	recipients := make([]int, 0, len(participantIDs))
+	for _, participantID := range participantIDs {
+		if participantID != exceptUserID {
+			recipients = append(recipients, participantID)
+		}
+	}
+	s.Publisher.SendToUsers(recipients, eventType, payload)
+}
+
+// GetMessages возвращает сообщения беседы ее участнику. Прочитанными они отмечаются отдельно, через MarkRead
+func (s *MessageService) GetMessages(ctx context.Context, conversationID, userID int) ([]models.Message, error) {
+	if _, _, err := s.conversationForParticipant(ctx, conversationID, userID); err != nil {
+		return nil, err
 	}
 
 	return s.Repo.GetMessages(ctx, conversationID)
 }
 
-func (s *MessageService) DeleteMessage(ctx context.Context, messageID int) error {
+// DeleteMessage удаляет сообщение его отправителя. Служебные сообщения удалить нельзя. Для сообщений чужих бесед возвращает ErrMessageNotFound
+func (s *MessageService) DeleteMessage(ctx context.Context, messageID, userID int) error {
+	msg, err := s.Repo.GetMessage(ctx, messageID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrMessageNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, _, err := s.conversationForParticipant(ctx, msg.ConversationID, userID); err != nil {
+		if errors.Is(err, ErrConversationNotFound) {
+			return ErrMessageNotFound
+		}
+		return err
+	}
+	if msg.SenderID != userID || msg.Kind != models.MessageText {
+		return ErrNotMessageSender
+	}
+
 	return s.Repo.DeleteMessage(ctx, messageID)
 }
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"InstaSpace/internal/models"
+	"InstaSpace/internal/repositories"
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultNotificationsLimit = 20
+	MaxNotificationsLimit     = 100
+)
+
+// NotificationPublisher доставляет события подключенным клиентам пользователя.
+// eventType — тип сообщения протокола WebSocket, например models.WSNotification
+type NotificationPublisher interface {
+	SendToUser(userID int, eventType string, payload interface{})
+	// SendToUsers доставляет одно событие нескольким пользователям
+	SendToUsers(userIDs []int, eventType string, payload interface{})
+}
+
+type NotificationServiceInterface interface {
+	Notify(ctx context.Context, recipientID, actorID int, notificationType string, photoID, commentID int)
+	GetNotifications(ctx context.Context, userID, limit, offset int) ([]models.Notification, error)
+	CountUnread(ctx context.Context, userID int) (int, error)
+	MarkRead(ctx context.Context, userID int, ids []int) (int64, error)
+}
+
+type NotificationService struct {
+	Repo      repositories.NotificationRepositoryInterface
+	Publisher NotificationPublisher
+	Logger    *zap.Logger
+}
+
+func NewNotificationService(repo repositories.NotificationRepositoryInterface, publisher NotificationPublisher, logger *zap.Logger) *NotificationService {
+	return &NotificationService{Repo: repo, Publisher: publisher, Logger: logger}
+}
+
+// Notify записывает событие и отправляет обновленное уведомление получателю.
+// Ошибки только логируются, чтобы не срывать основное действие
+func (s *NotificationService) Notify(ctx context.Context, recipientID, actorID int, notificationType string, photoID, commentID int) {
+	if recipientID <= 0 || recipientID == actorID {
+		return
+	}
+
+	n := &models.Notification{
+		UserID:  recipientID,
+		Type:    notificationType,
+		ActorID: actorID,
+	}
+	if photoID > 0 {
+		n.PhotoID = &photoID
+	}
+	if commentID > 0 {
+		n.CommentID = &commentID
+	}
+
+	changed, err := s.Repo.Upsert(ctx, n, notificationGroupKey(notificationType, photoID, commentID))
+	if err != nil {
+		s.Logger.Error("Не удалось сохранить уведомление", zap.Int("userID", recipientID), zap.String("type", notificationType), zap.Error(err))
+		return
+	}
+	if !changed || s.Publisher == nil {
+		return
+	}
+
+	full, err := s.Repo.GetByID(ctx, n.ID)
+	if err != nil {
+		s.Logger.Error("Не удалось получить уведомление", zap.Int("notificationID", n.ID), zap.Error(err))
+		return
+	}
+	full.Text = notificationText(full)
+
+	s.Publisher.SendToUser(recipientID, models.WSNotification, full)
+}
+
+// GetNotifications возвращает уведомления пользователя
+func (s *NotificationService) GetNotifications(ctx context.Context, userID, limit, offset int) ([]models.Notification, error) {
+	if limit <= 0 {
+		limit = DefaultNotificationsLimit
+	}
+	if limit > MaxNotificationsLimit {
+		limit = MaxNotificationsLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	notifications, err := s.Repo.GetNotifications(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	for i := range notifications {
+		notifications[i].Text = notificationText(&notifications[i])
+	}
+	return notifications, nil
+}
+
+// CountUnread возвращает количество непрочитанных уведомлений
+func (s *NotificationService) CountUnread(ctx context.Context, userID int) (int, error) {
+	return s.Repo.CountUnread(ctx, userID)
+}
+
+// MarkRead отмечает уведомления прочитанными, пустой ids означает все
+func (s *NotificationService) MarkRead(ctx context.Context, userID int, ids []int) (int64, error) {
+	return s.Repo.MarkRead(ctx, userID, ids)
+}
+
+// notificationGroupKey определяет, какие события объединяются в одно уведомление
+func notificationGroupKey(notificationType string, photoID, commentID int) string {
+	switch notificationType {
+	case models.NotificationFollow:
+		return notificationType
+	case models.NotificationMention:
+		if commentID > 0 {
+			return fmt.Sprintf("%s:comment:%d", notificationType, commentID)
+		}
+		return fmt.Sprintf("%s:photo:%d", notificationType, photoID)
+	default:
+		return fmt.Sprintf("%s:photo:%d", notificationType, photoID)
+	}
+}
+
+// notificationText формирует текст вида "alice and 12 others liked your photo"
+func notificationText(n *models.Notification) string {
+	var action string
+	switch n.Type {
+	case models.NotificationLike:
+		action = "liked your photo"
+	case models.NotificationComment:
+		action = "commented on your photo"
+	case models.NotificationFollow:
+		action = "started following you"
+	case models.NotificationMention:
+		if n.CommentID != nil {
+			action = "mentioned you in a comment"
+		} else {
+			action = "mentioned you in a photo"
+		}
+	default:
+		action = n.Type
+	}
+
+	switch others := n.ActorsCount - 1; {
+	case others <= 0:
+		return fmt.Sprintf("%s %s", n.ActorUsername, action)
+	case others == 1:
+		return fmt.Sprintf("%s and 1 other %s", n.ActorUsername, action)
+	default:
+		return fmt.Sprintf("%s and %d others %s", n.ActorUsername, others, action)
+	}
+}
@@ -3,26 +3,61 @@ package services
 import (
 	"InstaSpace/internal/models"
 	"InstaSpace/internal/repositories"
+	"context"
 	"errors"
 )
 
 var ErrInvalidPhotoData = errors.New("invalid photo data: user_id or URL is missing")
 
+const (
+	DefaultFeedLimit = 20
+	MaxFeedLimit     = 100
+)
+
 type PhotoService struct {
 	Repository repositories.PhotoRepositoryInterface
+	Mentions   MentionServiceInterface
 }
 
-func NewPhotoService(repo repositories.PhotoRepositoryInterface) *PhotoService {
-	return &PhotoService{Repository: repo}
+func NewPhotoService(repo repositories.PhotoRepositoryInterface, mentions MentionServiceInterface) *PhotoService {
+	return &PhotoService{Repository: repo, Mentions: mentions}
 }
 
 type PhotoServiceInterface interface {
-	SavePhoto(photo *models.Photo) error
+	SavePhoto(ctx context.Context, photo *models.Photo) error
+	GetFeed(ctx context.Context, userID, limit, offset int) ([]models.Photo, error)
 }
 
-func (s *PhotoService) SavePhoto(photo *models.Photo) error {
+// SavePhoto сохраняет фото и упоминания в его описании
+func (s *PhotoService) SavePhoto(ctx context.Context, photo *models.Photo) error {
 	if photo.UserID == 0 || photo.URL == "" {
 		return ErrInvalidPhotoData
 	}
-	return s.Repository.Create(photo)
+	if err := s.Repository.Create(photo); err != nil {
+		return err
+	}
+	s.Mentions.SavePhotoMentions(ctx, photo)
+	return nil
+}
+
+// GetFeed возвращает ленту пользователя без скрытых и заблокированных авторов
+func (s *PhotoService) GetFeed(ctx context.Context, userID, limit, offset int) ([]models.Photo, error) {
+	if limit <= 0 {
+		limit = DefaultFeedLimit
+	}
+	if limit > MaxFeedLimit {
+		limit = MaxFeedLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	photos, err := s.Repository.GetFeed(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Mentions.AttachToPhotos(ctx, photos); err != nil {
+		return nil, err
+	}
+	return photos, nil
 }
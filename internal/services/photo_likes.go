@@ -4,32 +4,130 @@ import (
 	"InstaSpace/internal/models"
 	"InstaSpace/internal/repositories"
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 )
 
+const (
+	DefaultLikersLimit = 20
+	MaxLikersLimit     = 100
+
+	// MaxViewerStateBatch максимальное количество фото в одном запросе состояния
+	MaxViewerStateBatch = 100
+)
+
+var ErrInvalidPhotoIDs = errors.New("photo_ids must contain from 1 to 100 positive IDs")
+
+// LikeListParams параметры запроса страницы пользователей, поставивших лайк
+type LikeListParams struct {
+	ViewerID int
+	Cursor   string
+	Limit    int
+}
+
 type LikeService struct {
-	Repo *repositories.LikeRepository
+	Repo      *repositories.LikeRepository
+	Reactions ReactionServiceInterface
+	Comments  repositories.CommentRepositoryInterface
+	Blocks    repositories.BlockRepositoryInterface
 }
 
-func NewLikeService(repo *repositories.LikeRepository) *LikeService {
-	return &LikeService{Repo: repo}
+func NewLikeService(repo *repositories.LikeRepository, reactions ReactionServiceInterface, comments repositories.CommentRepositoryInterface, blocks repositories.BlockRepositoryInterface) *LikeService {
+	return &LikeService{Repo: repo, Reactions: reactions, Comments: comments, Blocks: blocks}
 }
 
-// AddLike добавляет лайк к фотографии
+// AddLike ставит на фото реакцию-сердечко. Оставлен для совместимости с /api/likes
 func (s *LikeService) AddLike(ctx context.Context, photoID, userID int) error {
-	return s.Repo.AddLike(ctx, photoID, userID)
+	return s.Reactions.SetPhotoReaction(ctx, photoID, userID, models.ReactionHeart)
 }
 
-// RemoveLike удаляет лайк с фотографии
+// RemoveLike снимает с фото реакцию-сердечко, если она была
 func (s *LikeService) RemoveLike(ctx context.Context, photoID, userID int) error {
-	return s.Repo.RemoveLike(ctx, photoID, userID)
+	return s.Reactions.RemovePhotoReaction(ctx, photoID, userID, models.ReactionHeart)
 }
 
-// GetLikes возвращает список пользователей, которые поставили лайк
-func (s *LikeService) GetLikes(ctx context.Context, photoID int) ([]models.User, error) {
-	return s.Repo.GetLikes(ctx, photoID)
+// GetLikes возвращает страницу пользователей, поставивших сердечко. Первыми идут те, на кого подписан зритель
+func (s *LikeService) GetLikes(ctx context.Context, photoID int, params LikeListParams) (*models.LikersPage, error) {
+	opts := repositories.LikerListOptions{ViewerID: params.ViewerID, Limit: params.Limit}
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultLikersLimit
+	}
+	if opts.Limit > MaxLikersLimit {
+		opts.Limit = MaxLikersLimit
+	}
+	if params.Cursor != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(params.Cursor)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		var cursor repositories.LikerCursor
+		if err := json.Unmarshal(raw, &cursor); err != nil || cursor.ID <= 0 {
+			return nil, ErrInvalidCursor
+		}
+		opts.After = &cursor
+	}
+
+	likers, next, err := s.Repo.GetLikers(ctx, photoID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.LikersPage{Users: likers}
+	if next != nil {
+		raw, err := json.Marshal(next)
+		if err != nil {
+			return nil, err
+		}
+		page.NextCursor = base64.RawURLEncoding.EncodeToString(raw)
+	}
+	return page, nil
 }
 
-// GetLikeCount возвращает количество лайков на фотографии
+// GetViewerStates возвращает состояние нескольких фото для зрителя одним запросом.
+// Повторяющиеся ID учитываются один раз, несуществующие фото пропускаются
+func (s *LikeService) GetViewerStates(ctx context.Context, photoIDs []int, viewerID int) ([]models.PhotoViewerState, error) {
+	if len(photoIDs) == 0 || len(photoIDs) > MaxViewerStateBatch {
+		return nil, ErrInvalidPhotoIDs
+	}
+
+	seen := make(map[int]bool, len(photoIDs))
+	unique := make([]int, 0, len(photoIDs))
+	for _, id := range photoIDs {
+		if id <= 0 {
+			return nil, ErrInvalidPhotoIDs
+		}
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	return s.Repo.GetViewerStates(ctx, unique, viewerID)
+}
+
+// GetLikeCount возвращает количество сердечек на фотографии
 func (s *LikeService) GetLikeCount(ctx context.Context, photoID int) (int, error) {
-	return s.Repo.GetLikeCount(ctx, photoID)
+	return s.Reactions.GetPhotoReactionCount(ctx, photoID, models.ReactionHeart)
+}
+
+// AddCommentLike добавляет лайк к комментарию, если его автор не заблокирован и не заблокировал пользователя
+func (s *LikeService) AddCommentLike(ctx context.Context, commentID, userID int) error {
+	comment, err := s.Comments.GetCommentByID(ctx, commentID)
+	if err != nil {
+		return err
+	}
+	if comment.Deleted {
+		return repositories.ErrCommentNotFound
+	}
+	if err := checkNotBlocked(ctx, s.Blocks, userID, comment.UserID); err != nil {
+		return err
+	}
+
+	return s.Repo.AddCommentLike(ctx, commentID, userID)
+}
+
+// RemoveCommentLike удаляет лайк с комментария
+func (s *LikeService) RemoveCommentLike(ctx context.Context, commentID, userID int) error {
+	return s.Repo.RemoveCommentLike(ctx, commentID, userID)
 }
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"InstaSpace/internal/models"
+	"InstaSpace/internal/repositories"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// MaxPresenceUsers сколько пользователей можно запросить за раз
+	MaxPresenceUsers = 100
+	// DefaultPresenceTTL через сколько перестают учитываться подключения экземпляра, который перестал их продлевать
+	DefaultPresenceTTL = 90 * time.Second
+)
+
+var ErrInvalidUserIDs = errors.New("user_ids must contain from 1 to 100 positive IDs")
+
+type PresenceServiceInterface interface {
+	GetPresence(ctx context.Context, viewerID int, userIDs []int) ([]models.Presence, error)
+	GetSettings(ctx context.Context, userID int) (*models.PresenceSettings, error)
+	UpdateSettings(ctx context.Context, userID int, settings models.PresenceSettings) error
+}
+
+// PresenceService отслеживает, кто в сети. Подключения всех экземпляров сервера учитываются в общей таблице,
+// поэтому пользователь остается в сети, пока у него есть подключение к любому из них
+type PresenceService struct {
+	Repo      repositories.PresenceRepositoryInterface
+	Publisher NotificationPublisher
+	Logger    *zap.Logger
+	// InstanceID отличает подключения этого экземпляра от подключений остальных
+	InstanceID string
+	// TTL через сколько перестают учитываться подключения экземпляра, который перестал их продлевать.
+	// RunHeartbeat продлевает подключения этого экземпляра втрое чаще
+	TTL time.Duration
+}
+
+// NewPresenceService создает сервис присутствия со случайным ID экземпляра. publisher доставляет события собеседникам
+func NewPresenceService(repo repositories.PresenceRepositoryInterface, publisher NotificationPublisher, logger *zap.Logger) *PresenceService {
+	return &PresenceService{Repo: repo, Publisher: publisher, Logger: logger, InstanceID: newInstanceID(), TTL: DefaultPresenceTTL}
+}
+
+// newInstanceID возвращает случайный ID экземпляра сервера, а если источник случайности недоступен — ID из времени запуска
+func newInstanceID() string {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(raw)
+}
+
+// GetPresence возвращает присутствие пользователей userIDs, видимое viewerID. Присутствие видно только
+// собеседникам, остальные пользователи пропускаются. Скрытое время выхода видно только самому пользователю
+func (s *PresenceService) GetPresence(ctx context.Context, viewerID int, userIDs []int) ([]models.Presence, error) {
+	if len(userIDs) == 0 || len(userIDs) > MaxPresenceUsers {
+		return nil, ErrInvalidUserIDs
+	}
+	for _, id := range userIDs {
+		if id <= 0 {
+			return nil, ErrInvalidUserIDs
+		}
+	}
+
+	records, err := s.Repo.GetPresence(ctx, viewerID, userIDs, s.TTL)
+	if err != nil {
+		return nil, err
+	}
+
+	presence := make([]models.Presence, 0, len(records))
+	for _, rec := range records {
+		p := models.Presence{UserID: rec.UserID, Online: rec.Online}
+		if !p.Online && (!rec.HideLastSeen || rec.UserID == viewerID) {
+			p.LastSeenAt = rec.LastSeenAt
+		}
+		presence = append(presence, p)
+	}
+	return presence, nil
+}
+
+// GetSettings возвращает настройки приватности присутствия
+func (s *PresenceService) GetSettings(ctx context.Context, userID int) (*models.PresenceSettings, error) {
+	return s.Repo.GetSettings(ctx, userID)
+}
+
+// UpdateSettings меняет настройки приватности присутствия
+func (s *PresenceService) UpdateSettings(ctx context.Context, userID int, settings models.PresenceSettings) error {
+	return s.Repo.UpdateSettings(ctx, userID, settings)
+}
+
+// Connected учитывает подключение пользователя к этому экземпляру. Если у пользователя не было других
+// подключений ни к одному экземпляру, сообщает собеседникам, что он появился в сети
+func (s *PresenceService) Connected(ctx context.Context, userID int) {
+	first, err := s.Repo.AddConnection(ctx, s.InstanceID, userID, s.TTL)
+	if err != nil {
+		s.Logger.Error("Не удалось учесть подключение", zap.Int("userID", userID), zap.Error(err))
+		return
+	}
+	if first {
+		s.publish(ctx, models.Presence{UserID: userID, Online: true})
+	}
+}
+
+// Disconnected снимает с учета подключение пользователя к этому экземпляру. Если это было последнее
+// подключение ко всем экземплярам, сохраняет время выхода из сети и сообщает о нем собеседникам
+func (s *PresenceService) Disconnected(ctx context.Context, userID int) {
+	now := time.Now().UTC()
+	last, err := s.Repo.RemoveConnection(ctx, s.InstanceID, userID, s.TTL, now)
+	if err != nil {
+		s.Logger.Error("Не удалось снять подключение с учета", zap.Int("userID", userID), zap.Error(err))
+		return
+	}
+	if !last {
+		return
+	}
+
+	p := models.Presence{UserID: userID, LastSeenAt: &now}
+	if settings, err := s.Repo.GetSettings(ctx, userID); err != nil || settings.HideLastSeen {
+		p.LastSeenAt = nil
+	}
+	s.publish(ctx, p)
+}
+
+// RunHeartbeat продлевает подключения этого экземпляра, пока не отменен ctx, и удаляет подключения экземпляров,
+// которые перестали их продлевать. Ошибки только логируются
+func (s *PresenceService) RunHeartbeat(ctx context.Context) {
+	ticker := time.NewTicker(s.TTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Repo.Heartbeat(ctx, s.InstanceID, s.TTL); err != nil && ctx.Err() == nil {
+				s.Logger.Error("Не удалось продлить подключения экземпляра", zap.String("instanceID", s.InstanceID), zap.Error(err))
+			}
+		}
+	}
+}
+
+// publish доставляет событие присутствия собеседникам пользователя. Ошибки только логируются
+func (s *PresenceService) publish(ctx context.Context, p models.Presence) {
+	if s.Publisher == nil {
+		return
+	}
+
+	contactIDs, err := s.Repo.GetContactIDs(ctx, p.UserID)
+	if err != nil {
+		s.Logger.Error("Не удалось получить собеседников", zap.Int("userID", p.UserID), zap.Error(err))
+		return
+	}
+	s.Publisher.SendToUsers(contactIDs, models.WSPresence, p)
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"InstaSpace/internal/models"
+	"InstaSpace/internal/repositories"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// DefaultReactions набор реакций, если он не задан в конфигурации
+var DefaultReactions = []string{models.ReactionHeart, "😂", "😮", "😢", "🔥"}
+
+var (
+	ErrInvalidReaction = errors.New("reaction is not allowed")
+	ErrMessageNotFound = errors.New("message not found")
+)
+
+type ReactionServiceInterface interface {
+	AllowedReactions() []string
+	SetPhotoReaction(ctx context.Context, photoID, userID int, reaction string) error
+	RemovePhotoReaction(ctx context.Context, photoID, userID int, reaction string) error
+	GetPhotoReactions(ctx context.Context, photoID, viewerID int) (*models.ReactionSummary, error)
+	GetPhotoReactionCount(ctx context.Context, photoID int, reaction string) (int, error)
+	SetMessageReaction(ctx context.Context, messageID, userID int, reaction string) error
+	RemoveMessageReaction(ctx context.Context, messageID, userID int) error
+	GetMessageReactions(ctx context.Context, messageID, viewerID int) (*models.ReactionSummary, error)
+	ReconcileCounts(ctx context.Context) ([]models.CountDrift, error)
+}
+
+type ReactionService struct {
+	Repo          repositories.ReactionRepositoryInterface
+	Photos        repositories.PhotoRepositoryInterface
+	Messages      repositories.MessageRepositoryInterface
+	Blocks        repositories.BlockRepositoryInterface
+	Notifications NotificationServiceInterface
+	allowed       []string
+}
+
+// NewReactionService создает сервис реакций. Пустой allowed означает DefaultReactions
+func NewReactionService(repo repositories.ReactionRepositoryInterface, photos repositories.PhotoRepositoryInterface, messages repositories.MessageRepositoryInterface, blocks repositories.BlockRepositoryInterface, notifications NotificationServiceInterface, allowed []string) *ReactionService {
+	if len(allowed) == 0 {
+		allowed = DefaultReactions
+	}
+	return &ReactionService{Repo: repo, Photos: photos, Messages: messages, Blocks: blocks, Notifications: notifications, allowed: allowed}
+}
+
+// AllowedReactions возвращает разрешенные реакции в порядке из конфигурации
+func (s *ReactionService) AllowedReactions() []string {
+	return s.allowed
+}
+
+func (s *ReactionService) checkReaction(reaction string) error {
+	for _, allowed := range s.allowed {
+		if reaction == allowed {
+			return nil
+		}
+	}
+	return ErrInvalidReaction
+}
+
+// SetPhotoReaction ставит или меняет реакцию на фото. Автор фото получает уведомление только о новой реакции
+func (s *ReactionService) SetPhotoReaction(ctx context.Context, photoID, userID int, reaction string) error {
+	if err := s.checkReaction(reaction); err != nil {
+		return err
+	}
+
+	ownerID, err := s.Photos.GetOwnerID(ctx, photoID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repositories.ErrInvalidPhotoID
+	}
+	if err != nil {
+		return err
+	}
+	if err := checkNotBlocked(ctx, s.Blocks, userID, ownerID); err != nil {
+		return err
+	}
+
+	previous, err := s.Repo.SetReaction(ctx, repositories.ReactionTargetPhoto, photoID, userID, reaction)
+	if errors.Is(err, repositories.ErrInvalidTarget) {
+		return repositories.ErrInvalidPhotoID
+	}
+	if err != nil {
+		return err
+	}
+
+	if previous == "" {
+		s.Notifications.Notify(ctx, ownerID, userID, models.NotificationLike, photoID, 0)
+	}
+	return nil
+}
+
+// RemovePhotoReaction снимает реакцию с фото. Непустой reaction снимает только такую реакцию
+func (s *ReactionService) RemovePhotoReaction(ctx context.Context, photoID, userID int, reaction string) error {
+	return s.Repo.RemoveReaction(ctx, repositories.ReactionTargetPhoto, photoID, userID, reaction)
+}
+
+// GetPhotoReactions возвращает счетчики реакций на фото и реакцию зрителя
+func (s *ReactionService) GetPhotoReactions(ctx context.Context, photoID, viewerID int) (*models.ReactionSummary, error) {
+	if _, err := s.Photos.GetOwnerID(ctx, photoID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repositories.ErrInvalidPhotoID
+		}
+		return nil, err
+	}
+	return s.Repo.GetSummary(ctx, repositories.ReactionTargetPhoto, photoID, viewerID)
+}
+
+// GetPhotoReactionCount возвращает количество реакций одного вида на фото
+func (s *ReactionService) GetPhotoReactionCount(ctx context.Context, photoID int, reaction string) (int, error) {
+	return s.Repo.GetCount(ctx, repositories.ReactionTargetPhoto, photoID, reaction)
+}
+
+// messageForParticipant возвращает сообщение, если пользователь участвует в его беседе
+func (s *ReactionService) messageForParticipant(ctx context.Context, messageID, userID int) (*models.Message, error) {
+	msg, err := s.Messages.GetMessage(ctx, messageID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrMessageNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := s.Messages.GetParticipantRole(ctx, msg.ConversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		return nil, ErrMessageNotFound
+	}
+	return msg, nil
+}
+
+// SetMessageReaction ставит или меняет реакцию участника беседы на сообщение
+func (s *ReactionService) SetMessageReaction(ctx context.Context, messageID, userID int, reaction string) error {
+	if err := s.checkReaction(reaction); err != nil {
+		return err
+	}
+
+	msg, err := s.messageForParticipant(ctx, messageID, userID)
+	if err != nil {
+		return err
+	}
+	if err := checkNotBlocked(ctx, s.Blocks, userID, msg.SenderID); err != nil {
+		return err
+	}
+
+	_, err = s.Repo.SetReaction(ctx, repositories.ReactionTargetMessage, messageID, userID, reaction)
+	if errors.Is(err, repositories.ErrInvalidTarget) {
+		return ErrMessageNotFound
+	}
+	return err
+}
+
+// RemoveMessageReaction снимает реакцию участника беседы с сообщения
+func (s *ReactionService) RemoveMessageReaction(ctx context.Context, messageID, userID int) error {
+	if _, err := s.messageForParticipant(ctx, messageID, userID); err != nil {
+		return err
+	}
+	return s.Repo.RemoveReaction(ctx, repositories.ReactionTargetMessage, messageID, userID, "")
+}
+
+// GetMessageReactions возвращает реакции на сообщение участнику беседы
+func (s *ReactionService) GetMessageReactions(ctx context.Context, messageID, viewerID int) (*models.ReactionSummary, error) {
+	if _, err := s.messageForParticipant(ctx, messageID, viewerID); err != nil {
+		return nil, err
+	}
+	return s.Repo.GetSummary(ctx, repositories.ReactionTargetMessage, messageID, viewerID)
+}
+
+// ReconcileCounts пересчитывает счетчики реакций на фото и возвращает найденные расхождения
+func (s *ReactionService) ReconcileCounts(ctx context.Context) ([]models.CountDrift, error) {
+	return s.Repo.ReconcilePhotoCounts(ctx)
+}
+
+// RunReconciliation периодически сверяет счетчики реакций до отмены ctx. interval <= 0 отключает сверку
+func (s *ReactionService) RunReconciliation(ctx context.Context, interval time.Duration, logger *zap.Logger) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			drifts, err := s.ReconcileCounts(ctx)
+			if err != nil {
+				logger.Error("Не удалось сверить счетчики лайков", zap.Error(err))
+				continue
+			}
+			for _, d := range drifts {
+				logger.Warn("Исправлено расхождение счетчика лайков",
+					zap.Int("photoID", d.PhotoID),
+					zap.String("counter", d.Counter),
+					zap.String("reaction", d.Reaction),
+					zap.Int("stored", d.Stored),
+					zap.Int("actual", d.Actual),
+				)
+			}
+			logger.Info("Сверка счетчиков лайков завершена", zap.Int("drifts", len(drifts)))
+		}
+	}
+}
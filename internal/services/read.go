@@ -0,0 +1,73 @@
+package services
+
+import (
+	"InstaSpace/internal/models"
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// MarkRead отмечает беседу прочитанной участником до messageID включительно, а без messageID — до последнего сообщения.
+// Сообщение должно принадлежать беседе. Отметка не сдвигается назад
+func (s *MessageService) MarkRead(ctx context.Context, conversationID, userID, messageID int) (*models.ReadReceipt, error) {
+	if _, _, err := s.conversationForParticipant(ctx, conversationID, userID); err != nil {
+		return nil, err
+	}
+
+	if messageID > 0 {
+		msg, err := s.Repo.GetMessage(ctx, messageID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMessageNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		if msg.ConversationID != conversationID {
+			return nil, ErrMessageNotFound
+		}
+	} else {
+		lastID, err := s.Repo.GetLastMessageID(ctx, conversationID)
+		if err != nil {
+			return nil, err
+		}
+		messageID = lastID
+	}
+
+	return s.markRead(ctx, conversationID, userID, messageID)
+}
+
+// CountUnread возвращает счетчик непрочитанного во всех беседах пользователя
+func (s *MessageService) CountUnread(ctx context.Context, userID int) (*models.UnreadCount, error) {
+	return s.Repo.CountUnread(ctx, userID)
+}
+
+// markRead сдвигает отметку прочтения и, если она сдвинулась, рассылает событие остальным участникам
+// и обновленный счетчик непрочитанного подключениям самого пользователя
+func (s *MessageService) markRead(ctx context.Context, conversationID, userID, messageID int) (*models.ReadReceipt, error) {
+	receipt, changed, err := s.Repo.MarkRead(ctx, conversationID, userID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if changed {
+		s.publishRead(ctx, receipt)
+	}
+	return receipt, nil
+}
+
+// publishRead доставляет событие прочтения. Ошибки только логируются, чтобы не срывать саму отметку
+func (s *MessageService) publishRead(ctx context.Context, receipt *models.ReadReceipt) {
+	if s.Publisher == nil {
+		return
+	}
+
+	s.publishToParticipants(ctx, receipt.ConversationID, receipt.UserID, models.WSRead, receipt)
+
+	unread, err := s.Repo.CountUnread(ctx, receipt.UserID)
+	if err != nil {
+		s.Logger.Error("Не удалось посчитать непрочитанные сообщения", zap.Int("userID", receipt.UserID), zap.Error(err))
+		return
+	}
+	s.Publisher.SendToUser(receipt.UserID, models.WSUnreadCount, unread)
+}
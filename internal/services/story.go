@@ -0,0 +1,134 @@
+package services
+
+import (
+	"InstaSpace/internal/models"
+	"InstaSpace/internal/repositories"
+	"context"
+	"errors"
+	"os"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+var ErrInvalidStoryCaption = errors.New("story caption is too long")
+
+const (
+	// DefaultStoryTTL сколько живет история
+	DefaultStoryTTL = 24 * time.Hour
+
+	MaxStoryCaptionLength = 2200
+)
+
+type StoryServiceInterface interface {
+	CreateStory(ctx context.Context, story *models.Story) error
+	GetUserStories(ctx context.Context, ownerID, viewerID int) ([]models.Story, error)
+	GetTray(ctx context.Context, viewerID int) ([]models.StoryTrayItem, error)
+	MarkSeen(ctx context.Context, storyID, viewerID int) error
+	GetViewers(ctx context.Context, storyID, authorID int) ([]models.StoryViewer, error)
+	DeleteStory(ctx context.Context, storyID, authorID int) error
+}
+
+type StoryService struct {
+	Repo   repositories.StoryRepositoryInterface
+	Logger *zap.Logger
+	// TTL сколько живет история
+	TTL time.Duration
+}
+
+func NewStoryService(repo repositories.StoryRepositoryInterface, logger *zap.Logger) *StoryService {
+	return &StoryService{Repo: repo, Logger: logger, TTL: DefaultStoryTTL}
+}
+
+// CreateStory сохраняет историю с теми же требованиями к данным, что и фото
+func (s *StoryService) CreateStory(ctx context.Context, story *models.Story) error {
+	if story.UserID == 0 || story.URL == "" {
+		return ErrInvalidPhotoData
+	}
+	if utf8.RuneCountInString(story.Caption) > MaxStoryCaptionLength {
+		return ErrInvalidStoryCaption
+	}
+	return s.Repo.Create(ctx, story, s.TTL)
+}
+
+// GetUserStories возвращает активные истории пользователя с отметками просмотра
+func (s *StoryService) GetUserStories(ctx context.Context, ownerID, viewerID int) ([]models.Story, error) {
+	return s.Repo.GetUserStories(ctx, ownerID, viewerID)
+}
+
+// GetTray возвращает подписки зрителя с активными историями
+func (s *StoryService) GetTray(ctx context.Context, viewerID int) ([]models.StoryTrayItem, error) {
+	return s.Repo.GetTray(ctx, viewerID)
+}
+
+// MarkSeen отмечает активную историю просмотренной. Просмотры автора не учитываются
+func (s *StoryService) MarkSeen(ctx context.Context, storyID, viewerID int) error {
+	story, err := s.Repo.GetStory(ctx, storyID, viewerID)
+	if err != nil {
+		return err
+	}
+	if story.UserID == viewerID {
+		return nil
+	}
+	return s.Repo.MarkSeen(ctx, storyID, viewerID)
+}
+
+// GetViewers возвращает просмотревших историю. Список виден только автору
+func (s *StoryService) GetViewers(ctx context.Context, storyID, authorID int) ([]models.StoryViewer, error) {
+	return s.Repo.GetViewers(ctx, storyID, authorID)
+}
+
+// DeleteStory удаляет историю автора вместе с файлом
+func (s *StoryService) DeleteStory(ctx context.Context, storyID, authorID int) error {
+	url, err := s.Repo.Delete(ctx, storyID, authorID)
+	if err != nil {
+		return err
+	}
+	s.removeFile(url)
+	return nil
+}
+
+// SweepExpired удаляет истекшие истории и их файлы. Возвращает количество удаленных историй
+func (s *StoryService) SweepExpired(ctx context.Context) (int, error) {
+	urls, err := s.Repo.DeleteExpired(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, url := range urls {
+		s.removeFile(url)
+	}
+	return len(urls), nil
+}
+
+// RunSweeper периодически удаляет истекшие истории до отмены ctx. interval <= 0 отключает очистку
+func (s *StoryService) RunSweeper(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			removed, err := s.SweepExpired(ctx)
+			if err != nil {
+				s.Logger.Error("Не удалось удалить истекшие истории", zap.Error(err))
+				continue
+			}
+			if removed > 0 {
+				s.Logger.Info("Истекшие истории удалены", zap.Int("count", removed))
+			}
+		}
+	}
+}
+
+func (s *StoryService) removeFile(path string) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		s.Logger.Warn("Не удалось удалить файл истории", zap.String("file", path), zap.Error(err))
+	}
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"InstaSpace/internal/models"
+	"context"
+	"time"
+)
+
+const (
+	// DefaultTypingTTL сколько действует событие набора, если клиент его не повторил
+	DefaultTypingTTL = 6 * time.Second
+	// DefaultTypingThrottle как часто участникам пересылается набор одного пользователя в беседе
+	DefaultTypingThrottle = 3 * time.Second
+)
+
+type typingKey struct {
+	conversationID int
+	userID         int
+}
+
+// typingState набор сообщения пользователем в беседе
+type typingState struct {
+	relayedAt time.Time
+	expiresAt time.Time
+	timer     *time.Timer
+}
+
+// SendTyping сообщает остальным участникам беседы, что пользователь набирает сообщение или перестал.
+// Повторы чаще TypingThrottle продлевают набор, но не пересылаются. Если набор не повторился за TypingTTL,
+// участники получают событие о его прекращении
+func (s *MessageService) SendTyping(ctx context.Context, conversationID, userID int, typing bool) error {
+	if _, _, err := s.conversationForParticipant(ctx, conversationID, userID); err != nil {
+		return err
+	}
+
+	if !typing {
+		s.stopTyping(ctx, conversationID, userID)
+		return nil
+	}
+
+	key := typingKey{conversationID: conversationID, userID: userID}
+	now := time.Now()
+
+	s.typingMu.Lock()
+	state, ok := s.typing[key]
+	if !ok {
+		state = &typingState{}
+		s.typing[key] = state
+	}
+	state.expiresAt = now.Add(s.TypingTTL)
+	relay := now.Sub(state.relayedAt) >= s.TypingThrottle
+	if relay {
+		state.relayedAt = now
+	}
+	if state.timer != nil {
+		state.timer.Stop()
+	}
+	state.timer = time.AfterFunc(s.TypingTTL, func() { s.expireTyping(key, state) })
+	expiresAt := state.expiresAt.UTC()
+	s.typingMu.Unlock()
+
+	if relay {
+		s.publishToParticipants(ctx, conversationID, userID, models.WSTyping, models.WSTypingPayload{
+			ConversationID: conversationID,
+			UserID:         userID,
+			Typing:         true,
+			ExpiresAt:      &expiresAt,
+		})
+	}
+	return nil
+}
+
+// stopTyping завершает набор пользователя в беседе и сообщает об этом участникам, если набор шел
+func (s *MessageService) stopTyping(ctx context.Context, conversationID, userID int) {
+	key := typingKey{conversationID: conversationID, userID: userID}
+
+	s.typingMu.Lock()
+	state, ok := s.typing[key]
+	if ok {
+		state.timer.Stop()
+		delete(s.typing, key)
+	}
+	s.typingMu.Unlock()
+
+	if ok {
+		s.publishTypingStopped(ctx, key)
+	}
+}
+
+// expireTyping завершает набор, который не продлили за TypingTTL
+func (s *MessageService) expireTyping(key typingKey, state *typingState) {
+	s.typingMu.Lock()
+	if s.typing[key] != state || time.Now().Before(state.expiresAt) {
+		s.typingMu.Unlock()
+		return
+	}
+	delete(s.typing, key)
+	s.typingMu.Unlock()
+
+	s.publishTypingStopped(context.Background(), key)
+}
+
+func (s *MessageService) publishTypingStopped(ctx context.Context, key typingKey) {
+	s.publishToParticipants(ctx, key.conversationID, key.userID, models.WSTyping, models.WSTypingPayload{
+		ConversationID: key.conversationID,
+		UserID:         key.userID,
+	})
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"InstaSpace/internal/models"
+	"InstaSpace/internal/repositories"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// DefaultWSTicketTTL сколько действует билет для подключения к WebSocket
+const DefaultWSTicketTTL = 30 * time.Second
+
+var ErrInvalidWSTicket = errors.New("invalid or expired ws ticket")
+
+type WSTicketServiceInterface interface {
+	IssueTicket(ctx context.Context, userID int, sessionExpiresAt time.Time) (*models.WSTicket, error)
+	RedeemTicket(ctx context.Context, ticket string) (int, time.Time, error)
+}
+
+type WSTicketService struct {
+	Repo repositories.WSTicketRepositoryInterface
+	// TTL сколько действует билет
+	TTL time.Duration
+}
+
+func NewWSTicketService(repo repositories.WSTicketRepositoryInterface) *WSTicketService {
+	return &WSTicketService{Repo: repo, TTL: DefaultWSTicketTTL}
+}
+
+// IssueTicket выдает одноразовый билет для подключения к WebSocket. Подключение по билету
+// живет не дольше сессии, в которой он выдан
+func (s *WSTicketService) IssueTicket(ctx context.Context, userID int, sessionExpiresAt time.Time) (*models.WSTicket, error) {
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	ticket := base64.RawURLEncoding.EncodeToString(raw)
+
+	if err := s.Repo.Create(ctx, hashWSTicket(ticket), userID, sessionExpiresAt, s.TTL); err != nil {
+		return nil, err
+	}
+	return &models.WSTicket{Ticket: ticket, ExpiresAt: time.Now().Add(s.TTL)}, nil
+}
+
+// RedeemTicket погашает билет и возвращает ID пользователя и время истечения его сессии
+func (s *WSTicketService) RedeemTicket(ctx context.Context, ticket string) (int, time.Time, error) {
+	if ticket == "" {
+		return 0, time.Time{}, ErrInvalidWSTicket
+	}
+
+	userID, sessionExpiresAt, err := s.Repo.Consume(ctx, hashWSTicket(ticket))
+	if errors.Is(err, repositories.ErrWSTicketNotFound) {
+		return 0, time.Time{}, ErrInvalidWSTicket
+	}
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return userID, sessionExpiresAt, nil
+}
+
+// hashWSTicket возвращает хэш билета, под которым он хранится, чтобы утечка таблицы не давала подключиться
+func hashWSTicket(ticket string) string {
+	sum := sha256.Sum256([]byte(ticket))
+	return hex.EncodeToString(sum[:])
+}
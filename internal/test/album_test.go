@@ -0,0 +1,104 @@
+package test
+
+import (
+	"InstaSpace/internal/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func getAlbumPhotoIDs(t *testing.T, albumID, userID int) []int {
+	t.Helper()
+
+	resp := doAuthRequest(t, "GET", fmt.Sprintf("/api/albums/%d/photos", albumID), userID, "")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Некорректный HTTP код ответа")
+
+	var photos []models.Photo
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&photos), "Ошибка декодирования ответа")
+	ids := make([]int, len(photos))
+	for i, photo := range photos {
+		ids[i] = photo.ID
+	}
+	return ids
+}
+
+func TestAlbums(t *testing.T) {
+	setupTestBlocks(t, db)
+
+	_, err := db.Exec(context.Background(), `
+		INSERT INTO photos (id, user_id, url) VALUES
+		(4, 1, 'uploads/photo4.jpg'), (5, 1, 'uploads/photo5.jpg')`)
+	require.NoError(t, err, "Не удалось создать фото")
+
+	resp := doAuthRequest(t, "POST", "/api/albums", 1, `{"title": "Summer", "visibility": "followers", "photo_ids": [4, 1, 5]}`)
+	require.Equal(t, http.StatusCreated, resp.StatusCode, "Не удалось создать альбом")
+	var album models.Album
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&album), "Ошибка декодирования ответа")
+	resp.Body.Close()
+	assert.Equal(t, 3, album.PhotosCount, "Некорректное количество фото")
+	assert.Equal(t, "uploads/photo4.jpg", album.CoverURL, "Обложкой по умолчанию должно быть первое фото")
+
+	resp = doAuthRequest(t, "POST", "/api/albums", 1, `{"title": "Stolen", "photo_ids": [2]}`)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "Чужие фото нельзя добавить в альбом")
+
+	albumURL := fmt.Sprintf("/api/albums/%d", album.ID)
+	assert.Equal(t, []int{4, 1, 5}, getAlbumPhotoIDs(t, album.ID, 1), "Некорректный порядок фото")
+
+	resp = doAuthRequest(t, "PUT", albumURL+"/order", 1, `{"photo_ids": [5, 4, 1]}`)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось изменить порядок")
+	assert.Equal(t, []int{5, 4, 1}, getAlbumPhotoIDs(t, album.ID, 1), "Порядок должен измениться")
+	resp = doAuthRequest(t, "PUT", albumURL+"/order", 1, `{"photo_ids": [5, 4]}`)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "Неполный порядок должен отклоняться")
+
+	resp = doAuthRequest(t, "PUT", albumURL, 1, `{"title": "Summer", "visibility": "followers", "cover_photo_id": 1}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось изменить альбом")
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&album), "Ошибка декодирования ответа")
+	resp.Body.Close()
+	assert.Equal(t, "uploads/photo1.jpg", album.CoverURL, "Обложка должна измениться")
+
+	resp = doAuthRequest(t, "DELETE", albumURL+"/photos/1", 1, "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось убрать фото")
+	resp = doAuthRequest(t, "GET", albumURL, 1, "")
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&album), "Ошибка декодирования ответа")
+	resp.Body.Close()
+	assert.Nil(t, album.CoverPhotoID, "Удаленное фото не должно оставаться обложкой")
+	assert.Equal(t, "uploads/photo5.jpg", album.CoverURL, "Обложкой должно стать первое фото")
+
+	// Пользователь 2 подписан на автора, пользователь 3 — нет
+	status := func(userID int) int {
+		resp := doAuthRequest(t, "GET", albumURL+"/photos", userID, "")
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	assert.Equal(t, http.StatusOK, status(2), "Подписчик должен видеть альбом для подписчиков")
+	assert.Equal(t, http.StatusNotFound, status(3), "Неподписанный пользователь не должен видеть альбом")
+
+	resp = doAuthRequest(t, "PUT", albumURL, 1, `{"title": "Summer", "visibility": "private"}`)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось изменить видимость")
+	assert.Equal(t, http.StatusNotFound, status(2), "Приватный альбом виден только автору")
+
+	resp = doAuthRequest(t, "GET", "/api/users/1/albums", 2, "")
+	var list struct {
+		Albums []models.Album `json:"albums"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list), "Ошибка декодирования ответа")
+	resp.Body.Close()
+	assert.Empty(t, list.Albums, "Приватный альбом не должен попадать в список")
+
+	resp = doAuthRequest(t, "DELETE", albumURL, 2, "")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "Чужой альбом удалять нельзя")
+	resp = doAuthRequest(t, "DELETE", albumURL, 1, "")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось удалить альбом")
+}
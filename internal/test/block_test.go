@@ -0,0 +1,232 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func setupTestBlocks(t *testing.T, db *pgxpool.Pool) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	_, err := db.Exec(ctx, "TRUNCATE TABLE user_blocks, user_mutes, follows, messages, conversations, comments, photo_reactions, photos, users RESTART IDENTITY CASCADE")
+	require.NoError(t, err, "Не удалось очистить таблицы")
+
+	_, err = db.Exec(ctx, `
+		INSERT INTO users (id, email, password, username) VALUES
+		(1, 'user1@example.com', 'password1', 'user1'),
+		(2, 'user2@example.com', 'password2', 'user2'),
+		(3, 'user3@example.com', 'password3', 'user3')`)
+	require.NoError(t, err, "Не удалось создать пользователей")
+
+	_, err = db.Exec(ctx, `
+		INSERT INTO photos (id, user_id, url) VALUES
+		(1, 1, 'uploads/photo1.jpg'),
+		(2, 2, 'uploads/photo2.jpg'),
+		(3, 3, 'uploads/photo3.jpg')`)
+	require.NoError(t, err, "Не удалось создать фото")
+
+	_, err = db.Exec(ctx, "INSERT INTO follows (follower_id, followee_id) VALUES (1, 2), (1, 3), (2, 1)")
+	require.NoError(t, err, "Не удалось создать подписки")
+
+	createTestConversation(t)
+	_, err = db.Exec(ctx, "SELECT setval('conversations_id_seq', 1)")
+	require.NoError(t, err, "Не удалось сдвинуть последовательность бесед")
+}
+
+func doAuthRequest(t *testing.T, method, url string, userID int, body string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(method, testServer.URL+url, strings.NewReader(body))
+	require.NoError(t, err, "Ошибка создания HTTP запроса")
+	req.Header.Set("Authorization", authHeader(t, userID))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err, "Ошибка выполнения HTTP запроса")
+	return resp
+}
+
+func TestBlockHandlers(t *testing.T) {
+	setupTestBlocks(t, db)
+
+	testCases := []struct {
+		Name         string
+		Method       string
+		URL          string
+		UserID       int
+		ExpectedCode int
+	}{
+		{
+			Name:         "Успешная блокировка",
+			Method:       "POST",
+			URL:          "/api/users/2/block",
+			UserID:       1,
+			ExpectedCode: http.StatusOK,
+		},
+		{
+			Name:         "Повторная блокировка не является ошибкой",
+			Method:       "POST",
+			URL:          "/api/users/2/block",
+			UserID:       1,
+			ExpectedCode: http.StatusOK,
+		},
+		{
+			Name:         "Ошибка: Блокировка самого себя",
+			Method:       "POST",
+			URL:          "/api/users/1/block",
+			UserID:       1,
+			ExpectedCode: http.StatusBadRequest,
+		},
+		{
+			Name:         "Ошибка: Блокировка несуществующего пользователя",
+			Method:       "POST",
+			URL:          "/api/users/99/block",
+			UserID:       1,
+			ExpectedCode: http.StatusBadRequest,
+		},
+		{
+			Name:         "Ошибка: Подписка заблокированного пользователя",
+			Method:       "POST",
+			URL:          "/api/users/1/follow",
+			UserID:       2,
+			ExpectedCode: http.StatusForbidden,
+		},
+		{
+			Name:         "Успешное скрытие пользователя",
+			Method:       "POST",
+			URL:          "/api/users/3/mute",
+			UserID:       1,
+			ExpectedCode: http.StatusOK,
+		},
+		{
+			Name:         "Успешная разблокировка",
+			Method:       "DELETE",
+			URL:          "/api/users/2/block",
+			UserID:       1,
+			ExpectedCode: http.StatusOK,
+		},
+		{
+			Name:         "Подписка после разблокировки",
+			Method:       "POST",
+			URL:          "/api/users/1/follow",
+			UserID:       2,
+			ExpectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			resp := doAuthRequest(t, tc.Method, tc.URL, tc.UserID, "")
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.ExpectedCode, resp.StatusCode, "Некорректный HTTP код ответа")
+		})
+	}
+
+	resp := doAuthRequest(t, "GET", "/api/mutes", 1, "")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Некорректный HTTP код ответа")
+
+	var result struct {
+		Users []struct {
+			ID int `json:"id"`
+		} `json:"users"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result), "Ошибка декодирования ответа")
+	require.Len(t, result.Users, 1, "Ожидался один скрытый пользователь")
+	assert.Equal(t, 3, result.Users[0].ID, "Некорректный скрытый пользователь")
+}
+
+func TestBlockRestrictions(t *testing.T) {
+	setupTestBlocks(t, db)
+
+	resp := doAuthRequest(t, "POST", "/api/users/2/block", 1, "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось заблокировать пользователя")
+
+	resp = doAuthRequest(t, "POST", "/api/users/3/mute", 1, "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось скрыть пользователя")
+
+	var followCount int
+	err := db.QueryRow(context.Background(), "SELECT COUNT(*) FROM follows WHERE (follower_id = 1 AND followee_id = 2) OR (follower_id = 2 AND followee_id = 1)").Scan(&followCount)
+	require.NoError(t, err, "Ошибка проверки подписок")
+	assert.Equal(t, 0, followCount, "Блокировка должна удалять подписки в обе стороны")
+
+	testCases := []struct {
+		Name         string
+		Method       string
+		URL          string
+		Payload      string
+		UserID       int
+		ExpectedCode int
+	}{
+		{
+			Name:         "Ошибка: Комментарий к фото заблокировавшего",
+			Method:       "POST",
+			URL:          "/api/comments",
+			Payload:      `{"photo_id": 1, "content": "Hi"}`,
+			UserID:       2,
+			ExpectedCode: http.StatusForbidden,
+		},
+		{
+			Name:         "Ошибка: Лайк фото заблокировавшего",
+			Method:       "POST",
+			URL:          "/api/likes?photoID=1",
+			UserID:       2,
+			ExpectedCode: http.StatusForbidden,
+		},
+		{
+			Name:         "Ошибка: Сообщение заблокировавшему",
+			Method:       "POST",
+			URL:          "/api/messages",
+			Payload:      `{"conversation_id": 1, "content": "Hi"}`,
+			UserID:       2,
+			ExpectedCode: http.StatusForbidden,
+		},
+		{
+			Name:         "Комментарий к фото скрытого пользователя разрешен",
+			Method:       "POST",
+			URL:          "/api/comments",
+			Payload:      `{"photo_id": 3, "content": "Hi"}`,
+			UserID:       1,
+			ExpectedCode: http.StatusCreated,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.Method, testServer.URL+tc.URL, strings.NewReader(tc.Payload))
+			require.NoError(t, err, "Ошибка создания HTTP запроса")
+			req.Header.Set("Content-Type", "application/json")
+			if tc.UserID != 0 {
+				req.Header.Set("Authorization", authHeader(t, tc.UserID))
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err, "Ошибка выполнения HTTP запроса")
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.ExpectedCode, resp.StatusCode, "Некорректный HTTP код ответа")
+		})
+	}
+
+	resp = doAuthRequest(t, "GET", "/api/feed", 1, "")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Некорректный HTTP код ответа")
+
+	var feed []struct {
+		UserID int `json:"user_id"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&feed), "Ошибка декодирования ленты")
+	require.Len(t, feed, 1, "В ленте должны остаться только собственные фото")
+	assert.Equal(t, 1, feed[0].UserID, "Некорректный автор фото в ленте")
+}
@@ -0,0 +1,110 @@
+package test
+
+import (
+	"InstaSpace/internal/models"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func getCollections(t *testing.T, userID int) []models.Collection {
+	t.Helper()
+
+	resp := doAuthRequest(t, "GET", "/api/collections", userID, "")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Некорректный HTTP код ответа")
+
+	var result struct {
+		Collections []models.Collection `json:"collections"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result), "Ошибка декодирования ответа")
+	return result.Collections
+}
+
+func getCollectionPhotos(t *testing.T, url string, userID int) models.SavedPhotosPage {
+	t.Helper()
+
+	resp := doAuthRequest(t, "GET", url, userID, "")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Некорректный HTTP код ответа")
+
+	var page models.SavedPhotosPage
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&page), "Ошибка декодирования ответа")
+	return page
+}
+
+func TestCollections(t *testing.T) {
+	setupTestBlocks(t, db)
+
+	collections := getCollections(t, 1)
+	require.Len(t, collections, 1, "Коллекция по умолчанию должна создаваться автоматически")
+	assert.Equal(t, models.DefaultCollectionName, collections[0].Name, "Некорректное название коллекции по умолчанию")
+	assert.True(t, collections[0].IsDefault, "Коллекция должна быть по умолчанию")
+	savedID := collections[0].ID
+
+	resp := doAuthRequest(t, "POST", "/api/collections", 1, `{"name": "Travel"}`)
+	require.Equal(t, http.StatusCreated, resp.StatusCode, "Не удалось создать коллекцию")
+	var travel models.Collection
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&travel), "Ошибка декодирования ответа")
+	resp.Body.Close()
+
+	resp = doAuthRequest(t, "POST", "/api/collections", 1, `{"name": "Travel"}`)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "Повторное название должно отклоняться")
+	resp = doAuthRequest(t, "POST", "/api/collections", 1, `{"name": "saved"}`)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "Зарезервированное название должно отклоняться")
+
+	save := func(userID int, photoID int, body string) int {
+		resp := doAuthRequest(t, "PUT", fmt.Sprintf("/api/photos/%d/save", photoID), userID, body)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	require.Equal(t, http.StatusOK, save(1, 2, ""), "Не удалось сохранить фото")
+	require.Equal(t, http.StatusOK, save(1, 3, ""), "Не удалось сохранить фото")
+	require.Equal(t, http.StatusOK, save(1, 3, ""), "Повторное сохранение не должно отклоняться")
+	require.Equal(t, http.StatusOK, save(1, 2, fmt.Sprintf(`{"collection_id": %d}`, travel.ID)), "Не удалось сохранить фото в коллекцию")
+	assert.Equal(t, http.StatusNotFound, save(1, 99, ""), "Сохранение несуществующего фото должно отклоняться")
+	assert.Equal(t, http.StatusNotFound, save(2, 1, fmt.Sprintf(`{"collection_id": %d}`, travel.ID)), "Чужая коллекция должна быть недоступна")
+
+	savedURL := fmt.Sprintf("/api/collections/%d/photos", savedID)
+	page := getCollectionPhotos(t, savedURL+"?limit=1", 1)
+	require.Len(t, page.Photos, 1, "Некорректный размер страницы")
+	assert.Equal(t, 3, page.Photos[0].ID, "Последнее сохраненное фото должно идти первым")
+	require.NotEmpty(t, page.NextCursor, "Должен быть курсор следующей страницы")
+	page = getCollectionPhotos(t, savedURL+"?limit=1&cursor="+page.NextCursor, 1)
+	require.Len(t, page.Photos, 1, "Некорректный размер страницы")
+	assert.Equal(t, 2, page.Photos[0].ID, "Некорректное фото второй страницы")
+	assert.Empty(t, page.NextCursor, "На последней странице курсора быть не должно")
+
+	resp = doAuthRequest(t, "GET", savedURL, 2, "")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "Чужая коллекция должна быть недоступна")
+
+	// Фото автора, заблокировавшего пользователя, скрывается из коллекций
+	resp = doAuthRequest(t, "POST", "/api/users/1/block", 3, "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось заблокировать пользователя")
+	page = getCollectionPhotos(t, savedURL, 1)
+	require.Len(t, page.Photos, 1, "Фото заблокировавшего автора должно скрываться")
+	assert.Equal(t, 2, page.Photos[0].ID, "Некорректное доступное фото")
+	assert.Equal(t, 1, getCollections(t, 1)[0].PhotosCount, "Скрытые фото не должны учитываться")
+
+	resp = doAuthRequest(t, "DELETE", "/api/photos/2/save", 1, "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось убрать фото из сохраненных")
+	collections = getCollections(t, 1)
+	require.Len(t, collections, 2, "Некорректное количество коллекций")
+	assert.Equal(t, 0, collections[0].PhotosCount, "Фото должно удаляться из всех коллекций")
+	assert.Equal(t, 0, collections[1].PhotosCount, "Фото должно удаляться из всех коллекций")
+
+	resp = doAuthRequest(t, "DELETE", fmt.Sprintf("/api/collections/%d", savedID), 1, "")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "Коллекцию по умолчанию удалять нельзя")
+	resp = doAuthRequest(t, "DELETE", fmt.Sprintf("/api/collections/%d", travel.ID), 1, "")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось удалить коллекцию")
+}
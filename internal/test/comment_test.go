@@ -11,7 +11,9 @@ import (
 	"github.com/stretchr/testify/require"
 	"io"
 	"net/http"
+	"strconv"
 	"testing"
+	"time"
 )
 
 var (
@@ -69,13 +71,12 @@ func TestCreateCommentHandler(t *testing.T) {
 			ShouldError:  true,
 		},
 		{
-			Name: "Ошибка: Неверный user_id",
+			Name: "Ошибка: Без токена",
 			Payload: models.Comment{
 				PhotoID: 1,
-				UserID:  0,
-				Content: "Invalid user_id",
+				Content: "Anonymous",
 			},
-			ExpectedCode: http.StatusBadRequest,
+			ExpectedCode: http.StatusUnauthorized,
 			ShouldError:  true,
 		},
 		{
@@ -98,6 +99,10 @@ func TestCreateCommentHandler(t *testing.T) {
 			req, err := http.NewRequest("POST", testServer.URL+"/api/comments", bytes.NewReader(payload))
 			require.NoError(t, err, "Ошибка создания HTTP запроса")
 			req.Header.Set("Content-Type", "application/json")
+			// Автор берется из токена
+			if tc.Payload.UserID != 0 {
+				req.Header.Set("Authorization", authHeader(t, tc.Payload.UserID))
+			}
 
 			client := &http.Client{}
 			resp, err := client.Do(req)
@@ -155,17 +160,22 @@ func TestGetCommentsByPhotoID(t *testing.T) {
 			assert.Equal(t, tc.ExpectedCode, resp.StatusCode, "Некорректный HTTP код ответа")
 
 			if !tc.ShouldError {
-				var comments []struct {
-					Content  string `json:"content"`
-					Username string `json:"username"`
+				var page struct {
+					Comments []struct {
+						Content  string `json:"content"`
+						Username string `json:"username"`
+						UserID   int    `json:"user_id"`
+					} `json:"comments"`
 				}
-				err := json.NewDecoder(resp.Body).Decode(&comments)
+				err := json.NewDecoder(resp.Body).Decode(&page)
 				require.NoError(t, err, "Ошибка декодирования ответа")
+				comments := page.Comments
 
 				assert.Equal(t, tc.ExpectedLen, len(comments), "Некорректное количество комментариев")
 
 				if len(comments) > 0 {
 					assert.Equal(t, "testuser", comments[0].Username, "Некорректное имя пользователя")
+					assert.Equal(t, 1, comments[0].UserID, "Некорректный автор комментария")
 				}
 			}
 		})
@@ -178,6 +188,7 @@ func TestUpdateComment(t *testing.T) {
 	testCases := []struct {
 		Name         string
 		CommentID    string
+		UserID       int
 		Payload      string
 		ExpectedCode int
 		ExpectedBody string
@@ -186,7 +197,8 @@ func TestUpdateComment(t *testing.T) {
 		{
 			Name:         "Успешное обновление комментария",
 			CommentID:    "1",
-			Payload:      `{"content": "Updated content", "user_id": 1}`,
+			UserID:       1,
+			Payload:      `{"content": "Updated content"}`,
 			ExpectedCode: http.StatusOK,
 			ExpectedBody: `comment updated successfully`,
 			ShouldError:  false,
@@ -194,13 +206,15 @@ func TestUpdateComment(t *testing.T) {
 		{
 			Name:         "Ошибка: Некорректный ID комментария",
 			CommentID:    "abc",
-			Payload:      `{"content": "Updated content", "user_id": 1}`,
+			UserID:       1,
+			Payload:      `{"content": "Updated content"}`,
 			ExpectedCode: http.StatusBadRequest,
 			ShouldError:  true,
 		},
 		{
 			Name:         "Ошибка: Некорректное тело запроса",
 			CommentID:    "1",
+			UserID:       1,
 			Payload:      `{"invalid_field": "value"}`,
 			ExpectedCode: http.StatusBadRequest,
 			ShouldError:  true,
@@ -208,10 +222,26 @@ func TestUpdateComment(t *testing.T) {
 		{
 			Name:         "Ошибка: Комментарий не найден",
 			CommentID:    "99",
-			Payload:      `{"content": "Updated content", "user_id": 1}`,
+			UserID:       1,
+			Payload:      `{"content": "Updated content"}`,
 			ExpectedCode: http.StatusInternalServerError,
 			ShouldError:  true,
 		},
+		{
+			Name:         "Ошибка: user_id в теле не позволяет править чужой комментарий",
+			CommentID:    "1",
+			UserID:       2,
+			Payload:      `{"content": "Hijacked", "user_id": 1}`,
+			ExpectedCode: http.StatusInternalServerError,
+			ShouldError:  true,
+		},
+		{
+			Name:         "Ошибка: Без токена",
+			CommentID:    "1",
+			Payload:      `{"content": "Anonymous"}`,
+			ExpectedCode: http.StatusUnauthorized,
+			ShouldError:  true,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -219,6 +249,9 @@ func TestUpdateComment(t *testing.T) {
 			req, err := http.NewRequest("PUT", testServer.URL+"/api/comments/"+tc.CommentID+"/edit", bytes.NewBuffer([]byte(tc.Payload)))
 			require.NoError(t, err, "Ошибка создания HTTP запроса")
 			req.Header.Set("Content-Type", "application/json")
+			if tc.UserID != 0 {
+				req.Header.Set("Authorization", authHeader(t, tc.UserID))
+			}
 
 			client := &http.Client{}
 			resp, err := client.Do(req)
@@ -244,7 +277,7 @@ func TestDeleteComment(t *testing.T) {
 	testCases := []struct {
 		Name         string
 		CommentID    string
-		UserID       string
+		UserID       int
 		ExpectedCode int
 		ExpectedBody string
 		ShouldError  bool
@@ -252,7 +285,7 @@ func TestDeleteComment(t *testing.T) {
 		{
 			Name:         "Успешное удаление комментария",
 			CommentID:    "1",
-			UserID:       "1",
+			UserID:       1,
 			ExpectedCode: http.StatusOK,
 			ExpectedBody: `{"message":"comment deleted successfully"}`,
 			ShouldError:  false,
@@ -260,23 +293,22 @@ func TestDeleteComment(t *testing.T) {
 		{
 			Name:         "Ошибка: Некорректный comment_id",
 			CommentID:    "abc",
-			UserID:       "1",
+			UserID:       1,
 			ExpectedCode: http.StatusBadRequest,
 			ExpectedBody: "invalid comment_id",
 			ShouldError:  true,
 		},
 		{
-			Name:         "Ошибка: Некорректный user_id",
+			Name:         "Ошибка: Без токена",
 			CommentID:    "1",
-			UserID:       "abc",
-			ExpectedCode: http.StatusBadRequest,
-			ExpectedBody: "invalid user_id",
+			ExpectedCode: http.StatusUnauthorized,
+			ExpectedBody: "Unauthorized",
 			ShouldError:  true,
 		},
 		{
 			Name:         "Ошибка: Комментарий не найден",
 			CommentID:    "99",
-			UserID:       "1",
+			UserID:       1,
 			ExpectedCode: http.StatusInternalServerError,
 			ExpectedBody: "no rows deleted",
 			ShouldError:  true,
@@ -285,8 +317,11 @@ func TestDeleteComment(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.Name, func(t *testing.T) {
-			req, err := http.NewRequest("DELETE", testServer.URL+"/api/comments/"+tc.CommentID+"/delete?user_id="+tc.UserID, nil)
+			req, err := http.NewRequest("DELETE", testServer.URL+"/api/comments/"+tc.CommentID+"/delete", nil)
 			require.NoError(t, err, "Ошибка создания HTTP запроса")
+			if tc.UserID != 0 {
+				req.Header.Set("Authorization", authHeader(t, tc.UserID))
+			}
 
 			client := &http.Client{}
 			resp, err := client.Do(req)
@@ -309,3 +344,313 @@ func TestDeleteComment(t *testing.T) {
 		})
 	}
 }
+
+func TestCommentReplies(t *testing.T) {
+	setupTestData(t, db)
+
+	createComment := func(payload string) int {
+		resp := doAuthRequest(t, "POST", "/api/comments", 1, payload)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode, "Некорректный HTTP код ответа")
+
+		var body struct {
+			ID int `json:"id"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body), "Ошибка декодирования ответа")
+		return body.ID
+	}
+
+	replyID := createComment(`{"photo_id": 1, "parent_id": 1, "content": "Reply"}`)
+	nestedID := createComment(`{"photo_id": 1, "parent_id": ` + strconv.Itoa(replyID) + `, "content": "Nested reply"}`)
+
+	var parentID int
+	err := db.QueryRow(context.Background(), "SELECT parent_id FROM comments WHERE id = $1", nestedID).Scan(&parentID)
+	require.NoError(t, err, "Ошибка чтения комментария")
+	assert.Equal(t, 1, parentID, "Ответ на ответ должен прикрепляться к комментарию верхнего уровня")
+
+	resp := doAuthRequest(t, "POST", "/api/comments", 1, `{"photo_id": 1, "parent_id": 999, "content": "Orphan"}`)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "Ответ на несуществующий комментарий должен отклоняться")
+
+	type commentDTO struct {
+		ID         int    `json:"id"`
+		Content    string `json:"content"`
+		ReplyCount int    `json:"reply_count"`
+		Deleted    bool   `json:"deleted"`
+	}
+	getComments := func(url string) []commentDTO {
+		resp, err := http.Get(testServer.URL + url)
+		require.NoError(t, err, "Ошибка выполнения HTTP запроса")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode, "Некорректный HTTP код ответа")
+
+		var page struct {
+			Comments []commentDTO `json:"comments"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&page), "Ошибка декодирования ответа")
+		return page.Comments
+	}
+	deleteComment := func(id int) {
+		resp := doAuthRequest(t, "DELETE", "/api/comments/"+strconv.Itoa(id)+"/delete", 1, "")
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось удалить комментарий")
+	}
+
+	comments := getComments("/api/comments/1")
+	require.Len(t, comments, 2, "Ответы не должны попадать в список верхнего уровня")
+	for _, c := range comments {
+		if c.ID == 1 {
+			assert.Equal(t, 2, c.ReplyCount, "Некорректное количество ответов")
+		}
+	}
+	assert.Len(t, getComments("/api/comments/1/replies"), 2, "Некорректное количество ответов")
+
+	deleteComment(1)
+	for _, c := range getComments("/api/comments/1") {
+		if c.ID == 1 {
+			assert.True(t, c.Deleted, "Комментарий с ответами должен остаться заглушкой")
+			assert.Equal(t, "[deleted]", c.Content, "Некорректный текст заглушки")
+		}
+	}
+
+	deleteComment(replyID)
+	deleteComment(nestedID)
+	assert.Len(t, getComments("/api/comments/1"), 1, "Заглушка без ответов должна удаляться")
+}
+
+func TestCommentPagination(t *testing.T) {
+	setupTestData(t, db)
+
+	_, err := db.Exec(context.Background(), `
+		INSERT INTO comments (user_id, photo_id, content, likes_count, created_at) VALUES
+		(1, 1, 'Third', 5, NOW() + INTERVAL '1 minute'),
+		(1, 1, 'Fourth', 1, NOW() + INTERVAL '2 minutes'),
+		(1, 1, 'Fifth', 3, NOW() + INTERVAL '3 minutes')`)
+	require.NoError(t, err, "Не удалось добавить комментарии")
+
+	testCases := []struct {
+		Name     string
+		Sort     string
+		Expected []string
+	}{
+		{
+			Name:     "Сначала новые",
+			Sort:     "newest",
+			Expected: []string{"Fifth", "Fourth", "Third", "Second", "Original content"},
+		},
+		{
+			Name:     "Сначала старые",
+			Sort:     "oldest",
+			Expected: []string{"Original content", "Second", "Third", "Fourth", "Fifth"},
+		},
+		{
+			Name:     "Сначала популярные",
+			Sort:     "top",
+			Expected: []string{"Third", "Fifth", "Fourth", "Second", "Original content"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var contents []string
+			cursor := ""
+			for pages := 0; pages < 10; pages++ {
+				resp, err := http.Get(testServer.URL + "/api/comments/1?limit=2&sort=" + tc.Sort + "&cursor=" + cursor)
+				require.NoError(t, err, "Ошибка выполнения HTTP запроса")
+
+				var page struct {
+					Comments []struct {
+						Content string `json:"content"`
+					} `json:"comments"`
+					NextCursor string `json:"next_cursor"`
+				}
+				require.Equal(t, http.StatusOK, resp.StatusCode, "Некорректный HTTP код ответа")
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(&page), "Ошибка декодирования ответа")
+				resp.Body.Close()
+
+				require.LessOrEqual(t, len(page.Comments), 2, "Страница больше лимита")
+				for _, c := range page.Comments {
+					contents = append(contents, c.Content)
+				}
+				if page.NextCursor == "" {
+					break
+				}
+				cursor = page.NextCursor
+			}
+
+			assert.Equal(t, tc.Expected, contents, "Некорректный порядок комментариев")
+		})
+	}
+
+	resp, err := http.Get(testServer.URL + "/api/comments/1?sort=random")
+	require.NoError(t, err, "Ошибка выполнения HTTP запроса")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "Неизвестная сортировка должна отклоняться")
+
+	resp, err = http.Get(testServer.URL + "/api/comments/1?cursor=broken")
+	require.NoError(t, err, "Ошибка выполнения HTTP запроса")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "Некорректный курсор должен отклоняться")
+}
+
+func TestCommentModeration(t *testing.T) {
+	setupTestBlocks(t, db)
+
+	createComment := func(userID int, content string) (int, int) {
+		resp := doAuthRequest(t, "POST", "/api/comments", userID, `{"photo_id": 1, "content": "`+content+`"}`)
+		defer resp.Body.Close()
+
+		var body struct {
+			ID int `json:"id"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&body)
+		return resp.StatusCode, body.ID
+	}
+	updateSettings := func(userID int, payload string) int {
+		resp := doAuthRequest(t, "PUT", "/api/photos/1/comment-settings", userID, payload)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusForbidden, updateSettings(2, `{"comments_policy": "off"}`), "Настройки может менять только автор фото")
+	assert.Equal(t, http.StatusBadRequest, updateSettings(1, `{"comments_policy": "friends"}`), "Неизвестная политика должна отклоняться")
+
+	require.Equal(t, http.StatusOK, updateSettings(1, `{"comments_policy": "followers"}`), "Не удалось изменить настройки")
+	code, _ := createComment(3, "Not a follower")
+	assert.Equal(t, http.StatusForbidden, code, "Не подписчик не должен комментировать")
+	code, followerCommentID := createComment(2, "Follower")
+	assert.Equal(t, http.StatusCreated, code, "Подписчик должен комментировать")
+
+	require.Equal(t, http.StatusOK, updateSettings(1, `{"comments_policy": "off"}`), "Не удалось изменить настройки")
+	code, _ = createComment(2, "Comments off")
+	assert.Equal(t, http.StatusForbidden, code, "Комментарии должны быть выключены")
+	code, ownerCommentID := createComment(1, "Owner")
+	assert.Equal(t, http.StatusCreated, code, "Автор фото может комментировать всегда")
+
+	require.Equal(t, http.StatusOK, updateSettings(1, `{"comments_policy": "everyone", "hidden_keywords": [" SPAM ", "spam"]}`),
+		"Не удалось изменить настройки")
+	_, spamID := createComment(3, "Buy Spam now")
+
+	listContents := func(viewerID int) []string {
+		page, err := commentService.GetCommentsByPhotoID(context.Background(), 1, services.CommentListParams{ViewerID: viewerID, Sort: "oldest"})
+		require.NoError(t, err, "Ошибка получения комментариев")
+		var contents []string
+		for _, c := range page.Comments {
+			contents = append(contents, c.Content)
+		}
+		return contents
+	}
+	assert.NotContains(t, listContents(1), "Buy Spam now", "Комментарий со скрываемым словом не должен быть виден")
+	assert.Contains(t, listContents(3), "Buy Spam now", "Автор должен видеть свой скрытый комментарий")
+
+	for userID, content := range map[int]string{2: "Reply", 3: "Spam reply"} {
+		resp := doAuthRequest(t, "POST", "/api/comments", userID,
+			`{"photo_id": 1, "parent_id": `+strconv.Itoa(ownerCommentID)+`, "content": "`+content+`"}`)
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode, "Не удалось ответить на комментарий")
+	}
+	replyCount := func(viewerID int) int {
+		page, err := commentService.GetCommentsByPhotoID(context.Background(), 1, services.CommentListParams{ViewerID: viewerID})
+		require.NoError(t, err, "Ошибка получения комментариев")
+		for _, c := range page.Comments {
+			if c.ID == ownerCommentID {
+				return c.ReplyCount
+			}
+		}
+		return -1
+	}
+	assert.Equal(t, 1, replyCount(1), "Скрытые ответы не должны учитываться")
+	assert.Equal(t, 2, replyCount(3), "Автор ответа должен видеть его в счетчике")
+
+	for _, id := range []int{followerCommentID, ownerCommentID} {
+		resp := doAuthRequest(t, "POST", "/api/comments/"+strconv.Itoa(id)+"/pin", 1, "")
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось закрепить комментарий")
+	}
+	resp := doAuthRequest(t, "POST", "/api/comments/"+strconv.Itoa(followerCommentID)+"/pin", 2, "")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "Закреплять может только автор фото")
+
+	_, extraID := createComment(3, "Extra")
+	_, lastID := createComment(3, "Last")
+	resp = doAuthRequest(t, "POST", "/api/comments/"+strconv.Itoa(extraID)+"/pin", 1, "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось закрепить комментарий")
+	resp = doAuthRequest(t, "POST", "/api/comments/"+strconv.Itoa(lastID)+"/pin", 1, "")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "Нельзя закрепить больше трех комментариев")
+
+	page, err := commentService.GetCommentsByPhotoID(context.Background(), 1, services.CommentListParams{ViewerID: 1})
+	require.NoError(t, err, "Ошибка получения комментариев")
+	require.Len(t, page.Comments, 4, "Некорректное количество комментариев")
+	assert.Equal(t, []string{"Follower", "Owner", "Extra"},
+		[]string{page.Comments[0].Content, page.Comments[1].Content, page.Comments[2].Content}, "Закрепленные комментарии должны идти первыми")
+	assert.True(t, page.Comments[0].Pinned, "Комментарий должен быть отмечен закрепленным")
+
+	resp = doAuthRequest(t, "DELETE", "/api/comments/"+strconv.Itoa(lastID)+"/delete", 2, "")
+	resp.Body.Close()
+	assert.NotEqual(t, http.StatusOK, resp.StatusCode, "Чужой комментарий к чужому фото удалять нельзя")
+
+	resp = doAuthRequest(t, "DELETE", "/api/comments/"+strconv.Itoa(lastID)+"/delete", 1, "")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Автор фото должен удалять чужие комментарии")
+
+	var remaining int
+	require.NoError(t, db.QueryRow(context.Background(), "SELECT COUNT(*) FROM comments WHERE id = ANY($1)",
+		[]int{lastID, spamID}).Scan(&remaining), "Ошибка чтения комментариев")
+	assert.Equal(t, 1, remaining, "Комментарий должен быть удален")
+}
+
+func TestCommentHistory(t *testing.T) {
+	setupTestBlocks(t, db)
+
+	_, err := db.Exec(context.Background(), `
+		INSERT INTO comments (id, user_id, photo_id, content, created_at) VALUES
+		(1, 2, 1, 'First', NOW()),
+		(2, 2, 1, 'Old', NOW() - INTERVAL '1 day')`)
+	require.NoError(t, err, "Не удалось добавить комментарии")
+	_, err = db.Exec(context.Background(), "UPDATE users SET is_moderator = TRUE WHERE id = 3")
+	require.NoError(t, err, "Не удалось назначить модератора")
+
+	edit := func(id int, content string) int {
+		resp := doAuthRequest(t, "PUT", "/api/comments/"+strconv.Itoa(id)+"/edit", 2, `{"content": "`+content+`"}`)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// Окно редактирования может быть дробным числом секунд
+	defer func(window time.Duration) { commentService.EditWindow = window }(commentService.EditWindow)
+	commentService.EditWindow = 90*time.Second + 500*time.Millisecond
+
+	require.Equal(t, http.StatusOK, edit(1, "Second"), "Не удалось изменить комментарий")
+	require.Equal(t, http.StatusOK, edit(1, "Third"), "Не удалось изменить комментарий")
+	assert.Equal(t, http.StatusForbidden, edit(2, "Too late"), "Правка после окна редактирования должна отклоняться")
+
+	page, err := commentService.GetCommentsByPhotoID(context.Background(), 1, services.CommentListParams{Sort: "oldest"})
+	require.NoError(t, err, "Ошибка получения комментариев")
+	for _, c := range page.Comments {
+		if c.ID == 1 {
+			assert.True(t, c.Edited, "Комментарий должен быть отмечен измененным")
+			assert.Equal(t, 2, c.RevisionCount, "Некорректное количество правок")
+		}
+	}
+
+	for _, userID := range []int{1, 3} {
+		resp := doAuthRequest(t, "GET", "/api/comments/1/history", userID, "")
+		require.Equal(t, http.StatusOK, resp.StatusCode, "История должна быть доступна автору фото и модератору")
+
+		var history models.CommentHistory
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&history), "Ошибка декодирования ответа")
+		resp.Body.Close()
+
+		var contents []string
+		for _, revision := range history.Revisions {
+			contents = append(contents, revision.Content)
+		}
+		assert.Equal(t, []string{"First", "Second", "Third"}, contents, "Некорректная история правок")
+	}
+
+	resp := doAuthRequest(t, "GET", "/api/comments/1/history", 2, "")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "Автор комментария не должен видеть историю")
+}
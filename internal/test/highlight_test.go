@@ -0,0 +1,115 @@
+package test
+
+import (
+	"InstaSpace/internal/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"os"
+	"testing"
+)
+
+func getHighlight(t *testing.T, highlightID, userID int) models.Highlight {
+	t.Helper()
+
+	resp := doAuthRequest(t, "GET", fmt.Sprintf("/api/highlights/%d", highlightID), userID, "")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Некорректный HTTP код ответа")
+
+	var highlight models.Highlight
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&highlight), "Ошибка декодирования ответа")
+	return highlight
+}
+
+func TestHighlights(t *testing.T) {
+	setupTestBlocks(t, db)
+	ctx := context.Background()
+
+	story := uploadStory(t, 1, "Sunset")
+
+	body := fmt.Sprintf(`{"title": "Trips", "items": [{"story_id": %d}, {"photo_id": 1}, {"story_id": %d}]}`, story.ID, story.ID)
+	resp := doAuthRequest(t, "POST", "/api/highlights", 1, body)
+	require.Equal(t, http.StatusCreated, resp.StatusCode, "Не удалось создать подборку")
+	var highlight models.Highlight
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&highlight), "Ошибка декодирования ответа")
+	resp.Body.Close()
+	require.Len(t, highlight.Items, 2, "Повторы должны отбрасываться")
+	assert.Equal(t, models.HighlightItemStory, highlight.Items[0].Type, "Некорректный вид элемента")
+	assert.Equal(t, "Sunset", highlight.Items[0].Caption, "Подпись истории должна возвращаться")
+	assert.Equal(t, story.URL, highlight.CoverURL, "Обложкой по умолчанию должен быть первый элемент")
+
+	resp = doAuthRequest(t, "POST", "/api/highlights", 1, `{"title": "Stolen", "items": [{"photo_id": 2}]}`)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "Чужие фото нельзя добавлять в подборку")
+	resp = doAuthRequest(t, "POST", "/api/highlights", 1, `{"title": "  "}`)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "Пустое название должно отклоняться")
+
+	photoItem := highlight.Items[1].ID
+	resp = doAuthRequest(t, "PUT", fmt.Sprintf("/api/highlights/%d", highlight.ID), 1, fmt.Sprintf(`{"title": "Travel", "cover_item_id": %d}`, photoItem))
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось изменить подборку")
+	highlight = getHighlight(t, highlight.ID, 2)
+	assert.Equal(t, "Travel", highlight.Title, "Название должно измениться")
+	assert.Equal(t, "uploads/photo1.jpg", highlight.CoverURL, "Обложка должна измениться")
+
+	resp = doAuthRequest(t, "PUT", fmt.Sprintf("/api/highlights/%d", highlight.ID), 2, `{"title": "Mine"}`)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "Чужую подборку нельзя менять")
+
+	resp = doAuthRequest(t, "PUT", fmt.Sprintf("/api/highlights/%d/order", highlight.ID), 1, fmt.Sprintf(`{"ids": [%d]}`, photoItem))
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "Порядок должен содержать все элементы")
+	resp = doAuthRequest(t, "PUT", fmt.Sprintf("/api/highlights/%d/order", highlight.ID), 1,
+		fmt.Sprintf(`{"ids": [%d, %d]}`, photoItem, highlight.Items[0].ID))
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось изменить порядок")
+	assert.Equal(t, photoItem, getHighlight(t, highlight.ID, 1).Items[0].ID, "Порядок элементов должен измениться")
+
+	resp = doAuthRequest(t, "POST", "/api/highlights", 1, `{"title": "Second"}`)
+	var second models.Highlight
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&second), "Ошибка декодирования ответа")
+	resp.Body.Close()
+	resp = doAuthRequest(t, "PUT", "/api/highlights/order", 1, fmt.Sprintf(`{"ids": [%d, %d]}`, second.ID, highlight.ID))
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось изменить порядок подборок")
+
+	resp = doAuthRequest(t, "GET", "/api/users/1/highlights", 2, "")
+	var list struct {
+		Highlights []models.Highlight `json:"highlights"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list), "Ошибка декодирования ответа")
+	resp.Body.Close()
+	require.Len(t, list.Highlights, 2, "Некорректное количество подборок")
+	assert.Equal(t, second.ID, list.Highlights[0].ID, "Подборки должны идти в заданном порядке")
+	assert.Equal(t, 2, list.Highlights[1].ItemsCount, "Некорректное количество элементов")
+
+	_, err := db.Exec(ctx, "INSERT INTO user_blocks (blocker_id, blocked_id) VALUES (1, 3)")
+	require.NoError(t, err, "Не удалось заблокировать пользователя")
+	resp = doAuthRequest(t, "GET", fmt.Sprintf("/api/highlights/%d", highlight.ID), 3, "")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "Заблокированный пользователь не должен видеть подборку")
+
+	// Истекшая история остается в подборке вместе с файлом
+	_, err = db.Exec(ctx, "UPDATE stories SET expires_at = NOW() - INTERVAL '1 minute' WHERE id = $1", story.ID)
+	require.NoError(t, err, "Не удалось состарить историю")
+	removed, err := storyService.SweepExpired(ctx)
+	require.NoError(t, err, "Ошибка очистки историй")
+	assert.Equal(t, 0, removed, "История из подборки не должна удаляться")
+	_, err = os.Stat(story.URL)
+	assert.NoError(t, err, "Файл истории из подборки должен остаться")
+	assert.Len(t, getHighlight(t, highlight.ID, 2).Items, 2, "История должна остаться в подборке")
+
+	// После удаления подборки очистка удаляет историю
+	resp = doAuthRequest(t, "DELETE", fmt.Sprintf("/api/highlights/%d", highlight.ID), 1, "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось удалить подборку")
+	removed, err = storyService.SweepExpired(ctx)
+	require.NoError(t, err, "Ошибка очистки историй")
+	assert.Equal(t, 1, removed, "История без подборки должна удаляться")
+	_, err = os.Stat(story.URL)
+	assert.True(t, os.IsNotExist(err), "Файл истории должен быть удален")
+}
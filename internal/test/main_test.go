@@ -9,27 +9,41 @@ import (
 	"testing"
 
 	"InstaSpace/internal/handlers"
+	"InstaSpace/internal/models"
+	"InstaSpace/internal/pubsub"
 	"InstaSpace/internal/repositories"
 	"InstaSpace/internal/services"
 	"InstaSpace/pkg/config"
 	"InstaSpace/pkg/logger"
+	"InstaSpace/pkg/middleware"
 	"github.com/gorilla/mux"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
 
 var (
-	db         *pgxpool.Pool
-	zapLogger  *zap.Logger
-	testServer *httptest.Server
+	db          *pgxpool.Pool
+	zapLogger   *zap.Logger
+	testServer  *httptest.Server
+	authService *services.AuthService
 )
 
+// authHeader возвращает заголовок Authorization с JWT для пользователя userID
+func authHeader(t *testing.T, userID int) string {
+	t.Helper()
+
+	token, err := authService.GenerateToken(&models.User{ID: userID})
+	require.NoError(t, err, "Не удалось сгенерировать токен")
+	return "Bearer " + token
+}
+
 func TestMain(m *testing.M) {
 	var err error
 
 	cfg := config.LoadConfig()
 
-	zapLogger, err = logger.NewLogger()
+	zapLogger, _, err = logger.NewLogger()
 	if err != nil {
 		log.Fatalf("Не удалось инициализировать логгер: %v", err)
 	}
@@ -49,50 +63,187 @@ func TestMain(m *testing.M) {
 
 	zapLogger.Info("Успешное подключение к базе данных для тестов")
 
+	jwtSecret := cfg.JWTSecret
+	if jwtSecret == "" {
+		jwtSecret = "test-secret"
+	}
+
 	r := mux.NewRouter()
 
 	userRepo := repositories.NewUserRepository(db)
-	authService := services.NewAuthService(userRepo, cfg.JWTSecret)
+	authService = services.NewAuthService(userRepo, jwtSecret)
 	authHandler := handlers.NewAuthHandler(authService, zapLogger)
 
+	blockRepo := repositories.NewBlockRepository(db)
+	blockService := services.NewBlockService(blockRepo)
+	blockHandler := handlers.NewBlockHandler(blockService, zapLogger)
+
+	messageRepo := repositories.NewMessageRepository(db)
+	messageService = services.NewMessageService(messageRepo, blockRepo, zapLogger)
+	messageHandler := handlers.NewMessageHandler(messageService, zapLogger)
+
+	wsTicketRepo := repositories.NewWSTicketRepository(db)
+	wsTicketService := services.NewWSTicketService(wsTicketRepo)
+	wsHandler = handlers.NewWebSocketHandler(zapLogger, messageService, wsTicketService, jwtSecret, []string{"http://allowed.example.com"})
+	eventBus := services.NewEventBus(pubsub.NewMemory(), zapLogger)
+	if err := eventBus.Run(context.Background(), wsHandler); err != nil {
+		zapLogger.Fatal("Не удалось подписаться на события", zap.Error(err))
+	}
+	messageService.Publisher = eventBus
+
+	presenceRepo := repositories.NewPresenceRepository(db)
+	presenceService := services.NewPresenceService(presenceRepo, eventBus, zapLogger)
+	wsHandler.Presence = presenceService
+	presenceHandler := handlers.NewPresenceHandler(presenceService, zapLogger)
+
+	notificationRepo := repositories.NewNotificationRepository(db)
+	notificationService := services.NewNotificationService(notificationRepo, eventBus, zapLogger)
+	notificationHandler := handlers.NewNotificationHandler(notificationService, zapLogger)
+
+	mentionRepo := repositories.NewMentionRepository(db)
+	mentionService := services.NewMentionService(mentionRepo, notificationService, zapLogger)
+
+	followRepo := repositories.NewFollowRepository(db)
+	followService := services.NewFollowService(followRepo, blockRepo, notificationService)
+	followHandler := handlers.NewFollowHandler(followService, zapLogger)
+
 	photoRepo := repositories.NewPhotoRepository(db)
-	photoService = services.NewPhotoService(photoRepo)
+	photoService = services.NewPhotoService(photoRepo, mentionService)
 	photoHandler := handlers.NewPhotoHandler(photoService, zapLogger)
 
 	commentRepo := repositories.NewCommentRepository(db)
-	commentService = services.NewCommentService(commentRepo)
+	commentService = services.NewCommentService(commentRepo, photoRepo, blockRepo, followRepo, notificationService, mentionService)
 	commentHandler := handlers.NewCommentHandler(commentService, zapLogger)
 
+	reactionRepo := repositories.NewReactionRepository(db)
+	reactionService := services.NewReactionService(reactionRepo, photoRepo, messageRepo, blockRepo, notificationService, nil)
+	reactionHandler := handlers.NewReactionHandler(reactionService, zapLogger)
+
+	collectionRepo := repositories.NewCollectionRepository(db)
+	collectionService := services.NewCollectionService(collectionRepo, photoRepo, blockRepo, mentionService)
+	collectionHandler := handlers.NewCollectionHandler(collectionService, zapLogger)
+
+	albumService := services.NewAlbumService(photoRepo, mentionService)
+	albumHandler := handlers.NewAlbumHandler(albumService, zapLogger)
+
+	storyRepo := repositories.NewStoryRepository(db)
+	storyService = services.NewStoryService(storyRepo, zapLogger)
+	storyHandler := handlers.NewStoryHandler(storyService, zapLogger)
+
+	highlightRepo := repositories.NewHighlightRepository(db)
+	highlightService := services.NewHighlightService(highlightRepo)
+	highlightHandler := handlers.NewHighlightHandler(highlightService, zapLogger)
+
 	likeRepo := repositories.NewLikeRepository(db)
-	likeService := services.NewLikeService(likeRepo)
+	likeService := services.NewLikeService(likeRepo, reactionService, commentRepo, blockRepo)
 	likeHandler := handlers.NewLikeHandler(likeService, zapLogger)
 
-	messageRepo := repositories.NewMessageRepository(db)
-	messageService := services.NewMessageService(messageRepo)
-	messageHandler := handlers.NewMessageHandler(messageService, zapLogger)
-
-	wsHandler = handlers.NewWebSocketHandler(zapLogger, messageService)
 	r.HandleFunc("/ws", wsHandler.HandleWS).Methods("GET")
-
-	r.HandleFunc("/api/messages", messageHandler.SendMessage).Methods("POST")
-	r.HandleFunc("/api/messages/{conversationID}", messageHandler.GetMessages).Methods("GET")
-	r.HandleFunc("/api/messages/{messageID}", messageHandler.DeleteMessageHandler).Methods("DELETE")
+	r.HandleFunc("/ws/schema", wsHandler.Schema).Methods("GET")
 
 	r.HandleFunc("/register", authHandler.Register).Methods("POST")
 	r.HandleFunc("/login", authHandler.Login).Methods("POST")
 
 	r.HandleFunc("/api/photos", photoHandler.UploadPhoto).Methods("POST")
 
-	r.HandleFunc("/api/comments", commentHandler.CreateComment).Methods("POST")
 	r.HandleFunc("/api/comments/{photoID}", commentHandler.GetCommentsByPhotoID).Methods("GET")
-	r.HandleFunc("/api/comments/{id}/edit", commentHandler.UpdateComment).Methods("PUT")
-	r.HandleFunc("/api/comments/{id}/delete", commentHandler.DeleteComment).Methods("DELETE")
+	r.HandleFunc("/api/comments/{id}/replies", commentHandler.GetReplies).Methods("GET")
 
-	r.HandleFunc("/api/likes", likeHandler.AddLikeHandler).Methods("POST")
-	r.HandleFunc("/api/likes", likeHandler.RemoveLikeHandler).Methods("DELETE")
 	r.HandleFunc("/api/likes", likeHandler.GetLikesHandler).Methods("GET")
 	r.HandleFunc("/api/likes/count", likeHandler.GetLikeCountHandler).Methods("GET")
 
+	secure := r.PathPrefix("/api").Subrouter()
+	secure.Use(middleware.JWTMiddleware(jwtSecret, zapLogger))
+
+	secure.HandleFunc("/feed", photoHandler.GetFeed).Methods("GET")
+
+	secure.HandleFunc("/users/{id}/follow", followHandler.Follow).Methods("POST")
+	secure.HandleFunc("/users/{id}/follow", followHandler.Unfollow).Methods("DELETE")
+	secure.HandleFunc("/users/{id}/block", blockHandler.Block).Methods("POST")
+	secure.HandleFunc("/users/{id}/block", blockHandler.Unblock).Methods("DELETE")
+	secure.HandleFunc("/users/{id}/mute", blockHandler.Mute).Methods("POST")
+	secure.HandleFunc("/users/{id}/mute", blockHandler.Unmute).Methods("DELETE")
+	secure.HandleFunc("/blocks", blockHandler.GetBlocked).Methods("GET")
+	secure.HandleFunc("/mutes", blockHandler.GetMuted).Methods("GET")
+
+	secure.HandleFunc("/notifications", notificationHandler.GetNotifications).Methods("GET")
+	secure.HandleFunc("/notifications/unread-count", notificationHandler.GetUnreadCount).Methods("GET")
+	secure.HandleFunc("/notifications/read", notificationHandler.MarkRead).Methods("POST")
+
+	secure.HandleFunc("/reactions", reactionHandler.GetAllowedReactions).Methods("GET")
+	secure.HandleFunc("/photos/{id}/reaction", reactionHandler.SetPhotoReaction).Methods("PUT")
+	secure.HandleFunc("/photos/{id}/reaction", reactionHandler.RemovePhotoReaction).Methods("DELETE")
+	secure.HandleFunc("/photos/{id}/reactions", reactionHandler.GetPhotoReactions).Methods("GET")
+	secure.HandleFunc("/messages/{id}/reaction", reactionHandler.SetMessageReaction).Methods("PUT")
+	secure.HandleFunc("/messages/{id}/reaction", reactionHandler.RemoveMessageReaction).Methods("DELETE")
+	secure.HandleFunc("/messages/{id}/reactions", reactionHandler.GetMessageReactions).Methods("GET")
+	secure.HandleFunc("/ws-ticket", wsHandler.IssueTicket).Methods("POST")
+	secure.HandleFunc("/presence", presenceHandler.GetPresence).Methods("GET")
+	secure.HandleFunc("/presence/settings", presenceHandler.GetSettings).Methods("GET")
+	secure.HandleFunc("/presence/settings", presenceHandler.UpdateSettings).Methods("PUT")
+	secure.HandleFunc("/conversations", messageHandler.GetInbox).Methods("GET")
+	secure.HandleFunc("/conversations", messageHandler.CreateConversation).Methods("POST")
+	secure.HandleFunc("/conversations/groups", messageHandler.CreateGroup).Methods("POST")
+	secure.HandleFunc("/conversations/unread-count", messageHandler.GetUnreadCount).Methods("GET")
+	secure.HandleFunc("/conversations/{id}", messageHandler.GetConversation).Methods("GET")
+	secure.HandleFunc("/conversations/{id}", messageHandler.UpdateGroup).Methods("PUT")
+	secure.HandleFunc("/conversations/{id}/participants", messageHandler.AddParticipants).Methods("POST")
+	secure.HandleFunc("/conversations/{id}/participants/{userID}", messageHandler.RemoveParticipant).Methods("DELETE")
+	secure.HandleFunc("/conversations/{id}/participants/{userID}/role", messageHandler.SetParticipantRole).Methods("PUT")
+	secure.HandleFunc("/conversations/{id}/leave", messageHandler.LeaveGroup).Methods("POST")
+	secure.HandleFunc("/conversations/{id}/read", messageHandler.MarkRead).Methods("POST")
+	secure.HandleFunc("/messages", messageHandler.SendMessage).Methods("POST")
+	secure.HandleFunc("/messages/{conversationID}", messageHandler.GetMessages).Methods("GET")
+	secure.HandleFunc("/messages/{messageID}", messageHandler.DeleteMessageHandler).Methods("DELETE")
+
+	secure.HandleFunc("/stories", storyHandler.CreateStory).Methods("POST")
+	secure.HandleFunc("/stories/tray", storyHandler.GetTray).Methods("GET")
+	secure.HandleFunc("/stories/{id}", storyHandler.DeleteStory).Methods("DELETE")
+	secure.HandleFunc("/stories/{id}/seen", storyHandler.MarkSeen).Methods("POST")
+	secure.HandleFunc("/stories/{id}/viewers", storyHandler.GetViewers).Methods("GET")
+	secure.HandleFunc("/users/{id}/stories", storyHandler.GetUserStories).Methods("GET")
+
+	secure.HandleFunc("/albums", albumHandler.CreateAlbum).Methods("POST")
+	secure.HandleFunc("/users/{id}/albums", albumHandler.GetUserAlbums).Methods("GET")
+	secure.HandleFunc("/albums/{id}", albumHandler.GetAlbum).Methods("GET")
+	secure.HandleFunc("/albums/{id}", albumHandler.UpdateAlbum).Methods("PUT")
+	secure.HandleFunc("/albums/{id}", albumHandler.DeleteAlbum).Methods("DELETE")
+	secure.HandleFunc("/albums/{id}/photos", albumHandler.GetAlbumPhotos).Methods("GET")
+	secure.HandleFunc("/albums/{id}/photos", albumHandler.AddAlbumPhotos).Methods("POST")
+	secure.HandleFunc("/albums/{id}/photos/{photoID}", albumHandler.RemoveAlbumPhoto).Methods("DELETE")
+	secure.HandleFunc("/albums/{id}/order", albumHandler.ReorderAlbum).Methods("PUT")
+
+	secure.HandleFunc("/highlights", highlightHandler.CreateHighlight).Methods("POST")
+	secure.HandleFunc("/highlights/order", highlightHandler.ReorderHighlights).Methods("PUT")
+	secure.HandleFunc("/users/{id}/highlights", highlightHandler.GetUserHighlights).Methods("GET")
+	secure.HandleFunc("/highlights/{id}", highlightHandler.GetHighlight).Methods("GET")
+	secure.HandleFunc("/highlights/{id}", highlightHandler.UpdateHighlight).Methods("PUT")
+	secure.HandleFunc("/highlights/{id}", highlightHandler.DeleteHighlight).Methods("DELETE")
+	secure.HandleFunc("/highlights/{id}/items", highlightHandler.AddHighlightItems).Methods("POST")
+	secure.HandleFunc("/highlights/{id}/items/{itemID}", highlightHandler.RemoveHighlightItem).Methods("DELETE")
+	secure.HandleFunc("/highlights/{id}/order", highlightHandler.ReorderHighlightItems).Methods("PUT")
+
+	secure.HandleFunc("/collections", collectionHandler.GetCollections).Methods("GET")
+	secure.HandleFunc("/collections", collectionHandler.CreateCollection).Methods("POST")
+	secure.HandleFunc("/collections/{id}", collectionHandler.DeleteCollection).Methods("DELETE")
+	secure.HandleFunc("/collections/{id}/photos", collectionHandler.GetCollectionPhotos).Methods("GET")
+	secure.HandleFunc("/photos/{id}/save", collectionHandler.SavePhoto).Methods("PUT")
+	secure.HandleFunc("/photos/{id}/save", collectionHandler.UnsavePhoto).Methods("DELETE")
+
+	secure.HandleFunc("/comments", commentHandler.CreateComment).Methods("POST")
+	secure.HandleFunc("/comments/{id}/edit", commentHandler.UpdateComment).Methods("PUT")
+	secure.HandleFunc("/comments/{id}/delete", commentHandler.DeleteComment).Methods("DELETE")
+	secure.HandleFunc("/likes", likeHandler.AddLikeHandler).Methods("POST", "PUT")
+	secure.HandleFunc("/likes", likeHandler.RemoveLikeHandler).Methods("DELETE")
+	secure.HandleFunc("/likes/state", likeHandler.GetViewerStatesHandler).Methods("GET")
+	secure.HandleFunc("/comments/{id}/like", likeHandler.AddCommentLikeHandler).Methods("POST")
+	secure.HandleFunc("/comments/{id}/like", likeHandler.RemoveCommentLikeHandler).Methods("DELETE")
+	secure.HandleFunc("/comments/{id}/history", commentHandler.GetCommentHistory).Methods("GET")
+	secure.HandleFunc("/comments/{id}/pin", commentHandler.PinComment).Methods("POST")
+	secure.HandleFunc("/comments/{id}/pin", commentHandler.UnpinComment).Methods("DELETE")
+	secure.HandleFunc("/photos/{id}/comment-settings", commentHandler.GetCommentSettings).Methods("GET")
+	secure.HandleFunc("/photos/{id}/comment-settings", commentHandler.UpdateCommentSettings).Methods("PUT")
+
 	testServer = httptest.NewServer(r)
 	defer testServer.Close()
 
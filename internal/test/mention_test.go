@@ -0,0 +1,111 @@
+package test
+
+import (
+	"InstaSpace/internal/services"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func TestParseMentions(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Text     string
+		Expected []string
+		Offsets  []int
+	}{
+		{
+			Name:     "Одно упоминание",
+			Text:     "Привет, @alice!",
+			Expected: []string{"alice"},
+			Offsets:  []int{8},
+		},
+		{
+			Name:     "Точка в конце предложения",
+			Text:     "@bob.smith и @carol.",
+			Expected: []string{"bob.smith", "carol"},
+			Offsets:  []int{0, 13},
+		},
+		{
+			Name:     "Email не является упоминанием",
+			Text:     "пишите на mail@example.com",
+			Expected: nil,
+		},
+		{
+			Name:     "Одиночный символ @",
+			Text:     "@ @@ user",
+			Expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			mentions := services.ParseMentions(tc.Text)
+			require.Len(t, mentions, len(tc.Expected), "Некорректное количество упоминаний")
+
+			for i, m := range mentions {
+				assert.Equal(t, tc.Expected[i], m.Username, "Некорректное имя")
+				assert.Equal(t, tc.Offsets[i], m.Offset, "Некорректная позиция")
+				assert.Equal(t, len([]rune(tc.Expected[i]))+1, m.Length, "Некорректная длина")
+			}
+		})
+	}
+}
+
+func TestCommentMentions(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := db.Exec(ctx, "TRUNCATE TABLE mentions, notifications, user_blocks, comments, photos, users RESTART IDENTITY CASCADE")
+	require.NoError(t, err, "Не удалось очистить таблицы")
+
+	_, err = db.Exec(ctx, `
+		INSERT INTO users (id, email, password, username) VALUES
+		(1, 'user1@example.com', 'password1', 'user1'),
+		(2, 'user2@example.com', 'password2', 'Alice'),
+		(3, 'user3@example.com', 'password3', 'bob')`)
+	require.NoError(t, err, "Не удалось создать пользователей")
+
+	_, err = db.Exec(ctx, "INSERT INTO photos (id, user_id, url) VALUES (1, 1, 'uploads/photo1.jpg')")
+	require.NoError(t, err, "Не удалось создать фото")
+
+	_, err = db.Exec(ctx, "INSERT INTO user_blocks (blocker_id, blocked_id) VALUES (3, 1)")
+	require.NoError(t, err, "Не удалось создать блокировку")
+
+	resp := doAuthRequest(t, "POST", "/api/comments", 1, `{"photo_id": 1, "content": "@alice, @ghost и @bob"}`)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode, "Некорректный HTTP код ответа")
+
+	resp, err = http.Get(testServer.URL + "/api/comments/1")
+	require.NoError(t, err, "Ошибка выполнения HTTP запроса")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Некорректный HTTP код ответа")
+
+	var page struct {
+		Comments []struct {
+			Entities []struct {
+				UserID   int    `json:"user_id"`
+				Username string `json:"username"`
+				Offset   int    `json:"offset"`
+				Length   int    `json:"length"`
+			} `json:"entities"`
+		} `json:"comments"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&page), "Ошибка декодирования ответа")
+	comments := page.Comments
+	require.Len(t, comments, 1, "Ожидался один комментарий")
+	require.Len(t, comments[0].Entities, 1, "Несуществующие и заблокированные пользователи должны игнорироваться")
+
+	entity := comments[0].Entities[0]
+	assert.Equal(t, 2, entity.UserID, "Некорректный упомянутый пользователь")
+	assert.Equal(t, "Alice", entity.Username, "Некорректное имя упомянутого пользователя")
+	assert.Equal(t, 0, entity.Offset, "Некорректная позиция")
+	assert.Equal(t, 6, entity.Length, "Некорректная длина")
+
+	var mentionNotifications int
+	err = db.QueryRow(ctx, "SELECT COUNT(*) FROM notifications WHERE user_id = 2 AND type = 'mention'").Scan(&mentionNotifications)
+	require.NoError(t, err, "Ошибка проверки уведомлений")
+	assert.Equal(t, 1, mentionNotifications, "Упомянутый пользователь должен получить уведомление")
+}
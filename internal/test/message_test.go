@@ -1,14 +1,30 @@
 package test
 
 import (
+	"InstaSpace/internal/models"
+	"InstaSpace/internal/repositories"
 	"context"
+	"encoding/json"
+	"fmt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"net/http"
-	"strings"
 	"testing"
 )
 
+// createTestConversation создает личную беседу 1 между пользователями 1 и 2
+func createTestConversation(t *testing.T) {
+	t.Helper()
+
+	_, err := db.Exec(context.Background(), `
+		WITH conv AS (
+			INSERT INTO conversations (id, user1_id, user2_id) VALUES (1, 1, 2) RETURNING id
+		)
+		INSERT INTO conversation_participants (conversation_id, user_id)
+		SELECT conv.id, u.id FROM conv, unnest(ARRAY[1, 2]) AS u(id)`)
+	require.NoError(t, err, "Не удалось создать тестовую переписку")
+}
+
 func TestSendMessage(t *testing.T) {
 	ctx := context.Background()
 
@@ -20,44 +36,54 @@ func TestSendMessage(t *testing.T) {
 	_, err = db.Exec(ctx, `
 		INSERT INTO users (id, email, password, username) VALUES 
 		(1, 'user1@example.com', 'hashedpassword1', 'user1'),
-		(2, 'user2@example.com', 'hashedpassword2', 'user2')
+		(2, 'user2@example.com', 'hashedpassword2', 'user2'),
+		(3, 'user3@example.com', 'hashedpassword3', 'user3')
 	`)
 	require.NoError(t, err, "Не удалось создать тестовых пользователей")
 
 	// Добавление тестовой переписки
-	_, err = db.Exec(ctx, "INSERT INTO conversations (id, user1_id, user2_id) VALUES (1, 1, 2)")
-	require.NoError(t, err, "Не удалось создать тестовую переписку")
+	createTestConversation(t)
 
 	testCases := []struct {
 		Name         string
+		UserID       int
 		Payload      string
 		ExpectedCode int
 		ShouldError  bool
 	}{
 		{
 			Name:         "Успешная отправка сообщения",
-			Payload:      `{"conversation_id": 1, "sender_id": 1, "content": "Hello!"}`,
+			UserID:       1,
+			Payload:      `{"conversation_id": 1, "content": "Hello!"}`,
 			ExpectedCode: http.StatusOK,
 			ShouldError:  false,
 		},
 		{
 			Name:         "Ошибка: Пустое сообщение",
-			Payload:      `{"conversation_id": 1, "sender_id": 1, "content": ""}`,
+			UserID:       1,
+			Payload:      `{"conversation_id": 1, "content": ""}`,
 			ExpectedCode: http.StatusBadRequest,
 			ShouldError:  true,
 		},
 		{
 			Name:         "Ошибка: Отправка в несуществующую переписку",
-			Payload:      `{"conversation_id": 999, "sender_id": 1, "content": "Test"}`,
-			ExpectedCode: http.StatusBadRequest,
+			UserID:       1,
+			Payload:      `{"conversation_id": 999, "content": "Test"}`,
+			ExpectedCode: http.StatusNotFound,
+			ShouldError:  true,
+		},
+		{
+			Name:         "Ошибка: Отправка в чужую переписку",
+			UserID:       3,
+			Payload:      `{"conversation_id": 1, "sender_id": 1, "content": "Test"}`,
+			ExpectedCode: http.StatusNotFound,
 			ShouldError:  true,
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.Name, func(t *testing.T) {
-			resp, err := http.Post(testServer.URL+"/api/messages", "application/json", strings.NewReader(tc.Payload))
-			require.NoError(t, err, "Ошибка отправки запроса")
+			resp := doAuthRequest(t, "POST", "/api/messages", tc.UserID, tc.Payload)
 			defer resp.Body.Close()
 
 			assert.Equal(t, tc.ExpectedCode, resp.StatusCode, "Некорректный HTTP код ответа")
@@ -74,40 +100,49 @@ func TestGetMessages(t *testing.T) {
 	_, err = db.Exec(ctx, `
 		INSERT INTO users (id, email, password, username) VALUES 
 		(1, 'user1@example.com', 'hashedpassword1', 'user1'),
-		(2, 'user2@example.com', 'hashedpassword2', 'user2')
+		(2, 'user2@example.com', 'hashedpassword2', 'user2'),
+		(3, 'user3@example.com', 'hashedpassword3', 'user3')
 	`)
 	require.NoError(t, err, "Не удалось создать тестовых пользователей")
 
-	_, err = db.Exec(ctx, "INSERT INTO conversations (id, user1_id, user2_id) VALUES (1, 1, 2)")
-	require.NoError(t, err, "Не удалось создать тестовую переписку")
+	createTestConversation(t)
 
 	_, err = db.Exec(ctx, "INSERT INTO messages (conversation_id, sender_id, content) VALUES ($1, $2, $3)", 1, 1, "Test message")
 	require.NoError(t, err, "Не удалось добавить сообщение")
 
 	testCases := []struct {
 		Name         string
+		UserID       int
 		URL          string
 		ExpectedCode int
 		ShouldError  bool
 	}{
 		{
 			Name:         "Успешное получение сообщений",
+			UserID:       2,
 			URL:          "/api/messages/1", // ✅ Исправлено
 			ExpectedCode: http.StatusOK,
 			ShouldError:  false,
 		},
 		{
 			Name:         "Ошибка: Переписка не найдена",
+			UserID:       1,
 			URL:          "/api/messages/999", // ✅ Ошибочный ID в URL
-			ExpectedCode: http.StatusBadRequest,
+			ExpectedCode: http.StatusNotFound,
+			ShouldError:  true,
+		},
+		{
+			Name:         "Ошибка: Чужая переписка неотличима от несуществующей",
+			UserID:       3,
+			URL:          "/api/messages/1",
+			ExpectedCode: http.StatusNotFound,
 			ShouldError:  true,
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.Name, func(t *testing.T) {
-			resp, err := http.Get(testServer.URL + tc.URL) // ✅ Передаем conversationID в URL
-			require.NoError(t, err, "Ошибка выполнения HTTP запроса")
+			resp := doAuthRequest(t, "GET", tc.URL, tc.UserID, "")
 			defer resp.Body.Close()
 
 			assert.Equal(t, tc.ExpectedCode, resp.StatusCode, "Некорректный HTTP код ответа")
@@ -126,13 +161,13 @@ func TestDeleteMessage(t *testing.T) {
 	_, err = db.Exec(ctx, `
 		INSERT INTO users (id, email, password, username) VALUES 
 		(1, 'user1@example.com', 'hashedpassword1', 'user1'),
-		(2, 'user2@example.com', 'hashedpassword2', 'user2')
+		(2, 'user2@example.com', 'hashedpassword2', 'user2'),
+		(3, 'user3@example.com', 'hashedpassword3', 'user3')
 	`)
 	require.NoError(t, err, "Не удалось создать тестовых пользователей")
 
 	// Добавление тестовой переписки
-	_, err = db.Exec(ctx, "INSERT INTO conversations (id, user1_id, user2_id) VALUES (1, 1, 2)")
-	require.NoError(t, err, "Не удалось создать тестовую переписку")
+	createTestConversation(t)
 
 	// Вставка тестового сообщения
 	_, err = db.Exec(ctx, "INSERT INTO messages (id, conversation_id, sender_id, content) VALUES (1, 1, 1, 'Test message')")
@@ -140,13 +175,31 @@ func TestDeleteMessage(t *testing.T) {
 
 	testCases := []struct {
 		Name         string
+		UserID       int
 		URL          string
 		Method       string
 		ExpectedCode int
 		ShouldError  bool
 	}{
+		{
+			Name:         "Ошибка: Удаление сообщения не участником беседы",
+			UserID:       3,
+			URL:          "/api/messages/1",
+			Method:       "DELETE",
+			ExpectedCode: http.StatusNotFound,
+			ShouldError:  true,
+		},
+		{
+			Name:         "Ошибка: Удаление чужого сообщения",
+			UserID:       2,
+			URL:          "/api/messages/1",
+			Method:       "DELETE",
+			ExpectedCode: http.StatusForbidden,
+			ShouldError:  true,
+		},
 		{
 			Name:         "Успешное удаление сообщения",
+			UserID:       1,
 			URL:          "/api/messages/1",
 			Method:       "DELETE",
 			ExpectedCode: http.StatusOK,
@@ -154,24 +207,171 @@ func TestDeleteMessage(t *testing.T) {
 		},
 		{
 			Name:         "Ошибка: Удаление несуществующего сообщения",
+			UserID:       1,
 			URL:          "/api/messages/999",
 			Method:       "DELETE",
-			ExpectedCode: http.StatusBadRequest,
+			ExpectedCode: http.StatusNotFound,
 			ShouldError:  true,
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.Name, func(t *testing.T) {
-			req, err := http.NewRequest(tc.Method, testServer.URL+tc.URL, nil)
-			require.NoError(t, err, "Ошибка создания HTTP запроса")
-
-			client := &http.Client{}
-			resp, err := client.Do(req)
-			require.NoError(t, err, "Ошибка выполнения HTTP запроса")
+			resp := doAuthRequest(t, tc.Method, tc.URL, tc.UserID, "")
 			defer resp.Body.Close()
 
 			assert.Equal(t, tc.ExpectedCode, resp.StatusCode, "Некорректный HTTP код ответа")
 		})
 	}
 }
+
+func TestConversationsInbox(t *testing.T) {
+	setupTestBlocks(t, db)
+
+	openConversation := func(userID, otherID int) (int, models.Conversation) {
+		resp := doAuthRequest(t, "POST", "/api/conversations", userID, fmt.Sprintf(`{"user_id": %d}`, otherID))
+		defer resp.Body.Close()
+		var conv models.Conversation
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&conv), "Ошибка декодирования ответа")
+		}
+		return resp.StatusCode, conv
+	}
+
+	code, conv := openConversation(3, 1)
+	require.Equal(t, http.StatusOK, code, "Не удалось создать беседу")
+	assert.Equal(t, 1, conv.User1ID, "Пара пользователей должна храниться упорядоченной")
+	assert.Equal(t, 3, conv.User2ID, "Пара пользователей должна храниться упорядоченной")
+	code, same := openConversation(1, 3)
+	require.Equal(t, http.StatusOK, code, "Не удалось получить беседу")
+	assert.Equal(t, conv.ID, same.ID, "(A,B) и (B,A) должны давать одну беседу")
+
+	code, _ = openConversation(1, 1)
+	assert.Equal(t, http.StatusBadRequest, code, "Беседа с самим собой не создается")
+
+	send := func(userID, conversationID int, content string) {
+		resp := doAuthRequest(t, "POST", "/api/messages", userID, fmt.Sprintf(`{"conversation_id": %d, "content": %q}`, conversationID, content))
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось отправить сообщение")
+	}
+	send(2, 1, "Hi there")
+	send(3, conv.ID, "Hello")
+	send(3, conv.ID, "Are you here?")
+
+	getInbox := func(query string) models.InboxPage {
+		resp := doAuthRequest(t, "GET", "/api/conversations"+query, 1, "")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode, "Некорректный HTTP код ответа")
+		var page models.InboxPage
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&page), "Ошибка декодирования ответа")
+		return page
+	}
+
+	inbox := getInbox("")
+	require.Len(t, inbox.Conversations, 2, "Некорректное количество бесед")
+	first := inbox.Conversations[0]
+	assert.Equal(t, conv.ID, first.ID, "Последняя активная беседа должна быть первой")
+	assert.Equal(t, "user3", first.User.Username, "Должен возвращаться собеседник")
+	require.NotNil(t, first.LastMessage, "Должно возвращаться последнее сообщение")
+	assert.Equal(t, "Are you here?", first.LastMessage.Content, "Некорректное последнее сообщение")
+	assert.Equal(t, 2, first.UnreadCount, "Некорректное количество непрочитанных")
+	assert.Equal(t, 1, inbox.Conversations[1].UnreadCount, "Некорректное количество непрочитанных")
+
+	resp := doAuthRequest(t, "GET", fmt.Sprintf("/api/messages/%d", conv.ID), 1, "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось получить сообщения")
+	assert.Equal(t, 2, getInbox("").Conversations[0].UnreadCount, "Получение сообщений не должно отмечать их прочитанными")
+
+	resp = doAuthRequest(t, "POST", fmt.Sprintf("/api/conversations/%d/read", conv.ID), 1, "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось прочитать сообщения")
+
+	page := getInbox("?limit=1")
+	require.Len(t, page.Conversations, 1, "Некорректный размер страницы")
+	assert.Equal(t, 0, page.Conversations[0].UnreadCount, "Прочитанные сообщения не должны считаться")
+	require.NotEmpty(t, page.NextCursor, "Должен возвращаться курсор следующей страницы")
+
+	page = getInbox("?limit=1&cursor=" + page.NextCursor)
+	require.Len(t, page.Conversations, 1, "Некорректный размер страницы")
+	assert.Equal(t, 1, page.Conversations[0].ID, "Вторая страница должна продолжать первую")
+	assert.Equal(t, 2, page.Conversations[0].User.ID, "Некорректный собеседник")
+	assert.Empty(t, page.NextCursor, "Последняя страница не должна возвращать курсор")
+}
+
+func TestGroupConversations(t *testing.T) {
+	setupTestBlocks(t, db)
+
+	_, err := db.Exec(context.Background(), "INSERT INTO users (id, email, password, username) VALUES (4, 'user4@example.com', 'password4', 'user4')")
+	require.NoError(t, err, "Не удалось создать пользователя")
+
+	resp := doAuthRequest(t, "POST", "/api/conversations/groups", 1, `{"title": "Trip", "user_ids": [2, 3, 2, 1]}`)
+	require.Equal(t, http.StatusCreated, resp.StatusCode, "Не удалось создать группу")
+	var group models.Conversation
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&group), "Ошибка декодирования ответа")
+	resp.Body.Close()
+	assert.True(t, group.IsGroup, "Беседа должна быть групповой")
+	require.Len(t, group.Participants, 3, "Повторы и создатель не должны дублироваться")
+	assert.Equal(t, 1, group.Participants[0].UserID, "Создатель должен быть первым")
+	assert.Equal(t, models.ParticipantAdmin, group.Participants[0].Role, "Создатель должен быть администратором")
+
+	do := func(method, url string, userID int, body string) int {
+		resp := doAuthRequest(t, method, url, userID, body)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	groupURL := fmt.Sprintf("/api/conversations/%d", group.ID)
+
+	assert.Equal(t, http.StatusForbidden, do("POST", groupURL+"/participants", 2, `{"user_ids": [4]}`), "Участник не может добавлять других")
+
+	// Права проверяются и внутри транзакции, на случай если роль отозвали после проверки в сервисе
+	repo := repositories.NewMessageRepository(db)
+	_, err = repo.AddParticipants(context.Background(), group.ID, 2, []int{4})
+	assert.ErrorIs(t, err, repositories.ErrNotGroupAdmin, "Участник не может добавлять других")
+	err = repo.RemoveParticipant(context.Background(), group.ID, 2, 3)
+	assert.ErrorIs(t, err, repositories.ErrNotGroupAdmin, "Участник не может исключать других")
+	err = repo.SetParticipantRole(context.Background(), group.ID, 2, 2, models.ParticipantAdmin)
+	assert.ErrorIs(t, err, repositories.ErrNotGroupAdmin, "Участник не может менять роли")
+
+	require.Equal(t, http.StatusOK, do("POST", groupURL+"/participants", 1, `{"user_ids": [4]}`), "Не удалось добавить участника")
+	require.Equal(t, http.StatusOK, do("POST", "/api/messages", 4, fmt.Sprintf(`{"conversation_id": %d, "content": "Hi all"}`, group.ID)), "Новый участник должен писать в группу")
+
+	resp = doAuthRequest(t, "GET", fmt.Sprintf("/api/messages/%d", group.ID), 3, "")
+	var messages []models.Message
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&messages), "Ошибка декодирования ответа")
+	resp.Body.Close()
+	require.Len(t, messages, 3, "Должны быть служебные сообщения и сообщение участника")
+	assert.Equal(t, models.MessageGroupCreated, messages[0].Kind, "Некорректное служебное сообщение")
+	assert.Equal(t, models.MessageMemberAdded, messages[1].Kind, "Некорректное служебное сообщение")
+	require.NotNil(t, messages[1].TargetUserID, "Служебное сообщение должно указывать участника")
+	assert.Equal(t, 4, *messages[1].TargetUserID, "Некорректный участник служебного сообщения")
+	assert.Equal(t, "user1 added user4", messages[1].Content, "Некорректный текст служебного сообщения")
+	assert.Equal(t, models.MessageText, messages[2].Kind, "Некорректный вид сообщения")
+	assert.Equal(t, http.StatusForbidden, do("DELETE", fmt.Sprintf("/api/messages/%d", messages[1].ID), 1, ""), "Служебные сообщения нельзя удалять")
+
+	resp = doAuthRequest(t, "GET", "/api/conversations", 4, "")
+	var inbox models.InboxPage
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&inbox), "Ошибка декодирования ответа")
+	resp.Body.Close()
+	require.Len(t, inbox.Conversations, 1, "Группа должна быть во входящих нового участника")
+	assert.Equal(t, "Trip", inbox.Conversations[0].Title, "Во входящих должно быть название группы")
+	assert.Nil(t, inbox.Conversations[0].User, "У группы нет собеседника")
+
+	require.Equal(t, http.StatusOK, do("PUT", groupURL+"/participants/2/role", 1, `{"role": "admin"}`), "Не удалось назначить администратора")
+	require.Equal(t, http.StatusOK, do("DELETE", groupURL+"/participants/3", 2, ""), "Администратор должен исключать участников")
+	assert.Equal(t, http.StatusNotFound, do("GET", groupURL, 3, ""), "Исключенный участник не должен видеть группу")
+	assert.Equal(t, http.StatusNotFound, do("POST", "/api/messages", 3, fmt.Sprintf(`{"conversation_id": %d, "content": "Hey"}`, group.ID)), "Исключенный участник не должен писать в группу")
+
+	require.Equal(t, http.StatusOK, do("POST", groupURL+"/leave", 1, ""), "Не удалось выйти из группы")
+	assert.Equal(t, http.StatusBadRequest, do("PUT", groupURL+"/participants/2/role", 2, `{"role": "member"}`), "Последнего администратора нельзя снять")
+	require.Equal(t, http.StatusOK, do("DELETE", groupURL+"/participants/2", 2, ""), "Исключение себя должно означать выход")
+
+	resp = doAuthRequest(t, "GET", groupURL, 4, "")
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&group), "Ошибка декодирования ответа")
+	resp.Body.Close()
+	require.Len(t, group.Participants, 1, "В группе должен остаться один участник")
+	assert.Equal(t, models.ParticipantAdmin, group.Participants[0].Role, "Оставшийся участник должен стать администратором")
+
+	assert.Equal(t, http.StatusBadRequest, do("PUT", "/api/conversations/1", 1, `{"title": "Pair"}`), "Личную беседу нельзя менять как группу")
+	assert.Equal(t, http.StatusBadRequest, do("POST", "/api/conversations/1/leave", 1, ""), "Из личной беседы нельзя выйти")
+	assert.Equal(t, http.StatusNotFound, do("GET", "/api/conversations/1", 3, ""), "Посторонний не должен видеть личную беседу")
+}
@@ -0,0 +1,151 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setupTestNotifications(t *testing.T, db *pgxpool.Pool) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	_, err := db.Exec(ctx, "TRUNCATE TABLE notifications, notification_actors, follows, user_blocks, comments, photo_reactions, photos, users RESTART IDENTITY CASCADE")
+	require.NoError(t, err, "Не удалось очистить таблицы")
+
+	_, err = db.Exec(ctx, `
+		INSERT INTO users (id, email, password, username) VALUES
+		(1, 'user1@example.com', 'password1', 'user1'),
+		(2, 'user2@example.com', 'password2', 'user2'),
+		(3, 'user3@example.com', 'password3', 'user3')`)
+	require.NoError(t, err, "Не удалось создать пользователей")
+
+	_, err = db.Exec(ctx, "INSERT INTO photos (id, user_id, url) VALUES (1, 1, 'uploads/photo1.jpg')")
+	require.NoError(t, err, "Не удалось создать фото")
+}
+
+func getNotifications(t *testing.T, userID int) []struct {
+	Type        string `json:"type"`
+	ActorsCount int    `json:"actors_count"`
+	Text        string `json:"text"`
+	Read        bool   `json:"read"`
+} {
+	t.Helper()
+
+	resp := doAuthRequest(t, "GET", "/api/notifications", userID, "")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Некорректный HTTP код ответа")
+
+	var notifications []struct {
+		Type        string `json:"type"`
+		ActorsCount int    `json:"actors_count"`
+		Text        string `json:"text"`
+		Read        bool   `json:"read"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&notifications), "Ошибка декодирования ответа")
+	return notifications
+}
+
+func getUnreadCount(t *testing.T, userID int) int {
+	t.Helper()
+
+	resp := doAuthRequest(t, "GET", "/api/notifications/unread-count", userID, "")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Некорректный HTTP код ответа")
+
+	var body map[string]int
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body), "Ошибка декодирования ответа")
+	return body["unread_count"]
+}
+
+func TestNotificationAggregation(t *testing.T) {
+	setupTestNotifications(t, db)
+
+	for _, userID := range []int{2, 3, 1} {
+		resp := doAuthRequest(t, "POST", "/api/likes?photoID=1", userID, "")
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось поставить лайк")
+	}
+
+	notifications := getNotifications(t, 1)
+	require.Len(t, notifications, 1, "Лайки должны объединяться в одно уведомление")
+	assert.Equal(t, "like", notifications[0].Type, "Некорректный тип уведомления")
+	assert.Equal(t, 2, notifications[0].ActorsCount, "Собственный лайк не должен учитываться")
+	assert.Equal(t, "user3 and 1 other liked your photo", notifications[0].Text, "Некорректный текст уведомления")
+
+	resp := doAuthRequest(t, "POST", "/api/users/1/follow", 2, "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось подписаться")
+
+	assert.Equal(t, 2, getUnreadCount(t, 1), "Некорректное количество непрочитанных")
+
+	resp = doAuthRequest(t, "POST", "/api/notifications/read", 1, "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось отметить уведомления")
+
+	assert.Equal(t, 0, getUnreadCount(t, 1), "Все уведомления должны быть прочитаны")
+}
+
+func TestNotificationBlockedActors(t *testing.T) {
+	setupTestNotifications(t, db)
+
+	for _, userID := range []int{2, 3} {
+		resp := doAuthRequest(t, "POST", "/api/likes?photoID=1", userID, "")
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось поставить лайк")
+	}
+
+	resp := doAuthRequest(t, "POST", "/api/users/3/block", 1, "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось заблокировать пользователя")
+
+	notifications := getNotifications(t, 1)
+	require.Len(t, notifications, 1, "Уведомление с незаблокированным участником должно остаться")
+	assert.Equal(t, 1, notifications[0].ActorsCount, "Заблокированный участник не должен учитываться")
+	assert.Equal(t, "user2 liked your photo", notifications[0].Text, "Последним должен показываться незаблокированный участник")
+	assert.Equal(t, 1, getUnreadCount(t, 1), "Некорректное количество непрочитанных")
+
+	resp = doAuthRequest(t, "POST", "/api/users/1/block", 2, "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось заблокировать пользователя")
+
+	assert.Empty(t, getNotifications(t, 1), "Уведомление только от заблокированных участников должно скрываться")
+	assert.Equal(t, 0, getUnreadCount(t, 1), "Скрытое уведомление не должно считаться")
+}
+
+func TestNotificationRealtime(t *testing.T) {
+	setupTestNotifications(t, db)
+
+	wsURL := "ws" + strings.TrimPrefix(testServer.URL, "http") + "/ws"
+	header := http.Header{}
+	header.Set("Authorization", authHeader(t, 1))
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, header)
+	require.NoError(t, err, "Не удалось подключиться к WebSocket")
+	defer conn.Close()
+
+	resp := doAuthRequest(t, "POST", "/api/comments", 2, `{"photo_id": 1, "content": "Nice!"}`)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode, "Не удалось создать комментарий")
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	var event struct {
+		Type         string `json:"type"`
+		Notification struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"payload"`
+	}
+	require.NoError(t, conn.ReadJSON(&event), "Уведомление не доставлено")
+
+	assert.Equal(t, "notification", event.Type, "Некорректный тип события")
+	assert.Equal(t, "comment", event.Notification.Type, "Некорректный тип уведомления")
+	assert.Equal(t, "user2 commented on your photo", event.Notification.Text, "Некорректный текст уведомления")
+}
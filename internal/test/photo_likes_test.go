@@ -1,12 +1,16 @@
 package test
 
 import (
+	"InstaSpace/internal/models"
+	"InstaSpace/internal/repositories"
+	"InstaSpace/internal/services"
 	"context"
 	"encoding/json"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"net/http"
+	"sync"
 	"testing"
 )
 
@@ -16,7 +20,7 @@ func setupTestLikes(t *testing.T, db *pgxpool.Pool) {
 	ctx := context.Background()
 
 	// Очистка таблиц
-	_, err := db.Exec(ctx, "TRUNCATE TABLE comments, photos, photo_likes, users RESTART IDENTITY CASCADE")
+	_, err := db.Exec(ctx, "TRUNCATE TABLE comments, photos, photo_reactions, users RESTART IDENTITY CASCADE")
 	require.NoError(t, err, "Не удалось очистить таблицы")
 
 	// Вставка тестового пользователя
@@ -48,6 +52,7 @@ func TestLikeHandlers(t *testing.T) {
 		Name         string
 		Method       string
 		URL          string
+		UserID       int
 		ExpectedCode int
 		Payload      map[string]string
 		ShouldError  bool
@@ -55,37 +60,49 @@ func TestLikeHandlers(t *testing.T) {
 		{
 			Name:         "Успешное добавление лайка",
 			Method:       "POST",
-			URL:          "/api/likes?photoID=1&userID=1",
+			URL:          "/api/likes?photoID=1",
+			UserID:       1,
 			ExpectedCode: http.StatusOK,
 			ShouldError:  false,
 		},
 		{
 			Name:         "Ошибка: Неверный photoID",
 			Method:       "POST",
-			URL:          "/api/likes?photoID=0&userID=1",
+			URL:          "/api/likes?photoID=0",
+			UserID:       1,
 			ExpectedCode: http.StatusBadRequest,
 			ShouldError:  true,
 		},
 		{
-			Name:         "Ошибка: Неверный userID",
+			Name:         "Ошибка: Без токена",
 			Method:       "POST",
-			URL:          "/api/likes?photoID=1&userID=0",
-			ExpectedCode: http.StatusBadRequest,
+			URL:          "/api/likes?photoID=1",
+			ExpectedCode: http.StatusUnauthorized,
 			ShouldError:  true,
 		},
 		{
 			Name:         "Успешное удаление лайка",
 			Method:       "DELETE",
-			URL:          "/api/likes?photoID=1&userID=1",
+			URL:          "/api/likes?photoID=1",
+			UserID:       1,
 			ExpectedCode: http.StatusOK,
 			ShouldError:  false,
 		},
 		{
-			Name:         "Ошибка: Удаление отсутствующего лайка",
+			Name:         "Повторное удаление лайка ничего не меняет",
 			Method:       "DELETE",
-			URL:          "/api/likes?photoID=1&userID=2",
-			ExpectedCode: http.StatusBadRequest,
-			ShouldError:  true,
+			URL:          "/api/likes?photoID=1",
+			UserID:       1,
+			ExpectedCode: http.StatusOK,
+			ShouldError:  false,
+		},
+		{
+			Name:         "Удаление отсутствующего лайка ничего не меняет",
+			Method:       "DELETE",
+			URL:          "/api/likes?photoID=1",
+			UserID:       2,
+			ExpectedCode: http.StatusOK,
+			ShouldError:  false,
 		},
 
 		{
@@ -109,12 +126,11 @@ func TestLikeHandlers(t *testing.T) {
 			var req *http.Request
 			var err error
 
-			if tc.Method == "POST" || tc.Method == "DELETE" {
-				req, err = http.NewRequest(tc.Method, testServer.URL+tc.URL, nil)
-			} else {
-				req, err = http.NewRequest(tc.Method, testServer.URL+tc.URL, nil)
-			}
+			req, err = http.NewRequest(tc.Method, testServer.URL+tc.URL, nil)
 			require.NoError(t, err, "Ошибка создания HTTP запроса")
+			if tc.UserID != 0 {
+				req.Header.Set("Authorization", authHeader(t, tc.UserID))
+			}
 
 			client := &http.Client{}
 			resp, err := client.Do(req)
@@ -130,4 +146,160 @@ func TestLikeHandlers(t *testing.T) {
 			}
 		})
 	}
+
+	// Повторное удаление не должно уводить счетчики ниже нуля
+	var likesCount, heartCount int
+	require.NoError(t, db.QueryRow(context.Background(), "SELECT likes_count FROM photos WHERE id = 1").Scan(&likesCount))
+	require.NoError(t, db.QueryRow(context.Background(), "SELECT count FROM photo_reaction_counts WHERE photo_id = 1 AND reaction = '❤️'").Scan(&heartCount))
+	assert.Equal(t, 0, likesCount, "Некорректный photos.likes_count после удаления лайка")
+	assert.Equal(t, 0, heartCount, "Некорректный счетчик реакций после удаления лайка")
+}
+
+func TestCommentLikes(t *testing.T) {
+	setupTestBlocks(t, db)
+
+	_, err := db.Exec(context.Background(), `
+		INSERT INTO comments (id, user_id, photo_id, content) VALUES
+		(1, 1, 1, 'First'), (2, 2, 1, 'Second'), (3, 3, 1, 'Third')`)
+	require.NoError(t, err, "Не удалось добавить комментарии")
+
+	like := func(method string, commentID string, userID int) int {
+		resp := doAuthRequest(t, method, "/api/comments/"+commentID+"/like", userID, "")
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	require.Equal(t, http.StatusOK, like("POST", "2", 1), "Не удалось лайкнуть комментарий")
+	require.Equal(t, http.StatusOK, like("POST", "2", 2), "Не удалось лайкнуть комментарий")
+	require.Equal(t, http.StatusOK, like("POST", "3", 2), "Не удалось лайкнуть комментарий")
+	assert.Equal(t, http.StatusOK, like("POST", "2", 1), "Повторный лайк не должен менять состояние")
+	assert.Equal(t, http.StatusNotFound, like("POST", "99", 1), "Лайк несуществующего комментария должен отклоняться")
+	assert.Equal(t, http.StatusOK, like("DELETE", "1", 1), "Удаление несуществующего лайка не должно менять состояние")
+
+	resp, err := http.Get(testServer.URL + "/api/comments/1?sort=top")
+	require.NoError(t, err, "Ошибка выполнения HTTP запроса")
+	var page struct {
+		Comments []struct {
+			ID         int `json:"id"`
+			LikesCount int `json:"likes_count"`
+		} `json:"comments"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&page), "Ошибка декодирования ответа")
+	resp.Body.Close()
+	require.Len(t, page.Comments, 3, "Некорректное количество комментариев")
+	assert.Equal(t, []int{2, 3, 1}, []int{page.Comments[0].ID, page.Comments[1].ID, page.Comments[2].ID}, "Некорректный порядок популярных комментариев")
+	assert.Equal(t, 2, page.Comments[0].LikesCount, "Некорректное количество лайков")
+
+	viewerPage, err := commentService.GetCommentsByPhotoID(context.Background(), 1, services.CommentListParams{ViewerID: 1, Sort: "top"})
+	require.NoError(t, err, "Ошибка получения комментариев")
+	assert.True(t, viewerPage.Comments[0].LikedByViewer, "Лайк зрителя должен быть отмечен")
+	assert.False(t, viewerPage.Comments[1].LikedByViewer, "Чужой лайк не должен отмечаться")
+
+	require.Equal(t, http.StatusOK, like("DELETE", "2", 1), "Не удалось убрать лайк")
+	var count int
+	require.NoError(t, db.QueryRow(context.Background(), "SELECT likes_count FROM comments WHERE id = 2").Scan(&count))
+	assert.Equal(t, 1, count, "Счетчик должен уменьшиться")
+}
+
+func TestConcurrentLikesAndReconciliation(t *testing.T) {
+	setupTestBlocks(t, db)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		for _, userID := range []int{2, 3} {
+			wg.Add(1)
+			go func(userID int) {
+				defer wg.Done()
+				resp := doAuthRequest(t, "PUT", "/api/photos/1/reaction", userID, `{"reaction": "❤️"}`)
+				resp.Body.Close()
+			}(userID)
+		}
+	}
+	wg.Wait()
+
+	var likesCount, heartCount int
+	require.NoError(t, db.QueryRow(ctx, "SELECT likes_count FROM photos WHERE id = 1").Scan(&likesCount))
+	require.NoError(t, db.QueryRow(ctx, "SELECT count FROM photo_reaction_counts WHERE photo_id = 1 AND reaction = '❤️'").Scan(&heartCount))
+	assert.Equal(t, 2, likesCount, "Параллельные лайки не должны завышать счетчик")
+	assert.Equal(t, 2, heartCount, "Параллельные лайки не должны завышать счетчик реакций")
+
+	_, err := db.Exec(ctx, "UPDATE photos SET likes_count = 7 WHERE id = 1")
+	require.NoError(t, err, "Не удалось испортить счетчик")
+	_, err = db.Exec(ctx, "UPDATE photo_reaction_counts SET count = 0 WHERE photo_id = 1")
+	require.NoError(t, err, "Не удалось испортить счетчик")
+
+	reactionRepo := repositories.NewReactionRepository(db)
+	drifts, err := reactionRepo.ReconcilePhotoCounts(ctx)
+	require.NoError(t, err, "Ошибка сверки счетчиков")
+	require.Len(t, drifts, 2, "Должны быть найдены оба расхождения")
+	assert.Equal(t, 7, drifts[0].Stored, "Некорректное сохраненное значение")
+	assert.Equal(t, 2, drifts[0].Actual, "Некорректное фактическое значение")
+
+	require.NoError(t, db.QueryRow(ctx, "SELECT likes_count FROM photos WHERE id = 1").Scan(&likesCount))
+	require.NoError(t, db.QueryRow(ctx, "SELECT count FROM photo_reaction_counts WHERE photo_id = 1 AND reaction = '❤️'").Scan(&heartCount))
+	assert.Equal(t, 2, likesCount, "Сверка должна исправить photos.likes_count")
+	assert.Equal(t, 2, heartCount, "Сверка должна исправить photo_reaction_counts")
+
+	drifts, err = reactionRepo.ReconcilePhotoCounts(ctx)
+	require.NoError(t, err, "Ошибка сверки счетчиков")
+	assert.Empty(t, drifts, "После исправления расхождений быть не должно")
+}
+
+func TestLikersAndViewerState(t *testing.T) {
+	setupTestBlocks(t, db)
+	ctx := context.Background()
+
+	_, err := db.Exec(ctx, "INSERT INTO users (id, email, password, username) VALUES (4, 'user4@example.com', 'password4', 'user4')")
+	require.NoError(t, err, "Не удалось создать пользователя")
+	for _, userID := range []int{2, 4, 3} {
+		resp := doAuthRequest(t, "PUT", "/api/photos/1/reaction", userID, `{"reaction": "❤️"}`)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось поставить лайк")
+	}
+	_, err = db.Exec(ctx, `
+		INSERT INTO comments (user_id, photo_id, content, deleted_at) VALUES
+		(2, 1, 'First', NULL), (3, 1, 'Second', NULL), (3, 1, 'Removed', NOW()), (1, 2, 'Other', NULL)`)
+	require.NoError(t, err, "Не удалось добавить комментарии")
+
+	likeRepo := repositories.NewLikeRepository(db)
+	likers, next, err := likeRepo.GetLikers(ctx, 1, repositories.LikerListOptions{ViewerID: 1, Limit: 2})
+	require.NoError(t, err, "Ошибка получения лайков")
+	require.Len(t, likers, 2, "Некорректный размер страницы")
+	assert.Equal(t, []int{3, 2}, []int{likers[0].ID, likers[1].ID}, "Сначала должны идти подписки зрителя")
+	assert.True(t, likers[0].FollowedByViewer, "Подписка должна быть отмечена")
+	require.NotNil(t, next, "Должен быть курсор следующей страницы")
+
+	likers, next, err = likeRepo.GetLikers(ctx, 1, repositories.LikerListOptions{ViewerID: 1, Limit: 2, After: next})
+	require.NoError(t, err, "Ошибка получения лайков")
+	require.Len(t, likers, 1, "Некорректный размер последней страницы")
+	assert.Equal(t, 4, likers[0].ID, "Остальные пользователи должны идти после подписок")
+	assert.False(t, likers[0].FollowedByViewer, "Пользователь без подписки не должен быть отмечен")
+	assert.Nil(t, next, "На последней странице курсора быть не должно")
+
+	resp := doAuthRequest(t, "GET", "/api/likes/state?photoIDs=2,1,99,1", 3, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Некорректный HTTP код ответа")
+	var result struct {
+		Photos []models.PhotoViewerState `json:"photos"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result), "Ошибка декодирования ответа")
+	resp.Body.Close()
+	assert.Equal(t, []models.PhotoViewerState{
+		{PhotoID: 2, LikesCount: 0, LikedByViewer: false, CommentsCount: 1},
+		{PhotoID: 1, LikesCount: 3, LikedByViewer: true, CommentsCount: 2},
+	}, result.Photos, "Некорректное состояние фото")
+
+	resp = doAuthRequest(t, "POST", "/api/users/3/block", 2, "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось заблокировать пользователя")
+	resp = doAuthRequest(t, "GET", "/api/likes/state?photoIDs=2,1", 3, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Некорректный HTTP код ответа")
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result), "Ошибка декодирования ответа")
+	resp.Body.Close()
+	require.Len(t, result.Photos, 1, "Фото заблокировавшего автора должно пропускаться")
+	assert.Equal(t, 1, result.Photos[0].PhotoID, "Некорректное фото")
+
+	resp = doAuthRequest(t, "GET", "/api/likes/state?photoIDs=", 3, "")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "Пустой список должен отклоняться")
 }
@@ -53,7 +53,7 @@ func TestSavePhoto(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.Name, func(t *testing.T) {
-			err := photoService.SavePhoto(tc.Input)
+			err := photoService.SavePhoto(context.Background(), tc.Input)
 
 			if tc.ShouldError {
 				require.Error(t, err, "Ожидалась ошибка, но её не было")
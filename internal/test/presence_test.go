@@ -0,0 +1,154 @@
+package test
+
+import (
+	"InstaSpace/internal/models"
+	"InstaSpace/internal/repositories"
+	"InstaSpace/internal/services"
+	"context"
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// readPresence читает следующее событие присутствия, пропуская остальные
+func readPresence(t *testing.T, conn *websocket.Conn) models.Presence {
+	t.Helper()
+
+	for {
+		env := readWSEnvelope(t, conn)
+		if env.Type != models.WSPresence {
+			continue
+		}
+		var p models.Presence
+		require.NoError(t, json.Unmarshal(env.Payload, &p), "Ошибка декодирования присутствия")
+		return p
+	}
+}
+
+func getPresence(t *testing.T, viewerID int, userIDs string) map[int]models.Presence {
+	t.Helper()
+
+	resp := doAuthRequest(t, "GET", "/api/presence?user_ids="+userIDs, viewerID, "")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Некорректный HTTP код ответа")
+
+	var result struct {
+		Users []models.Presence `json:"users"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result), "Ошибка декодирования ответа")
+	presence := make(map[int]models.Presence, len(result.Users))
+	for _, p := range result.Users {
+		presence[p.UserID] = p
+	}
+	return presence
+}
+
+func TestPresenceAcrossInstances(t *testing.T) {
+	setupTestBlocks(t, db)
+	ctx := context.Background()
+
+	watcher := dialWS(t, 2)
+	defer watcher.Close()
+
+	// Второй экземпляр сервера с общей базой. Его события доставляются тем же хабом, как через pub/sub
+	other := services.NewPresenceService(repositories.NewPresenceRepository(db), wsHandler, zapLogger)
+	other.Connected(ctx, 1)
+	p := readPresence(t, watcher)
+	assert.Equal(t, models.Presence{UserID: 1, Online: true}, p, "Собеседник должен узнать о подключении к другому экземпляру")
+	assert.True(t, getPresence(t, 2, "1")[1].Online, "Подключение к другому экземпляру должно учитываться")
+
+	// Подключение к этому экземпляру и отключение от него не меняют присутствие, пока открыто подключение к другому
+	conn := dialWS(t, 1)
+	conn.Close()
+	require.Eventually(t, func() bool { return !wsHandler.IsOnline(1) }, 2*time.Second, 10*time.Millisecond, "Подключение должно закрыться")
+	assert.True(t, getPresence(t, 2, "1")[1].Online, "Пользователь должен оставаться в сети")
+
+	var lastSeen *time.Time
+	require.NoError(t, db.QueryRow(ctx, "SELECT last_seen_at FROM users WHERE id = 1").Scan(&lastSeen))
+	assert.Nil(t, lastSeen, "Время выхода не должно сохраняться, пока пользователь в сети")
+
+	other.Disconnected(ctx, 1)
+	p = readPresence(t, watcher)
+	assert.False(t, p.Online, "Собеседник должен узнать о выходе из сети")
+	require.NotNil(t, p.LastSeenAt, "Событие должно содержать время выхода")
+	assert.False(t, getPresence(t, 2, "1")[1].Online, "Пользователь должен быть не в сети")
+
+	// Подключения экземпляра, который перестал их продлевать, не учитываются
+	other.Connected(ctx, 1)
+	readPresence(t, watcher)
+	_, err := db.Exec(ctx, "UPDATE presence_connections SET heartbeat_at = NOW() - INTERVAL '1 hour' WHERE instance_id = $1", other.InstanceID)
+	require.NoError(t, err, "Не удалось состарить подключения")
+	assert.False(t, getPresence(t, 2, "1")[1].Online, "Подключения упавшего экземпляра не должны учитываться")
+}
+
+func TestPresence(t *testing.T) {
+	setupTestBlocks(t, db)
+
+	watcher := dialWS(t, 2)
+	defer watcher.Close()
+
+	phone := dialWS(t, 1)
+	p := readPresence(t, watcher)
+	assert.Equal(t, models.Presence{UserID: 1, Online: true}, p, "Собеседник должен узнать о подключении")
+
+	// Второе устройство не меняет присутствие
+	laptop := dialWS(t, 1)
+
+	presence := getPresence(t, 2, "1,2,3")
+	require.Len(t, presence, 2, "Пользователь без общей беседы должен пропускаться")
+	assert.True(t, presence[1].Online, "Пользователь должен быть в сети")
+	assert.True(t, presence[2].Online, "Пользователь должен видеть себя в сети")
+
+	phone.Close()
+	laptop.Close()
+	p = readPresence(t, watcher)
+	assert.Equal(t, 1, p.UserID, "Некорректный пользователь")
+	assert.False(t, p.Online, "Собеседник должен узнать о выходе из сети")
+	require.NotNil(t, p.LastSeenAt, "Событие должно содержать время выхода")
+	assert.WithinDuration(t, time.Now(), *p.LastSeenAt, 5*time.Second, "Некорректное время выхода")
+
+	var lastSeen *time.Time
+	require.NoError(t, db.QueryRow(context.Background(), "SELECT last_seen_at FROM users WHERE id = 1").Scan(&lastSeen))
+	assert.NotNil(t, lastSeen, "Время выхода должно сохраняться")
+
+	presence = getPresence(t, 2, "1")
+	assert.False(t, presence[1].Online, "Пользователь должен быть не в сети")
+	assert.NotNil(t, presence[1].LastSeenAt, "Время выхода должно быть видно")
+
+	// Скрытое время выхода видно только самому пользователю
+	resp := doAuthRequest(t, "PUT", "/api/presence/settings", 1, `{"hide_last_seen": true}`)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось изменить настройки")
+
+	presence = getPresence(t, 2, "1")
+	assert.Nil(t, presence[1].LastSeenAt, "Скрытое время выхода не должно возвращаться")
+	presence = getPresence(t, 1, "1")
+	assert.NotNil(t, presence[1].LastSeenAt, "Пользователь должен видеть свое время выхода")
+
+	conn := dialWS(t, 1)
+	readPresence(t, watcher)
+	conn.Close()
+	p = readPresence(t, watcher)
+	assert.False(t, p.Online, "Собеседник должен узнать о выходе из сети")
+	assert.Nil(t, p.LastSeenAt, "Скрытое время выхода не должно рассылаться")
+
+	resp = doAuthRequest(t, "GET", "/api/presence/settings", 1, "")
+	var settings models.PresenceSettings
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&settings), "Ошибка декодирования ответа")
+	resp.Body.Close()
+	assert.True(t, settings.HideLastSeen, "Настройка должна сохраняться")
+
+	// Заблокированный пользователь не видит присутствия
+	resp = doAuthRequest(t, "POST", "/api/users/2/block", 1, "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось заблокировать пользователя")
+	assert.Empty(t, getPresence(t, 2, "1"), "Присутствие не должно быть видно после блокировки")
+
+	resp = doAuthRequest(t, "GET", "/api/presence", 2, "")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "Пустой список должен отклоняться")
+}
@@ -0,0 +1,57 @@
+package test
+
+import (
+	"InstaSpace/internal/pubsub"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPostgresPubSub(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Два экземпляра сервера: событие, опубликованное одним, получают оба
+	newInstance := func() (*pubsub.Postgres, chan pubsub.Event) {
+		ps := pubsub.NewPostgres(db, zapLogger)
+		ps.Channel = "test_events"
+		events := make(chan pubsub.Event, 10)
+		require.NoError(t, ps.Subscribe(ctx, func(e pubsub.Event) { events <- e }), "Не удалось подписаться на события")
+		return ps, events
+	}
+	first, firstEvents := newInstance()
+	_, secondEvents := newInstance()
+
+	receive := func(events chan pubsub.Event) pubsub.Event {
+		t.Helper()
+		select {
+		case e := <-events:
+			return e
+		case <-time.After(2 * time.Second):
+			t.Fatal("Событие не доставлено")
+			return pubsub.Event{}
+		}
+	}
+
+	small := pubsub.Event{UserIDs: []int{1, 2}, Type: "message", Payload: json.RawMessage(`{"content":"Hi"}`)}
+	require.NoError(t, first.Publish(ctx, small), "Не удалось опубликовать событие")
+	for _, events := range []chan pubsub.Event{firstEvents, secondEvents} {
+		e := receive(events)
+		assert.Equal(t, small.UserIDs, e.UserIDs, "Некорректный получатель")
+		assert.Equal(t, small.Type, e.Type, "Некорректный тип события")
+		assert.JSONEq(t, string(small.Payload), string(e.Payload), "Некорректное содержимое")
+	}
+
+	// Событие больше лимита NOTIFY передается через таблицу
+	content, err := json.Marshal(map[string]string{"content": strings.Repeat("x", 10000)})
+	require.NoError(t, err)
+	large := pubsub.Event{UserIDs: []int{3}, Type: "message", Payload: content}
+	require.NoError(t, first.Publish(ctx, large), "Не удалось опубликовать большое событие")
+	e := receive(secondEvents)
+	assert.Equal(t, large.UserIDs, e.UserIDs, "Некорректный получатель")
+	assert.JSONEq(t, string(large.Payload), string(e.Payload), "Большое событие должно доставляться целиком")
+}
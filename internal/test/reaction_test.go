@@ -0,0 +1,110 @@
+package test
+
+import (
+	"InstaSpace/internal/models"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func getReactions(t *testing.T, url string, userID int) models.ReactionSummary {
+	t.Helper()
+
+	resp := doAuthRequest(t, "GET", url, userID, "")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Некорректный HTTP код ответа")
+
+	var summary models.ReactionSummary
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&summary), "Ошибка декодирования ответа")
+	return summary
+}
+
+func TestPhotoReactions(t *testing.T) {
+	setupTestBlocks(t, db)
+
+	react := func(userID int, body string) int {
+		resp := doAuthRequest(t, "PUT", "/api/photos/1/reaction", userID, body)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	require.Equal(t, http.StatusOK, react(2, `{"reaction": "🔥"}`), "Не удалось поставить реакцию")
+	require.Equal(t, http.StatusOK, react(3, `{"reaction": "🔥"}`), "Не удалось поставить реакцию")
+	assert.Equal(t, http.StatusBadRequest, react(2, `{"reaction": "👎"}`), "Недопустимая реакция должна отклоняться")
+
+	summary := getReactions(t, "/api/photos/1/reactions", 2)
+	assert.Equal(t, map[string]int{"🔥": 2}, summary.Counts, "Некорректные счетчики")
+	assert.Equal(t, "🔥", summary.ViewerReaction, "Некорректная реакция зрителя")
+
+	require.Equal(t, http.StatusOK, react(2, `{"reaction": "❤️"}`), "Не удалось сменить реакцию")
+	summary = getReactions(t, "/api/photos/1/reactions", 2)
+	assert.Equal(t, map[string]int{"🔥": 1, "❤️": 1}, summary.Counts, "Смена реакции должна переносить счетчик")
+
+	resp, err := http.Get(testServer.URL + "/api/likes/count?photoID=1")
+	require.NoError(t, err, "Ошибка выполнения HTTP запроса")
+	var count map[string]int
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&count), "Ошибка декодирования ответа")
+	resp.Body.Close()
+	assert.Equal(t, 1, count["likes_count"], "Сердечко должно считаться лайком")
+
+	var likesCount int
+	require.NoError(t, db.QueryRow(context.Background(), "SELECT likes_count FROM photos WHERE id = 1").Scan(&likesCount))
+	assert.Equal(t, 1, likesCount, "photos.likes_count должен считать сердечки")
+
+	resp = doAuthRequest(t, "DELETE", "/api/photos/1/reaction", 3, "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось убрать реакцию")
+	resp = doAuthRequest(t, "DELETE", "/api/photos/1/reaction", 3, "")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Повторное удаление не должно менять состояние")
+
+	summary = getReactions(t, "/api/photos/1/reactions", 3)
+	assert.Equal(t, map[string]int{"❤️": 1}, summary.Counts, "Некорректные счетчики после удаления")
+	assert.Empty(t, summary.ViewerReaction, "Реакция зрителя должна быть снята")
+}
+
+func TestMessageReactions(t *testing.T) {
+	setupTestBlocks(t, db)
+
+	_, err := db.Exec(context.Background(), "INSERT INTO messages (id, conversation_id, sender_id, content) VALUES (1, 1, 1, 'Hi')")
+	require.NoError(t, err, "Не удалось создать сообщение")
+
+	resp := doAuthRequest(t, "PUT", "/api/messages/1/reaction", 2, `{"reaction": "😂"}`)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Участник беседы должен ставить реакцию")
+
+	resp = doAuthRequest(t, "PUT", "/api/messages/1/reaction", 3, `{"reaction": "😂"}`)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "Посторонний не должен видеть сообщение")
+
+	summary := getReactions(t, "/api/messages/1/reactions", 1)
+	assert.Equal(t, map[string]int{"😂": 1}, summary.Counts, "Некорректные счетчики")
+	assert.Empty(t, summary.ViewerReaction, "Отправитель не ставил реакцию")
+
+	resp = doAuthRequest(t, "PUT", "/api/messages/1/reaction", 1, `{"reaction": "😂"}`)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось поставить реакцию")
+	resp = doAuthRequest(t, "PUT", "/api/messages/1/reaction", 2, `{"reaction": "🔥"}`)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось сменить реакцию")
+
+	summary = getReactions(t, "/api/messages/1/reactions", 2)
+	assert.Equal(t, map[string]int{"😂": 1, "🔥": 1}, summary.Counts, "Смена реакции должна переносить счетчик")
+	assert.Equal(t, "🔥", summary.ViewerReaction, "Некорректная реакция зрителя")
+
+	resp = doAuthRequest(t, "DELETE", "/api/messages/1/reaction", 1, "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось убрать реакцию")
+
+	summary = getReactions(t, "/api/messages/1/reactions", 1)
+	assert.Equal(t, map[string]int{"🔥": 1}, summary.Counts, "Некорректные счетчики после удаления")
+	assert.Empty(t, summary.ViewerReaction, "Реакция зрителя должна быть снята")
+
+	var laughCount int
+	require.NoError(t, db.QueryRow(context.Background(),
+		"SELECT count FROM message_reaction_counts WHERE message_id = 1 AND reaction = '😂'").Scan(&laughCount))
+	assert.Equal(t, 0, laughCount, "Счетчик снятой реакции должен обнулиться")
+}
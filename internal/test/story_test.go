@@ -0,0 +1,124 @@
+package test
+
+import (
+	"InstaSpace/internal/models"
+	"InstaSpace/internal/services"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"testing"
+)
+
+var (
+	storyService *services.StoryService
+)
+
+func uploadStory(t *testing.T, userID int, caption string) models.Story {
+	t.Helper()
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", "story.jpg")
+	require.NoError(t, err, "Ошибка создания файла в multipart")
+	_, err = part.Write([]byte("test story content"))
+	require.NoError(t, err, "Ошибка записи файла в multipart")
+	writer.WriteField("caption", caption)
+	require.NoError(t, writer.Close(), "Ошибка закрытия writer")
+
+	req, err := http.NewRequest("POST", testServer.URL+"/api/stories", body)
+	require.NoError(t, err, "Ошибка создания запроса")
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", authHeader(t, userID))
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err, "Ошибка выполнения запроса")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode, "Не удалось опубликовать историю")
+
+	var story models.Story
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&story), "Ошибка декодирования ответа")
+	return story
+}
+
+func TestStories(t *testing.T) {
+	setupTestBlocks(t, db)
+
+	// Пользователь 1 подписан на 2 и 3
+	story2 := uploadStory(t, 2, "Morning")
+	story3 := uploadStory(t, 3, "Evening")
+	assert.WithinDuration(t, story2.CreatedAt.Add(services.DefaultStoryTTL), story2.ExpiresAt, 0, "История должна жить 24 часа")
+
+	getTray := func() []models.StoryTrayItem {
+		resp := doAuthRequest(t, "GET", "/api/stories/tray", 1, "")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode, "Некорректный HTTP код ответа")
+		var result struct {
+			Tray []models.StoryTrayItem `json:"tray"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result), "Ошибка декодирования ответа")
+		return result.Tray
+	}
+
+	tray := getTray()
+	require.Len(t, tray, 2, "В ленте должны быть обе подписки")
+	assert.Equal(t, 3, tray[0].UserID, "Свежие истории должны идти первыми")
+
+	resp := doAuthRequest(t, "POST", fmt.Sprintf("/api/stories/%d/seen", story3.ID), 1, "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось отметить просмотр")
+	resp = doAuthRequest(t, "POST", fmt.Sprintf("/api/stories/%d/seen", story3.ID), 1, "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Повторный просмотр не должен отклоняться")
+
+	tray = getTray()
+	require.Len(t, tray, 2, "В ленте должны быть обе подписки")
+	assert.Equal(t, 2, tray[0].UserID, "Непросмотренные истории должны идти первыми")
+	assert.False(t, tray[1].HasUnseen, "Просмотренная история должна быть отмечена")
+
+	resp = doAuthRequest(t, "GET", fmt.Sprintf("/api/stories/%d/viewers", story3.ID), 3, "")
+	var viewers struct {
+		Viewers []models.StoryViewer `json:"viewers"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&viewers), "Ошибка декодирования ответа")
+	resp.Body.Close()
+	require.Len(t, viewers.Viewers, 1, "Просмотр должен учитываться один раз")
+	assert.Equal(t, 1, viewers.Viewers[0].UserID, "Некорректный зритель")
+
+	resp = doAuthRequest(t, "GET", fmt.Sprintf("/api/stories/%d/viewers", story3.ID), 1, "")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "Просмотры видны только автору")
+
+	resp = doAuthRequest(t, "GET", "/api/users/3/stories", 1, "")
+	var list struct {
+		Stories []models.Story `json:"stories"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list), "Ошибка декодирования ответа")
+	resp.Body.Close()
+	require.Len(t, list.Stories, 1, "Некорректное количество историй")
+	assert.True(t, list.Stories[0].Seen, "История должна быть отмечена просмотренной")
+	assert.Nil(t, list.Stories[0].ViewsCount, "Количество просмотров видно только автору")
+
+	// Истекшая история исчезает из ленты, а очистка удаляет запись и файл
+	_, err := db.Exec(context.Background(), "UPDATE stories SET expires_at = NOW() - INTERVAL '1 minute' WHERE id = $1", story2.ID)
+	require.NoError(t, err, "Не удалось состарить историю")
+	tray = getTray()
+	require.Len(t, tray, 1, "Истекшая история не должна попадать в ленту")
+
+	resp = doAuthRequest(t, "POST", fmt.Sprintf("/api/stories/%d/seen", story2.ID), 1, "")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "Истекшую историю нельзя просмотреть")
+
+	removed, err := storyService.SweepExpired(context.Background())
+	require.NoError(t, err, "Ошибка очистки историй")
+	assert.Equal(t, 1, removed, "Должна быть удалена одна история")
+	_, err = os.Stat(story2.URL)
+	assert.True(t, os.IsNotExist(err), "Файл истекшей истории должен быть удален")
+	_, err = os.Stat(story3.URL)
+	assert.NoError(t, err, "Файл активной истории должен остаться")
+}
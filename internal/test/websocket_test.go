@@ -2,7 +2,12 @@ package test
 
 import (
 	"InstaSpace/internal/handlers"
+	"InstaSpace/internal/models"
+	"InstaSpace/internal/services"
 	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -14,7 +19,8 @@ import (
 )
 
 var (
-	wsHandler *handlers.WebSocketHandler
+	wsHandler      *handlers.WebSocketHandler
+	messageService *services.MessageService
 )
 
 func TestWebSocketMessaging(t *testing.T) {
@@ -28,88 +34,426 @@ func TestWebSocketMessaging(t *testing.T) {
 		(2, 'user2@example.com', 'password2', 'user2')`)
 	require.NoError(t, err, "Не удалось создать пользователей")
 
-	_, err = db.Exec(ctx, "INSERT INTO conversations (id, user1_id, user2_id) VALUES (1, 1, 2)")
-	require.NoError(t, err, "Не удалось создать тестовую переписку")
+	createTestConversation(t)
 
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		wsHandler.HandleWS(w, r)
-	}))
-	defer server.Close()
-
-	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
-	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
-	require.NoError(t, err, "Не удалось подключиться к WebSocket")
+	conn := dialWS(t, 1)
 	defer conn.Close()
 
-	testMessage := map[string]interface{}{
+	// sender_id от клиента игнорируется, отправитель — владелец токена
+	sendWS(t, conn, models.WSSend, "msg-1", map[string]interface{}{
 		"conversation_id": 1,
-		"sender_id":       1,
+		"sender_id":       2,
 		"content":         "Hello WebSocket!",
-	}
+	})
 
-	err = conn.WriteJSON(testMessage)
-	require.NoError(t, err, "Ошибка при отправке WebSocket-сообщения")
+	// Отправитель получает подтверждение и само сообщение, как и другие подключения участников
+	var ack models.WSAckPayload
+	env := readWS(t, conn, &ack)
+	require.Equal(t, models.WSAck, env.Type, "Ожидалось подтверждение")
+	assert.Equal(t, "msg-1", env.ID, "Подтверждение должно приходить с ID запроса")
+	assert.False(t, ack.Duplicate, "Первая отправка не является повтором")
 
-	var response map[string]interface{}
-	err = conn.ReadJSON(&response)
-	require.NoError(t, err, "Ошибка при чтении WebSocket-сообщения")
+	var msg models.Message
+	env = readWS(t, conn, &msg)
+	require.Equal(t, models.WSMessage, env.Type, "Ожидалось сообщение")
+	assert.Equal(t, ack.MessageID, msg.ID, "Некорректный ID сообщения")
+	assert.Equal(t, "Hello WebSocket!", msg.Content, "Сообщения не совпадают")
+	assert.Equal(t, 1, msg.SenderID, "Отправителем должен быть владелец токена")
+	assert.Equal(t, "msg-1", msg.ClientMessageID, "Сообщение должно содержать ID клиента")
 
-	// Проверяем, что ответ содержит message_id и совпадает с отправленным содержимым
-	assert.Equal(t, testMessage["content"], response["content"], "Сообщения не совпадают")
-	assert.Contains(t, response, "message_id", "Ожидалось, что сообщение содержит message_id")
-}
+	// Повтор с тем же ID не создает дубликат и не рассылается заново
+	sendWS(t, conn, models.WSSend, "msg-1", map[string]interface{}{"conversation_id": 1, "content": "Hello WebSocket!"})
+	var again models.WSAckPayload
+	env = readWS(t, conn, &again)
+	require.Equal(t, models.WSAck, env.Type, "Ожидалось подтверждение")
+	assert.True(t, again.Duplicate, "Повторная отправка должна быть отмечена")
+	assert.Equal(t, ack.MessageID, again.MessageID, "Повтор должен возвращать сохраненное сообщение")
 
-func TestWebSocketInvalidMessage(t *testing.T) {
+	var count int
+	require.NoError(t, db.QueryRow(ctx, "SELECT COUNT(*) FROM messages").Scan(&count))
+	assert.Equal(t, 1, count, "Повтор не должен создавать дубликат")
 
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		wsHandler.HandleWS(w, r)
-	}))
-	defer server.Close()
+	var wsErr models.WSErrorPayload
+	sendWS(t, conn, models.WSSend, "msg-2", map[string]interface{}{"conversation_id": 99, "content": "Hi"})
+	env = readWS(t, conn, &wsErr)
+	require.Equal(t, models.WSError, env.Type, "Ожидалась ошибка")
+	assert.Equal(t, "msg-2", env.ID, "Ошибка должна приходить с ID запроса")
+	assert.Equal(t, models.WSErrConversationNotFound, wsErr.Code, "Некорректный код ошибки")
 
-	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
-	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
-	require.NoError(t, err, "Не удалось подключиться к WebSocket")
+	// ID клиента, занятый в одной беседе, нельзя повторить в другой
+	_, err = db.Exec(ctx, "INSERT INTO users (id, email, password, username) VALUES (3, 'user3@example.com', 'password3', 'user3')")
+	require.NoError(t, err, "Не удалось создать пользователя")
+	other, err := messageService.GetOrCreateConversation(ctx, 1, 3)
+	require.NoError(t, err, "Не удалось создать беседу")
+	sendWS(t, conn, models.WSSend, "msg-1", map[string]interface{}{"conversation_id": other.ID, "content": "Hello WebSocket!"})
+	env = readWS(t, conn, &wsErr)
+	require.Equal(t, models.WSError, env.Type, "Ожидалась ошибка")
+	assert.Equal(t, models.WSErrInvalidPayload, wsErr.Code, "Некорректный код ошибки")
+}
+
+func TestWebSocketInvalidMessage(t *testing.T) {
+	conn := dialWS(t, 1)
 	defer conn.Close()
 
-	err = conn.WriteMessage(websocket.TextMessage, []byte("invalid data"))
+	err := conn.WriteMessage(websocket.TextMessage, []byte("invalid data"))
 	require.NoError(t, err, "Ошибка при отправке некорректного сообщения")
 
-	time.Sleep(1 * time.Second)
+	var wsErr models.WSErrorPayload
+	env := readWS(t, conn, &wsErr)
+	require.Equal(t, models.WSError, env.Type, "Ожидалась ошибка")
+	assert.Equal(t, models.WSErrInvalidEnvelope, wsErr.Code, "Некорректный код ошибки")
+
+	sendWS(t, conn, "dance", "x-1", nil)
+	env = readWS(t, conn, &wsErr)
+	assert.Equal(t, models.WSErrUnknownType, wsErr.Code, "Некорректный код ошибки")
+	assert.Equal(t, "x-1", env.ID, "Ошибка должна приходить с ID запроса")
+
+	require.NoError(t, conn.WriteJSON(map[string]interface{}{"v": 2, "type": models.WSSend, "id": "x-2"}))
+	readWS(t, conn, &wsErr)
+	assert.Equal(t, models.WSErrUnsupportedVersion, wsErr.Code, "Некорректный код ошибки")
+
+	resp, err := http.Get(testServer.URL + "/ws/schema")
+	require.NoError(t, err, "Ошибка выполнения HTTP запроса")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Схема протокола должна быть доступна")
+	var schema map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&schema), "Схема должна быть корректным JSON")
+	assert.Contains(t, schema, "$defs", "Схема должна описывать типы сообщений")
+}
+
+func TestWebSocketAuth(t *testing.T) {
+	setupTestBlocks(t, db)
+
+	wsURL := "ws" + strings.TrimPrefix(testServer.URL, "http") + "/ws"
+	dial := func(query string, header http.Header) (*websocket.Conn, *http.Response, error) {
+		return websocket.DefaultDialer.Dial(wsURL+query, header)
+	}
+
+	_, resp, err := dial("", nil)
+	require.Error(t, err, "Подключение без токена должно отклоняться")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "Некорректный HTTP код ответа")
+
+	_, resp, err = dial("", http.Header{"Authorization": {"Bearer invalid"}})
+	require.Error(t, err, "Подключение с неверным токеном должно отклоняться")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "Некорректный HTTP код ответа")
+
+	_, resp, err = dial("", http.Header{"Authorization": {authHeader(t, 1)}, "Origin": {"http://evil.example.com"}})
+	require.Error(t, err, "Подключение с чужого источника должно отклоняться")
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "Некорректный HTTP код ответа")
 
+	conn, _, err := dial("", http.Header{"Authorization": {authHeader(t, 1)}, "Origin": {"http://allowed.example.com"}})
+	require.NoError(t, err, "Подключение с разрешенного источника должно проходить")
+	conn.Close()
+
+	// Токен в подпротоколе: сервер выбирает подпротокол без токена
+	token := strings.TrimPrefix(authHeader(t, 1), "Bearer ")
+	conn, resp, err = dial("", http.Header{"Sec-WebSocket-Protocol": {"instaspace, bearer." + token}})
+	require.NoError(t, err, "Не удалось подключиться с токеном в подпротоколе")
+	assert.Equal(t, "instaspace", resp.Header.Get("Sec-WebSocket-Protocol"), "Сервер не должен возвращать токен")
+	conn.Close()
+
+	// Билет одноразовый
+	resp = doAuthRequest(t, "POST", "/api/ws-ticket", 1, "")
+	var ticket models.WSTicket
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&ticket), "Ошибка декодирования ответа")
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode, "Не удалось получить билет")
+
+	conn, _, err = dial("?ticket="+ticket.Ticket, nil)
+	require.NoError(t, err, "Не удалось подключиться по билету")
+	sendWS(t, conn, models.WSSend, "ticket-1", map[string]interface{}{"conversation_id": 1, "content": "By ticket"})
+	readWS(t, conn, nil)
+	var msg models.Message
+	readWS(t, conn, &msg)
+	assert.Equal(t, 1, msg.SenderID, "Подключение должно принадлежать владельцу билета")
+	conn.Close()
+
+	_, resp, err = dial("?ticket="+ticket.Ticket, nil)
+	require.Error(t, err, "Билет нельзя использовать повторно")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "Некорректный HTTP код ответа")
+
+	// По истечении токена сервер закрывает соединение
+	shortToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": 1,
+		"exp":     time.Now().Add(2 * time.Second).Unix(),
+	}).SignedString([]byte(authService.JWTSecret))
+	require.NoError(t, err, "Не удалось подписать токен")
+	conn, _, err = dial("", http.Header{"Authorization": {"Bearer " + shortToken}})
+	require.NoError(t, err, "Не удалось подключиться")
+	defer conn.Close()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
 	_, _, err = conn.ReadMessage()
-	assert.Error(t, err, "Ожидалось закрытие соединения из-за некорректных данных")
+	assert.True(t, websocket.IsCloseError(err, websocket.ClosePolicyViolation), "Соединение должно закрываться при истечении токена: %v", err)
 }
 
-func TestWebSocketMultipleClients(t *testing.T) {
+// dialWS подключается к WebSocket тестового сервера от имени пользователя
+func dialWS(t *testing.T, userID int) *websocket.Conn {
+	t.Helper()
 
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		wsHandler.HandleWS(w, r)
-	}))
-	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(testServer.URL, "http") + "/ws"
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, http.Header{"Authorization": {authHeader(t, userID)}})
+	require.NoError(t, err, "Не удалось подключиться к WebSocket")
+	return conn
+}
 
-	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+// sendWS отправляет конверт протокола WebSocket
+func sendWS(t *testing.T, conn *websocket.Conn, eventType, id string, payload interface{}) {
+	t.Helper()
 
-	conn1, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
-	require.NoError(t, err, "Не удалось подключиться к WebSocket клиенту 1")
-	defer conn1.Close()
+	raw, err := json.Marshal(payload)
+	require.NoError(t, err, "Ошибка кодирования содержимого")
+	err = conn.WriteJSON(models.WSEnvelope{V: models.WSProtocolVersion, Type: eventType, ID: id, Payload: raw})
+	require.NoError(t, err, "Ошибка при отправке WebSocket-сообщения")
+}
+
+// readWS читает следующий конверт, пропуская события присутствия, которые зависят от порядка подключений.
+// Если payload не nil, декодирует в него содержимое
+func readWS(t *testing.T, conn *websocket.Conn, payload interface{}) models.WSEnvelope {
+	t.Helper()
+
+	for {
+		env := readWSEnvelope(t, conn)
+		if env.Type == models.WSPresence {
+			continue
+		}
+		if payload != nil {
+			require.NoError(t, json.Unmarshal(env.Payload, payload), "Ошибка декодирования содержимого %s", env.Type)
+		}
+		return env
+	}
+}
+
+// readWSEnvelope читает следующий конверт любого типа
+func readWSEnvelope(t *testing.T, conn *websocket.Conn) models.WSEnvelope {
+	t.Helper()
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	var env models.WSEnvelope
+	require.NoError(t, conn.ReadJSON(&env), "Ошибка при чтении WebSocket-сообщения")
+	assert.Equal(t, models.WSProtocolVersion, env.V, "Некорректная версия протокола")
+	return env
+}
+
+func TestWebSocketMultipleClients(t *testing.T) {
+	setupTestBlocks(t, db)
 
-	conn2, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
-	require.NoError(t, err, "Не удалось подключиться к WebSocket клиенту 2")
-	defer conn2.Close()
+	// У пользователя 1 два устройства, пользователь 3 в беседе не участвует
+	phone := dialWS(t, 1)
+	defer phone.Close()
+	laptop := dialWS(t, 1)
+	defer laptop.Close()
+	recipient := dialWS(t, 2)
+	defer recipient.Close()
+	outsider := dialWS(t, 3)
+	defer outsider.Close()
 
-	testMessage := map[string]interface{}{
+	sendWS(t, phone, models.WSSend, "phone-1", map[string]interface{}{
 		"conversation_id": 1,
-		"sender_id":       1,
 		"content":         "Hello from Client 1!",
+	})
+
+	var ack models.WSAckPayload
+	env := readWS(t, phone, &ack)
+	require.Equal(t, models.WSAck, env.Type, "Отправитель должен получить подтверждение")
+
+	for name, conn := range map[string]*websocket.Conn{"phone": phone, "laptop": laptop, "recipient": recipient} {
+		var msg models.Message
+		env := readWS(t, conn, &msg)
+		require.Equal(t, models.WSMessage, env.Type, "Ожидалось сообщение: %s", name)
+		assert.Equal(t, "Hello from Client 1!", msg.Content, "Сообщения не совпадают: %s", name)
+		assert.Equal(t, 1, msg.SenderID, "Некорректный отправитель: %s", name)
+		assert.Equal(t, ack.MessageID, msg.ID, "Некорректный ID сообщения: %s", name)
+	}
+
+	require.NoError(t, outsider.SetReadDeadline(time.Now().Add(300*time.Millisecond)))
+	_, _, err := outsider.ReadMessage()
+	assert.Error(t, err, "Сообщение не должно доставляться пользователю вне беседы")
+}
+
+func TestReadReceipts(t *testing.T) {
+	setupTestBlocks(t, db)
+
+	var messageIDs []int
+	for _, content := range []string{"Hi", "Are you there?"} {
+		resp := doAuthRequest(t, "POST", "/api/messages", 2, fmt.Sprintf(`{"conversation_id": 1, "content": %q}`, content))
+		var result map[string]int
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result), "Ошибка декодирования ответа")
+		resp.Body.Close()
+		messageIDs = append(messageIDs, result["message_id"])
+	}
+
+	getUnread := func() models.UnreadCount {
+		resp := doAuthRequest(t, "GET", "/api/conversations/unread-count", 1, "")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode, "Некорректный HTTP код ответа")
+		var count models.UnreadCount
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&count), "Ошибка декодирования ответа")
+		return count
+	}
+	assert.Equal(t, models.UnreadCount{Messages: 2, Conversations: 1}, getUnread(), "Некорректный счетчик непрочитанного")
+
+	sender := dialWS(t, 2)
+	defer sender.Close()
+
+	resp := doAuthRequest(t, "POST", "/api/conversations/1/read", 1, fmt.Sprintf(`{"message_id": %d}`, messageIDs[0]))
+	var receipt models.ReadReceipt
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&receipt), "Ошибка декодирования ответа")
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Не удалось отметить беседу прочитанной")
+	assert.Equal(t, messageIDs[0], receipt.LastReadMessageID, "Некорректная отметка прочтения")
+
+	var event models.ReadReceipt
+	env := readWS(t, sender, &event)
+	assert.Equal(t, models.WSRead, env.Type, "Отправитель должен получить событие прочтения")
+	assert.Equal(t, 1, event.UserID, "Некорректный прочитавший")
+	assert.Equal(t, messageIDs[0], event.LastReadMessageID, "Некорректная отметка в событии")
+	assert.Equal(t, models.UnreadCount{Messages: 1, Conversations: 1}, getUnread(), "Некорректный счетчик непрочитанного")
+
+	// Отметка не сдвигается назад, а чужое сообщение отклоняется
+	resp = doAuthRequest(t, "POST", "/api/conversations/1/read", 1, `{"message_id": 1}`)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&receipt), "Ошибка декодирования ответа")
+	resp.Body.Close()
+	assert.Equal(t, messageIDs[0], receipt.LastReadMessageID, "Отметка не должна сдвигаться назад")
+	resp = doAuthRequest(t, "POST", "/api/conversations/1/read", 3, "")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "Чужую беседу нельзя отметить прочитанной")
+
+	// Без message_id беседа читается до последнего сообщения
+	reader := dialWS(t, 1)
+	defer reader.Close()
+	sendWS(t, reader, models.WSRead, "", map[string]interface{}{"conversation_id": 1})
+
+	var unread models.UnreadCount
+	env = readWS(t, reader, &unread)
+	assert.Equal(t, models.WSUnreadCount, env.Type, "Читатель должен получить обновленный счетчик")
+	assert.Equal(t, models.UnreadCount{}, unread, "Непрочитанных сообщений не должно остаться")
+	env = readWS(t, sender, &event)
+	assert.Equal(t, models.WSRead, env.Type, "Отправитель должен получить событие прочтения")
+	assert.Equal(t, messageIDs[1], event.LastReadMessageID, "Беседа должна быть прочитана до последнего сообщения")
+
+	resp = doAuthRequest(t, "GET", "/api/conversations/1", 2, "")
+	var conv models.Conversation
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&conv), "Ошибка декодирования ответа")
+	resp.Body.Close()
+	for _, p := range conv.Participants {
+		assert.Equal(t, messageIDs[1], p.LastReadMessageID, "Участники должны видеть отметки прочтения друг друга")
+	}
+}
+
+func TestTyping(t *testing.T) {
+	setupTestBlocks(t, db)
+
+	ttl, throttle := messageService.TypingTTL, messageService.TypingThrottle
+	messageService.TypingTTL, messageService.TypingThrottle = 300*time.Millisecond, time.Hour
+	defer func() { messageService.TypingTTL, messageService.TypingThrottle = ttl, throttle }()
+
+	typist := dialWS(t, 1)
+	defer typist.Close()
+	watcher := dialWS(t, 2)
+	defer watcher.Close()
+
+	sendWS(t, typist, models.WSTyping, "", map[string]interface{}{"conversation_id": 1, "typing": true})
+
+	var event models.WSTypingPayload
+	env := readWS(t, watcher, &event)
+	require.Equal(t, models.WSTyping, env.Type, "Участник должен получить событие набора")
+	assert.True(t, event.Typing, "Пользователь должен набирать сообщение")
+	assert.Equal(t, 1, event.UserID, "Некорректный набирающий")
+	require.NotNil(t, event.ExpiresAt, "Событие набора должно содержать время истечения")
+
+	// Повтор в пределах интервала продлевает набор, но не пересылается. Без повторов набор истекает
+	sendWS(t, typist, models.WSTyping, "", map[string]interface{}{"conversation_id": 1, "typing": true})
+	env = readWS(t, watcher, &event)
+	require.Equal(t, models.WSTyping, env.Type, "Участник должен получить окончание набора")
+	assert.False(t, event.Typing, "Набор должен истечь без повторов")
+
+	// Отправка сообщения завершает набор
+	sendWS(t, typist, models.WSTyping, "", map[string]interface{}{"conversation_id": 1, "typing": true})
+	readWS(t, watcher, &event)
+	require.True(t, event.Typing, "Участник должен получить событие набора")
+	sendWS(t, typist, models.WSSend, "typed-1", map[string]interface{}{"conversation_id": 1, "content": "Done"})
+	env = readWS(t, watcher, &event)
+	require.Equal(t, models.WSTyping, env.Type, "Отправка сообщения должна завершать набор")
+	assert.False(t, event.Typing, "Набор должен завершиться")
+	var msg models.Message
+	env = readWS(t, watcher, &msg)
+	require.Equal(t, models.WSMessage, env.Type, "Участник должен получить сообщение")
+
+	require.Equal(t, models.WSAck, readWS(t, typist, nil).Type, "Отправитель должен получить подтверждение")
+	require.Equal(t, models.WSMessage, readWS(t, typist, nil).Type, "Отправитель должен получить сообщение")
+
+	var wsErr models.WSErrorPayload
+	sendWS(t, typist, models.WSTyping, "t-1", map[string]interface{}{"conversation_id": 99, "typing": true})
+	env = readWS(t, typist, &wsErr)
+	assert.Equal(t, models.WSError, env.Type, "Набор в чужой беседе должен отклоняться")
+	assert.Equal(t, models.WSErrConversationNotFound, wsErr.Code, "Некорректный код ошибки")
+}
+
+func TestWebSocketHeartbeat(t *testing.T) {
+	setupTestBlocks(t, db)
+
+	hub := wsHandler.Hub
+	ping, pong, limit := hub.PingInterval, hub.PongTimeout, hub.MaxMessageSize
+	hub.PingInterval, hub.PongTimeout, hub.MaxMessageSize = 50*time.Millisecond, 300*time.Millisecond, 1024
+	defer func() { hub.PingInterval, hub.PongTimeout, hub.MaxMessageSize = ping, pong, limit }()
+
+	// Клиент, который читает, отвечает pong и остается подключенным дольше PongTimeout
+	alive := dialWS(t, 1)
+	defer alive.Close()
+	pings := 0
+	alive.SetPingHandler(func(data string) error {
+		pings++
+		return alive.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+	require.NoError(t, alive.SetReadDeadline(time.Now().Add(600*time.Millisecond)))
+	_, _, err := alive.ReadMessage()
+	require.Error(t, err, "Событий быть не должно")
+	assert.GreaterOrEqual(t, pings, 2, "Сервер должен отправлять ping")
+	assert.True(t, wsHandler.IsOnline(1), "Отвечающий клиент не должен отключаться")
+
+	// Клиент, который не читает и не отвечает pong, отключается
+	silent := dialWS(t, 3)
+	defer silent.Close()
+	require.Eventually(t, func() bool { return !wsHandler.IsOnline(3) }, 2*time.Second, 50*time.Millisecond, "Молчащий клиент должен отключаться")
+
+	// Слишком большое сообщение закрывает соединение с кодом 1009
+	big := dialWS(t, 2)
+	defer big.Close()
+	require.NoError(t, big.WriteMessage(websocket.TextMessage, []byte(strings.Repeat("x", 2048))))
+	require.NoError(t, big.SetReadDeadline(time.Now().Add(2*time.Second)))
+	for {
+		if _, _, err = big.ReadMessage(); err != nil {
+			break
+		}
 	}
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseMessageTooBig), "Ожидался код 1009, получено %v", err)
+}
+
+func TestWebSocketShutdown(t *testing.T) {
+	// Отдельный обработчик, чтобы остановка не затронула общий тестовый сервер
+	handler := handlers.NewWebSocketHandler(zapLogger, messageService, nil, authService.JWTSecret, nil)
+	server := httptest.NewServer(http.HandlerFunc(handler.HandleWS))
+	defer server.Close()
 
-	err = conn1.WriteJSON(testMessage)
-	require.NoError(t, err, "Ошибка при отправке WebSocket-сообщения клиентом 1")
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, http.Header{"Authorization": {authHeader(t, 1)}})
+	require.NoError(t, err, "Не удалось подключиться к WebSocket")
+	defer conn.Close()
+	require.Eventually(t, func() bool { return handler.IsOnline(1) }, time.Second, 10*time.Millisecond, "Подключение должно зарегистрироваться")
 
-	var response map[string]interface{}
-	err = conn2.ReadJSON(&response)
-	require.NoError(t, err, "Ошибка при чтении WebSocket-сообщения клиентом 2")
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	require.NoError(t, handler.Hub.Shutdown(ctx), "Обработчики подключений должны завершиться")
 
-	assert.Equal(t, testMessage["content"], response["content"], "Сообщения не совпадают")
-	assert.Contains(t, response, "message_id", "Ожидалось, что сообщение содержит message_id")
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseServiceRestart), "Ожидался код 1012, получено %v", err)
+
+	// Подключение после остановки сразу закрывается
+	late, _, err := websocket.DefaultDialer.Dial(wsURL, http.Header{"Authorization": {authHeader(t, 2)}})
+	require.NoError(t, err, "Не удалось подключиться к WebSocket")
+	defer late.Close()
+	require.NoError(t, late.SetReadDeadline(time.Now().Add(2*time.Second)))
+	_, _, err = late.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseServiceRestart), "Ожидался код 1012, получено %v", err)
 }
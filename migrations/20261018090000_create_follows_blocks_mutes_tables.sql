@@ -0,0 +1,36 @@
+-- +goose Up
+CREATE TABLE follows (
+                         follower_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                         followee_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                         created_at TIMESTAMP DEFAULT NOW(),
+                         PRIMARY KEY (follower_id, followee_id),
+                         CHECK (follower_id <> followee_id)
+);
+
+CREATE INDEX idx_follows_followee ON follows (followee_id);
+
+CREATE TABLE user_blocks (
+                             blocker_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                             blocked_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                             created_at TIMESTAMP DEFAULT NOW(),
+                             PRIMARY KEY (blocker_id, blocked_id),
+                             CHECK (blocker_id <> blocked_id)
+);
+
+CREATE INDEX idx_user_blocks_blocked ON user_blocks (blocked_id);
+
+CREATE TABLE user_mutes (
+                            muter_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                            muted_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                            created_at TIMESTAMP DEFAULT NOW(),
+                            PRIMARY KEY (muter_id, muted_id),
+                            CHECK (muter_id <> muted_id)
+);
+
+CREATE INDEX idx_photos_user_created ON photos (user_id, created_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_photos_user_created;
+DROP TABLE IF EXISTS user_mutes;
+DROP TABLE IF EXISTS user_blocks;
+DROP TABLE IF EXISTS follows;
@@ -0,0 +1,28 @@
+-- +goose Up
+CREATE TABLE notifications (
+                               id SERIAL PRIMARY KEY,
+                               user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                               type VARCHAR(32) NOT NULL,
+                               group_key VARCHAR(255) NOT NULL,
+                               photo_id INT REFERENCES photos(id) ON DELETE CASCADE,
+                               comment_id INT REFERENCES comments(id) ON DELETE CASCADE,
+                               actor_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                               actors_count INT NOT NULL DEFAULT 0,
+                               read_at TIMESTAMP,
+                               created_at TIMESTAMP DEFAULT NOW(),
+                               updated_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Непрочитанные события одного вида объединяются в одно уведомление
+CREATE UNIQUE INDEX idx_notifications_unread_group ON notifications (user_id, group_key) WHERE read_at IS NULL;
+CREATE INDEX idx_notifications_user_updated ON notifications (user_id, updated_at DESC);
+
+CREATE TABLE notification_actors (
+                                     notification_id INT NOT NULL REFERENCES notifications(id) ON DELETE CASCADE,
+                                     actor_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                     PRIMARY KEY (notification_id, actor_id)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS notification_actors;
+DROP TABLE IF EXISTS notifications;
@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE mentions (
+                          id SERIAL PRIMARY KEY,
+                          comment_id INT REFERENCES comments(id) ON DELETE CASCADE,
+                          photo_id INT REFERENCES photos(id) ON DELETE CASCADE,
+                          user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                          "offset" INT NOT NULL,
+                          length INT NOT NULL,
+                          created_at TIMESTAMP DEFAULT NOW(),
+                          CHECK ((comment_id IS NULL) <> (photo_id IS NULL))
+);
+
+CREATE INDEX idx_mentions_comment ON mentions (comment_id) WHERE comment_id IS NOT NULL;
+CREATE INDEX idx_mentions_photo ON mentions (photo_id) WHERE photo_id IS NOT NULL;
+CREATE INDEX idx_mentions_user ON mentions (user_id);
+CREATE INDEX idx_users_username_lower ON users (LOWER(username));
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_users_username_lower;
+DROP TABLE IF EXISTS mentions;
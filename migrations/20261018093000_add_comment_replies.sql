@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE comments
+    ADD COLUMN parent_id INT REFERENCES comments(id) ON DELETE CASCADE,
+    ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX idx_comments_parent ON comments (parent_id, created_at) WHERE parent_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_comments_parent;
+ALTER TABLE comments
+    DROP COLUMN IF EXISTS deleted_at,
+    DROP COLUMN IF EXISTS parent_id;
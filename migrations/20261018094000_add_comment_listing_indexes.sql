@@ -0,0 +1,21 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN avatar_url TEXT;
+
+ALTER TABLE comments ADD COLUMN likes_count INT NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_comments_photo_created ON comments (photo_id, created_at, id) WHERE parent_id IS NULL;
+CREATE INDEX idx_comments_photo_top ON comments (photo_id, likes_count, id) WHERE parent_id IS NULL;
+
+DROP INDEX IF EXISTS idx_comments_parent;
+CREATE INDEX idx_comments_parent ON comments (parent_id, created_at, id) WHERE parent_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_comments_parent;
+CREATE INDEX idx_comments_parent ON comments (parent_id, created_at) WHERE parent_id IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_comments_photo_top;
+DROP INDEX IF EXISTS idx_comments_photo_created;
+
+ALTER TABLE comments DROP COLUMN IF EXISTS likes_count;
+
+ALTER TABLE users DROP COLUMN IF EXISTS avatar_url;
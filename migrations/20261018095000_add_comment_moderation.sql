@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE photos
+    ADD COLUMN comments_policy VARCHAR(16) NOT NULL DEFAULT 'everyone'
+        CHECK (comments_policy IN ('everyone', 'followers', 'off')),
+    ADD COLUMN hidden_keywords TEXT[] NOT NULL DEFAULT '{}';
+
+ALTER TABLE comments ADD COLUMN pinned_at TIMESTAMP;
+
+CREATE INDEX idx_comments_photo_pinned ON comments (photo_id, pinned_at) WHERE pinned_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_comments_photo_pinned;
+
+ALTER TABLE comments DROP COLUMN IF EXISTS pinned_at;
+
+ALTER TABLE photos
+    DROP COLUMN IF EXISTS hidden_keywords,
+    DROP COLUMN IF EXISTS comments_policy;
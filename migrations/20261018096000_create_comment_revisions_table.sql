@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE comment_revisions (
+    id SERIAL PRIMARY KEY,
+    comment_id INT NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    content TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_comment_revisions_comment ON comment_revisions (comment_id, id);
+
+ALTER TABLE comments ADD COLUMN revision_count INT NOT NULL DEFAULT 0;
+
+ALTER TABLE users ADD COLUMN is_moderator BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN IF EXISTS is_moderator;
+
+ALTER TABLE comments DROP COLUMN IF EXISTS revision_count;
+
+DROP TABLE IF EXISTS comment_revisions;
@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE comment_likes (
+    comment_id INT NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (comment_id, user_id)
+);
+
+CREATE INDEX idx_comment_likes_user ON comment_likes (user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS comment_likes;
@@ -0,0 +1,42 @@
+-- +goose Up
+-- Существующие лайки становятся реакциями-сердечками
+ALTER TABLE photo_likes RENAME TO photo_reactions;
+ALTER TABLE photo_reactions
+    ADD COLUMN reaction VARCHAR(32) NOT NULL DEFAULT '❤️',
+    ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT NOW();
+ALTER TABLE photo_reactions ALTER COLUMN reaction DROP DEFAULT;
+
+CREATE TABLE photo_reaction_counts (
+    photo_id INT NOT NULL REFERENCES photos(id) ON DELETE CASCADE,
+    reaction VARCHAR(32) NOT NULL,
+    count INT NOT NULL DEFAULT 0 CHECK (count >= 0),
+    PRIMARY KEY (photo_id, reaction)
+);
+
+INSERT INTO photo_reaction_counts (photo_id, reaction, count)
+SELECT photo_id, reaction, COUNT(*) FROM photo_reactions GROUP BY photo_id, reaction;
+
+CREATE TABLE message_reactions (
+    message_id INT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reaction VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (message_id, user_id)
+);
+
+CREATE TABLE message_reaction_counts (
+    message_id INT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    reaction VARCHAR(32) NOT NULL,
+    count INT NOT NULL DEFAULT 0 CHECK (count >= 0),
+    PRIMARY KEY (message_id, reaction)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS message_reaction_counts;
+DROP TABLE IF EXISTS message_reactions;
+DROP TABLE IF EXISTS photo_reaction_counts;
+
+DELETE FROM photo_reactions WHERE reaction <> '❤️';
+ALTER TABLE photo_reactions DROP COLUMN reaction, DROP COLUMN updated_at;
+ALTER TABLE photo_reactions RENAME TO photo_likes;
@@ -0,0 +1,7 @@
+-- +goose Up
+CREATE INDEX idx_photo_reactions_photo_listing ON photo_reactions (photo_id, reaction, updated_at, user_id);
+CREATE INDEX idx_comments_photo_alive ON comments (photo_id) WHERE deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_comments_photo_alive;
+DROP INDEX IF EXISTS idx_photo_reactions_photo_listing;
@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE TABLE collections (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, name)
+);
+
+-- У каждого пользователя не больше одной коллекции «Сохраненное»
+CREATE UNIQUE INDEX idx_collections_default ON collections (user_id) WHERE is_default;
+
+-- Удаление фото или коллекции удаляет и сохранения
+CREATE TABLE saved_photos (
+    collection_id INT NOT NULL REFERENCES collections(id) ON DELETE CASCADE,
+    photo_id INT NOT NULL REFERENCES photos(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (collection_id, photo_id)
+);
+
+CREATE INDEX idx_saved_photos_listing ON saved_photos (collection_id, created_at, photo_id);
+CREATE INDEX idx_saved_photos_photo ON saved_photos (photo_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS saved_photos;
+DROP TABLE IF EXISTS collections;
@@ -0,0 +1,28 @@
+-- +goose Up
+CREATE TABLE albums (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    cover_photo_id INT REFERENCES photos(id) ON DELETE SET NULL,
+    visibility VARCHAR(16) NOT NULL DEFAULT 'public'
+        CHECK (visibility IN ('public', 'followers', 'private')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_albums_user ON albums (user_id, created_at);
+
+CREATE TABLE album_photos (
+    album_id INT NOT NULL REFERENCES albums(id) ON DELETE CASCADE,
+    photo_id INT NOT NULL REFERENCES photos(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    added_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (album_id, photo_id)
+);
+
+CREATE INDEX idx_album_photos_position ON album_photos (album_id, position);
+
+-- +goose Down
+DROP TABLE IF EXISTS album_photos;
+DROP TABLE IF EXISTS albums;
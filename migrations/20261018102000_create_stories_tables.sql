@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE stories (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    caption TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_stories_user_active ON stories (user_id, expires_at);
+CREATE INDEX idx_stories_expires ON stories (expires_at);
+
+CREATE TABLE story_views (
+    story_id INT NOT NULL REFERENCES stories(id) ON DELETE CASCADE,
+    viewer_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    viewed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (story_id, viewer_id)
+);
+
+CREATE INDEX idx_story_views_viewer ON story_views (viewer_id, story_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS story_views;
+DROP TABLE IF EXISTS stories;
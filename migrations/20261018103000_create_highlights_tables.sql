@@ -0,0 +1,34 @@
+-- +goose Up
+CREATE TABLE highlights (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title VARCHAR(50) NOT NULL,
+    position INT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_highlights_user_position ON highlights (user_id, position);
+
+-- Элемент подборки — фото или история. Пока история в подборке, очистка истекших историй ее не удаляет
+CREATE TABLE highlight_items (
+    id SERIAL PRIMARY KEY,
+    highlight_id INT NOT NULL REFERENCES highlights(id) ON DELETE CASCADE,
+    photo_id INT REFERENCES photos(id) ON DELETE CASCADE,
+    story_id INT REFERENCES stories(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    added_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK ((photo_id IS NULL) <> (story_id IS NULL)),
+    UNIQUE (highlight_id, photo_id),
+    UNIQUE (highlight_id, story_id)
+);
+
+CREATE INDEX idx_highlight_items_position ON highlight_items (highlight_id, position);
+CREATE INDEX idx_highlight_items_story ON highlight_items (story_id) WHERE story_id IS NOT NULL;
+
+ALTER TABLE highlights ADD COLUMN cover_item_id INT REFERENCES highlight_items(id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE highlights DROP COLUMN IF EXISTS cover_item_id;
+DROP TABLE IF EXISTS highlight_items;
+DROP TABLE IF EXISTS highlights;
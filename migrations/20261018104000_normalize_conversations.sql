@@ -0,0 +1,47 @@
+-- +goose Up
+-- Беседы (A,B) и (B,A) сливаются в одну: сообщения переносятся в беседу с меньшим ID
+WITH pairs AS (
+    SELECT id, MIN(id) OVER (PARTITION BY LEAST(user1_id, user2_id), GREATEST(user1_id, user2_id)) AS keep_id
+    FROM conversations
+)
+UPDATE messages m SET conversation_id = p.keep_id
+FROM pairs p
+WHERE m.conversation_id = p.id AND p.id <> p.keep_id;
+
+DELETE FROM conversations c
+USING conversations other
+WHERE LEAST(c.user1_id, c.user2_id) = LEAST(other.user1_id, other.user2_id)
+  AND GREATEST(c.user1_id, c.user2_id) = GREATEST(other.user1_id, other.user2_id)
+  AND c.id > other.id;
+
+UPDATE conversations SET user1_id = user2_id, user2_id = user1_id WHERE user1_id > user2_id;
+
+ALTER TABLE conversations ADD CONSTRAINT conversations_ordered_pair CHECK (user1_id <= user2_id);
+
+-- Время последней активности для сортировки входящих и последнее прочитанное сообщение каждого участника
+ALTER TABLE conversations
+    ADD COLUMN last_activity_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    ADD COLUMN user1_last_read_id INT NOT NULL DEFAULT 0,
+    ADD COLUMN user2_last_read_id INT NOT NULL DEFAULT 0;
+
+-- Существующая переписка считается прочитанной
+UPDATE conversations c
+SET last_activity_at = COALESCE((SELECT MAX(created_at) FROM messages WHERE conversation_id = c.id), c.created_at, NOW()),
+    user1_last_read_id = COALESCE((SELECT MAX(id) FROM messages WHERE conversation_id = c.id), 0),
+    user2_last_read_id = COALESCE((SELECT MAX(id) FROM messages WHERE conversation_id = c.id), 0);
+
+CREATE INDEX idx_conversations_user1_activity ON conversations (user1_id, last_activity_at DESC, id DESC);
+CREATE INDEX idx_conversations_user2_activity ON conversations (user2_id, last_activity_at DESC, id DESC);
+CREATE INDEX idx_messages_conversation ON messages (conversation_id, id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_messages_conversation;
+DROP INDEX IF EXISTS idx_conversations_user2_activity;
+DROP INDEX IF EXISTS idx_conversations_user1_activity;
+
+ALTER TABLE conversations
+    DROP COLUMN IF EXISTS user2_last_read_id,
+    DROP COLUMN IF EXISTS user1_last_read_id,
+    DROP COLUMN IF EXISTS last_activity_at;
+
+ALTER TABLE conversations DROP CONSTRAINT IF EXISTS conversations_ordered_pair;
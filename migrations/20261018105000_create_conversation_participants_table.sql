@@ -0,0 +1,65 @@
+-- +goose Up
+-- Участники бесед. В личной беседе два участника, пара по-прежнему хранится в user1_id/user2_id
+-- для поиска беседы по паре; у групповых бесед эти поля пустые
+CREATE TABLE conversation_participants (
+    conversation_id INT NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(10) NOT NULL DEFAULT 'member' CHECK (role IN ('admin', 'member')),
+    last_read_id INT NOT NULL DEFAULT 0,
+    joined_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (conversation_id, user_id)
+);
+
+CREATE INDEX idx_conversation_participants_user ON conversation_participants (user_id, conversation_id);
+
+INSERT INTO conversation_participants (conversation_id, user_id, last_read_id, joined_at)
+SELECT id, user1_id, user1_last_read_id, COALESCE(created_at, NOW()) FROM conversations
+UNION ALL
+SELECT id, user2_id, user2_last_read_id, COALESCE(created_at, NOW()) FROM conversations WHERE user2_id <> user1_id;
+
+DROP INDEX IF EXISTS idx_conversations_user1_activity;
+DROP INDEX IF EXISTS idx_conversations_user2_activity;
+
+ALTER TABLE conversations
+    DROP COLUMN user1_last_read_id,
+    DROP COLUMN user2_last_read_id,
+    ALTER COLUMN user1_id DROP NOT NULL,
+    ALTER COLUMN user2_id DROP NOT NULL,
+    ADD COLUMN is_group BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN title VARCHAR(100),
+    ADD COLUMN avatar_url TEXT,
+    ADD CONSTRAINT conversations_kind CHECK (
+        (is_group AND user1_id IS NULL AND user2_id IS NULL) OR
+        (NOT is_group AND user1_id IS NOT NULL AND user2_id IS NOT NULL)
+    );
+
+-- Служебные сообщения об изменениях группы: kind — вид события, target_user_id — над кем оно совершено
+ALTER TABLE messages
+    ADD COLUMN kind VARCHAR(20) NOT NULL DEFAULT 'text',
+    ADD COLUMN target_user_id INT REFERENCES users(id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE messages
+    DROP COLUMN IF EXISTS target_user_id,
+    DROP COLUMN IF EXISTS kind;
+
+DELETE FROM conversations WHERE is_group;
+
+ALTER TABLE conversations
+    DROP CONSTRAINT IF EXISTS conversations_kind,
+    DROP COLUMN IF EXISTS avatar_url,
+    DROP COLUMN IF EXISTS title,
+    DROP COLUMN IF EXISTS is_group,
+    ALTER COLUMN user1_id SET NOT NULL,
+    ALTER COLUMN user2_id SET NOT NULL,
+    ADD COLUMN user1_last_read_id INT NOT NULL DEFAULT 0,
+    ADD COLUMN user2_last_read_id INT NOT NULL DEFAULT 0;
+
+UPDATE conversations c
+SET user1_last_read_id = COALESCE((SELECT last_read_id FROM conversation_participants p WHERE p.conversation_id = c.id AND p.user_id = c.user1_id), 0),
+    user2_last_read_id = COALESCE((SELECT last_read_id FROM conversation_participants p WHERE p.conversation_id = c.id AND p.user_id = c.user2_id), 0);
+
+CREATE INDEX idx_conversations_user1_activity ON conversations (user1_id, last_activity_at DESC, id DESC);
+CREATE INDEX idx_conversations_user2_activity ON conversations (user2_id, last_activity_at DESC, id DESC);
+
+DROP TABLE IF EXISTS conversation_participants;
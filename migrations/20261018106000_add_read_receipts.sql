@@ -0,0 +1,8 @@
+-- +goose Up
+-- Отметка прочтения участника: ID последнего прочитанного сообщения и время, когда она сдвинулась
+ALTER TABLE conversation_participants RENAME COLUMN last_read_id TO last_read_message_id;
+ALTER TABLE conversation_participants ADD COLUMN last_read_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE conversation_participants DROP COLUMN IF EXISTS last_read_at;
+ALTER TABLE conversation_participants RENAME COLUMN last_read_message_id TO last_read_id;
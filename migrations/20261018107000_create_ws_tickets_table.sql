@@ -0,0 +1,14 @@
+-- +goose Up
+-- Одноразовые билеты для подключения к WebSocket. Хранится только хэш билета.
+-- session_expires_at — время истечения JWT, по которому билет выдан: до него живет и подключение. NULL — токен без срока
+CREATE TABLE ws_tickets (
+    ticket_hash TEXT PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    session_expires_at TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_ws_tickets_expires ON ws_tickets (expires_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS ws_tickets;
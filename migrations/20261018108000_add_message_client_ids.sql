@@ -0,0 +1,10 @@
+-- +goose Up
+-- ID, который клиент присваивает сообщению до отправки. Повторная отправка с тем же ID
+-- возвращает уже сохраненное сообщение вместо дубликата
+ALTER TABLE messages ADD COLUMN client_message_id VARCHAR(64);
+
+CREATE UNIQUE INDEX ux_messages_sender_client_id ON messages (sender_id, client_message_id) WHERE client_message_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS ux_messages_sender_client_id;
+ALTER TABLE messages DROP COLUMN IF EXISTS client_message_id;
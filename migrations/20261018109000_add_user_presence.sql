@@ -0,0 +1,10 @@
+-- +goose Up
+-- Время, когда пользователь последний раз вышел из сети, и настройка, скрывающая его от других
+ALTER TABLE users
+    ADD COLUMN last_seen_at TIMESTAMP,
+    ADD COLUMN hide_last_seen BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE users
+    DROP COLUMN IF EXISTS hide_last_seen,
+    DROP COLUMN IF EXISTS last_seen_at;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- События pub/sub, которые не помещаются в NOTIFY (до 8000 байт). Уведомление несет только ID записи,
+-- запись живет, пока ее успевают прочитать все экземпляры сервера
+CREATE TABLE pubsub_events (
+    id BIGSERIAL PRIMARY KEY,
+    payload TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_pubsub_events_created_at ON pubsub_events (created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS pubsub_events;
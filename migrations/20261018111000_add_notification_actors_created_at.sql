@@ -0,0 +1,8 @@
+-- +goose Up
+-- Время события участника, чтобы показывать последнего участника, не скрытого блокировкой
+ALTER TABLE notification_actors
+    ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+-- +goose Down
+ALTER TABLE notification_actors
+    DROP COLUMN IF EXISTS created_at;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- Открытые WebSocket-подключения пользователей по экземплярам сервера. Пользователь в сети, пока у него
+-- есть подключения к живому экземпляру. Экземпляр продлевает heartbeat_at своих записей, пока работает,
+-- записи упавшего экземпляра перестают учитываться по истечении срока
+CREATE TABLE presence_connections (
+    instance_id VARCHAR(64) NOT NULL,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    connections INT NOT NULL,
+    heartbeat_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (instance_id, user_id)
+);
+
+CREATE INDEX idx_presence_connections_user ON presence_connections (user_id, heartbeat_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS presence_connections;
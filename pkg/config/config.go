@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -19,14 +21,47 @@ type Config struct {
 	DBPort     string
 	ServerPort string
 	JWTSecret  string
+	// CommentEditWindow сколько времени после создания комментарий можно править, 0 — без ограничений
+	CommentEditWindow time.Duration
+	// Reactions разрешенные реакции через запятую, пустой список означает набор по умолчанию
+	Reactions []string
+	// LikesReconcileInterval как часто пересчитывать счетчики лайков, 0 — не пересчитывать
+	LikesReconcileInterval time.Duration
+	// StorySweepInterval как часто удалять истекшие истории, 0 — не удалять
+	StorySweepInterval time.Duration
+	// WSAllowedOrigins источники, с которых браузер может подключиться к WebSocket, через запятую.
+	// Пустой список разрешает только тот же хост, "*" — любые
+	WSAllowedOrigins []string
+	// WSTicketTTL сколько действует билет для подключения к WebSocket
+	WSTicketTTL time.Duration
+	// WSPingInterval как часто отправлять ping в WebSocket, 0 — не отправлять
+	WSPingInterval time.Duration
+	// WSPongTimeout сколько ждать pong или сообщения клиента, прежде чем отключить его, 0 — без ограничения.
+	// Должен быть больше WSPingInterval
+	WSPongTimeout time.Duration
+	// WSWriteTimeout сколько ждать записи сообщения в WebSocket, 0 — без ограничения
+	WSWriteTimeout time.Duration
+	// WSMaxMessageSize максимальный размер сообщения клиента WebSocket в байтах, 0 — без ограничения
+	WSMaxMessageSize int64
 }
 
+const (
+	defaultCommentEditWindow      = 15 * time.Minute
+	defaultLikesReconcileInterval = time.Hour
+	defaultStorySweepInterval     = 5 * time.Minute
+	defaultWSTicketTTL            = 30 * time.Second
+	defaultWSPingInterval         = 30 * time.Second
+	defaultWSPongTimeout          = 60 * time.Second
+	defaultWSWriteTimeout         = 10 * time.Second
+	defaultWSMaxMessageSize       = 32 << 10
+)
+
 func LoadConfig() *Config {
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found, using environment variables")
 	}
 
-	return &Config{
+	cfg := &Config{
 		DBUser:     os.Getenv("DB_USER"),
 		DBPassword: os.Getenv("DB_PASSWORD"),
 		DBName:     os.Getenv("DB_NAME"),
@@ -34,7 +69,55 @@ func LoadConfig() *Config {
 		DBPort:     os.Getenv("DB_PORT"),
 		ServerPort: os.Getenv("SERVER_PORT"),
 		JWTSecret:  os.Getenv("JWT_SECRET"),
+
+		CommentEditWindow: durationEnv("COMMENT_EDIT_WINDOW", defaultCommentEditWindow),
+		Reactions:         listEnv("REACTIONS"),
+
+		LikesReconcileInterval: durationEnv("LIKES_RECONCILE_INTERVAL", defaultLikesReconcileInterval),
+		StorySweepInterval:     durationEnv("STORY_SWEEP_INTERVAL", defaultStorySweepInterval),
+
+		WSAllowedOrigins: listEnv("WS_ALLOWED_ORIGINS"),
+		WSTicketTTL:      durationEnv("WS_TICKET_TTL", defaultWSTicketTTL),
+		WSPingInterval:   durationEnv("WS_PING_INTERVAL", defaultWSPingInterval),
+		WSPongTimeout:    durationEnv("WS_PONG_TIMEOUT", defaultWSPongTimeout),
+		WSWriteTimeout:   durationEnv("WS_WRITE_TIMEOUT", defaultWSWriteTimeout),
+		WSMaxMessageSize: int64Env("WS_MAX_MESSAGE_SIZE", defaultWSMaxMessageSize),
+	}
+
+	// Клиент отвечает pong только на ping, поэтому ждать его меньше интервала ping значит обрывать живые подключения
+	if cfg.WSPingInterval > 0 && cfg.WSPongTimeout > 0 && cfg.WSPongTimeout <= cfg.WSPingInterval {
+		log.Printf("WS_PONG_TIMEOUT=%s не больше WS_PING_INTERVAL=%s, используется %s", cfg.WSPongTimeout, cfg.WSPingInterval, 2*cfg.WSPingInterval)
+		cfg.WSPongTimeout = 2 * cfg.WSPingInterval
+	}
+	return cfg
+}
+
+// int64Env читает неотрицательное целое из переменной окружения
+func int64Env(key string, fallback int64) int64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		log.Printf("Некорректное значение %s=%q, используется %d", key, value, fallback)
+		return fallback
 	}
+	return n
+}
+
+// durationEnv читает длительность вида "15m" из переменной окружения
+func durationEnv(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		log.Printf("Некорректное значение %s=%q, используется %s", key, value, fallback)
+		return fallback
+	}
+	return d
 }
 
 func ConnectDB(cfg *Config) (*pgxpool.Pool, error) {
@@ -65,3 +148,14 @@ func PingDB(pool *pgxpool.Pool) error {
 
 	return pool.Ping(ctx)
 }
+
+// listEnv читает список значений через запятую из переменной окружения
+func listEnv(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -1,13 +1,71 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"go.uber.org/zap"
 )
 
+type contextKey string
+
+// UserIDKey ключ контекста, под которым хранится ID пользователя из JWT
+const UserIDKey contextKey = "user_id"
+
+// TokenExpiresAtKey ключ контекста, под которым хранится время истечения JWT
+const TokenExpiresAtKey contextKey = "token_expires_at"
+
+// UserIDFromContext возвращает ID аутентифицированного пользователя
+func UserIDFromContext(ctx context.Context) (int, bool) {
+	userID, ok := ctx.Value(UserIDKey).(int)
+	return userID, ok && userID > 0
+}
+
+// TokenExpiresAtFromContext возвращает время истечения JWT текущего запроса. Для токена без exp ok равно false
+func TokenExpiresAtFromContext(ctx context.Context) (time.Time, bool) {
+	expiresAt, ok := ctx.Value(TokenExpiresAtKey).(time.Time)
+	return expiresAt, ok && !expiresAt.IsZero()
+}
+
+// ParseToken проверяет JWT и возвращает ID пользователя из него
+func ParseToken(tokenString, secret string) (int, error) {
+	userID, _, err := ParseTokenExpiry(tokenString, secret)
+	return userID, err
+}
+
+// ParseTokenExpiry проверяет JWT и возвращает ID пользователя и время истечения токена.
+// Для токена без exp время нулевое
+func ParseTokenExpiry(tokenString, secret string) (int, time.Time, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	})
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	if !token.Valid {
+		return 0, time.Time{}, errors.New("invalid token")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return 0, time.Time{}, errors.New("invalid token claims")
+	}
+	userID, ok := claims["user_id"].(float64)
+	if !ok || userID <= 0 {
+		return 0, time.Time{}, errors.New("token has no user_id")
+	}
+
+	var expiresAt time.Time
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		expiresAt = exp.Time
+	}
+	return int(userID), expiresAt, nil
+}
+
 func JWTMiddleware(secret string, logger *zap.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -21,12 +79,8 @@ func JWTMiddleware(secret string, logger *zap.Logger) func(http.Handler) http.Ha
 				return
 			}
 
-			tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-			token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-				return []byte(secret), nil
-			})
-
-			if err != nil || !token.Valid {
+			userID, expiresAt, err := ParseTokenExpiry(strings.TrimPrefix(authHeader, "Bearer "), secret)
+			if err != nil {
 				logger.Warn("Неверный токен",
 					zap.String("path", r.URL.Path),
 					zap.String("method", r.Method),
@@ -41,7 +95,9 @@ func JWTMiddleware(secret string, logger *zap.Logger) func(http.Handler) http.Ha
 				zap.String("method", r.Method),
 			)
 
-			next.ServeHTTP(w, r)
+			ctx := context.WithValue(r.Context(), UserIDKey, userID)
+			ctx = context.WithValue(ctx, TokenExpiresAtKey, expiresAt)
+			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }